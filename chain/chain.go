@@ -172,6 +172,15 @@ func (c *chain) Init() error {
 		return err
 	}
 
+	// mark the height the indexed plugins start from
+	if c.chainCfg.OpenPlugins {
+		if err := c.plugins.InitIndexedFrom(); err != nil {
+			cErr := errors.New(fmt.Sprintf("c.plugins.InitIndexedFrom failed. Error: %s", err))
+			c.log.Error(cErr.Error(), "method", "Init")
+			return cErr
+		}
+	}
+
 	// reconstruct the plugins
 	/*	if c.chainCfg.OpenPlugins {
 			c.plugins.BuildPluginsDb(c.flusher)
@@ -0,0 +1,343 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/vitelabs/go-vite/common/db"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+// quotaUsedSnapshotCount is the count of the latest snapshot blocks whose quota is accumulated in the quota used list
+const quotaUsedSnapshotCount = 74
+
+// historyChain reads the state of the snapshot block which an account block is based on,
+// and the changes of the previous blocks of the account which are confirmed by the same snapshot block.
+// The blocks of the other accounts which are confirmed by the same snapshot block may be inserted before
+// or after the account block, so the reads depending on them return an error.
+type historyChain struct {
+	*chain
+
+	snapshotBlock *ledger.SnapshotBlock
+	block         *ledger.AccountBlock
+
+	addr       types.Address
+	storage    *vm_db.Unsaved
+	balanceMap map[types.TokenTypeId]*big.Int
+
+	// the previous blocks of the account which were not confirmed when the account block was inserted
+	unconfirmedBlocks []*ledger.AccountBlock
+	quotaChunks       []*ledger.SnapshotChunk
+}
+
+// NewHistoryVmDb returns a vm db with the state before the account block was inserted, it is used to execute the block again.
+// The state is rebuilt by the redo log, so the archive mode is required for the old blocks.
+func (c *chain) NewHistoryVmDb(block *ledger.AccountBlock) (vm_db.VmDb, error) {
+	confirmSnapshotBlock, err := c.GetConfirmSnapshotHeaderByAbHash(block.Hash)
+	if err != nil {
+		return nil, err
+	}
+	redoHeight := c.GetLatestSnapshotBlock().Height + 1
+	if confirmSnapshotBlock != nil {
+		redoHeight = confirmSnapshotBlock.Height
+	}
+
+	snapshotLog, ok, err := c.stateDB.Redo().QueryLog(redoHeight)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("the account block %s is confirmed by snapshot height %d, which is out of the redo logs kept, enable the archive mode to keep them", block.Hash, redoHeight))
+	}
+
+	snapshotBlock, err := c.GetSnapshotHeaderByHeight(redoHeight - 1)
+	if err != nil {
+		return nil, err
+	}
+	if snapshotBlock == nil {
+		return nil, errors.New(fmt.Sprintf("snapshot block of height %d is not existed", redoHeight-1))
+	}
+
+	hc := &historyChain{
+		chain:         c,
+		snapshotBlock: snapshotBlock,
+		block:         block,
+		addr:          block.AccountAddress,
+		storage:       vm_db.NewUnsaved(),
+		balanceMap:    make(map[types.TokenTypeId]*big.Int),
+	}
+	for _, logItem := range snapshotLog[block.AccountAddress] {
+		if logItem.Height >= block.Height {
+			break
+		}
+		for _, kv := range logItem.Storage {
+			hc.storage.SetValue(kv[0], kv[1])
+		}
+		for tokenId, balance := range logItem.BalanceMap {
+			hc.balanceMap[tokenId] = balance
+		}
+
+		prevBlock, err := c.GetAccountBlockByHeight(block.AccountAddress, logItem.Height)
+		if err != nil {
+			return nil, err
+		}
+		if prevBlock == nil {
+			return nil, errors.New(fmt.Sprintf("account block %s of height %d is not existed", block.AccountAddress, logItem.Height))
+		}
+		hc.unconfirmedBlocks = append(hc.unconfirmedBlocks, prevBlock)
+	}
+
+	if hc.quotaChunks, err = hc.getQuotaChunks(); err != nil {
+		return nil, err
+	}
+
+	return vm_db.NewVmDb(hc, &hc.addr, &snapshotBlock.Hash, &block.PrevHash)
+}
+
+// getQuotaChunks returns the latest snapshot chunks whose quota is accumulated in the quota used list
+func (hc *historyChain) getQuotaChunks() ([]*ledger.SnapshotChunk, error) {
+	startHeight := uint64(1)
+	if hc.snapshotBlock.Height > quotaUsedSnapshotCount {
+		startHeight = hc.snapshotBlock.Height - quotaUsedSnapshotCount
+	}
+	chunks, err := hc.chain.GetSubLedger(startHeight, hc.snapshotBlock.Height)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) > 0 && (chunks[0].SnapshotBlock == nil || chunks[0].SnapshotBlock.Height == startHeight) {
+		chunks = chunks[1:]
+	}
+	return chunks, nil
+}
+
+func (hc *historyChain) GetValue(addr types.Address, key []byte) ([]byte, error) {
+	if addr == hc.addr {
+		if value, ok := hc.storage.GetValue(key); ok {
+			return value, nil
+		}
+	}
+	return hc.chain.GetValueAt(addr, key, hc.snapshotBlock.Height)
+}
+
+func (hc *historyChain) GetBalance(addr types.Address, tokenId types.TokenTypeId) (*big.Int, error) {
+	if addr == hc.addr {
+		if balance, ok := hc.balanceMap[tokenId]; ok {
+			return new(big.Int).Set(balance), nil
+		}
+	}
+	return hc.chain.GetBalanceAt(addr, tokenId, hc.snapshotBlock.Height)
+}
+
+func (hc *historyChain) GetStorageIterator(addr types.Address, prefix []byte) (interfaces.StorageIterator, error) {
+	iter, err := hc.stateDB.NewSnapshotStorageIteratorByHeight(hc.snapshotBlock.Height, addr, prefix)
+	if err != nil || addr != hc.addr {
+		return iter, err
+	}
+	return db.NewMergedIterator([]interfaces.StorageIterator{
+		hc.storage.NewStorageIterator(prefix),
+		iter,
+	}, hc.storage.IsDelete), nil
+}
+
+func (hc *historyChain) GetStakeBeneficialAmount(addr types.Address) (*big.Int, error) {
+	sd, err := hc.stateDB.NewStorageDatabase(hc.snapshotBlock.Hash, types.AddressQuota)
+	if err != nil {
+		return nil, err
+	}
+	return abi.GetStakeBeneficialAmount(sd, addr)
+}
+
+// GetContractMeta returns the current meta of the account of the block, which is created before the blocks of the account
+func (hc *historyChain) GetContractMeta(addr types.Address) (*ledger.ContractMeta, error) {
+	meta, err := hc.chain.GetContractMeta(addr)
+	if err != nil || meta == nil || addr == hc.addr || ledger.GetBuiltinContractMeta(addr) != nil {
+		return meta, err
+	}
+
+	confirmHeight, err := hc.indexDB.GetConfirmHeightByHash(&meta.CreateBlockHash)
+	if err != nil {
+		return nil, err
+	}
+	if confirmHeight > 0 && confirmHeight <= hc.snapshotBlock.Height {
+		return meta, nil
+	}
+	for _, prevBlock := range hc.unconfirmedBlocks {
+		if prevBlock.Hash == meta.CreateBlockHash {
+			return meta, nil
+		}
+	}
+	return nil, errors.New(fmt.Sprintf("contract %s is created after snapshot block %d, whether it exists before the account block is unknown",
+		addr, hc.snapshotBlock.Height))
+}
+
+func (hc *historyChain) GetContractMetaInSnapshot(addr types.Address, snapshotHeight uint64) (*ledger.ContractMeta, error) {
+	if snapshotHeight > hc.snapshotBlock.Height {
+		snapshotHeight = hc.snapshotBlock.Height
+	}
+	return hc.chain.GetContractMetaInSnapshot(addr, snapshotHeight)
+}
+
+func (hc *historyChain) IsContractAccount(addr types.Address) (bool, error) {
+	if types.IsBuiltinContractAddrInUse(addr) {
+		return true, nil
+	}
+	meta, err := hc.GetContractMeta(addr)
+	if err != nil {
+		return false, err
+	}
+	return meta != nil, nil
+}
+
+func (hc *historyChain) GetContractCode(addr types.Address) ([]byte, error) {
+	if meta, err := hc.GetContractMeta(addr); err != nil || meta == nil {
+		return nil, err
+	}
+	return hc.chain.GetContractCode(addr)
+}
+
+func (hc *historyChain) GetSnapshotHeaderByHash(hash types.Hash) (*ledger.SnapshotBlock, error) {
+	return hc.beforeSnapshotBlock(hc.chain.GetSnapshotHeaderByHash(hash))
+}
+
+func (hc *historyChain) GetSnapshotBlockByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	if height > hc.snapshotBlock.Height {
+		return nil, nil
+	}
+	return hc.chain.GetSnapshotBlockByHeight(height)
+}
+
+func (hc *historyChain) GetConfirmSnapshotHeaderByAbHash(abHash types.Hash) (*ledger.SnapshotBlock, error) {
+	return hc.beforeSnapshotBlock(hc.chain.GetConfirmSnapshotHeaderByAbHash(abHash))
+}
+
+// beforeSnapshotBlock drops the snapshot block which is inserted after the snapshot block of the history
+func (hc *historyChain) beforeSnapshotBlock(snapshotBlock *ledger.SnapshotBlock, err error) (*ledger.SnapshotBlock, error) {
+	if err != nil || snapshotBlock == nil || snapshotBlock.Height > hc.snapshotBlock.Height {
+		return nil, err
+	}
+	return snapshotBlock, nil
+}
+
+func (hc *historyChain) GetConfirmedTimes(blockHash types.Hash) (uint64, error) {
+	confirmHeight, err := hc.indexDB.GetConfirmHeightByHash(&blockHash)
+	if err != nil {
+		return 0, err
+	}
+	if confirmHeight <= 0 || confirmHeight > hc.snapshotBlock.Height {
+		return 0, nil
+	}
+	return hc.snapshotBlock.Height + 1 - confirmHeight, nil
+}
+
+func (hc *historyChain) GetSnapshotBlockByContractMeta(addr types.Address, fromHash types.Hash) (*ledger.SnapshotBlock, error) {
+	limitSb, err := hc.chain.GetSnapshotBlockByContractMeta(addr, fromHash)
+	if err != nil || limitSb == nil {
+		return limitSb, err
+	}
+	if limitSb.Height > hc.snapshotBlock.Height {
+		return nil, errors.New("fromBlock confirmed times not enough")
+	}
+	return limitSb, nil
+}
+
+func (hc *historyChain) GetSeedConfirmedSnapshotBlock(addr types.Address, fromHash types.Hash) (*ledger.SnapshotBlock, error) {
+	limitSb, err := hc.chain.GetSeedConfirmedSnapshotBlock(addr, fromHash)
+	if err != nil || limitSb == nil {
+		return limitSb, err
+	}
+	if limitSb.Height > hc.snapshotBlock.Height {
+		return nil, errors.New("fromBlock confirmed times not enough")
+	}
+	return limitSb, nil
+}
+
+// GetAccountBlockByHash drops the block of the account which is not inserted before the account block
+func (hc *historyChain) GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error) {
+	block, err := hc.chain.GetAccountBlockByHash(blockHash)
+	if err != nil || block == nil {
+		return nil, err
+	}
+	if block.AccountAddress == hc.addr && block.Height >= hc.block.Height {
+		return nil, nil
+	}
+	return block, nil
+}
+
+func (hc *historyChain) GetLatestAccountBlock(addr types.Address) (*ledger.AccountBlock, error) {
+	if addr == hc.addr {
+		if hc.block.Height <= 1 {
+			return nil, nil
+		}
+		return hc.chain.GetAccountBlockByHash(hc.block.PrevHash)
+	}
+
+	latestBlock, err := hc.chain.GetLatestAccountBlock(addr)
+	if err != nil || latestBlock == nil {
+		return nil, err
+	}
+	confirmHeight, err := hc.indexDB.GetConfirmHeightByHash(&latestBlock.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if confirmHeight <= 0 || confirmHeight > hc.snapshotBlock.Height {
+		return nil, errors.New(fmt.Sprintf("account %s has blocks inserted after snapshot block %d, its latest block before the account block is unknown",
+			addr, hc.snapshotBlock.Height))
+	}
+	return latestBlock, nil
+}
+
+// GetUnconfirmedBlocks returns nil for the other accounts, whose unconfirmed blocks before the account block are unknown
+func (hc *historyChain) GetUnconfirmedBlocks(addr types.Address) []*ledger.AccountBlock {
+	if addr != hc.addr {
+		return nil
+	}
+	return hc.unconfirmedBlocks
+}
+
+// GetQuotaUsedList returns the quota used in the latest snapshot blocks, the unconfirmed quota is known for the account of the block only
+func (hc *historyChain) GetQuotaUsedList(addr types.Address) []types.QuotaInfo {
+	usedList := make([]types.QuotaInfo, 0, len(hc.quotaChunks)+1)
+	for _, chunk := range hc.quotaChunks {
+		var quotaInfo types.QuotaInfo
+		for _, block := range chunk.AccountBlocks {
+			if block.AccountAddress == addr {
+				addQuotaInfo(&quotaInfo, block)
+			}
+		}
+		usedList = append(usedList, quotaInfo)
+	}
+
+	var unconfirmedQuotaInfo types.QuotaInfo
+	if addr == hc.addr {
+		for _, block := range hc.unconfirmedBlocks {
+			addQuotaInfo(&unconfirmedQuotaInfo, block)
+		}
+	}
+	return append(usedList, unconfirmedQuotaInfo)
+}
+
+// GetGlobalQuota returns the quota used in the latest snapshot blocks and the unconfirmed quota of the account of the block.
+// The unconfirmed quota of the other accounts is unknown, so the quota may be less than it was.
+func (hc *historyChain) GetGlobalQuota() types.QuotaInfo {
+	var globalQuota types.QuotaInfo
+	for _, chunk := range hc.quotaChunks {
+		for _, block := range chunk.AccountBlocks {
+			addQuotaInfo(&globalQuota, block)
+		}
+	}
+	for _, block := range hc.unconfirmedBlocks {
+		addQuotaInfo(&globalQuota, block)
+	}
+	return globalQuota
+}
+
+func addQuotaInfo(quotaInfo *types.QuotaInfo, block *ledger.AccountBlock) {
+	quotaInfo.BlockCount += 1
+	quotaInfo.QuotaTotal += block.Quota
+	quotaInfo.QuotaUsedTotal += block.QuotaUsed
+}
@@ -0,0 +1,159 @@
+package chain
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain/test_tools"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm_db"
+	"gotest.tools/assert"
+)
+
+type historyTestConsensus struct {
+	test_tools.MockConsensus
+	genesisTime time.Time
+}
+
+func (c *historyTestConsensus) SBPReader() core.SBPStatReader {
+	return historyTestSBPReader{timeIndex: core.NewTimeIndex(c.genesisTime, 75*time.Second)}
+}
+
+type historyTestSBPReader struct {
+	core.SBPStatReader
+	timeIndex core.TimeIndex
+}
+
+func (r historyTestSBPReader) GetPeriodTimeIndex() core.TimeIndex {
+	return r.timeIndex
+}
+
+func newHistoryTestChain(t *testing.T) (*chain, func()) {
+	if !fork.IsInitForkPoint() {
+		fork.SetForkPoints(&config.ForkPoints{
+			SeedFork:      &config.ForkPoint{Height: 100, Version: 1},
+			DexFork:       &config.ForkPoint{Height: 200, Version: 2},
+			DexFeeFork:    &config.ForkPoint{Height: 250, Version: 3},
+			StemFork:      &config.ForkPoint{Height: 300, Version: 4},
+			LeafFork:      &config.ForkPoint{Height: 400, Version: 5},
+			EarthFork:     &config.ForkPoint{Height: 500, Version: 6},
+			DexMiningFork: &config.ForkPoint{Height: 600, Version: 7},
+			DexRobotFork:  &config.ForkPoint{Height: 700, Version: 8},
+			CryptoFork:    &config.ForkPoint{Height: 800, Version: 9},
+		})
+	}
+
+	dir, err := ioutil.TempDir("", "history_vm_db")
+	assert.NilError(t, err)
+
+	genesisConfig := &config.Genesis{}
+	assert.NilError(t, json.Unmarshal([]byte(GenesisJson), genesisConfig))
+
+	chainInstance := NewChain(dir, &config.Chain{}, genesisConfig)
+	assert.NilError(t, chainInstance.Init())
+	chainInstance.SetConsensus(&historyTestConsensus{genesisTime: *chainInstance.GetGenesisSnapshotBlock().Timestamp})
+	assert.NilError(t, chainInstance.Start())
+
+	return chainInstance, func() {
+		chainInstance.Stop()
+		os.RemoveAll(dir)
+	}
+}
+
+func insertHistoryTestSendBlock(t *testing.T, chainInstance *chain, from, to *Account, accounts map[types.Address]*Account,
+	quota uint64, keyValue map[string][]byte) *vm_db.VmAccountBlock {
+	vmBlock, err := from.CreateSendBlock(to, &CreateTxOptions{MockSignature: true, Quota: quota, KeyValue: keyValue})
+	assert.NilError(t, err)
+	assert.NilError(t, chainInstance.InsertAccountBlock(vmBlock))
+	from.InsertBlock(vmBlock, accounts)
+	return vmBlock
+}
+
+func TestChain_NewHistoryVmDb(t *testing.T) {
+	chainInstance, clear := newHistoryTestChain(t)
+	defer clear()
+
+	accounts := MakeAccounts(chainInstance, 2)
+	var alice, bob *Account
+	for _, account := range accounts {
+		if alice == nil {
+			alice = account
+		} else {
+			bob = account
+		}
+	}
+
+	send1 := insertHistoryTestSendBlock(t, chainInstance, alice, bob, accounts, 10, map[string][]byte{"a": []byte("1")})
+	sb1, _, err := InsertSnapshotBlock(chainInstance, true)
+	assert.NilError(t, err)
+
+	send2 := insertHistoryTestSendBlock(t, chainInstance, alice, bob, accounts, 20, map[string][]byte{"a": []byte("2"), "b": []byte("1")})
+	send3 := insertHistoryTestSendBlock(t, chainInstance, alice, bob, accounts, 30, map[string][]byte{"a": []byte("3")})
+	_, _, err = InsertSnapshotBlock(chainInstance, true)
+	assert.NilError(t, err)
+
+	db, err := chainInstance.NewHistoryVmDb(send3.AccountBlock)
+	assert.NilError(t, err)
+
+	// the state of sb1 and the changes of send2
+	sb, err := db.LatestSnapshotBlock()
+	assert.NilError(t, err)
+	assert.Equal(t, sb.Hash, sb1.Hash)
+	value, err := db.GetValue([]byte("a"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(value), "2")
+	balance, err := db.GetBalance(&ledger.ViteTokenId)
+	assert.NilError(t, err)
+	assert.Equal(t, balance.Uint64(), uint64(2))
+
+	iter, err := db.NewStorageIterator(nil)
+	assert.NilError(t, err)
+	var kvs []string
+	for iter.Next() {
+		kvs = append(kvs, string(iter.Key())+"="+string(iter.Value()))
+	}
+	assert.NilError(t, iter.Error())
+	iter.Release()
+	assert.DeepEqual(t, kvs, []string{"a=2", "b=1"})
+
+	// the blocks inserted after sb1
+	prevBlock, err := db.PrevAccountBlock()
+	assert.NilError(t, err)
+	assert.Equal(t, prevBlock.Hash, send2.AccountBlock.Hash)
+	latestBlock, err := db.GetLatestAccountBlock(alice.Addr)
+	assert.NilError(t, err)
+	assert.Equal(t, latestBlock.Hash, send2.AccountBlock.Hash)
+	unconfirmedBlocks := db.GetUnconfirmedBlocks(alice.Addr)
+	assert.Equal(t, len(unconfirmedBlocks), 1)
+	assert.Equal(t, unconfirmedBlocks[0].Hash, send2.AccountBlock.Hash)
+
+	confirmedTimes, err := db.GetConfirmedTimes(send1.AccountBlock.Hash)
+	assert.NilError(t, err)
+	assert.Equal(t, confirmedTimes, uint64(1))
+	confirmedTimes, err = db.GetConfirmedTimes(send2.AccountBlock.Hash)
+	assert.NilError(t, err)
+	assert.Equal(t, confirmedTimes, uint64(0))
+
+	quotaUsedList := db.GetQuotaUsedList(alice.Addr)
+	assert.DeepEqual(t, quotaUsedList[len(quotaUsedList)-2], types.QuotaInfo{BlockCount: 1, QuotaTotal: 10})
+	assert.DeepEqual(t, quotaUsedList[len(quotaUsedList)-1], types.QuotaInfo{BlockCount: 1, QuotaTotal: 20})
+	assert.Equal(t, db.GetGlobalQuota().QuotaTotal, uint64(30))
+
+	// the first block after sb1
+	db, err = chainInstance.NewHistoryVmDb(send2.AccountBlock)
+	assert.NilError(t, err)
+	value, err = db.GetValue([]byte("a"))
+	assert.NilError(t, err)
+	assert.Equal(t, string(value), "1")
+	value, err = db.GetValue([]byte("b"))
+	assert.NilError(t, err)
+	assert.Assert(t, len(value) == 0)
+	assert.Equal(t, len(db.GetUnconfirmedBlocks(alice.Addr)), 0)
+}
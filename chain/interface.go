@@ -215,9 +215,15 @@ type Chain interface {
 	// get Balance map
 	GetBalanceMap(addr types.Address) (map[types.TokenTypeId]*big.Int, error)
 
+	// get the Balance map set by the genesis account blocks
+	GetGenesisBalanceMap(addr types.Address) map[types.TokenTypeId]*big.Int
+
 	// get confirmed snapshot Balance, if history is too old, failed
 	GetConfirmedBalanceList(addrList []types.Address, tokenId types.TokenTypeId, sbHash types.Hash) (map[types.Address]*big.Int, error)
 
+	// get the Balance after the snapshot block of snapshotHeight was inserted
+	GetBalanceAt(addr types.Address, tokenId types.TokenTypeId, snapshotHeight uint64) (*big.Int, error)
+
 	// get contract code
 	GetContractCode(contractAddr types.Address) ([]byte, error)
 
@@ -237,6 +243,15 @@ type Chain interface {
 
 	GetValue(address types.Address, key []byte) ([]byte, error)
 
+	// get the storage value after the snapshot block of snapshotHeight was inserted
+	GetValueAt(address types.Address, key []byte, snapshotHeight uint64) ([]byte, error)
+
+	// get a vm db with the state before the account block was inserted
+	NewHistoryVmDb(block *ledger.AccountBlock) (vm_db.VmDb, error)
+
+	// aggregate the redo logs of the snapshot blocks in [fromHeight, toHeight] into the changes of every account
+	GetStateDiff(fromHeight, toHeight uint64, addrList []types.Address) (map[types.Address]*AccountStateDiff, error)
+
 	GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error)
 
 	// ====== Query built-in contract storage ======
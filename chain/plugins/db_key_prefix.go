@@ -1,11 +1,32 @@
 package chain_plugins
 
-import "github.com/vitelabs/go-vite/common/types"
+import (
+	"encoding/binary"
+
+	"github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/types"
+)
 
 const (
 	OnRoadInfoKeyPrefix = byte(1)
 
 	DiffTokenHash = byte(2)
+
+	VmLogTopicKeyPrefix = byte(3)
+
+	VmLogAddressKeyPrefix = byte(4)
+
+	VmLogSnapshotKeyPrefix = byte(5)
+
+	VmLogBloomKeyPrefix = byte(6)
+
+	TxHistoryKeyPrefix = byte(7)
+
+	TxHistorySnapshotKeyPrefix = byte(8)
+
+	IndexedFromKeyPrefix = byte(9)
+
+	TxHistoryTokenKeyPrefix = byte(10)
 )
 
 func CreateOnRoadInfoKey(addr *types.Address, tId *types.TokenTypeId) []byte {
@@ -22,3 +43,121 @@ func CreateOnRoadInfoPrefixKey(addr *types.Address) []byte {
 	key = append(key, addr.Bytes()...)
 	return key
 }
+
+func CreateVmLogTopicKey(position byte, topic types.Hash, snapshotHeight uint64, blockHash types.Hash, logIndex uint32) []byte {
+	key := make([]byte, 0, 1+1+types.HashSize+8+types.HashSize+4)
+	key = append(key, CreateVmLogTopicPrefixKey(position, topic, snapshotHeight)...)
+	key = append(key, blockHash.Bytes()...)
+	key = append(key, uint32ToBytes(logIndex)...)
+	return key
+}
+
+func CreateVmLogTopicPrefixKey(position byte, topic types.Hash, snapshotHeight uint64) []byte {
+	key := make([]byte, 0, 1+1+types.HashSize+8)
+	key = append(key, VmLogTopicKeyPrefix)
+	key = append(key, position)
+	key = append(key, topic.Bytes()...)
+	key = append(key, chain_utils.Uint64ToBytes(snapshotHeight)...)
+	return key
+}
+
+func CreateVmLogAddressKey(addr types.Address, snapshotHeight uint64, blockHash types.Hash, logIndex uint32) []byte {
+	key := make([]byte, 0, 1+types.AddressSize+8+types.HashSize+4)
+	key = append(key, CreateVmLogAddressPrefixKey(addr, snapshotHeight)...)
+	key = append(key, blockHash.Bytes()...)
+	key = append(key, uint32ToBytes(logIndex)...)
+	return key
+}
+
+func CreateVmLogAddressPrefixKey(addr types.Address, snapshotHeight uint64) []byte {
+	key := make([]byte, 0, 1+types.AddressSize+8)
+	key = append(key, VmLogAddressKeyPrefix)
+	key = append(key, addr.Bytes()...)
+	key = append(key, chain_utils.Uint64ToBytes(snapshotHeight)...)
+	return key
+}
+
+func CreateVmLogSnapshotKey(snapshotHeight uint64, blockHash types.Hash) []byte {
+	key := make([]byte, 0, 1+8+types.HashSize)
+	key = append(key, CreateVmLogSnapshotPrefixKey(snapshotHeight)...)
+	key = append(key, blockHash.Bytes()...)
+	return key
+}
+
+func CreateVmLogSnapshotPrefixKey(snapshotHeight uint64) []byte {
+	key := make([]byte, 0, 1+8)
+	key = append(key, VmLogSnapshotKeyPrefix)
+	key = append(key, chain_utils.Uint64ToBytes(snapshotHeight)...)
+	return key
+}
+
+func CreateVmLogBloomKey(snapshotHeight uint64) []byte {
+	key := make([]byte, 0, 1+8)
+	key = append(key, VmLogBloomKeyPrefix)
+	key = append(key, chain_utils.Uint64ToBytes(snapshotHeight)...)
+	return key
+}
+
+func CreateTxHistoryKey(addr types.Address, direction byte, snapshotHeight uint64, sendBlockHash types.Hash) []byte {
+	key := make([]byte, 0, 1+types.AddressSize+1+8+types.HashSize)
+	key = append(key, CreateTxHistoryPrefixKey(addr, direction)...)
+	key = append(key, chain_utils.Uint64ToBytes(snapshotHeight)...)
+	key = append(key, sendBlockHash.Bytes()...)
+	return key
+}
+
+func CreateTxHistoryPrefixKey(addr types.Address, direction byte) []byte {
+	key := make([]byte, 0, 1+types.AddressSize+1)
+	key = append(key, TxHistoryKeyPrefix)
+	key = append(key, addr.Bytes()...)
+	key = append(key, direction)
+	return key
+}
+
+func CreateTxHistoryTokenKey(addr types.Address, tokenId types.TokenTypeId, direction byte, snapshotHeight uint64, sendBlockHash types.Hash) []byte {
+	key := make([]byte, 0, 1+types.AddressSize+types.TokenTypeIdSize+1+8+types.HashSize)
+	key = append(key, CreateTxHistoryTokenPrefixKey(addr, tokenId, direction)...)
+	key = append(key, chain_utils.Uint64ToBytes(snapshotHeight)...)
+	key = append(key, sendBlockHash.Bytes()...)
+	return key
+}
+
+func CreateTxHistoryTokenPrefixKey(addr types.Address, tokenId types.TokenTypeId, direction byte) []byte {
+	key := make([]byte, 0, 1+types.AddressSize+types.TokenTypeIdSize+1)
+	key = append(key, TxHistoryTokenKeyPrefix)
+	key = append(key, addr.Bytes()...)
+	key = append(key, tokenId.Bytes()...)
+	key = append(key, direction)
+	return key
+}
+
+func CreateTxHistorySnapshotKey(snapshotHeight uint64, sendBlockHash types.Hash) []byte {
+	key := make([]byte, 0, 1+8+types.HashSize)
+	key = append(key, CreateTxHistorySnapshotPrefixKey(snapshotHeight)...)
+	key = append(key, sendBlockHash.Bytes()...)
+	return key
+}
+
+func CreateTxHistorySnapshotPrefixKey(snapshotHeight uint64) []byte {
+	key := make([]byte, 0, 1+8)
+	key = append(key, TxHistorySnapshotKeyPrefix)
+	key = append(key, chain_utils.Uint64ToBytes(snapshotHeight)...)
+	return key
+}
+
+func CreateIndexedFromKey(pluginName string) []byte {
+	key := make([]byte, 0, 1+len(pluginName))
+	key = append(key, IndexedFromKeyPrefix)
+	key = append(key, pluginName...)
+	return key
+}
+
+func uint32ToBytes(n uint32) []byte {
+	bytes := make([]byte, 4)
+	binary.BigEndian.PutUint32(bytes, n)
+	return bytes
+}
+
+func bytesToUint32(bytes []byte) uint32 {
+	return binary.BigEndian.Uint32(bytes)
+}
@@ -0,0 +1,59 @@
+package chain_plugins
+
+import (
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/chain/test_tools"
+	"github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// mockChain serves the account blocks and vm logs of a test_tools.MockVmLogChain to the plugins
+type mockChain struct {
+	Chain
+	mock   *test_tools.MockVmLogChain
+	latest *ledger.SnapshotBlock
+}
+
+func newMockChain(mock *test_tools.MockVmLogChain) *mockChain {
+	if mock == nil {
+		mock = test_tools.NewMockVmLogChain(0, 0, nil, nil, 0)
+	}
+	return &mockChain{mock: mock}
+}
+
+func (c *mockChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return c.latest
+}
+
+func (c *mockChain) GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error) {
+	return c.mock.GetAccountBlockByHash(blockHash)
+}
+
+func (c *mockChain) GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error) {
+	return c.mock.GetVmLogList(logListHash)
+}
+
+// addBlock adds an account block of addr at height which emits logList
+func (c *mockChain) addBlock(addr types.Address, height uint64, logList ledger.VmLogList) *ledger.AccountBlock {
+	hash := types.DataHash(append(addr.Bytes(), byte(height)))
+	logHash := types.DataHash(hash.Bytes())
+
+	block := &ledger.AccountBlock{
+		AccountAddress: addr,
+		Height:         height,
+		Hash:           hash,
+		LogHash:        &logHash,
+	}
+	c.mock.AddBlock(block, logList)
+	return block
+}
+
+// markIndexedFrom marks the indexed plugins in store as indexed from height
+func markIndexedFrom(store *chain_db.Store, height uint64) {
+	batch := store.NewBatch()
+	for _, name := range indexedPlugins {
+		batch.Put(CreateIndexedFromKey(name), chain_utils.Uint64ToBytes(height))
+	}
+	store.WriteDirectly(batch)
+}
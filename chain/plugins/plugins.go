@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/helper"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/vm_db"
@@ -15,6 +17,10 @@ import (
 
 const roundSize = uint64(10)
 
+// indexedPlugins index the history by snapshot height. They are added to existing nodes, so the history below
+// the height they are indexed from is missing until RebuildData.
+var indexedPlugins = []string{"vmLogIndex", "vmLogBloom", "txHistory"}
+
 const (
 	stop  = 0
 	start = 1
@@ -45,6 +51,9 @@ func NewPlugins(chainDir string, chain Chain) (*Plugins, error) {
 	plugins := map[string]Plugin{
 		"filterToken": newFilterToken(store, chain),
 		"onRoadInfo":  newOnRoadInfo(store, chain),
+		"vmLogIndex":  newVmLogIndex(store, chain),
+		"vmLogBloom":  newVmLogBloom(store, chain),
+		"txHistory":   newTxHistory(store, chain),
 	}
 
 	return &Plugins{
@@ -166,11 +175,61 @@ func (p *Plugins) RebuildData() error {
 		h = targetH
 	}
 
+	// the history is indexed from genesis
+	batch := p.store.NewBatch()
+	for _, name := range indexedPlugins {
+		if _, ok := p.plugins[name]; ok {
+			batch.Put(CreateIndexedFromKey(name), chain_utils.Uint64ToBytes(1))
+		}
+	}
+	p.store.WriteDirectly(batch)
+	flusher.Flush()
+
 	// success
 	p.log.Info("Succeed rebuild plugin data")
 	return nil
 }
 
+// InitIndexedFrom marks the snapshot height from which the indexed plugins have data, it must be called after the
+// genesis is inserted. The plugins which have no mark yet index from the next snapshot block, or from genesis on a
+// new ledger, because the genesis is inserted after the plugins are registered.
+func (p *Plugins) InitIndexedFrom() error {
+	latestSnapshot := p.chain.GetLatestSnapshotBlock()
+	if latestSnapshot == nil {
+		return errors.New("GetLatestSnapshotBlock fail")
+	}
+
+	from := latestSnapshot.Height + 1
+	if latestSnapshot.Height <= 1 {
+		from = 1
+	}
+
+	batch := p.store.NewBatch()
+	for _, name := range indexedPlugins {
+		if _, ok := p.plugins[name]; !ok {
+			continue
+		}
+
+		ok, err := p.store.Has(CreateIndexedFromKey(name))
+		if err != nil {
+			return err
+		}
+		if ok {
+			continue
+		}
+
+		batch.Put(CreateIndexedFromKey(name), chain_utils.Uint64ToBytes(from))
+		if from > 1 {
+			p.log.Info(fmt.Sprintf("plugin %s is indexed from snapshot height %d, rebuild the plugin data to index the history", name, from), "method", "InitIndexedFrom")
+		}
+	}
+
+	if batch.Len() > 0 {
+		p.store.WriteDirectly(batch)
+	}
+	return nil
+}
+
 func (p *Plugins) Close() error {
 	if err := p.store.Close(); err != nil {
 		return err
@@ -304,3 +363,15 @@ func (p *Plugins) DeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
 func (p *Plugins) checkAndRecover() (*chain_db.Store, error) {
 	return nil, nil
 }
+
+// getIndexedFrom returns the snapshot height from which the plugin has data, helper.MaxUint64 if it is not marked.
+func getIndexedFrom(store *chain_db.Store, pluginName string) (uint64, error) {
+	value, err := store.Get(CreateIndexedFromKey(pluginName))
+	if err != nil {
+		return 0, err
+	}
+	if len(value) < 8 {
+		return helper.MaxUint64, nil
+	}
+	return chain_utils.BytesToUint64(value), nil
+}
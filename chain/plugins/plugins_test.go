@@ -0,0 +1,50 @@
+package chain_plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/vitelabs/go-vite/ledger"
+	"gotest.tools/assert"
+)
+
+func TestPlugins_InitIndexedFrom(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plugins")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	chain := newMockChain(nil)
+
+	// genesis is inserted after the plugins are registered
+	chain.latest = &ledger.SnapshotBlock{Height: 1}
+	p, err := NewPlugins(dir, chain)
+	assert.NilError(t, err)
+	assert.NilError(t, p.InitIndexedFrom())
+
+	vli := p.GetPlugin("vmLogIndex").(*VmLogIndex)
+	from, err := vli.IndexedFrom()
+	assert.NilError(t, err)
+	assert.Equal(t, from, uint64(1))
+
+	// the mark is kept
+	chain.latest = &ledger.SnapshotBlock{Height: 100}
+	assert.NilError(t, p.InitIndexedFrom())
+	from, err = vli.IndexedFrom()
+	assert.NilError(t, err)
+	assert.Equal(t, from, uint64(1))
+	assert.NilError(t, p.Close())
+
+	// plugins opened on an existing ledger
+	assert.NilError(t, os.RemoveAll(dir))
+	p, err = NewPlugins(dir, chain)
+	assert.NilError(t, err)
+	defer p.Close()
+	assert.NilError(t, p.InitIndexedFrom())
+
+	for _, name := range indexedPlugins {
+		from, err := getIndexedFrom(p.Store(), name)
+		assert.NilError(t, err)
+		assert.Equal(t, from, uint64(101))
+	}
+}
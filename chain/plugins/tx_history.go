@@ -0,0 +1,343 @@
+package chain_plugins
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+const (
+	TxDirectionIncoming = byte(1)
+	TxDirectionOutgoing = byte(2)
+)
+
+const txHistoryCursorSize = 8 + types.HashSize + 1
+
+// TxHistory indexes every confirmed send block twice, as an outgoing transfer of the sender
+// and as an incoming transfer of the receiver, keyed on the height of the snapshot block which confirms it.
+// So the incoming transfers are listed even if they are not received yet.
+// Every transfer is keyed on (address, direction, height) and on (address, token, direction, height),
+// so the filters by token and direction are served by the key ranges instead of skipping entries.
+type TxHistory struct {
+	store *chain_db.Store
+	chain Chain
+}
+
+type TxHistoryItem struct {
+	SnapshotHeight uint64
+	Direction      byte
+	Counterparty   types.Address
+	TokenId        types.TokenTypeId
+	SendBlock      *ledger.AccountBlock
+}
+
+// TxHistoryCursor points to the last returned item, the next page starts right before it
+type TxHistoryCursor struct {
+	SnapshotHeight uint64
+	SendBlockHash  types.Hash
+	Direction      byte
+}
+
+func (c *TxHistoryCursor) String() string {
+	buf := make([]byte, 0, txHistoryCursorSize)
+	buf = append(buf, chain_utils.Uint64ToBytes(c.SnapshotHeight)...)
+	buf = append(buf, c.SendBlockHash.Bytes()...)
+	buf = append(buf, c.Direction)
+	return hex.EncodeToString(buf)
+}
+
+func ParseTxHistoryCursor(s string) (*TxHistoryCursor, error) {
+	buf, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(buf) != txHistoryCursorSize {
+		return nil, errors.New("invalid cursor")
+	}
+
+	hash, err := types.BytesToHash(buf[8 : 8+types.HashSize])
+	if err != nil {
+		return nil, err
+	}
+	return &TxHistoryCursor{
+		SnapshotHeight: chain_utils.BytesToUint64(buf[:8]),
+		SendBlockHash:  hash,
+		Direction:      buf[txHistoryCursorSize-1],
+	}, nil
+}
+
+func newTxHistory(store *chain_db.Store, chain Chain) Plugin {
+	return &TxHistory{
+		store: store,
+		chain: chain,
+	}
+}
+
+func (th *TxHistory) SetStore(store *chain_db.Store) {
+	th.store = store
+}
+
+func (th *TxHistory) InsertAccountBlock(batch *leveldb.Batch, accountBlock *ledger.AccountBlock) error {
+	return nil
+}
+
+func (th *TxHistory) InsertSnapshotBlock(batch *leveldb.Batch, snapshotBlock *ledger.SnapshotBlock, confirmedBlocks []*ledger.AccountBlock) error {
+	for _, accountBlock := range confirmedBlocks {
+		if accountBlock.IsSendBlock() {
+			th.writeSendBlock(batch, snapshotBlock.Height, accountBlock)
+			continue
+		}
+
+		// the send blocks triggered by the contract
+		for _, sendBlock := range accountBlock.SendBlockList {
+			th.writeSendBlock(batch, snapshotBlock.Height, sendBlock)
+		}
+	}
+	return nil
+}
+
+func (th *TxHistory) DeleteAccountBlocks(batch *leveldb.Batch, accountBlocks []*ledger.AccountBlock) error {
+	// unconfirmed blocks are not indexed
+	return nil
+}
+
+func (th *TxHistory) DeleteSnapshotBlocks(batch *leveldb.Batch, chunks []*ledger.SnapshotChunk) error {
+	for _, chunk := range chunks {
+		if chunk.SnapshotBlock == nil {
+			continue
+		}
+		if err := th.deleteBySnapshotHeight(batch, chunk.SnapshotBlock.Height); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (th *TxHistory) RemoveNewUnconfirmed(*leveldb.Batch, []*ledger.AccountBlock) error {
+	return nil
+}
+
+// txHistoryIterator iterates the transfers of a key range from the newest to the oldest
+type txHistoryIterator struct {
+	iter      interfaces.StorageIterator
+	prefixLen int
+	direction byte
+	tokenId   *types.TokenTypeId
+	ok        bool
+}
+
+func (it *txHistoryIterator) snapshotHeight() uint64 {
+	return chain_utils.BytesToUint64(it.iter.Key()[it.prefixLen : it.prefixLen+8])
+}
+
+func (it *txHistoryIterator) sendBlockHash() []byte {
+	return it.iter.Key()[it.prefixLen+8 : it.prefixLen+8+types.HashSize]
+}
+
+// newerThan compares the transfers by (snapshot height, send block hash, direction)
+func (it *txHistoryIterator) newerThan(other *txHistoryIterator) bool {
+	if h1, h2 := it.snapshotHeight(), other.snapshotHeight(); h1 != h2 {
+		return h1 > h2
+	}
+	if c := bytes.Compare(it.sendBlockHash(), other.sendBlockHash()); c != 0 {
+		return c > 0
+	}
+	return it.direction > other.direction
+}
+
+// GetTransfers returns the transfers of addr from the newest to the oldest.
+// tokenId is optional and direction 0 means both directions. If cursor is nil, it starts from the newest transfer.
+// The returned cursor is nil if there is no more transfer.
+func (th *TxHistory) GetTransfers(addr types.Address, tokenId *types.TokenTypeId, direction byte, cursor *TxHistoryCursor, count uint64) ([]*TxHistoryItem, *TxHistoryCursor, error) {
+	if count <= 0 {
+		return nil, nil, nil
+	}
+
+	directions := []byte{TxDirectionIncoming, TxDirectionOutgoing}
+	if direction != 0 {
+		directions = []byte{direction}
+	}
+
+	iterators := make([]*txHistoryIterator, 0, len(directions))
+	defer func() {
+		for _, it := range iterators {
+			it.iter.Release()
+		}
+	}()
+
+	for _, d := range directions {
+		var iterRange *util.Range
+		if tokenId != nil {
+			iterRange = util.BytesPrefix(CreateTxHistoryTokenPrefixKey(addr, *tokenId, d))
+			if cursor != nil {
+				iterRange.Limit = CreateTxHistoryTokenKey(addr, *tokenId, d, cursor.SnapshotHeight, cursor.SendBlockHash)
+			}
+		} else {
+			iterRange = util.BytesPrefix(CreateTxHistoryPrefixKey(addr, d))
+			if cursor != nil {
+				iterRange.Limit = CreateTxHistoryKey(addr, d, cursor.SnapshotHeight, cursor.SendBlockHash)
+			}
+		}
+		// the transfer of the cursor block in the lower direction is not returned yet
+		if cursor != nil && d < cursor.Direction {
+			iterRange.Limit = append(iterRange.Limit, 0)
+		}
+
+		it := &txHistoryIterator{
+			iter:      th.store.NewIterator(iterRange),
+			prefixLen: len(iterRange.Start),
+			direction: d,
+			tokenId:   tokenId,
+		}
+		it.ok = it.iter.Last()
+		iterators = append(iterators, it)
+	}
+
+	items := make([]*TxHistoryItem, 0, count)
+	for uint64(len(items)) < count {
+		var newest *txHistoryIterator
+		for _, it := range iterators {
+			if it.ok && (newest == nil || it.newerThan(newest)) {
+				newest = it
+			}
+		}
+		if newest == nil {
+			break
+		}
+
+		item, err := th.parseTransfer(newest)
+		if err != nil {
+			return nil, nil, err
+		}
+		items = append(items, item)
+
+		newest.ok = newest.iter.Prev()
+	}
+
+	for _, it := range iterators {
+		if err := it.iter.Error(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if uint64(len(items)) < count {
+		return items, nil, nil
+	}
+
+	last := items[len(items)-1]
+	return items, &TxHistoryCursor{
+		SnapshotHeight: last.SnapshotHeight,
+		SendBlockHash:  last.SendBlock.Hash,
+		Direction:      last.Direction,
+	}, nil
+}
+
+func (th *TxHistory) parseTransfer(it *txHistoryIterator) (*TxHistoryItem, error) {
+	value := it.iter.Value()
+
+	var itemTokenId types.TokenTypeId
+	if it.tokenId != nil {
+		itemTokenId = *it.tokenId
+	} else {
+		var err error
+		if itemTokenId, err = types.BytesToTokenTypeId(value[:types.TokenTypeIdSize]); err != nil {
+			return nil, err
+		}
+		value = value[types.TokenTypeIdSize:]
+	}
+
+	counterparty, err := types.BytesToAddress(value)
+	if err != nil {
+		return nil, err
+	}
+
+	sendBlockHash, err := types.BytesToHash(it.sendBlockHash())
+	if err != nil {
+		return nil, err
+	}
+
+	sendBlock, err := th.chain.GetAccountBlockByHash(sendBlockHash)
+	if err != nil {
+		return nil, err
+	}
+	if sendBlock == nil {
+		return nil, errors.New(fmt.Sprintf("send block %s is not existed", sendBlockHash))
+	}
+
+	return &TxHistoryItem{
+		SnapshotHeight: it.snapshotHeight(),
+		Direction:      it.direction,
+		Counterparty:   counterparty,
+		TokenId:        itemTokenId,
+		SendBlock:      sendBlock,
+	}, nil
+}
+
+func (th *TxHistory) writeSendBlock(batch *leveldb.Batch, snapshotHeight uint64, sendBlock *ledger.AccountBlock) {
+	from := sendBlock.AccountAddress
+	to := sendBlock.ToAddress
+	tokenId := sendBlock.TokenId
+
+	batch.Put(CreateTxHistoryKey(from, TxDirectionOutgoing, snapshotHeight, sendBlock.Hash), txHistoryValue(tokenId, to))
+	batch.Put(CreateTxHistoryKey(to, TxDirectionIncoming, snapshotHeight, sendBlock.Hash), txHistoryValue(tokenId, from))
+
+	batch.Put(CreateTxHistoryTokenKey(from, tokenId, TxDirectionOutgoing, snapshotHeight, sendBlock.Hash), to.Bytes())
+	batch.Put(CreateTxHistoryTokenKey(to, tokenId, TxDirectionIncoming, snapshotHeight, sendBlock.Hash), from.Bytes())
+
+	// for rollback
+	value := make([]byte, 0, 2*types.AddressSize+types.TokenTypeIdSize)
+	value = append(value, from.Bytes()...)
+	value = append(value, to.Bytes()...)
+	value = append(value, tokenId.Bytes()...)
+	batch.Put(CreateTxHistorySnapshotKey(snapshotHeight, sendBlock.Hash), value)
+}
+
+func (th *TxHistory) deleteBySnapshotHeight(batch *leveldb.Batch, snapshotHeight uint64) error {
+	iter := th.store.NewIterator(util.BytesPrefix(CreateTxHistorySnapshotPrefixKey(snapshotHeight)))
+	defer iter.Release()
+
+	for iter.Next() {
+		key := iter.Key()
+		value := iter.Value()
+
+		sendBlockHash, err := types.BytesToHash(key[1+8:])
+		if err != nil {
+			return err
+		}
+		from, err := types.BytesToAddress(value[:types.AddressSize])
+		if err != nil {
+			return err
+		}
+		to, err := types.BytesToAddress(value[types.AddressSize : 2*types.AddressSize])
+		if err != nil {
+			return err
+		}
+		tokenId, err := types.BytesToTokenTypeId(value[2*types.AddressSize:])
+		if err != nil {
+			return err
+		}
+
+		batch.Delete(CreateTxHistoryKey(from, TxDirectionOutgoing, snapshotHeight, sendBlockHash))
+		batch.Delete(CreateTxHistoryKey(to, TxDirectionIncoming, snapshotHeight, sendBlockHash))
+		batch.Delete(CreateTxHistoryTokenKey(from, tokenId, TxDirectionOutgoing, snapshotHeight, sendBlockHash))
+		batch.Delete(CreateTxHistoryTokenKey(to, tokenId, TxDirectionIncoming, snapshotHeight, sendBlockHash))
+		batch.Delete(key)
+	}
+
+	return iter.Error()
+}
+
+func txHistoryValue(tokenId types.TokenTypeId, counterparty types.Address) []byte {
+	value := make([]byte, 0, types.TokenTypeIdSize+types.AddressSize)
+	value = append(value, tokenId.Bytes()...)
+	value = append(value, counterparty.Bytes()...)
+	return value
+}
@@ -0,0 +1,160 @@
+package chain_plugins
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"gotest.tools/assert"
+)
+
+func TestTxHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tx_history")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	store, err := chain_db.NewStore(dir, "plugins")
+	assert.NilError(t, err)
+	defer store.Close()
+
+	markIndexedFrom(store, 1)
+	chain := newMockChain(nil)
+	th := newTxHistory(store, chain).(*TxHistory)
+
+	alice, bob, contract := types.AddressGovernance, types.AddressDexTrade, types.AddressAsset
+	newBlock := func(blockType byte, from, to types.Address, tokenId types.TokenTypeId, height uint64) *ledger.AccountBlock {
+		block := &ledger.AccountBlock{
+			BlockType:      blockType,
+			AccountAddress: from,
+			ToAddress:      to,
+			TokenId:        tokenId,
+			Amount:         big.NewInt(1),
+			Height:         height,
+			Hash:           types.DataHash(append(from.Bytes(), byte(height))),
+		}
+		chain.mock.AddBlock(block, nil)
+		return block
+	}
+
+	// alice -> bob, alice -> contract, contract -> bob triggered by the receive of contract
+	send1 := newBlock(ledger.BlockTypeSendCall, alice, bob, ledger.ViteTokenId, 1)
+	send2 := newBlock(ledger.BlockTypeSendCall, alice, contract, ledger.VCPTokenId, 2)
+	receive := newBlock(ledger.BlockTypeReceive, contract, types.Address{}, types.TokenTypeId{}, 1)
+	triggered := newBlock(ledger.BlockTypeSendCall, contract, bob, ledger.ViteTokenId, 2)
+	receive.SendBlockList = []*ledger.AccountBlock{triggered}
+
+	insert := func(height uint64, blocks ...*ledger.AccountBlock) {
+		batch := store.NewBatch()
+		assert.NilError(t, th.InsertSnapshotBlock(batch, &ledger.SnapshotBlock{Height: height}, blocks))
+		store.WriteDirectly(batch)
+	}
+	insert(10, send1)
+	insert(11, send2)
+	insert(12, receive)
+
+	// bob has not received any of them
+	items, cursor, err := th.GetTransfers(bob, nil, 0, nil, 10)
+	assert.NilError(t, err)
+	assert.Assert(t, cursor == nil)
+	assert.Equal(t, len(items), 2)
+	assert.Equal(t, items[0].SendBlock.Hash, triggered.Hash)
+	assert.Equal(t, items[0].Counterparty, contract)
+	assert.Equal(t, items[0].Direction, TxDirectionIncoming)
+	assert.Equal(t, items[1].SendBlock.Hash, send1.Hash)
+
+	// direction and token filter
+	items, _, err = th.GetTransfers(alice, nil, TxDirectionIncoming, nil, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(items), 0)
+
+	items, _, err = th.GetTransfers(alice, &ledger.VCPTokenId, TxDirectionOutgoing, nil, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(items), 1)
+	assert.Equal(t, items[0].SendBlock.Hash, send2.Hash)
+
+	items, _, err = th.GetTransfers(contract, nil, 0, nil, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(items), 2)
+	assert.Equal(t, items[0].Direction, TxDirectionOutgoing)
+	assert.Equal(t, items[1].Direction, TxDirectionIncoming)
+
+	// pagination by cursor
+	items, cursor, err = th.GetTransfers(alice, nil, 0, nil, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(items), 1)
+	assert.Equal(t, items[0].SendBlock.Hash, send2.Hash)
+	assert.Assert(t, cursor != nil)
+
+	cursor, err = ParseTxHistoryCursor(cursor.String())
+	assert.NilError(t, err)
+
+	items, cursor, err = th.GetTransfers(alice, nil, 0, cursor, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(items), 1)
+	assert.Equal(t, items[0].SendBlock.Hash, send1.Hash)
+
+	items, cursor, err = th.GetTransfers(alice, nil, 0, cursor, 1)
+	assert.NilError(t, err)
+	assert.Equal(t, len(items), 0)
+	assert.Assert(t, cursor == nil)
+
+	// the transfers of a rare token and of a direction are found by key range
+	for i := uint64(0); i < 50; i++ {
+		insert(20+i, newBlock(ledger.BlockTypeSendCall, alice, bob, ledger.ViteTokenId, 10+i))
+	}
+	items, cursor, err = th.GetTransfers(alice, &ledger.VCPTokenId, 0, nil, 10)
+	assert.NilError(t, err)
+	assert.Assert(t, cursor == nil)
+	assert.Equal(t, len(items), 1)
+	assert.Equal(t, items[0].SendBlock.Hash, send2.Hash)
+	assert.Equal(t, items[0].TokenId, ledger.VCPTokenId)
+
+	items, _, err = th.GetTransfers(bob, &ledger.ViteTokenId, TxDirectionIncoming, nil, 100)
+	assert.NilError(t, err)
+	assert.Equal(t, len(items), 52)
+	assert.Equal(t, items[0].SnapshotHeight, uint64(69))
+
+	// a transfer to itself is listed in both directions, the cursor keeps the direction
+	self := newBlock(ledger.BlockTypeSendCall, bob, bob, ledger.ViteTokenId, 1)
+	insert(70, self)
+	var selfItems []*TxHistoryItem
+	for cursor = nil; len(selfItems) < 3; {
+		items, cursor, err = th.GetTransfers(bob, &ledger.ViteTokenId, 0, cursor, 1)
+		assert.NilError(t, err)
+		selfItems = append(selfItems, items...)
+	}
+	assert.Equal(t, selfItems[0].SendBlock.Hash, self.Hash)
+	assert.Equal(t, selfItems[0].Direction, TxDirectionOutgoing)
+	assert.Equal(t, selfItems[1].SendBlock.Hash, self.Hash)
+	assert.Equal(t, selfItems[1].Direction, TxDirectionIncoming)
+	assert.Equal(t, selfItems[2].SnapshotHeight, uint64(69))
+
+	// rollback
+	batch := store.NewBatch()
+	assert.NilError(t, th.DeleteSnapshotBlocks(batch, []*ledger.SnapshotChunk{
+		{SnapshotBlock: &ledger.SnapshotBlock{Height: 70}, AccountBlocks: []*ledger.AccountBlock{self}},
+	}))
+	for i := uint64(0); i < 50; i++ {
+		assert.NilError(t, th.DeleteSnapshotBlocks(batch, []*ledger.SnapshotChunk{{SnapshotBlock: &ledger.SnapshotBlock{Height: 20 + i}}}))
+	}
+	store.WriteDirectly(batch)
+
+	items, _, err = th.GetTransfers(bob, &ledger.ViteTokenId, 0, nil, 100)
+	assert.NilError(t, err)
+	assert.Equal(t, len(items), 2)
+
+	batch = store.NewBatch()
+	assert.NilError(t, th.DeleteSnapshotBlocks(batch, []*ledger.SnapshotChunk{
+		{SnapshotBlock: &ledger.SnapshotBlock{Height: 12}, AccountBlocks: []*ledger.AccountBlock{receive}},
+	}))
+	store.WriteDirectly(batch)
+
+	items, _, err = th.GetTransfers(bob, nil, 0, nil, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(items), 1)
+	assert.Equal(t, items[0].SendBlock.Hash, send1.Hash)
+}
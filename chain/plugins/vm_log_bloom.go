@@ -0,0 +1,244 @@
+package chain_plugins
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/bloom"
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"sync"
+)
+
+// VmLogBloom records a log bloom for every snapshot chunk, built from the addresses and topics
+// of the vm logs confirmed by the snapshot block. Chunks without any vm log are not recorded, so a chunk
+// without bloom has no vm log only if it is not lower than IndexedFrom.
+type VmLogBloom struct {
+	store *chain_db.Store
+	chain Chain
+
+	// blooms of the latest rolled back snapshot chunks, nil means the chunk has no vm log
+	rolledBack map[uint64]*bloom.LogBloom
+	mu         sync.RWMutex
+}
+
+func newVmLogBloom(store *chain_db.Store, chain Chain) Plugin {
+	return &VmLogBloom{
+		store: store,
+		chain: chain,
+	}
+}
+
+func (vlb *VmLogBloom) SetStore(store *chain_db.Store) {
+	vlb.store = store
+}
+
+func (vlb *VmLogBloom) InsertAccountBlock(batch *leveldb.Batch, accountBlock *ledger.AccountBlock) error {
+	return nil
+}
+
+func (vlb *VmLogBloom) InsertSnapshotBlock(batch *leveldb.Batch, snapshotBlock *ledger.SnapshotBlock, confirmedBlocks []*ledger.AccountBlock) error {
+	var logBloom bloom.LogBloom
+
+	for _, accountBlock := range confirmedBlocks {
+		if accountBlock.LogHash == nil {
+			continue
+		}
+
+		logList, err := vlb.chain.GetVmLogList(accountBlock.LogHash)
+		if err != nil {
+			return errors.New(fmt.Sprintf("vlb.chain.GetVmLogList failed, logHash is %s. Error: %s", accountBlock.LogHash, err))
+		}
+
+		AddVmLogsToBloom(&logBloom, accountBlock.AccountAddress, logList)
+	}
+
+	if !logBloom.IsEmpty() {
+		batch.Put(CreateVmLogBloomKey(snapshotBlock.Height), logBloom.Bytes())
+	}
+	return nil
+}
+
+func (vlb *VmLogBloom) DeleteAccountBlocks(batch *leveldb.Batch, accountBlocks []*ledger.AccountBlock) error {
+	// unconfirmed blocks are not recorded
+	return nil
+}
+
+func (vlb *VmLogBloom) DeleteSnapshotBlocks(batch *leveldb.Batch, chunks []*ledger.SnapshotChunk) error {
+	rolledBack := make(map[uint64]*bloom.LogBloom, len(chunks))
+	for _, chunk := range chunks {
+		if chunk.SnapshotBlock == nil {
+			continue
+		}
+
+		logBloom, ok, err := vlb.GetBloom(chunk.SnapshotBlock.Height)
+		if err != nil {
+			return err
+		}
+		if ok {
+			rolledBack[chunk.SnapshotBlock.Height] = logBloom
+		}
+
+		batch.Delete(CreateVmLogBloomKey(chunk.SnapshotBlock.Height))
+	}
+
+	vlb.mu.Lock()
+	vlb.rolledBack = rolledBack
+	vlb.mu.Unlock()
+	return nil
+}
+
+func (vlb *VmLogBloom) RemoveNewUnconfirmed(*leveldb.Batch, []*ledger.AccountBlock) error {
+	return nil
+}
+
+// IndexedFrom returns the snapshot height from which the log blooms are recorded
+func (vlb *VmLogBloom) IndexedFrom() (uint64, error) {
+	return getIndexedFrom(vlb.store, "vmLogBloom")
+}
+
+// GetBloom returns nil if the snapshot chunk has no vm log. ok is false if the chunk is lower than IndexedFrom,
+// whether it has vm logs is unknown.
+func (vlb *VmLogBloom) GetBloom(snapshotHeight uint64) (logBloom *bloom.LogBloom, ok bool, err error) {
+	indexedFrom, err := vlb.IndexedFrom()
+	if err != nil {
+		return nil, false, err
+	}
+	if snapshotHeight < indexedFrom {
+		return nil, false, nil
+	}
+
+	value, err := vlb.store.Get(CreateVmLogBloomKey(snapshotHeight))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(value) <= 0 {
+		return nil, true, nil
+	}
+	logBloom, err = bloom.BytesToLogBloom(value)
+	if err != nil {
+		return nil, false, err
+	}
+	return logBloom, true, nil
+}
+
+// GetRolledBackBloom returns the bloom of a snapshot chunk deleted by the latest rollback, so that listeners
+// triggered after the plugins can still skip the chunk. ok is false if the chunk is not in the latest rollback.
+func (vlb *VmLogBloom) GetRolledBackBloom(snapshotHeight uint64) (logBloom *bloom.LogBloom, ok bool) {
+	vlb.mu.RLock()
+	defer vlb.mu.RUnlock()
+
+	logBloom, ok = vlb.rolledBack[snapshotHeight]
+	return
+}
+
+// FilterHeights returns the heights in [fromHeight, toHeight] whose bloom may contain the logs
+// matching addrList and topics, in ascending order. Snapshot chunks without vm log are skipped,
+// the heights lower than IndexedFrom are all returned.
+func (vlb *VmLogBloom) FilterHeights(fromHeight, toHeight uint64, addrList []types.Address, topics [][]types.Hash) ([]uint64, error) {
+	if toHeight < fromHeight {
+		return nil, errors.New(fmt.Sprintf("toHeight %d < fromHeight %d", toHeight, fromHeight))
+	}
+	if toHeight == helper.MaxUint64 {
+		toHeight--
+	}
+
+	indexedFrom, err := vlb.IndexedFrom()
+	if err != nil {
+		return nil, err
+	}
+
+	heights := make([]uint64, 0)
+	for ; fromHeight < indexedFrom; fromHeight++ {
+		heights = append(heights, fromHeight)
+		if fromHeight == toHeight {
+			return heights, nil
+		}
+	}
+
+	iter := vlb.store.NewIterator(&util.Range{Start: CreateVmLogBloomKey(fromHeight), Limit: CreateVmLogBloomKey(toHeight + 1)})
+	defer iter.Release()
+
+	for iter.Next() {
+		logBloom, err := bloom.BytesToLogBloom(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		if BloomMatches(logBloom, addrList, topics) {
+			heights = append(heights, chain_utils.BytesToUint64(iter.Key()[1:]))
+		}
+	}
+
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+	return heights, nil
+}
+
+// MayContain returns false only if no snapshot chunk in [fromHeight, toHeight] contains the logs matching addrList and topics
+func (vlb *VmLogBloom) MayContain(fromHeight, toHeight uint64, addrList []types.Address, topics [][]types.Hash) (bool, error) {
+	indexedFrom, err := vlb.IndexedFrom()
+	if err != nil {
+		return false, err
+	}
+	if fromHeight < indexedFrom {
+		return true, nil
+	}
+
+	heights, err := vlb.FilterHeights(fromHeight, toHeight, addrList, topics)
+	if err != nil {
+		return false, err
+	}
+	return len(heights) > 0, nil
+}
+
+func AddVmLogsToBloom(logBloom *bloom.LogBloom, addr types.Address, logList ledger.VmLogList) {
+	if len(logList) <= 0 {
+		return
+	}
+
+	logBloom.Add(addr.Bytes())
+	for _, vmLog := range logList {
+		for _, topic := range vmLog.Topics {
+			logBloom.Add(topic.Bytes())
+		}
+	}
+}
+
+// BloomMatches follows the rule of the log filter: any address of addrList, and any topic of every non-empty topics[i].
+// Positions of topics are not recorded in the bloom.
+func BloomMatches(logBloom *bloom.LogBloom, addrList []types.Address, topics [][]types.Hash) bool {
+	if len(addrList) > 0 {
+		matched := false
+		for _, addr := range addrList {
+			if logBloom.Test(addr.Bytes()) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	for _, topicList := range topics {
+		if len(topicList) == 0 {
+			continue
+		}
+		matched := false
+		for _, topic := range topicList {
+			if logBloom.Test(topic.Bytes()) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,188 @@
+package chain_plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/chain/test_tools"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"gotest.tools/assert"
+)
+
+var (
+	bloomTestContracts = []types.Address{types.AddressQuota, types.AddressGovernance, types.AddressAsset}
+	bloomTestTopics    = []types.Hash{
+		types.DataHash([]byte("Rare")),
+		types.DataHash([]byte("Transfer")),
+		types.DataHash([]byte("Approve")),
+		types.DataHash([]byte("Mint")),
+	}
+)
+
+func newTestVmLogBloom(tb testing.TB, snapshotCount, blocksPerChunk, hitRate int) (*VmLogBloom, *test_tools.MockVmLogChain, func()) {
+	dir, err := ioutil.TempDir("", "vm_log_bloom")
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	store, err := chain_db.NewStore(dir, "plugins")
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	markIndexedFrom(store, 1)
+
+	mock := test_tools.NewMockVmLogChain(snapshotCount, blocksPerChunk, bloomTestContracts, bloomTestTopics, hitRate)
+	vlb := newVmLogBloom(store, newMockChain(mock)).(*VmLogBloom)
+
+	for _, chunk := range mock.Chunks {
+		batch := store.NewBatch()
+		if err := vlb.InsertSnapshotBlock(batch, chunk.SnapshotBlock, chunk.AccountBlocks); err != nil {
+			tb.Fatal(err)
+		}
+		store.WriteDirectly(batch)
+	}
+
+	return vlb, mock, func() {
+		store.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestVmLogBloom(t *testing.T) {
+	vlb, mock, clean := newTestVmLogBloom(t, 200, 5, 50)
+	defer clean()
+
+	topics := [][]types.Hash{{bloomTestTopics[0]}}
+
+	// no false negative
+	heights, err := vlb.FilterHeights(1, 200, nil, topics)
+	assert.NilError(t, err)
+
+	matched := make(map[uint64]bool, len(heights))
+	for _, h := range heights {
+		matched[h] = true
+	}
+
+	expected := 0
+	for _, chunk := range mock.Chunks {
+		for _, block := range chunk.AccountBlocks {
+			logList, err := mock.GetVmLogList(block.LogHash)
+			assert.NilError(t, err)
+			if matchTopics(logList[0], topics) {
+				assert.Assert(t, matched[chunk.SnapshotBlock.Height], "height %d", chunk.SnapshotBlock.Height)
+				expected++
+			}
+		}
+	}
+	assert.Assert(t, expected > 0)
+	assert.Assert(t, len(heights) < 200)
+
+	// unknown address
+	mayContain, err := vlb.MayContain(1, 200, []types.Address{types.AddressDexFund}, nil)
+	assert.NilError(t, err)
+	assert.Assert(t, !mayContain)
+
+	// rollback
+	last := mock.Chunks[len(mock.Chunks)-1]
+	batch := vlb.store.NewBatch()
+	assert.NilError(t, vlb.DeleteSnapshotBlocks(batch, []*ledger.SnapshotChunk{last}))
+	vlb.store.WriteDirectly(batch)
+
+	logBloom, ok, err := vlb.GetBloom(last.SnapshotBlock.Height)
+	assert.NilError(t, err)
+	assert.Assert(t, ok)
+	assert.Assert(t, logBloom == nil)
+
+	rolledBack, ok := vlb.GetRolledBackBloom(last.SnapshotBlock.Height)
+	assert.Assert(t, ok)
+	assert.Assert(t, rolledBack != nil)
+}
+
+func TestVmLogBloom_indexedFrom(t *testing.T) {
+	vlb, _, clean := newTestVmLogBloom(t, 200, 5, 50)
+	defer clean()
+
+	// the chunks lower than 101 are not recorded, as on a node which opens the plugin at 100
+	batch := vlb.store.NewBatch()
+	for h := uint64(1); h <= 100; h++ {
+		batch.Delete(CreateVmLogBloomKey(h))
+	}
+	vlb.store.WriteDirectly(batch)
+	markIndexedFrom(vlb.store, 101)
+
+	unknown := []types.Address{types.AddressDexFund}
+
+	mayContain, err := vlb.MayContain(1, 200, unknown, nil)
+	assert.NilError(t, err)
+	assert.Assert(t, mayContain)
+
+	mayContain, err = vlb.MayContain(101, 200, unknown, nil)
+	assert.NilError(t, err)
+	assert.Assert(t, !mayContain)
+
+	heights, err := vlb.FilterHeights(91, 200, unknown, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(heights), 10)
+	assert.Equal(t, heights[9], uint64(100))
+
+	_, ok, err := vlb.GetBloom(50)
+	assert.NilError(t, err)
+	assert.Assert(t, !ok)
+
+	// the rollback of an unknown chunk is not recorded
+	assert.NilError(t, vlb.DeleteSnapshotBlocks(vlb.store.NewBatch(), []*ledger.SnapshotChunk{{SnapshotBlock: &ledger.SnapshotBlock{Height: 50}}}))
+	_, ok = vlb.GetRolledBackBloom(50)
+	assert.Assert(t, !ok)
+}
+
+func scanLogs(b *testing.B, mock *test_tools.MockVmLogChain, chunks []*ledger.SnapshotChunk, topics [][]types.Hash) int {
+	count := 0
+	for _, chunk := range chunks {
+		for _, block := range chunk.AccountBlocks {
+			logList, err := mock.GetVmLogList(block.LogHash)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, vmLog := range logList {
+				if matchTopics(vmLog, topics) {
+					count++
+				}
+			}
+		}
+	}
+	return count
+}
+
+func BenchmarkVmLogBloom(b *testing.B) {
+	const snapshotCount = 2000
+
+	vlb, mock, clean := newTestVmLogBloom(b, snapshotCount, 10, 1000)
+	defer clean()
+
+	topics := [][]types.Hash{{bloomTestTopics[0]}}
+
+	b.Run("FullScan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			scanLogs(b, mock, mock.Chunks, topics)
+		}
+	})
+
+	b.Run("BloomFiltered", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			heights, err := vlb.FilterHeights(1, snapshotCount, nil, topics)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			chunks := make([]*ledger.SnapshotChunk, 0, len(heights))
+			for _, h := range heights {
+				chunks = append(chunks, mock.Chunks[h-1])
+			}
+			scanLogs(b, mock, chunks, topics)
+		}
+	})
+}
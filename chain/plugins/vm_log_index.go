@@ -0,0 +1,391 @@
+package chain_plugins
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/chain/utils"
+	"github.com/vitelabs/go-vite/common/db/xleveldb"
+	"github.com/vitelabs/go-vite/common/db/xleveldb/util"
+	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"sort"
+)
+
+// only the first maxIndexedTopics topics of a vm log are indexed, the same as the number of event topics
+const maxIndexedTopics = 4
+
+// VmLogIndex indexes the vm logs of confirmed account blocks by topic and by contract address,
+// keyed on the height of the snapshot block which confirms the account block.
+type VmLogIndex struct {
+	store *chain_db.Store
+	chain Chain
+}
+
+type VmLogIndexResult struct {
+	SnapshotHeight uint64
+	AccountBlock   *ledger.AccountBlock
+	LogIndex       uint32
+	Log            *ledger.VmLog
+}
+
+type vmLogLocation struct {
+	snapshotHeight uint64
+	blockHash      types.Hash
+	logIndex       uint32
+}
+
+func newVmLogIndex(store *chain_db.Store, chain Chain) Plugin {
+	return &VmLogIndex{
+		store: store,
+		chain: chain,
+	}
+}
+
+func (vli *VmLogIndex) SetStore(store *chain_db.Store) {
+	vli.store = store
+}
+
+func (vli *VmLogIndex) InsertAccountBlock(batch *leveldb.Batch, accountBlock *ledger.AccountBlock) error {
+	return nil
+}
+
+func (vli *VmLogIndex) InsertSnapshotBlock(batch *leveldb.Batch, snapshotBlock *ledger.SnapshotBlock, confirmedBlocks []*ledger.AccountBlock) error {
+	for _, accountBlock := range confirmedBlocks {
+		if accountBlock.LogHash == nil {
+			continue
+		}
+
+		logList, err := vli.chain.GetVmLogList(accountBlock.LogHash)
+		if err != nil {
+			return errors.New(fmt.Sprintf("vli.chain.GetVmLogList failed, logHash is %s. Error: %s", accountBlock.LogHash, err))
+		}
+
+		if err := vli.writeLogList(batch, snapshotBlock.Height, accountBlock, logList); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (vli *VmLogIndex) DeleteAccountBlocks(batch *leveldb.Batch, accountBlocks []*ledger.AccountBlock) error {
+	// unconfirmed blocks are not indexed
+	return nil
+}
+
+func (vli *VmLogIndex) DeleteSnapshotBlocks(batch *leveldb.Batch, chunks []*ledger.SnapshotChunk) error {
+	for _, chunk := range chunks {
+		if chunk.SnapshotBlock == nil {
+			continue
+		}
+		if err := vli.deleteBySnapshotHeight(batch, chunk.SnapshotBlock.Height); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (vli *VmLogIndex) RemoveNewUnconfirmed(*leveldb.Batch, []*ledger.AccountBlock) error {
+	return nil
+}
+
+// IndexedFrom returns the snapshot height from which the vm logs are indexed
+func (vli *VmLogIndex) IndexedFrom() (uint64, error) {
+	return getIndexedFrom(vli.store, "vmLogIndex")
+}
+
+// GetLogs returns the vm logs confirmed by snapshot blocks in [fromHeight, toHeight], ordered by snapshot height.
+// addrList and topics are optional, but at least one of them must be usable for the index lookup.
+// topics follow the same rule as the log filter: topics[i] matches any of the hashes at position i, empty matches all.
+//
+// At most count index entries are loaded from each lookup key, the logs of a snapshot block are never split
+// across pages, so a page may have a few more or less than count logs. nextHeight is the fromHeight of the
+// next page, it is 0 if all the logs in range are returned. It fails if fromHeight is lower than IndexedFrom.
+func (vli *VmLogIndex) GetLogs(fromHeight, toHeight uint64, addrList []types.Address, topics [][]types.Hash, count int) (results []*VmLogIndexResult, nextHeight uint64, err error) {
+	if toHeight < fromHeight {
+		return nil, 0, errors.New(fmt.Sprintf("toHeight %d < fromHeight %d", toHeight, fromHeight))
+	}
+	if count <= 0 {
+		return nil, 0, errors.New(fmt.Sprintf("count %d should be positive", count))
+	}
+	if toHeight == helper.MaxUint64 {
+		toHeight--
+	}
+
+	indexedFrom, err := vli.IndexedFrom()
+	if err != nil {
+		return nil, 0, err
+	}
+	if fromHeight < indexedFrom {
+		return nil, 0, errors.New(fmt.Sprintf("vm logs are indexed from snapshot height %d, rebuild the plugin data to query lower heights", indexedFrom))
+	}
+
+	locations, lastHeight, err := vli.findLocations(fromHeight, toHeight, addrList, topics, count)
+	if err != nil {
+		return nil, 0, err
+	}
+	if lastHeight < toHeight {
+		nextHeight = lastHeight + 1
+	}
+
+	addrMap := make(map[types.Address]struct{}, len(addrList))
+	for _, addr := range addrList {
+		addrMap[addr] = struct{}{}
+	}
+
+	blockCache := make(map[types.Hash]*ledger.AccountBlock)
+	logListCache := make(map[types.Hash]ledger.VmLogList)
+
+	results = make([]*VmLogIndexResult, 0, len(locations))
+	for _, location := range locations {
+		block, ok := blockCache[location.blockHash]
+		if !ok {
+			block, err = vli.chain.GetAccountBlockByHash(location.blockHash)
+			if err != nil {
+				return nil, 0, err
+			}
+			if block == nil {
+				return nil, 0, errors.New(fmt.Sprintf("block %s is not existed", location.blockHash))
+			}
+			blockCache[location.blockHash] = block
+		}
+
+		if len(addrMap) > 0 {
+			if _, ok := addrMap[block.AccountAddress]; !ok {
+				continue
+			}
+		}
+
+		logList, ok := logListCache[location.blockHash]
+		if !ok {
+			logList, err = vli.chain.GetVmLogList(block.LogHash)
+			if err != nil {
+				return nil, 0, err
+			}
+			logListCache[location.blockHash] = logList
+		}
+
+		if int(location.logIndex) >= len(logList) {
+			return nil, 0, errors.New(fmt.Sprintf("log index %d of block %s is out of range", location.logIndex, location.blockHash))
+		}
+
+		vmLog := logList[location.logIndex]
+		if !matchTopics(vmLog, topics) {
+			continue
+		}
+
+		results = append(results, &VmLogIndexResult{
+			SnapshotHeight: location.snapshotHeight,
+			AccountBlock:   block,
+			LogIndex:       location.logIndex,
+			Log:            vmLog,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.SnapshotHeight != b.SnapshotHeight {
+			return a.SnapshotHeight < b.SnapshotHeight
+		}
+		if a.AccountBlock.AccountAddress != b.AccountBlock.AccountAddress {
+			return bytes.Compare(a.AccountBlock.AccountAddress.Bytes(), b.AccountBlock.AccountAddress.Bytes()) < 0
+		}
+		if a.AccountBlock.Height != b.AccountBlock.Height {
+			return a.AccountBlock.Height < b.AccountBlock.Height
+		}
+		return a.LogIndex < b.LogIndex
+	})
+
+	return results, nextHeight, nil
+}
+
+// findLocations looks up the index keys, at most count locations and the rest ones in the same snapshot block are
+// loaded from each key. lastHeight is the highest snapshot height of which all the locations are loaded, the
+// locations higher than it are dropped.
+func (vli *VmLogIndex) findLocations(fromHeight, toHeight uint64, addrList []types.Address, topics [][]types.Hash, count int) (locations []vmLogLocation, lastHeight uint64, err error) {
+	var ranges [][2][]byte
+
+	// prefer the topic index, the first non-empty indexed position is enough to narrow the candidates
+	for position, topicList := range topics {
+		if position >= maxIndexedTopics {
+			break
+		}
+		if len(topicList) == 0 {
+			continue
+		}
+
+		for _, topic := range topicList {
+			ranges = append(ranges, [2][]byte{
+				CreateVmLogTopicPrefixKey(byte(position), topic, fromHeight),
+				CreateVmLogTopicPrefixKey(byte(position), topic, toHeight+1),
+			})
+		}
+		break
+	}
+
+	if len(ranges) == 0 {
+		if len(addrList) <= 0 {
+			return nil, 0, errors.New("addresses and indexed topics are both empty")
+		}
+
+		for _, addr := range addrList {
+			ranges = append(ranges, [2][]byte{
+				CreateVmLogAddressPrefixKey(addr, fromHeight),
+				CreateVmLogAddressPrefixKey(addr, toHeight+1),
+			})
+		}
+	}
+
+	lastHeight = toHeight
+	for _, r := range ranges {
+		height, truncated, err := vli.iterateLocations(r[0], r[1], len(r[0])-8, count, &locations)
+		if err != nil {
+			return nil, 0, err
+		}
+		if truncated && height < lastHeight {
+			lastHeight = height
+		}
+	}
+
+	if lastHeight < toHeight {
+		kept := locations[:0]
+		for _, location := range locations {
+			if location.snapshotHeight <= lastHeight {
+				kept = append(kept, location)
+			}
+		}
+		locations = kept
+	}
+	return locations, lastHeight, nil
+}
+
+// iterateLocations parses keys in the layout of [prefix][snapshotHeight][blockHash][logIndex],
+// heightOffset is the offset of snapshotHeight in the key. It stops at the first snapshot height after count
+// locations, and reports the last height and whether there are more keys in range.
+func (vli *VmLogIndex) iterateLocations(startKey, endKey []byte, heightOffset int, count int, locations *[]vmLogLocation) (lastHeight uint64, truncated bool, err error) {
+	iter := vli.store.NewIterator(&util.Range{Start: startKey, Limit: endKey})
+	defer iter.Release()
+
+	n := 0
+	for iter.Next() {
+		key := iter.Key()
+
+		snapshotHeight := chain_utils.BytesToUint64(key[heightOffset : heightOffset+8])
+		if n >= count && snapshotHeight != lastHeight {
+			return lastHeight, true, nil
+		}
+
+		blockHash, err := types.BytesToHash(key[heightOffset+8 : heightOffset+8+types.HashSize])
+		if err != nil {
+			return 0, false, err
+		}
+
+		*locations = append(*locations, vmLogLocation{
+			snapshotHeight: snapshotHeight,
+			blockHash:      blockHash,
+			logIndex:       bytesToUint32(key[heightOffset+8+types.HashSize:]),
+		})
+		lastHeight = snapshotHeight
+		n++
+	}
+
+	return lastHeight, false, iter.Error()
+}
+
+func (vli *VmLogIndex) writeLogList(batch *leveldb.Batch, snapshotHeight uint64, accountBlock *ledger.AccountBlock, logList ledger.VmLogList) error {
+	if len(logList) <= 0 {
+		return nil
+	}
+
+	// only the topics are kept for rollback
+	topicsList := make(ledger.VmLogList, 0, len(logList))
+
+	for index, vmLog := range logList {
+		logIndex := uint32(index)
+
+		batch.Put(CreateVmLogAddressKey(accountBlock.AccountAddress, snapshotHeight, accountBlock.Hash, logIndex), nil)
+
+		for position, topic := range vmLog.Topics {
+			if position >= maxIndexedTopics {
+				break
+			}
+			batch.Put(CreateVmLogTopicKey(byte(position), topic, snapshotHeight, accountBlock.Hash, logIndex), nil)
+		}
+
+		topicsList = append(topicsList, &ledger.VmLog{Topics: vmLog.Topics})
+	}
+
+	value, err := topicsList.Serialize()
+	if err != nil {
+		return err
+	}
+
+	batch.Put(CreateVmLogSnapshotKey(snapshotHeight, accountBlock.Hash), append(accountBlock.AccountAddress.Bytes(), value...))
+	return nil
+}
+
+func (vli *VmLogIndex) deleteBySnapshotHeight(batch *leveldb.Batch, snapshotHeight uint64) error {
+	iter := vli.store.NewIterator(util.BytesPrefix(CreateVmLogSnapshotPrefixKey(snapshotHeight)))
+	defer iter.Release()
+
+	for iter.Next() {
+		key := iter.Key()
+		value := iter.Value()
+
+		blockHash, err := types.BytesToHash(key[1+8:])
+		if err != nil {
+			return err
+		}
+
+		addr, err := types.BytesToAddress(value[:types.AddressSize])
+		if err != nil {
+			return err
+		}
+
+		topicsList := ledger.VmLogList{}
+		if err := topicsList.Deserialize(value[types.AddressSize:]); err != nil {
+			return err
+		}
+
+		for index, vmLog := range topicsList {
+			logIndex := uint32(index)
+
+			batch.Delete(CreateVmLogAddressKey(addr, snapshotHeight, blockHash, logIndex))
+
+			for position, topic := range vmLog.Topics {
+				if position >= maxIndexedTopics {
+					break
+				}
+				batch.Delete(CreateVmLogTopicKey(byte(position), topic, snapshotHeight, blockHash, logIndex))
+			}
+		}
+
+		batch.Delete(key)
+	}
+
+	return iter.Error()
+}
+
+func matchTopics(vmLog *ledger.VmLog, topics [][]types.Hash) bool {
+	if len(vmLog.Topics) < len(topics) {
+		return false
+	}
+	for i, topicList := range topics {
+		if len(topicList) == 0 {
+			continue
+		}
+		matched := false
+		for _, topic := range topicList {
+			if topic == vmLog.Topics[i] {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,155 @@
+package chain_plugins
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/vitelabs/go-vite/chain/db"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"gotest.tools/assert"
+)
+
+func newTestVmLogIndex(t *testing.T) (*VmLogIndex, *mockChain, func()) {
+	dir, err := ioutil.TempDir("", "vm_log_index")
+	assert.NilError(t, err)
+
+	store, err := chain_db.NewStore(dir, "plugins")
+	assert.NilError(t, err)
+
+	markIndexedFrom(store, 1)
+	chain := newMockChain(nil)
+
+	return newVmLogIndex(store, chain).(*VmLogIndex), chain, func() {
+		store.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestVmLogIndex(t *testing.T) {
+	vli, chain, clean := newTestVmLogIndex(t)
+	defer clean()
+
+	transfer := types.DataHash([]byte("Transfer"))
+	approve := types.DataHash([]byte("Approve"))
+	holder := types.DataHash([]byte("holder"))
+
+	block1 := chain.addBlock(types.AddressAsset, 1, ledger.VmLogList{
+		{Topics: []types.Hash{transfer, holder}},
+		{Topics: []types.Hash{approve}},
+	})
+	block2 := chain.addBlock(types.AddressQuota, 1, ledger.VmLogList{
+		{Topics: []types.Hash{transfer}},
+	})
+	block3 := chain.addBlock(types.AddressAsset, 2, ledger.VmLogList{
+		{Topics: []types.Hash{transfer, holder}},
+	})
+
+	insert := func(height uint64, blocks ...*ledger.AccountBlock) {
+		batch := vli.store.NewBatch()
+		assert.NilError(t, vli.InsertSnapshotBlock(batch, &ledger.SnapshotBlock{Height: height}, blocks))
+		vli.store.WriteDirectly(batch)
+	}
+	insert(10, block1, block2)
+	insert(11, block3)
+
+	// by topic, across addresses
+	results, _, err := vli.GetLogs(1, 100, nil, [][]types.Hash{{transfer}}, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(results), 3)
+	assert.Equal(t, results[0].SnapshotHeight, uint64(10))
+	// ordered by address in the same snapshot block
+	assert.Equal(t, results[0].AccountBlock.Hash, block2.Hash)
+	assert.Equal(t, results[1].AccountBlock.Hash, block1.Hash)
+	assert.Equal(t, results[2].SnapshotHeight, uint64(11))
+
+	// by topic at second position and snapshot range
+	results, _, err = vli.GetLogs(11, 11, nil, [][]types.Hash{{}, {holder}}, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(results), 1)
+	assert.Equal(t, results[0].AccountBlock.Hash, block3.Hash)
+
+	// by topic and address
+	results, _, err = vli.GetLogs(1, 100, []types.Address{types.AddressQuota}, [][]types.Hash{{transfer}}, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(results), 1)
+	assert.Equal(t, results[0].AccountBlock.Hash, block2.Hash)
+
+	// by address only
+	results, _, err = vli.GetLogs(1, 100, []types.Address{types.AddressAsset}, nil, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(results), 3)
+	assert.Equal(t, results[1].LogIndex, uint32(1))
+
+	// neither address nor topic
+	_, _, err = vli.GetLogs(1, 100, nil, nil, 10)
+	assert.Assert(t, err != nil)
+
+	// rollback
+	batch := vli.store.NewBatch()
+	assert.NilError(t, vli.DeleteSnapshotBlocks(batch, []*ledger.SnapshotChunk{
+		{SnapshotBlock: &ledger.SnapshotBlock{Height: 11}, AccountBlocks: []*ledger.AccountBlock{block3}},
+	}))
+	vli.store.WriteDirectly(batch)
+
+	results, _, err = vli.GetLogs(1, 100, nil, [][]types.Hash{{transfer}}, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(results), 2)
+
+	results, _, err = vli.GetLogs(1, 100, nil, [][]types.Hash{{}, {holder}}, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(results), 1)
+	assert.Equal(t, results[0].AccountBlock.Hash, block1.Hash)
+
+	// not indexed below the marked height
+	markIndexedFrom(vli.store, 11)
+	_, _, err = vli.GetLogs(10, 100, nil, [][]types.Hash{{transfer}}, 10)
+	assert.Assert(t, err != nil)
+
+	results, _, err = vli.GetLogs(11, 100, nil, [][]types.Hash{{transfer}}, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(results), 0)
+}
+
+func TestVmLogIndex_paging(t *testing.T) {
+	vli, chain, clean := newTestVmLogIndex(t)
+	defer clean()
+
+	transfer := types.DataHash([]byte("Transfer"))
+
+	// 2 logs at each snapshot height from 1 to 5
+	for height := uint64(1); height <= 5; height++ {
+		block1 := chain.addBlock(types.AddressAsset, height, ledger.VmLogList{{Topics: []types.Hash{transfer}}})
+		block2 := chain.addBlock(types.AddressQuota, height, ledger.VmLogList{{Topics: []types.Hash{transfer}}})
+
+		batch := vli.store.NewBatch()
+		assert.NilError(t, vli.InsertSnapshotBlock(batch, &ledger.SnapshotBlock{Height: height}, []*ledger.AccountBlock{block1, block2}))
+		vli.store.WriteDirectly(batch)
+	}
+
+	// the logs of a snapshot block are not split
+	var heights []uint64
+	from := uint64(1)
+	for from != 0 {
+		results, next, err := vli.GetLogs(from, 100, nil, [][]types.Hash{{transfer}}, 3)
+		assert.NilError(t, err)
+		assert.Assert(t, len(results) <= 4)
+		for _, r := range results {
+			heights = append(heights, r.SnapshotHeight)
+		}
+		from = next
+	}
+	assert.DeepEqual(t, heights, []uint64{1, 1, 2, 2, 3, 3, 4, 4, 5, 5})
+
+	// across lookup keys, the page ends at the lowest truncated height
+	results, next, err := vli.GetLogs(1, 100, []types.Address{types.AddressAsset, types.AddressQuota}, nil, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, len(results), 4)
+	assert.Equal(t, next, uint64(3))
+
+	results, next, err = vli.GetLogs(4, 5, nil, [][]types.Hash{{transfer}}, 10)
+	assert.NilError(t, err)
+	assert.Equal(t, len(results), 4)
+	assert.Equal(t, next, uint64(0))
+}
@@ -29,6 +29,20 @@ func (c *chain) GetBalanceMap(addr types.Address) (map[types.TokenTypeId]*big.In
 	return result, nil
 }
 
+// GetGenesisBalanceMap return the balances of addr set by the genesis account blocks
+func (c *chain) GetGenesisBalanceMap(addr types.Address) map[types.TokenTypeId]*big.Int {
+	balanceMap := make(map[types.TokenTypeId]*big.Int)
+	for _, vmBlock := range c.genesisAccountBlocks {
+		if vmBlock.AccountBlock.AccountAddress != addr {
+			continue
+		}
+		for tokenId, balance := range vmBlock.VmDb.GetUnsavedBalanceMap() {
+			balanceMap[tokenId] = new(big.Int).Set(balance)
+		}
+	}
+	return balanceMap
+}
+
 // get confirmed snapshot Balance, if history is too old, failed
 func (c *chain) GetConfirmedBalanceList(addrList []types.Address, tokenId types.TokenTypeId, sbHash types.Hash) (map[types.Address]*big.Int, error) {
 	balanceMap := make(map[types.Address]*big.Int, len(addrList))
@@ -41,6 +55,28 @@ func (c *chain) GetConfirmedBalanceList(addrList []types.Address, tokenId types.
 	return balanceMap, nil
 }
 
+// checkHistoryHeight fails if the state of snapshotHeight is not in the history
+func (c *chain) checkHistoryHeight(snapshotHeight uint64) error {
+	if latestHeight := c.GetLatestSnapshotBlock().Height; snapshotHeight > latestHeight {
+		return errors.New(fmt.Sprintf("snapshot height %d is higher than the latest snapshot height %d", snapshotHeight, latestHeight))
+	}
+	return nil
+}
+
+func (c *chain) GetBalanceAt(addr types.Address, tokenId types.TokenTypeId, snapshotHeight uint64) (*big.Int, error) {
+	if err := c.checkHistoryHeight(snapshotHeight); err != nil {
+		return nil, err
+	}
+
+	result, err := c.stateDB.GetSnapshotBalance(snapshotHeight, addr, tokenId)
+	if err != nil {
+		cErr := errors.New(fmt.Sprintf("c.stateDB.GetSnapshotBalance failed, Addr is %s, tokenId is %s, snapshotHeight is %d. Error: %s", addr, tokenId, snapshotHeight, err))
+		c.log.Error(cErr.Error(), "method", "GetBalanceAt")
+		return nil, cErr
+	}
+	return result, nil
+}
+
 // get contract code
 func (c *chain) GetContractCode(contractAddress types.Address) ([]byte, error) {
 	code, err := c.stateDB.GetCode(contractAddress)
@@ -155,3 +191,17 @@ func (c *chain) GetValue(address types.Address, key []byte) ([]byte, error) {
 	}
 	return value, err
 }
+
+func (c *chain) GetValueAt(address types.Address, key []byte, snapshotHeight uint64) ([]byte, error) {
+	if err := c.checkHistoryHeight(snapshotHeight); err != nil {
+		return nil, err
+	}
+
+	value, err := c.stateDB.GetSnapshotValue(snapshotHeight, address, key)
+	if err != nil {
+		cErr := errors.New(fmt.Sprintf("c.stateDB.GetSnapshotValue failed, address is %s, key is %s, snapshotHeight is %d. Error: %s", address, key, snapshotHeight, err))
+		c.log.Error(cErr.Error(), "method", "GetValueAt")
+		return nil, cErr
+	}
+	return value, nil
+}
@@ -66,6 +66,7 @@ type Redo struct {
 
 	chain Chain
 
+	// the redo logs older than retainHeight are deleted, 0 means keep all of them
 	retainHeight uint64
 
 	log log15.Logger
@@ -161,7 +162,7 @@ func (redo *Redo) InsertSnapshotBlock(snapshotBlock *ledger.SnapshotBlock, confi
 	batch.Put(chain_utils.CreateRedoSnapshot(snapshotBlock.Height).Bytes(), value)
 
 	// rollback stale data
-	if snapshotBlock.Height > redo.retainHeight {
+	if redo.retainHeight > 0 && snapshotBlock.Height > redo.retainHeight {
 		batch.Delete(chain_utils.CreateRedoSnapshot(snapshotBlock.Height - redo.retainHeight).Bytes())
 		//redo.log.Info(fmt.Sprintf("delete %d", snapshotBlock.Height-redo.retainHeight), "method", "InsertSnapshotBlock")
 	}
@@ -68,6 +68,10 @@ func NewStateDBWithStore(chain Chain, chainCfg *config.Chain, store *chain_db.St
 	if err != nil {
 		return nil, err
 	}
+	if chainCfg.ArchiveMode {
+		// keep the balance and storage changes of every snapshot block
+		storageRedo.retainHeight = 0
+	}
 
 	stateDb := &StateDB{
 		chain:               chain,
@@ -332,6 +336,25 @@ func (sDB *StateDB) GetSnapshotValue(snapshotBlockHeight uint64, addr types.Addr
 	return nil, nil
 }
 
+// GetSnapshotBalance returns the balance of addr when the snapshot block of snapshotBlockHeight was inserted
+func (sDB *StateDB) GetSnapshotBalance(snapshotBlockHeight uint64, addr types.Address, tokenId types.TokenTypeId) (*big.Int, error) {
+	startHistoryBalanceKey := chain_utils.CreateHistoryBalanceKey(addr, tokenId, 0)
+	endHistoryBalanceKey := chain_utils.CreateHistoryBalanceKey(addr, tokenId, snapshotBlockHeight+1)
+
+	iter := sDB.store.NewIterator(&util.Range{Start: startHistoryBalanceKey.Bytes(), Limit: endHistoryBalanceKey.Bytes()})
+	defer iter.Release()
+
+	if iter.Last() {
+		return big.NewInt(0).SetBytes(iter.Value()), nil
+	}
+
+	if err := iter.Error(); err != nil && err != leveldb.ErrNotFound {
+		return nil, err
+	}
+
+	return big.NewInt(0), nil
+}
+
 func (sDB *StateDB) SetCacheLevelForConsensus(level uint32) {
 	atomic.StoreUint32(&sDB.consensusCacheLevel, level)
 }
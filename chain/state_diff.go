@@ -0,0 +1,121 @@
+package chain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+// AccountStateDiff holds the changes of an account between two snapshot blocks
+type AccountStateDiff struct {
+	Balance map[types.TokenTypeId]*BalanceDiff
+	Storage map[string]*StorageDiff
+	Code    []byte // the code of the contract created in the range
+}
+
+type BalanceDiff struct {
+	From *big.Int
+	To   *big.Int
+}
+
+// StorageDiff holds the values of a storage key, an empty value means the key is not existed
+type StorageDiff struct {
+	Key  []byte
+	From []byte
+	To   []byte
+}
+
+// GetStateDiff aggregates the redo logs of the snapshot blocks in [fromHeight, toHeight] into the changes of every account.
+// The From values are the state of the snapshot block fromHeight-1. If addrList is empty, all accounts are included.
+// The redo logs of the old snapshot blocks are pruned unless the archive mode is enabled.
+func (c *chain) GetStateDiff(fromHeight, toHeight uint64, addrList []types.Address) (map[types.Address]*AccountStateDiff, error) {
+	if fromHeight < 1 || fromHeight > toHeight {
+		return nil, errors.New(fmt.Sprintf("invalid snapshot height range [%d, %d]", fromHeight, toHeight))
+	}
+	if latestHeight := c.GetLatestSnapshotBlock().Height; toHeight > latestHeight {
+		return nil, errors.New(fmt.Sprintf("snapshot height %d is higher than the latest snapshot height %d", toHeight, latestHeight))
+	}
+
+	var addrSet map[types.Address]struct{}
+	if len(addrList) > 0 {
+		addrSet = make(map[types.Address]struct{}, len(addrList))
+		for _, addr := range addrList {
+			addrSet[addr] = struct{}{}
+		}
+	}
+
+	diffs := make(map[types.Address]*AccountStateDiff)
+	for height := fromHeight; height <= toHeight; height++ {
+		snapshotLog, ok, err := c.stateDB.Redo().QueryLog(height)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("the redo log of snapshot height %d is pruned, enable the archive mode to keep it", height))
+		}
+
+		for addr, logItems := range snapshotLog {
+			if addrSet != nil {
+				if _, ok := addrSet[addr]; !ok {
+					continue
+				}
+			}
+			diff, ok := diffs[addr]
+			if !ok {
+				diff = &AccountStateDiff{
+					Balance: make(map[types.TokenTypeId]*BalanceDiff),
+					Storage: make(map[string]*StorageDiff),
+				}
+				diffs[addr] = diff
+			}
+
+			for _, logItem := range logItems {
+				for _, kv := range logItem.Storage {
+					if storageDiff, ok := diff.Storage[string(kv[0])]; ok {
+						storageDiff.To = kv[1]
+					} else {
+						diff.Storage[string(kv[0])] = &StorageDiff{Key: kv[0], To: kv[1]}
+					}
+				}
+				for tokenId, balance := range logItem.BalanceMap {
+					if balanceDiff, ok := diff.Balance[tokenId]; ok {
+						balanceDiff.To = balance
+					} else {
+						diff.Balance[tokenId] = &BalanceDiff{To: balance}
+					}
+				}
+				if len(logItem.Code) > 0 {
+					diff.Code = logItem.Code
+				}
+			}
+		}
+	}
+
+	// the state before the range
+	for addr, diff := range diffs {
+		for tokenId, balanceDiff := range diff.Balance {
+			if fromHeight <= 1 {
+				balanceDiff.From = big.NewInt(0)
+				continue
+			}
+			balance, err := c.GetBalanceAt(addr, tokenId, fromHeight-1)
+			if err != nil {
+				return nil, err
+			}
+			balanceDiff.From = balance
+		}
+		for _, storageDiff := range diff.Storage {
+			if fromHeight <= 1 {
+				continue
+			}
+			value, err := c.GetValueAt(addr, storageDiff.Key, fromHeight-1)
+			if err != nil {
+				return nil, err
+			}
+			storageDiff.From = value
+		}
+	}
+	return diffs, nil
+}
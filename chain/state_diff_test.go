@@ -0,0 +1,69 @@
+package chain
+
+import (
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"gotest.tools/assert"
+)
+
+func TestChain_GetStateDiff(t *testing.T) {
+	chainInstance, clear := newHistoryTestChain(t)
+	defer clear()
+
+	accounts := MakeAccounts(chainInstance, 2)
+	var alice, bob *Account
+	for _, account := range accounts {
+		if alice == nil {
+			alice = account
+		} else {
+			bob = account
+		}
+	}
+
+	insertHistoryTestSendBlock(t, chainInstance, alice, bob, accounts, 10, map[string][]byte{"a": []byte("1")})
+	sb1, _, err := InsertSnapshotBlock(chainInstance, true)
+	assert.NilError(t, err)
+	insertHistoryTestSendBlock(t, chainInstance, alice, bob, accounts, 10, map[string][]byte{"a": []byte("2"), "b": []byte("1")})
+	sb2, _, err := InsertSnapshotBlock(chainInstance, true)
+	assert.NilError(t, err)
+	insertHistoryTestSendBlock(t, chainInstance, alice, bob, accounts, 10, map[string][]byte{"a": []byte("3")})
+	sb3, _, err := InsertSnapshotBlock(chainInstance, true)
+	assert.NilError(t, err)
+
+	// the changes of sb2 and sb3 are aggregated, the from values are the state of sb1
+	diffs, err := chainInstance.GetStateDiff(sb2.Height, sb3.Height, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(diffs), 1)
+	diff := diffs[alice.Addr]
+	assert.Assert(t, diff != nil)
+	assert.Equal(t, len(diff.Storage), 2)
+	assert.Equal(t, string(diff.Storage["a"].From), "1")
+	assert.Equal(t, string(diff.Storage["a"].To), "3")
+	assert.Equal(t, len(diff.Storage["b"].From), 0)
+	assert.Equal(t, string(diff.Storage["b"].To), "1")
+	assert.Equal(t, len(diff.Balance), 1)
+	assert.Equal(t, diff.Balance[ledger.ViteTokenId].From.Uint64(), uint64(1))
+	assert.Equal(t, diff.Balance[ledger.ViteTokenId].To.Uint64(), uint64(3))
+	assert.Equal(t, len(diff.Code), 0)
+
+	// the state of the account before sb1 is empty
+	diffs, err = chainInstance.GetStateDiff(sb1.Height, sb1.Height, []types.Address{alice.Addr})
+	assert.NilError(t, err)
+	diff = diffs[alice.Addr]
+	assert.Equal(t, len(diff.Storage["a"].From), 0)
+	assert.Equal(t, string(diff.Storage["a"].To), "1")
+	assert.Equal(t, diff.Balance[ledger.ViteTokenId].From.Sign(), 0)
+	assert.Equal(t, diff.Balance[ledger.ViteTokenId].To.Uint64(), uint64(1))
+
+	// the accounts not in the list are skipped
+	diffs, err = chainInstance.GetStateDiff(sb2.Height, sb3.Height, []types.Address{bob.Addr})
+	assert.NilError(t, err)
+	assert.Equal(t, len(diffs), 0)
+
+	_, err = chainInstance.GetStateDiff(sb3.Height, sb2.Height, nil)
+	assert.ErrorContains(t, err, "invalid snapshot height range")
+	_, err = chainInstance.GetStateDiff(sb2.Height, sb3.Height+1, nil)
+	assert.ErrorContains(t, err, "is higher than the latest snapshot height")
+}
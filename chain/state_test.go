@@ -48,6 +48,10 @@ func testState(t *testing.T, chainInstance *chain, accounts map[types.Address]*A
 		GetConfirmedBalanceList(chainInstance, accounts, snapshotBlocks)
 	})
 
+	t.Run("GetBalanceAt", func(t *testing.T) {
+		GetBalanceAt(chainInstance, accounts, snapshotBlocks)
+	})
+
 	t.Run("GetContractMeta", func(t *testing.T) {
 		GetContractMeta(chainInstance, accounts)
 	})
@@ -81,6 +85,8 @@ func testStateNoTesting(chainInstance *chain, accounts map[types.Address]*Accoun
 
 	GetConfirmedBalanceList(chainInstance, accounts, snapshotBlocks)
 
+	GetBalanceAt(chainInstance, accounts, snapshotBlocks)
+
 	GetContractMeta(chainInstance, accounts)
 
 	GetContractCode(chainInstance, accounts)
@@ -180,6 +186,35 @@ func GetConfirmedBalanceList(chainInstance *chain, accounts map[types.Address]*A
 	}
 }
 
+func GetBalanceAt(chainInstance *chain, accounts map[types.Address]*Account, snapshotBlocks []*ledger.SnapshotBlock) {
+	for index, snapshotBlock := range snapshotBlocks {
+		for _, account := range accounts {
+			var highBlock *ledger.AccountBlock
+
+			for i := index; i >= 0 && highBlock == nil; i-- {
+				for hash := range account.ConfirmedBlockMap[snapshotBlocks[i].Hash] {
+					block := account.BlocksMap[hash]
+					if highBlock == nil || block.Height > highBlock.Height {
+						highBlock = block
+					}
+				}
+			}
+
+			if highBlock == nil {
+				continue
+			}
+
+			balance, err := chainInstance.GetBalanceAt(account.Addr, ledger.ViteTokenId, snapshotBlock.Height)
+			if err != nil {
+				panic(err)
+			}
+			if balance.Cmp(account.BalanceMap[highBlock.Hash]) != 0 {
+				panic(fmt.Sprintf("snapshotBlock %d, addr: %s, highBlock: %+v, queryBalance: %d, Balance: %d", snapshotBlock.Height, account.Addr, highBlock, balance, account.BalanceMap[highBlock.Hash]))
+			}
+		}
+	}
+}
+
 func GetContractCode(chainInstance *chain, accounts map[types.Address]*Account) {
 	for _, account := range accounts {
 		code, err := chainInstance.GetContractCode(account.Addr)
@@ -492,3 +527,18 @@ func printAllKey(db *leveldb.DB) {
 		fmt.Println(k, v)
 	}
 }
+
+func TestChain_GetGenesisBalanceMap(t *testing.T) {
+	chainInstance, clear := newHistoryTestChain(t)
+	defer clear()
+
+	addr, err := types.HexToAddress("vite_56fd05b23ff26cd7b0a40957fb77bde60c9fd6ebc35f809c23")
+	assert.NilError(t, err)
+	balanceMap := chainInstance.GetGenesisBalanceMap(addr)
+	assert.Equal(t, len(balanceMap), 1)
+	expected, _ := new(big.Int).SetString("100000000000000000000000000", 10)
+	assert.Equal(t, balanceMap[ledger.ViteTokenId].Cmp(expected), 0)
+
+	addr2, _, _ := types.CreateAddress()
+	assert.Equal(t, len(chainInstance.GetGenesisBalanceMap(addr2)), 0)
+}
@@ -0,0 +1,104 @@
+package test_tools
+
+import (
+	"encoding/binary"
+	"math/rand"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// MockVmLogChain is a synthetic chain of snapshot chunks whose account blocks carry vm logs
+type MockVmLogChain struct {
+	Chunks  []*ledger.SnapshotChunk
+	Blocks  map[types.Hash]*ledger.AccountBlock
+	LogList map[types.Hash][]byte // serialized vm log list by log hash
+}
+
+// NewMockVmLogChain creates snapshotCount chunks, each confirms blocksPerChunk account blocks of random contracts.
+// Every block emits one log with topics picked from topics, the topic in position 0 is picked with the probability of 1/hitRate.
+func NewMockVmLogChain(snapshotCount, blocksPerChunk int, contracts []types.Address, topics []types.Hash, hitRate int) *MockVmLogChain {
+	c := &MockVmLogChain{
+		Chunks:  make([]*ledger.SnapshotChunk, 0, snapshotCount),
+		Blocks:  make(map[types.Hash]*ledger.AccountBlock),
+		LogList: make(map[types.Hash][]byte),
+	}
+
+	r := rand.New(rand.NewSource(1))
+	heights := make(map[types.Address]uint64, len(contracts))
+
+	for i := 1; i <= snapshotCount; i++ {
+		chunk := &ledger.SnapshotChunk{
+			SnapshotBlock: &ledger.SnapshotBlock{Height: uint64(i)},
+		}
+
+		for j := 0; j < blocksPerChunk; j++ {
+			addr := contracts[r.Intn(len(contracts))]
+			heights[addr]++
+
+			var topic types.Hash
+			if r.Intn(hitRate) == 0 {
+				topic = topics[0]
+			} else {
+				topic = topics[1+r.Intn(len(topics)-1)]
+			}
+
+			data := make([]byte, 8)
+			binary.BigEndian.PutUint64(data, heights[addr])
+
+			logList := ledger.VmLogList{{Topics: []types.Hash{topic}, Data: data}}
+			buf, err := logList.Serialize()
+			if err != nil {
+				panic(err)
+			}
+			logHash := types.DataHash(append(addr.Bytes(), buf...))
+
+			block := &ledger.AccountBlock{
+				AccountAddress: addr,
+				Height:         heights[addr],
+				Hash:           types.DataHash(append(addr.Bytes(), data...)),
+				LogHash:        &logHash,
+			}
+
+			c.Blocks[block.Hash] = block
+			c.LogList[logHash] = buf
+			chunk.AccountBlocks = append(chunk.AccountBlocks, block)
+		}
+
+		c.Chunks = append(c.Chunks, chunk)
+	}
+	return c
+}
+
+func (c *MockVmLogChain) GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error) {
+	return c.Blocks[blockHash], nil
+}
+
+func (c *MockVmLogChain) GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error) {
+	if logListHash == nil {
+		return nil, nil
+	}
+	buf, ok := c.LogList[*logListHash]
+	if !ok {
+		return nil, nil
+	}
+	logList := ledger.VmLogList{}
+	if err := logList.Deserialize(buf); err != nil {
+		return nil, err
+	}
+	return logList, nil
+}
+
+// AddBlock adds an account block and its vm logs, the block is not confirmed by any of the chunks.
+func (c *MockVmLogChain) AddBlock(block *ledger.AccountBlock, logList ledger.VmLogList) {
+	c.Blocks[block.Hash] = block
+	if block.LogHash == nil {
+		return
+	}
+
+	buf, err := logList.Serialize()
+	if err != nil {
+		panic(err)
+	}
+	c.LogList[*block.LogHash] = buf
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"math/big"
+	"sync"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/rpc"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+	"github.com/vitelabs/go-vite/rpcapi/api/filters"
+)
+
+// TransactOpts is the sender of the request blocks built by the contract bindings
+type TransactOpts struct {
+	SelfAddr types.Address
+	Amount   *big.Int           // nil means zero
+	TokenId  types.TokenTypeId  // zero means VITE
+	Prev     *ledger.HashHeight // the latest block of SelfAddr, query by rpc if nil
+}
+
+// RequestTxParams returns the params to call contract toAddr with data
+func (opts *TransactOpts) RequestTxParams(toAddr types.Address, data []byte) RequestTxParams {
+	params := RequestTxParams{
+		ToAddr:   toAddr,
+		SelfAddr: opts.SelfAddr,
+		Amount:   opts.Amount,
+		TokenId:  opts.TokenId,
+		Data:     data,
+	}
+	if params.Amount == nil {
+		params.Amount = new(big.Int)
+	}
+	if params.TokenId == (types.TokenTypeId{}) {
+		params.TokenId = ledger.ViteTokenId
+	}
+	return params
+}
+
+// DeployOpts is the sender and the meta data of the contract creation
+type DeployOpts struct {
+	SelfAddr types.Address
+	Fee      *big.Int           // nil means the default fee
+	Prev     *ledger.HashHeight // the latest block of SelfAddr, query by rpc if nil
+	Meta     api.CreateContractDataParam
+}
+
+// RequestCreateContractParams returns the params to create the contract of abiStr, arguments are passed to the constructor
+func (opts *DeployOpts) RequestCreateContractParams(abiStr string, arguments ...interface{}) RequestCreateContractParams {
+	return RequestCreateContractParams{
+		SelfAddr:   opts.SelfAddr,
+		fee:        opts.Fee,
+		arguments:  arguments,
+		abiStr:     abiStr,
+		metaParams: opts.Meta,
+	}
+}
+
+// LogSubscription is the subscription of contract events, created by WatchVmLogs
+type LogSubscription struct {
+	sub  *rpc.ClientSubscription
+	err  chan error
+	quit chan struct{}
+	once sync.Once
+}
+
+// WatchVmLogs subscribes the vm logs of contract addr matching topics, the logs are passed to handle one by one,
+// and quit is closed when the subscription is unsubscribed. The confirmed logs from fromSnapshotHeight are
+// replayed if it is not nil.
+func WatchVmLogs(ctx context.Context, cli RpcClient, addr types.Address, topics [][]types.Hash, fromSnapshotHeight *string,
+	handle func(l *filters.LogsV2, quit <-chan struct{})) (*LogSubscription, error) {
+
+	param := api.VmLogFilterParam{
+		AddrRange: map[string]*api.Range{addr.String(): nil},
+		Topics:    topics,
+	}
+	logs := make(chan []*filters.LogsV2, 128)
+	sub, err := cli.SubscribeVmLogs(ctx, param, fromSnapshotHeight, logs)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &LogSubscription{
+		sub:  sub,
+		err:  make(chan error, 1),
+		quit: make(chan struct{}),
+	}
+	go s.loop(logs, handle)
+	return s, nil
+}
+
+func (s *LogSubscription) loop(logs <-chan []*filters.LogsV2, handle func(l *filters.LogsV2, quit <-chan struct{})) {
+	defer close(s.err)
+
+	for {
+		select {
+		case batch := <-logs:
+			for _, l := range batch {
+				handle(l, s.quit)
+			}
+		case err, ok := <-s.sub.Err():
+			if ok && err != nil {
+				s.err <- err
+			}
+			return
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// Err returns the error of the subscription, it is closed after Unsubscribe
+func (s *LogSubscription) Err() <-chan error {
+	return s.err
+}
+
+// Unsubscribe stops the delivery of logs, it can be called more than once
+func (s *LogSubscription) Unsubscribe() {
+	s.once.Do(func() {
+		close(s.quit)
+		s.sub.Unsubscribe()
+	})
+}
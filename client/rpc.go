@@ -12,6 +12,7 @@ type RpcClient interface {
 	rpc2.ContractApi
 	rpc2.DexTradeApi
 	rpc2.RandomApi
+	rpc2.SubscribeApi
 
 	GetClient() *rpc.Client
 }
@@ -23,13 +24,14 @@ func NewRpcClient(rawurl string) (RpcClient, error) {
 	}
 
 	r := &rpcClient{
-		LedgerApi:   rpc2.NewLedgerApi(c),
-		OnroadApi:   rpc2.NewOnroadApi(c),
-		TxApi:       rpc2.NewTxApi(c),
-		ContractApi: rpc2.NewContractApi(c),
-		DexTradeApi: rpc2.NewDexTradeApi(c),
-		RandomApi:   rpc2.NewRandomApi(c),
-		cc:          c,
+		LedgerApi:    rpc2.NewLedgerApi(c),
+		OnroadApi:    rpc2.NewOnroadApi(c),
+		TxApi:        rpc2.NewTxApi(c),
+		ContractApi:  rpc2.NewContractApi(c),
+		DexTradeApi:  rpc2.NewDexTradeApi(c),
+		RandomApi:    rpc2.NewRandomApi(c),
+		SubscribeApi: rpc2.NewSubscribeApi(c),
+		cc:           c,
 	}
 	return r, nil
 }
@@ -41,6 +43,7 @@ type rpcClient struct {
 	rpc2.ContractApi
 	rpc2.DexTradeApi
 	rpc2.RandomApi
+	rpc2.SubscribeApi
 
 	cc *rpc.Client
 }
@@ -0,0 +1,27 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/vitelabs/go-vite/rpc"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+	"github.com/vitelabs/go-vite/rpcapi/api/filters"
+)
+
+// SubscribeApi ...
+type SubscribeApi interface {
+	SubscribeVmLogs(ctx context.Context, param api.VmLogFilterParam, fromSnapshotHeight *string, ch chan<- []*filters.LogsV2) (*rpc.ClientSubscription, error)
+}
+
+type subscribeApi struct {
+	cc *rpc.Client
+}
+
+func NewSubscribeApi(cc *rpc.Client) SubscribeApi {
+	return &subscribeApi{cc: cc}
+}
+
+// SubscribeVmLogs sends the matched vm logs to ch, the confirmed logs from fromSnapshotHeight are replayed if it is not nil
+func (s subscribeApi) SubscribeVmLogs(ctx context.Context, param api.VmLogFilterParam, fromSnapshotHeight *string, ch chan<- []*filters.LogsV2) (*rpc.ClientSubscription, error) {
+	return s.cc.Subscribe(ctx, "subscribe", ch, "createVmlogSubscription", param, fromSnapshotHeight)
+}
@@ -0,0 +1,39 @@
+package gvite_plugins
+
+import (
+	"fmt"
+	"github.com/vitelabs/go-vite/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+	"os"
+)
+
+var (
+	disassembleCommand = cli.Command{
+		Action:   utils.MigrateFlags(disassembleAction),
+		Name:     "disassemble",
+		Usage:    "disassemble --contract=vite_xxx | --code=608060405260043610...",
+		Flags:    append(disassembleFlags, configFlags...),
+		Category: "DISASSEMBLE COMMANDS",
+		Description: `
+Disassemble the code of a contract in the local chain or the hex code, and print the basic blocks, the functions found in the dispatcher
+and the issues of unreachable code, invalid opcodes, invalid jumps and unbounded loops as json.
+`,
+	}
+)
+
+func disassembleAction(ctx *cli.Context) error {
+	nodeManager, err := nodemanager.NewDisassembleNodeManager(ctx, nodemanager.FullNodeMaker{})
+	if err != nil {
+		log.Error(fmt.Sprintf("new Node error, %+v", err))
+		return err
+	}
+	if err := nodeManager.Start(); err != nil {
+		log.Error(err.Error())
+		fmt.Println(err.Error())
+		return err
+	}
+
+	os.Exit(0)
+	return nil
+}
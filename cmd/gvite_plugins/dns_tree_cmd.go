@@ -0,0 +1,39 @@
+package gvite_plugins
+
+import (
+	"fmt"
+	"github.com/vitelabs/go-vite/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+	"os"
+)
+
+var (
+	dnsTreeCommand = cli.Command{
+		Action:   utils.MigrateFlags(dnsTreeAction),
+		Name:     "dnsTree",
+		Usage:    "dnsTree --domain=nodes.example.org --signKey=xxx --links=vtree://yyy@nodes.example.com",
+		Flags:    append(dnsTreeFlags, configFlags...),
+		Category: "DNS TREE COMMANDS",
+		Description: `
+Build a node tree from the nodes in the local node database, sign it and print the TXT records should be published under the domain as json.
+The node tree can be used by other nodes through the BootTrees config.
+`,
+	}
+)
+
+func dnsTreeAction(ctx *cli.Context) error {
+	nodeManager, err := nodemanager.NewDNSTreeNodeManager(ctx, nodemanager.FullNodeMaker{})
+	if err != nil {
+		log.Error(fmt.Sprintf("new Node error, %+v", err))
+		return err
+	}
+	if err := nodeManager.Start(); err != nil {
+		log.Error(err.Error())
+		fmt.Println(err.Error())
+		return err
+	}
+
+	os.Exit(0)
+	return nil
+}
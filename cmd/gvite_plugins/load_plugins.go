@@ -48,6 +48,7 @@ var (
 		utils.ListenPortFlag,
 		utils.NodeKeyHexFlag,
 		utils.DiscoveryFlag,
+		utils.NATFlag,
 	}
 
 	//IPC
@@ -128,6 +129,29 @@ var (
 	exportFlags = []cli.Flag{
 		utils.ExportSbHeightFlags,
 	}
+
+	// State diff
+	stateDiffFlags = []cli.Flag{
+		utils.StateDiffFromHeightFlag,
+		utils.StateDiffToHeightFlag,
+		utils.StateDiffAddressesFlag,
+	}
+
+	// DNS tree
+	dnsTreeFlags = []cli.Flag{
+		utils.DNSDomainFlag,
+		utils.DNSSignKeyFlag,
+		utils.DNSSeqFlag,
+		utils.DNSLinksFlag,
+		utils.DNSNodeMaxAgeFlag,
+	}
+
+	// Disassemble
+	disassembleFlags = []cli.Flag{
+		utils.DisassembleCodeFlag,
+		utils.DisassembleContractFlag,
+		utils.DisassembleHeightFlag,
+	}
 )
 
 func init() {
@@ -156,13 +180,16 @@ func init() {
 		exportCommand,
 		pluginDataCommand,
 		checkChainCommand,
+		stateDiffCommand,
+		dnsTreeCommand,
+		disassembleCommand,
 	}
 	sort.Sort(cli.CommandsByName(app.Commands))
 
 	//Import: Please add the New Flags here
 	app.Flags = utils.MergeFlags(configFlags, generalFlags, p2pFlags,
 		ipcFlags, httpFlags, wsFlags, consoleFlags, producerFlags, logFlags,
-		vmFlags, netFlags, statFlags, metricsFlags, ledgerFlags, exportFlags)
+		vmFlags, netFlags, statFlags, metricsFlags, ledgerFlags, exportFlags, stateDiffFlags, dnsTreeFlags, disassembleFlags)
 
 	app.Before = beforeAction
 	app.Action = action
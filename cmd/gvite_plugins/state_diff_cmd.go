@@ -0,0 +1,36 @@
+package gvite_plugins
+
+import (
+	"fmt"
+	"github.com/vitelabs/go-vite/cmd/nodemanager"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	stateDiffCommand = cli.Command{
+		Action:   utils.MigrateFlags(stateDiffAction),
+		Name:     "stateDiff",
+		Usage:    "stateDiff --fromHeight=5000000 --toHeight=5000010 --addresses=vite_xxx,vite_yyy",
+		Flags:    append(stateDiffFlags, configFlags...),
+		Category: "STATE DIFF COMMANDS",
+		Description: `
+Print the changes of the balances and storage of the accounts made by the snapshot blocks in [fromHeight, toHeight] as json.
+`,
+	}
+)
+
+func stateDiffAction(ctx *cli.Context) error {
+	// Create and start the node based on the CLI flags
+	nodeManager, err := nodemanager.NewStateDiffNodeManager(ctx, nodemanager.FullNodeMaker{})
+	if err != nil {
+		log.Error(fmt.Sprintf("new Node error, %+v", err))
+		return err
+	}
+	if err := nodeManager.Start(); err != nil {
+		log.Error(err.Error())
+		fmt.Println(err.Error())
+		return err
+	}
+	return nil
+}
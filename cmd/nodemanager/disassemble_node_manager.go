@@ -0,0 +1,110 @@
+package nodemanager
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/node"
+	"github.com/vitelabs/go-vite/vm/asm"
+	"github.com/vitelabs/go-vite/vm/util"
+	"gopkg.in/urfave/cli.v1"
+)
+
+type DisassembleNodeManager struct {
+	ctx  *cli.Context
+	node *node.Node
+
+	chain chain.Chain
+}
+
+func NewDisassembleNodeManager(ctx *cli.Context, maker NodeMaker) (*DisassembleNodeManager, error) {
+	node, err := maker.MakeNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DisassembleNodeManager{
+		ctx:  ctx,
+		node: node,
+	}, nil
+}
+
+// readContractCode reads the code of the contract and the latest snapshot height from the local chain
+func (nodeManager *DisassembleNodeManager) readContractCode(addrStr string) ([]byte, uint64, error) {
+	addr, err := types.HexToAddress(addrStr)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	viteConfig := nodeManager.node.ViteConfig()
+	c := chain.NewChain(viteConfig.DataDir, viteConfig.Chain, viteConfig.Genesis)
+	nodeManager.chain = c
+	if err := c.Init(); err != nil {
+		return nil, 0, err
+	}
+	if err := c.Start(); err != nil {
+		return nil, 0, err
+	}
+
+	code, err := c.GetContractCode(addr)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(code) == 0 {
+		return nil, 0, errors.New(fmt.Sprintf("contract %s has no code", addr))
+	}
+	_, code = util.UnpackContractCode(code)
+	return code, c.GetLatestSnapshotBlock().Height, nil
+}
+
+func (nodeManager *DisassembleNodeManager) Start() error {
+	viteConfig := nodeManager.node.ViteConfig()
+
+	// set fork points
+	fork.SetForkPoints(viteConfig.ForkPoints)
+
+	var code []byte
+	var height uint64
+	var err error
+	if contract := nodeManager.ctx.GlobalString(utils.DisassembleContractFlag.Name); contract != "" {
+		code, height, err = nodeManager.readContractCode(contract)
+		defer nodeManager.Stop()
+	} else if hexCode := nodeManager.ctx.GlobalString(utils.DisassembleCodeFlag.Name); hexCode != "" {
+		code, err = hex.DecodeString(strings.TrimPrefix(hexCode, "0x"))
+		height = math.MaxUint64
+	} else {
+		return errors.New("contract or code is required")
+	}
+	if err != nil {
+		return err
+	}
+	if nodeManager.ctx.GlobalIsSet(utils.DisassembleHeightFlag.Name) {
+		height = nodeManager.ctx.GlobalUint64(utils.DisassembleHeightFlag.Name)
+	}
+
+	result, err := json.MarshalIndent(asm.Analyze(code, height), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(result))
+	return nil
+}
+
+func (nodeManager *DisassembleNodeManager) Stop() error {
+	if nodeManager.chain != nil {
+		nodeManager.chain.Stop()
+	}
+	return nil
+}
+
+func (nodeManager *DisassembleNodeManager) Node() *node.Node {
+	return nodeManager.node
+}
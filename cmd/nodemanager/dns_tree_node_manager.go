@@ -0,0 +1,114 @@
+package nodemanager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/net"
+	"github.com/vitelabs/go-vite/net/database"
+	"github.com/vitelabs/go-vite/net/discovery/dnsdisc"
+	"github.com/vitelabs/go-vite/net/vnode"
+	"github.com/vitelabs/go-vite/node"
+	"gopkg.in/urfave/cli.v1"
+)
+
+type DNSTreeNodeManager struct {
+	ctx  *cli.Context
+	node *node.Node
+}
+
+type dnsTreeResult struct {
+	URL     string            `json:"url"`
+	Seq     uint              `json:"seq"`
+	Nodes   int               `json:"nodes"`
+	Records map[string]string `json:"records"`
+}
+
+func NewDNSTreeNodeManager(ctx *cli.Context, maker NodeMaker) (*DNSTreeNodeManager, error) {
+	node, err := maker.MakeNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DNSTreeNodeManager{
+		ctx:  ctx,
+		node: node,
+	}, nil
+}
+
+func (nodeManager *DNSTreeNodeManager) getLinks() []string {
+	var links []string
+	for _, url := range strings.Split(nodeManager.ctx.GlobalString(utils.DNSLinksFlag.Name), ",") {
+		if url = strings.TrimSpace(url); url != "" {
+			links = append(links, url)
+		}
+	}
+	return links
+}
+
+func (nodeManager *DNSTreeNodeManager) Start() error {
+	domain := nodeManager.ctx.GlobalString(utils.DNSDomainFlag.Name)
+	if domain == "" {
+		return errors.New("domain is required")
+	}
+	if !nodeManager.ctx.GlobalIsSet(utils.DNSSignKeyFlag.Name) {
+		return errors.New("signKey is required")
+	}
+	key, err := ed25519.HexToPrivateKey(nodeManager.ctx.GlobalString(utils.DNSSignKeyFlag.Name))
+	if err != nil {
+		return errors.New(fmt.Sprintf("failed to parse signKey: %v", err))
+	}
+
+	seq := uint(time.Now().Unix())
+	if nodeManager.ctx.GlobalIsSet(utils.DNSSeqFlag.Name) {
+		seq = nodeManager.ctx.GlobalUint(utils.DNSSeqFlag.Name)
+	}
+
+	netConfig := nodeManager.node.ViteConfig().Net
+	if netConfig.DataDir == "" {
+		return errors.New("no node database in memory mode")
+	}
+	db, err := database.New(path.Join(netConfig.DataDir, net.DBDirName), 1, vnode.ZERO)
+	if err != nil {
+		return errors.New(fmt.Sprintf("failed to open node database: %v", err))
+	}
+	defer db.Close()
+
+	nodes := db.ReadNodes(nodeManager.ctx.GlobalInt64(utils.DNSNodeMaxAgeFlag.Name))
+
+	tree, err := dnsdisc.MakeTree(seq, nodes, nodeManager.getLinks())
+	if err != nil {
+		return err
+	}
+	url := tree.Sign(key, domain)
+	records, err := tree.Records(domain)
+	if err != nil {
+		return err
+	}
+
+	result, err := json.MarshalIndent(dnsTreeResult{
+		URL:     url,
+		Seq:     seq,
+		Nodes:   len(nodes),
+		Records: records,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(result))
+	return nil
+}
+
+func (nodeManager *DNSTreeNodeManager) Stop() error {
+	return nil
+}
+
+func (nodeManager *DNSTreeNodeManager) Node() *node.Node {
+	return nodeManager.node
+}
@@ -96,6 +96,10 @@ func mappingNodeConfig(ctx *cli.Context, cfg *node.Config) {
 		cfg.SetPrivateKey(nodeKeyHex)
 	}
 
+	if nat := ctx.GlobalString(utils.NATFlag.Name); len(nat) > 0 {
+		cfg.NAT = nat
+	}
+
 	//Ipc Config
 	if ctx.GlobalIsSet(utils.IPCEnabledFlag.Name) {
 		cfg.IPCEnabled = ctx.GlobalBool(utils.IPCEnabledFlag.Name)
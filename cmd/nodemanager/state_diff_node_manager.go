@@ -0,0 +1,104 @@
+package nodemanager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/cmd/utils"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/node"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+	"gopkg.in/urfave/cli.v1"
+)
+
+type StateDiffNodeManager struct {
+	ctx  *cli.Context
+	node *node.Node
+
+	chain chain.Chain
+}
+
+func NewStateDiffNodeManager(ctx *cli.Context, maker NodeMaker) (*StateDiffNodeManager, error) {
+	node, err := maker.MakeNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StateDiffNodeManager{
+		ctx:  ctx,
+		node: node,
+	}, nil
+}
+
+func (nodeManager *StateDiffNodeManager) getAddrList() ([]types.Address, error) {
+	if !nodeManager.ctx.GlobalIsSet(utils.StateDiffAddressesFlag.Name) {
+		return nil, nil
+	}
+	var addrList []types.Address
+	for _, addrStr := range strings.Split(nodeManager.ctx.GlobalString(utils.StateDiffAddressesFlag.Name), ",") {
+		if addrStr = strings.TrimSpace(addrStr); addrStr == "" {
+			continue
+		}
+		addr, err := types.HexToAddress(addrStr)
+		if err != nil {
+			return nil, err
+		}
+		addrList = append(addrList, addr)
+	}
+	return addrList, nil
+}
+
+func (nodeManager *StateDiffNodeManager) Start() error {
+	viteConfig := nodeManager.node.ViteConfig()
+
+	// set fork points
+	fork.SetForkPoints(viteConfig.ForkPoints)
+
+	c := chain.NewChain(viteConfig.DataDir, viteConfig.Chain, viteConfig.Genesis)
+	nodeManager.chain = c
+
+	if err := c.Init(); err != nil {
+		return err
+	}
+	if err := c.Start(); err != nil {
+		return err
+	}
+	defer nodeManager.Stop()
+
+	if !nodeManager.ctx.GlobalIsSet(utils.StateDiffFromHeightFlag.Name) {
+		return errors.New("fromHeight is required")
+	}
+	fromHeight := nodeManager.ctx.GlobalUint64(utils.StateDiffFromHeightFlag.Name)
+	toHeight := c.GetLatestSnapshotBlock().Height
+	if nodeManager.ctx.GlobalIsSet(utils.StateDiffToHeightFlag.Name) {
+		toHeight = nodeManager.ctx.GlobalUint64(utils.StateDiffToHeightFlag.Name)
+	}
+	addrList, err := nodeManager.getAddrList()
+	if err != nil {
+		return err
+	}
+
+	diffs, err := c.GetStateDiff(fromHeight, toHeight, addrList)
+	if err != nil {
+		return err
+	}
+	result, err := json.MarshalIndent(api.ToAccountStateDiffList(diffs), "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(result))
+	return nil
+}
+
+func (nodeManager *StateDiffNodeManager) Stop() error {
+	nodeManager.chain.Stop()
+	return nil
+}
+
+func (nodeManager *StateDiffNodeManager) Node() *node.Node {
+	return nodeManager.node
+}
@@ -72,6 +72,10 @@ var (
 		Name:  "discovery", //mapping:p2p.Discovery
 		Usage: "enable p2p discovery or not",
 	}
+	NATFlag = cli.StringFlag{
+		Name:  "nat", //mapping:p2p.NAT
+		Usage: "NAT port mapping mechanism (none|any|upnp|pmp|pmp:<IP>|extip:<IP>)",
+	}
 
 	//IPC Settings
 	IPCEnabledFlag = cli.BoolFlag{
@@ -190,6 +194,57 @@ var (
 		Usage: "The snapshot block height",
 	}
 
+	// State diff
+	StateDiffFromHeightFlag = cli.Uint64Flag{
+		Name:  "fromHeight",
+		Usage: "The first snapshot block height of the state diff",
+	}
+	StateDiffToHeightFlag = cli.Uint64Flag{
+		Name:  "toHeight",
+		Usage: "The last snapshot block height of the state diff, the latest snapshot block by default",
+	}
+	StateDiffAddressesFlag = cli.StringFlag{
+		Name:  "addresses",
+		Usage: "Comma separated addresses of the state diff, all accounts by default",
+	}
+
+	//DNS tree
+	DNSDomainFlag = cli.StringFlag{
+		Name:  "domain",
+		Usage: "The domain where the node tree will be published",
+	}
+	DNSSignKeyFlag = cli.StringFlag{
+		Name:  "signKey",
+		Usage: "The hex ed25519 private key to sign the node tree",
+	}
+	DNSSeqFlag = cli.UintFlag{
+		Name:  "seq",
+		Usage: "The sequence number of the node tree, the current unix time by default",
+	}
+	DNSLinksFlag = cli.StringFlag{
+		Name:  "links",
+		Usage: "Comma separated urls of other node trees linked by the node tree",
+	}
+	DNSNodeMaxAgeFlag = cli.Int64Flag{
+		Name:  "nodeMaxAge",
+		Usage: "Only the nodes active in the last nodeMaxAge seconds are published",
+		Value: 24 * 3600,
+	}
+
+	// Disassemble
+	DisassembleCodeFlag = cli.StringFlag{
+		Name:  "code",
+		Usage: "The hex code to disassemble",
+	}
+	DisassembleContractFlag = cli.StringFlag{
+		Name:  "contract",
+		Usage: "The address of the contract to disassemble, the code is read from the local chain",
+	}
+	DisassembleHeightFlag = cli.Uint64Flag{
+		Name:  "snapshotHeight",
+		Usage: "The snapshot block height of the instruction set, the latest snapshot block of the local chain by default, or all forks are active for --code",
+	}
+
 	//Net
 	SingleFlag = cli.BoolFlag{
 		Name:  "single",
@@ -0,0 +1,396 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"go/token"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/vitelabs/go-vite/vm/abi"
+)
+
+type tmplArg struct {
+	Name string // parameter name or field name in Go
+	Type string // type in Go
+}
+
+type tmplMethod struct {
+	Name     string // method name in Go
+	Original string // method name in ABI
+	Sig      string
+	Inputs   []tmplArg
+	Outputs  []tmplArg
+}
+
+type tmplEvent struct {
+	Name     string // event name in Go
+	Original string // event name in ABI
+	Sig      string
+	Fields   []tmplArg
+	Indexed  []tmplArg // the topic rules of indexed fields
+}
+
+type tmplStruct struct {
+	Name   string
+	Fields []tmplField
+}
+
+type tmplField struct {
+	Name string
+	Type string
+	Tag  string
+}
+
+type tmplData struct {
+	Package      string
+	Type         string
+	ABI          string // Go literal of the ABI json
+	OffChainCode string
+	StdImports   []string
+	Imports      []string
+
+	Constructor *tmplMethod
+	Methods     []*tmplMethod
+	OffChains   []*tmplMethod
+	Events      []*tmplEvent
+	Structs     []*tmplStruct
+}
+
+// the local variables of generated functions, parameters are renamed if conflict with them
+var reservedNames = map[string]bool{
+	"cli":                true,
+	"opts":               true,
+	"ctx":                true,
+	"sink":               true,
+	"fromSnapshotHeight": true,
+	"data":               true,
+	"output":             true,
+	"values":             true,
+	"err":                true,
+	"event":              true,
+	"abiEvent":           true,
+	"topics":             true,
+	"log":                true,
+	"l":                  true,
+	"quit":               true,
+	"v":                  true,
+}
+
+// binder collects the Go types and identifiers used by the binding
+type binder struct {
+	typeName string
+	imports  map[string]bool
+	names    map[string]bool        // the top-level identifiers
+	structs  map[string]*tmplStruct // tuple signature with field names => struct
+	list     []*tmplStruct
+}
+
+// bind generates the Go binding of contract typeName in package pkg from the ABI json,
+// the offchain code is embedded if it is not empty.
+func bind(pkg, typeName, abiJSON, offchainCode string) ([]byte, error) {
+	if !token.IsIdentifier(pkg) || !token.IsIdentifier(typeName) {
+		return nil, fmt.Errorf("invalid package %q or type %q", pkg, typeName)
+	}
+	typeName = capitalise(typeName)
+
+	contract, err := abi.JSONToABIContract(strings.NewReader(abiJSON))
+	if err != nil {
+		return nil, err
+	}
+	// JSONToABIContract doesn't tell whether the constructor is defined
+	var fields []struct {
+		Type string
+	}
+	if err = json.Unmarshal([]byte(abiJSON), &fields); err != nil {
+		return nil, err
+	}
+
+	b := &binder{
+		typeName: typeName,
+		imports: map[string]bool{
+			"strings":                                  true,
+			"github.com/vitelabs/go-vite/client":       true,
+			"github.com/vitelabs/go-vite/common/types": true,
+			"github.com/vitelabs/go-vite/rpcapi/api":   true,
+			"github.com/vitelabs/go-vite/vm/abi":       true,
+		},
+		names:   make(map[string]bool),
+		structs: make(map[string]*tmplStruct),
+	}
+	for _, name := range []string{typeName, "New" + typeName, "Deploy" + typeName, typeName + "ABI", typeName + "OffChainCode"} {
+		b.names[name] = true
+	}
+
+	data := &tmplData{
+		Package:      pkg,
+		Type:         typeName,
+		ABI:          "`" + strings.TrimSpace(abiJSON) + "`",
+		OffChainCode: strings.TrimSpace(offchainCode),
+	}
+	if strings.Contains(abiJSON, "`") {
+		data.ABI = strconv.Quote(strings.TrimSpace(abiJSON))
+	}
+
+	// reserve the names of event types before tuple structs
+	eventNames := sortedKeys(contract.Events)
+	for _, name := range eventNames {
+		goName := typeName + capitalise(name)
+		if b.names[goName] {
+			return nil, fmt.Errorf("duplicated identifier %s of event %s", goName, name)
+		}
+		b.names[goName] = true
+	}
+
+	members := make(map[string]string) // the methods of the binding => the origin
+	member := func(goName, origin string) error {
+		if other, ok := members[goName]; ok {
+			return fmt.Errorf("duplicated method %s of %s and %s", goName, other, origin)
+		}
+		members[goName] = origin
+		return nil
+	}
+
+	for _, field := range fields {
+		if field.Type == "constructor" {
+			data.Constructor = b.method("", contract.Constructor)
+			break
+		}
+	}
+	for _, name := range sortedKeys(contract.Methods) {
+		m := b.method("Build"+capitalise(name), contract.Methods[name])
+		if err = member(m.Name, name); err != nil {
+			return nil, err
+		}
+		data.Methods = append(data.Methods, m)
+	}
+	for _, name := range sortedKeys(contract.OffChains) {
+		m := b.method(capitalise(name), contract.OffChains[name])
+		if err = member(m.Name, name); err != nil {
+			return nil, err
+		}
+		data.OffChains = append(data.OffChains, m)
+	}
+	for _, name := range eventNames {
+		e := b.event(contract.Events[name])
+		if err = member("Unpack"+e.Name, name); err != nil {
+			return nil, err
+		}
+		if err = member("Watch"+e.Name, name); err != nil {
+			return nil, err
+		}
+		data.Events = append(data.Events, e)
+	}
+	if len(data.Events) > 0 {
+		b.imports["context"] = true
+		b.imports["errors"] = true
+		b.imports["github.com/vitelabs/go-vite/ledger"] = true
+		b.imports["github.com/vitelabs/go-vite/rpcapi/api/filters"] = true
+	}
+
+	data.Structs = b.list
+	for path := range b.imports {
+		if strings.Contains(path, ".") {
+			data.Imports = append(data.Imports, path)
+		} else {
+			data.StdImports = append(data.StdImports, path)
+		}
+	}
+	sort.Strings(data.StdImports)
+	sort.Strings(data.Imports)
+
+	var buf bytes.Buffer
+	if err = bindTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %v\n%s", err, buf.Bytes())
+	}
+	return code, nil
+}
+
+func (b *binder) method(goName string, method abi.Method) *tmplMethod {
+	m := &tmplMethod{
+		Name:     goName,
+		Original: method.Name,
+		Sig:      method.Sig(),
+	}
+
+	used := make(map[string]bool)
+	for i, output := range method.Outputs {
+		name := fmt.Sprintf("out%d", i)
+		used[name] = true
+		m.Outputs = append(m.Outputs, tmplArg{name, b.goType(output.Type, output.Name)})
+	}
+	for i, input := range method.Inputs {
+		m.Inputs = append(m.Inputs, tmplArg{paramName(input.Name, i, used), b.goType(input.Type, input.Name)})
+	}
+	return m
+}
+
+func (b *binder) event(event abi.Event) *tmplEvent {
+	types := make([]string, len(event.Inputs))
+	for i, input := range event.Inputs {
+		types[i] = input.Type.String()
+	}
+	e := &tmplEvent{
+		Name:     capitalise(event.Name),
+		Original: event.Name,
+		Sig:      fmt.Sprintf("%s(%s)", event.Name, strings.Join(types, ",")),
+	}
+
+	fields := map[string]bool{"Raw": true, "Removed": true}
+	used := make(map[string]bool)
+	for i, input := range event.Inputs {
+		typ := "types.Hash"
+		if !input.Indexed || !hashedTopic(input.Type) {
+			typ = b.goType(input.Type, input.Name)
+		}
+
+		name := capitalise(input.Name)
+		if name == "" {
+			name = fmt.Sprintf("Arg%d", i)
+		}
+		for fields[name] {
+			name += "_"
+		}
+		fields[name] = true
+		e.Fields = append(e.Fields, tmplArg{name, typ})
+
+		if input.Indexed {
+			e.Indexed = append(e.Indexed, tmplArg{paramName(input.Name, i, used), typ})
+		}
+	}
+	return e
+}
+
+// goType returns the Go type of abi type t, name is the argument name to name the tuple struct
+func (b *binder) goType(t abi.Type, name string) string {
+	switch t.T {
+	case abi.IntTy, abi.UintTy:
+		if t.Type.Kind() == reflect.Ptr {
+			b.imports["math/big"] = true
+			return "*big.Int"
+		}
+		return t.Type.String()
+	case abi.BoolTy:
+		return "bool"
+	case abi.StringTy:
+		return "string"
+	case abi.AddressTy:
+		return "types.Address"
+	case abi.GidTy:
+		return "types.Gid"
+	case abi.TokenIdTy:
+		return "types.TokenTypeId"
+	case abi.FixedBytesTy:
+		return fmt.Sprintf("[%d]byte", t.Size)
+	case abi.BytesTy:
+		return "[]byte"
+	case abi.SliceTy:
+		return "[]" + b.goType(*t.Elem, name)
+	case abi.ArrayTy:
+		return fmt.Sprintf("[%d]%s", t.Size, b.goType(*t.Elem, name))
+	case abi.TupleTy:
+		return b.tupleStruct(t, name)
+	}
+	panic(fmt.Sprintf("unsupported abi type %s", t))
+}
+
+// tupleStruct returns the name of struct for tuple t, the tuples with the same fields share the struct
+func (b *binder) tupleStruct(t abi.Type, name string) string {
+	key := t.String() + strings.Join(t.TupleRawNames, ",")
+	if s, ok := b.structs[key]; ok {
+		return s.Name
+	}
+
+	s := &tmplStruct{
+		Name: b.typeName + capitalise(name),
+	}
+	for i := 2; b.names[s.Name]; i++ {
+		s.Name = b.typeName + capitalise(name) + strconv.Itoa(i)
+	}
+	b.names[s.Name] = true
+	b.structs[key] = s
+
+	for i, elem := range t.TupleElems {
+		raw := t.TupleRawNames[i]
+		s.Fields = append(s.Fields, tmplField{
+			Name: capitalise(raw),
+			Type: b.goType(*elem, raw),
+			Tag:  fmt.Sprintf("`json:\"%s\"`", raw),
+		})
+	}
+	// the nested structs are listed before
+	b.list = append(b.list, s)
+	return s.Name
+}
+
+// hashedTopic reports whether the indexed argument of type t is logged by the hash
+func hashedTopic(t abi.Type) bool {
+	switch t.T {
+	case abi.ArrayTy, abi.StringTy, abi.SliceTy, abi.BytesTy, abi.TupleTy:
+		return true
+	}
+	return false
+}
+
+// capitalise makes the abi name exported, the same as the field names of tuples in package abi
+func capitalise(name string) string {
+	name = strings.TrimLeft(name, "_")
+	if name == "" {
+		return ""
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// paramName returns the unique parameter name of abi argument name at index
+func paramName(name string, index int, used map[string]bool) string {
+	name = strings.TrimLeft(name, "_")
+	if name == "" {
+		name = fmt.Sprintf("arg%d", index)
+	} else {
+		name = strings.ToLower(name[:1]) + name[1:]
+	}
+	for token.IsKeyword(name) || reservedNames[name] || used[name] {
+		name += "_"
+	}
+	used[name] = true
+	return name
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := reflect.ValueOf(m).MapKeys()
+	list := make([]string, len(keys))
+	for i, key := range keys {
+		list[i] = key.String()
+	}
+	sort.Strings(list)
+	return list
+}
+
+var bindTemplate = template.Must(template.New("bind").Parse(tmplSource))
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// builtinABI reads the ABI json of builtin contract from the constant name in file of package vm/contracts/abi
+func builtinABI(t *testing.T, file, name string) string {
+	path := filepath.Join("..", "..", "vm", "contracts", "abi", file)
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			for i, ident := range value.Names {
+				if ident.Name != name {
+					continue
+				}
+				json, err := strconv.Unquote(value.Values[i].(*ast.BasicLit).Value)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return json
+			}
+		}
+	}
+
+	t.Fatalf("constant %s not found in %s", name, path)
+	return ""
+}
+
+func checkGolden(t *testing.T, name string, code []byte) {
+	path := filepath.Join("testdata", name+".go.golden")
+	if *update {
+		if err := ioutil.WriteFile(path, code, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(code, want) {
+		t.Errorf("generated code of %s is different from %s, run go test -update to update it", name, path)
+	}
+}
+
+func TestBind_builtin(t *testing.T) {
+	cases := []struct {
+		file, constant, pkg string
+	}{
+		{"abi_quota.go", "jsonQuota", "quota"},
+		{"abi_asset.go", "jsonAsset", "asset"},
+		{"abi_gonvernance.go", "jsonGovernance", "governance"},
+		{"abi_dex_trade.go", "jsonDexTrade", "dextrade"},
+	}
+
+	for _, c := range cases {
+		code, err := bind(c.pkg, c.pkg, builtinABI(t, c.file, c.constant), "")
+		if err != nil {
+			t.Fatalf("bind %s: %v", c.pkg, err)
+		}
+		if bytes.Contains(code, []byte("func Deploy")) {
+			t.Errorf("builtin contract %s should not be deployed", c.pkg)
+		}
+		checkGolden(t, c.pkg, code)
+	}
+}
+
+func TestBind_token(t *testing.T) {
+	abiJSON, err := ioutil.ReadFile(filepath.Join("testdata", "token.abi"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	offchainCode, err := ioutil.ReadFile(filepath.Join("testdata", "token.offchain"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err := bind("token", "token", string(abiJSON), string(offchainCode))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "token", code)
+}
+
+func TestBind_conflict(t *testing.T) {
+	cases := []string{
+		// Build + Transfer
+		`[{"type":"function","name":"Transfer","inputs":[]},{"type":"offchain","name":"buildTransfer","inputs":[],"outputs":[]}]`,
+		// the type of event conflicts with the constructor
+		`[{"type":"event","name":"ABI","inputs":[]}]`,
+	}
+	for _, c := range cases {
+		if _, err := bind("token", "token", c, ""); err == nil {
+			t.Errorf("conflict should fail: %s", c)
+		}
+	}
+
+	if _, err := bind("token", "to-ken", `[]`, ""); err == nil {
+		t.Error("invalid type name should fail")
+	}
+}
+
+func TestParamName(t *testing.T) {
+	used := make(map[string]bool)
+	for i, c := range []struct {
+		name, param string
+	}{
+		{"Amount", "amount"},
+		{"_amount", "amount_"},
+		{"type", "type_"},
+		{"data", "data_"},
+		{"", "arg4"},
+	} {
+		if param := paramName(c.name, i, used); param != c.param {
+			t.Errorf("param of %q should be %s, got %s", c.name, c.param, param)
+		}
+	}
+
+	if !strings.HasPrefix(capitalise("__tokenId"), "TokenId") {
+		t.Error("wrong capitalised name")
+	}
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// vitebind generates the Go binding of a Solidity++ contract from its ABI json, including the builders
+// of request blocks, the offchain getters, the decoders and watchers of events.
+//
+//	vitebind -abi token.abi -offchain token.offchain -pkg token -out token.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+var (
+	abiFlag      = flag.String("abi", "", "path of the ABI json, - for stdin")
+	offchainFlag = flag.String("offchain", "", "path of the hex encoded offchain code, optional")
+	pkgFlag      = flag.String("pkg", "", "package name of the generated code")
+	typeFlag     = flag.String("type", "", "type name of the contract, default is the package name")
+	outFlag      = flag.String("out", "", "output file, default is stdout")
+)
+
+func main() {
+	flag.Parse()
+
+	if *abiFlag == "" || *pkgFlag == "" {
+		fmt.Fprintln(os.Stderr, "vitebind: -abi and -pkg are required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var abiJSON []byte
+	var err error
+	if *abiFlag == "-" {
+		abiJSON, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		abiJSON, err = ioutil.ReadFile(*abiFlag)
+	}
+	if err != nil {
+		fatal(err)
+	}
+
+	var offchainCode []byte
+	if *offchainFlag != "" {
+		if offchainCode, err = ioutil.ReadFile(*offchainFlag); err != nil {
+			fatal(err)
+		}
+	}
+
+	typeName := *typeFlag
+	if typeName == "" {
+		typeName = *pkgFlag
+	}
+
+	code, err := bind(*pkgFlag, typeName, string(abiJSON), string(offchainCode))
+	if err != nil {
+		fatal(err)
+	}
+
+	if *outFlag == "" {
+		_, _ = os.Stdout.Write(code)
+		return
+	}
+	if err = ioutil.WriteFile(*outFlag, code, 0644); err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "vitebind: %v\n", err)
+	os.Exit(1)
+}
@@ -0,0 +1,180 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package main
+
+const tmplSource = `// Code generated by vitebind. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .StdImports}}
+	"{{.}}"
+{{- end}}
+{{range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+
+// {{.Type}}ABI is the ABI of contract {{.Type}}
+const {{.Type}}ABI = {{.ABI}}
+{{if .OffChainCode}}
+// {{.Type}}OffChainCode is the offchain code of contract {{.Type}}
+const {{.Type}}OffChainCode = "{{.OffChainCode}}"
+{{end}}
+{{- range .Structs}}
+// {{.Name}} is a tuple of contract {{$.Type}}
+type {{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}} {{.Tag}}
+{{- end}}
+}
+{{end}}
+// {{.Type}} is the binding of contract {{.Type}}
+type {{.Type}} struct {
+	OffChainCode string // the offchain code to call the offchain methods
+
+	abi     abi.ABIContract
+	address types.Address
+	client  client.Client
+	rpc     client.RpcClient
+}
+
+// New{{.Type}} creates the binding of contract {{.Type}} at address
+func New{{.Type}}(address types.Address, cli client.Client, rpcCli client.RpcClient) (*{{.Type}}, error) {
+	contract, err := abi.JSONToABIContract(strings.NewReader({{.Type}}ABI))
+	if err != nil {
+		return nil, err
+	}
+	return &{{.Type}}{
+		{{- if .OffChainCode}}
+		OffChainCode: {{.Type}}OffChainCode,
+		{{- end}}
+		abi:     contract,
+		address: address,
+		client:  cli,
+		rpc:     rpcCli,
+	}, nil
+}
+
+// Address returns the address of contract
+func (_{{.Type}} *{{.Type}}) Address() types.Address {
+	return _{{.Type}}.address
+}
+{{with .Constructor}}
+// Deploy{{$.Type}} builds the request block to create contract {{$.Type}}, the code is set by opts.Meta.HexCode
+func Deploy{{$.Type}}(cli client.Client, opts *client.DeployOpts{{range .Inputs}}, {{.Name}} {{.Type}}{{end}}) (*api.AccountBlock, error) {
+	return cli.BuildRequestCreateContractBlock(opts.RequestCreateContractParams({{$.Type}}ABI{{range .Inputs}}, {{.Name}}{{end}}), opts.Prev)
+}
+{{end}}
+{{- range .Methods}}
+// {{.Name}} builds the request block to call {{.Sig}}
+func (_{{$.Type}} *{{$.Type}}) {{.Name}}(opts *client.TransactOpts{{range .Inputs}}, {{.Name}} {{.Type}}{{end}}) (*api.AccountBlock, error) {
+	data, err := _{{$.Type}}.abi.PackMethod("{{.Original}}"{{range .Inputs}}, {{.Name}}{{end}})
+	if err != nil {
+		return nil, err
+	}
+	return _{{$.Type}}.client.BuildNormalRequestBlock(opts.RequestTxParams(_{{$.Type}}.address, data), opts.Prev)
+}
+{{end}}
+{{- range .OffChains}}
+// {{.Name}} calls the offchain method {{.Sig}}
+func (_{{$.Type}} *{{$.Type}}) {{.Name}}({{range $i, $in := .Inputs}}{{if $i}}, {{end}}{{.Name}} {{.Type}}{{end}}) ({{range .Outputs}}{{.Name}} {{.Type}}, {{end}}err error) {
+	data, err := _{{$.Type}}.abi.PackOffChain("{{.Original}}"{{range .Inputs}}, {{.Name}}{{end}})
+	if err != nil {
+		return
+	}
+	output, err := _{{$.Type}}.rpc.CallOffChainMethod(api.CallOffChainMethodParam{
+		SelfAddr:     _{{$.Type}}.address,
+		OffChainCode: _{{$.Type}}.OffChainCode,
+		Data:         data,
+	})
+	if err != nil {
+		return
+	}
+	{{if .Outputs}}values, err :={{else}}_, err ={{end}} _{{$.Type}}.abi.DirectUnpackOffchainOutput("{{.Original}}", output)
+	if err != nil {
+		return
+	}
+	{{- range $i, $out := .Outputs}}
+	if err = abi.Convert(&{{.Name}}, values[{{$i}}]); err != nil {
+		return
+	}
+	{{- end}}
+	return
+}
+{{end}}
+{{- range .Events}}
+// {{$.Type}}{{.Name}} is the log of event {{.Sig}}
+type {{$.Type}}{{.Name}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+
+	Raw     *ledger.VmLog // the log
+	Removed bool          // the log is removed by the rollback of chain
+}
+
+// Unpack{{.Name}} decodes the log of event {{.Sig}}
+func (_{{$.Type}} *{{$.Type}}) Unpack{{.Name}}(log *ledger.VmLog) (*{{$.Type}}{{.Name}}, error) {
+	abiEvent := _{{$.Type}}.abi.Events["{{.Original}}"]
+	if len(log.Topics) != len(abiEvent.IndexedInputs)+1 || log.Topics[0] != abiEvent.Id() {
+		return nil, errors.New("not the log of event {{.Original}}")
+	}
+	{{if .Fields}}values{{else}}_{{end}}, err := abiEvent.DirectUnPack(log.Topics, log.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	event := &{{$.Type}}{{.Name}}{Raw: log}
+	{{- range $i, $f := .Fields}}
+	if err = abi.Convert(&event.{{.Name}}, values[{{$i}}]); err != nil {
+		return nil, err
+	}
+	{{- end}}
+	return event, nil
+}
+
+// Watch{{.Name}} subscribes the logs of event {{.Sig}}, the indexed fields are filtered
+// by the given values, nil matches any value. The confirmed logs from fromSnapshotHeight are
+// replayed if it is not nil.
+func (_{{$.Type}} *{{$.Type}}) Watch{{.Name}}(ctx context.Context, fromSnapshotHeight *string, sink chan<- *{{$.Type}}{{.Name}}{{range .Indexed}}, {{.Name}} []{{.Type}}{{end}}) (*client.LogSubscription, error) {
+	{{- range .Indexed}}
+	var {{.Name}}Rule []interface{}
+	for _, v := range {{.Name}} {
+		{{.Name}}Rule = append({{.Name}}Rule, v)
+	}
+	{{- end}}
+	topics, err := _{{$.Type}}.abi.Events["{{.Original}}"].Topics({{range $i, $in := .Indexed}}{{if $i}}, {{end}}{{.Name}}Rule{{end}})
+	if err != nil {
+		return nil, err
+	}
+
+	return client.WatchVmLogs(ctx, _{{$.Type}}.rpc, _{{$.Type}}.address, topics, fromSnapshotHeight, func(l *filters.LogsV2, quit <-chan struct{}) {
+		event, err := _{{$.Type}}.Unpack{{.Name}}(l.Log)
+		if err != nil {
+			return
+		}
+		event.Removed = l.Removed
+		select {
+		case sink <- event:
+		case <-quit:
+		}
+	})
+}
+{{end}}`
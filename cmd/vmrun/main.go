@@ -0,0 +1,230 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// vmrun runs account blocks through the vm on in-memory states, and prints the results as json.
+// It runs the test cases of a fixture file in the format of vm/test/run_test:
+//
+//	vmrun send_call.json
+//
+// or calls the code of a contract with the calldata:
+//
+//	vmrun -code 01600160005401600055 -input 00 -amount 10
+//
+// or applies the blocks to the pre-state and writes the post-state, for conformance tests between clients:
+//
+//	vmrun t8n -pre pre.json -blocks blocks.json -post post.json
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm"
+)
+
+// the default accounts of the call of -code
+const (
+	defaultFrom = "vite_ab24ef68b84e642c0ddca06beec81c9acb1977bbd7da27a87a"
+	defaultTo   = "vite_a3ab3f8ce81936636af4c6f4da41612f11136d71f53bf8fa86"
+)
+
+var (
+	codeFlag     = flag.String("code", "", "hex encoded code of the contract, with the contract type prefix")
+	inputFlag    = flag.String("input", "", "hex encoded calldata")
+	amountFlag   = flag.String("amount", "", "hex encoded amount of the call")
+	tokenFlag    = flag.String("token", ledger.ViteTokenId.String(), "token id of the call")
+	fromFlag     = flag.String("from", defaultFrom, "address of the caller")
+	toFlag       = flag.String("to", defaultTo, "address of the contract")
+	sbHeightFlag = flag.Uint64("sbheight", 1000, "height of the latest snapshot block")
+)
+
+func main() {
+	vm.InitVMConfig(false, false, false, false, common.HomeDir())
+	initForkPoints()
+
+	if len(os.Args) > 1 && os.Args[1] == "t8n" {
+		if err := t8nMain(os.Args[2:]); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	flag.Parse()
+	var result interface{}
+	var err error
+	switch {
+	case *codeFlag != "":
+		result, err = runCode()
+	case flag.NArg() == 1:
+		result, err = runFixture(flag.Arg(0))
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fatal(err)
+	}
+	if err = writeJSON(os.Stdout, result); err != nil {
+		fatal(err)
+	}
+}
+
+// runFixture runs the test cases of the fixture file, the expected results in it are ignored
+func runFixture(filename string) (map[string]*runResult, error) {
+	testCases := make(map[string]*testCase)
+	if err := readJSON(filename, &testCases); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(testCases))
+	for name := range testCases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make(map[string]*runResult, len(testCases))
+	for _, name := range names {
+		result, err := testCases[name].Run()
+		if err != nil {
+			return nil, fmt.Errorf("test case %s: %v", name, err)
+		}
+		results[name] = result
+	}
+	return results, nil
+}
+
+// runCode runs a receive call block of the contract with the code, the contract is staked for quota
+func runCode() (*runResult, error) {
+	from, err := types.HexToAddress(*fromFlag)
+	if err != nil {
+		return nil, err
+	}
+	to, err := types.HexToAddress(*toFlag)
+	if err != nil {
+		return nil, err
+	}
+	if !types.IsContractAddr(to) {
+		return nil, errors.New("-to is not a contract address")
+	}
+	tokenId, err := types.HexToTokenTypeId(*tokenFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	tc := &testCase{
+		SbHeight:               *sbHeightFlag,
+		BlockType:              ledger.BlockTypeReceive,
+		SendBlockType:          ledger.BlockTypeSendCall,
+		FromAddress:            from,
+		ToAddress:              to,
+		Data:                   *inputFlag,
+		Amount:                 *amountFlag,
+		TokenId:                tokenId,
+		Code:                   *codeFlag,
+		NeedGlobalStatus:       true,
+		PledgeBeneficialAmount: "10000000000000000000000",
+		PreContractMetaMap: map[types.Address]*ledger.ContractMeta{
+			to: {QuotaRatio: 10},
+		},
+	}
+	return tc.Run()
+}
+
+func t8nMain(args []string) error {
+	fs := flag.NewFlagSet("t8n", flag.ExitOnError)
+	preFlag := fs.String("pre", "", "file of the pre-state")
+	blocksFlag := fs.String("blocks", "", "file of the blocks to apply, a list of test cases without expectation")
+	postFlag := fs.String("post", "", "file to write the post-state, default is stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *preFlag == "" || *blocksFlag == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	post, results, err := t8n(*preFlag, *blocksFlag)
+	if err != nil {
+		return err
+	}
+	if *postFlag == "" {
+		return writeJSON(os.Stdout, post)
+	}
+
+	f, err := os.Create(*postFlag)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err = writeJSON(f, post); err != nil {
+		return err
+	}
+	return writeJSON(os.Stdout, results)
+}
+
+// t8n applies the blocks in file blocks to the state in file pre
+func t8n(pre, blocks string) (*vmState, []*runResult, error) {
+	state := new(vmState)
+	if err := readJSON(pre, state); err != nil {
+		return nil, nil, err
+	}
+	var list []*testCase
+	if err := readJSON(blocks, &list); err != nil {
+		return nil, nil, err
+	}
+
+	results, err := state.transition(list)
+	if err != nil {
+		return nil, nil, err
+	}
+	return state, results, nil
+}
+
+func readJSON(filename string, v interface{}) error {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("decode %s: %v", filename, err)
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "vmrun: %v\n", err)
+	os.Exit(1)
+}
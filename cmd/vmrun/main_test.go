@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm"
+)
+
+func init() {
+	vm.InitVMConfig(false, false, false, false, common.HomeDir())
+	initForkPoints()
+}
+
+func TestRunFixture(t *testing.T) {
+	const filename = "../../vm/test/run_test/receive_call.json"
+	results, err := runFixture(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := make(map[string]*testCase)
+	if err = json.Unmarshal(data, &expected); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results, got %d", len(expected), len(results))
+	}
+	for name, tc := range expected {
+		result := results[name]
+		if result.Err != tc.Err || result.IsRetry != tc.IsRetry || result.Success != tc.Success {
+			t.Fatalf("%s: expected [%v,%v,%v], got [%v,%v,%v]", name, tc.Err, tc.IsRetry, tc.Success, result.Err, result.IsRetry, result.Success)
+		}
+		if tc.Success && (result.Quota != tc.Quota || result.QuotaUsed != tc.QuotaUsed || len(result.LogList) != len(tc.LogList) || len(result.SendBlockList) != len(tc.SendBlockList)) {
+			t.Fatalf("%s: wrong result %+v", name, result)
+		}
+	}
+}
+
+func TestT8n(t *testing.T) {
+	post, results, err := t8n("testdata/pre.json", "testdata/blocks.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, result := range results {
+		if !result.Success || result.Err != "" {
+			t.Fatalf("block %d failed: %v", i, result.Err)
+		}
+	}
+	// the hash of the send block is referred by the receive block
+	if results[0].Hash.String() != "d6348f93c76672132e7a9e121281ad4686f8f559da97da8e424529f13873bf6c" {
+		t.Fatalf("wrong send block hash %s", results[0].Hash)
+	}
+
+	contract, _ := types.HexToAddress(defaultTo)
+	account := post.Accounts[contract]
+	if account.Height != 2 || *results[1].Hash != account.Hash {
+		t.Fatalf("wrong contract height %d hash %s", account.Height, account.Hash)
+	}
+	if account.BalanceMap[ledger.ViteTokenId] != "10" {
+		t.Fatalf("wrong contract balance %v", account.BalanceMap)
+	}
+	if len(account.Storage) != 1 || account.Storage["0000000000000000000000000000000000000000000000000000000000000000"] != "01" {
+		t.Fatalf("wrong contract storage %v", account.Storage)
+	}
+}
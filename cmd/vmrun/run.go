@@ -0,0 +1,540 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/util"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+// testCase is an account block to run on the in-memory state, with the expected result,
+// in the format of the test cases of vm/test/run_test. Amounts and balances are hex encoded.
+type testCase struct {
+	// global status
+	SbHeight uint64
+	SbTime   int64
+	SbHash   string
+	CsDetail map[uint64]map[string]*consensusDetail
+	// block
+	BlockType        byte
+	SendBlockType    byte
+	SendBlockHash    string
+	FromAddress      types.Address
+	ToAddress        types.Address
+	Data             string
+	Amount           string
+	TokenId          types.TokenTypeId
+	Fee              string
+	Code             string
+	NeedGlobalStatus bool
+	BlockHeight      uint64
+	// environment
+	PledgeBeneficialAmount string
+	PreStorage             map[string]string
+	PreBalanceMap          map[types.TokenTypeId]string
+	PreContractMetaMap     map[types.Address]*ledger.ContractMeta
+	ContractMetaMap        map[types.Address]*ledger.ContractMeta
+	// result
+	Err           string
+	IsRetry       bool
+	Success       bool
+	Quota         uint64
+	QuotaUsed     uint64
+	BlockData     *string
+	SendBlockList []*testCaseSendBlock
+	LogList       []testLog
+	Storage       map[string]string
+	BalanceMap    map[types.TokenTypeId]string
+}
+
+type testCaseSendBlock struct {
+	BlockType byte              `json:"blockType"`
+	ToAddress types.Address     `json:"toAddress"`
+	Amount    string            `json:"amount"`
+	TokenID   types.TokenTypeId `json:"tokenID"`
+	Data      string            `json:"data"`
+}
+type testLog struct {
+	Data   string   `json:"data"`
+	Topics []string `json:"topics"`
+}
+
+// runResult is the result of running a testCase, in the format of the expected result of test cases
+type runResult struct {
+	Err           string               `json:"err,omitempty"`
+	IsRetry       bool                 `json:"isRetry"`
+	Success       bool                 `json:"success"`
+	BlockType     byte                 `json:"blockType,omitempty"`
+	Hash          *types.Hash          `json:"hash,omitempty"`
+	Quota         uint64               `json:"quota"`
+	QuotaUsed     uint64               `json:"quotaUsed"`
+	BlockData     string               `json:"blockData,omitempty"`
+	SendBlockList []*testCaseSendBlock `json:"sendBlockList,omitempty"`
+	LogList       []testLog            `json:"logList,omitempty"`
+	LogHash       *types.Hash          `json:"logHash,omitempty"`
+	// the states of the account after the block is executed
+	Storage         map[string]string                      `json:"storage,omitempty"`
+	BalanceMap      map[types.TokenTypeId]string           `json:"balanceMap,omitempty"`
+	ContractMetaMap map[types.Address]*ledger.ContractMeta `json:"contractMetaMap,omitempty"`
+	Code            string                                 `json:"code,omitempty"`
+}
+
+var (
+	quotaInfoList = make([]types.QuotaInfo, 75)
+	prevHash, _   = types.HexToHash("82a8ecfe0df3dea6256651ee3130747386d4d6ab61201ce0050a6fe394a0f595")
+)
+
+const (
+	genesisTimestamp int64 = 1546272000
+	csInterval       int64 = 24 * 3600
+)
+
+var (
+	forkTimestamp100     = time.Unix(1546272100, 0)
+	forkTimestamp200     = time.Unix(1546272200, 0)
+	forkTimestamp250     = time.Unix(1546272250, 0)
+	forkTimestamp300     = time.Unix(1546272300, 0)
+	forkTimestamp400     = time.Unix(1546272400, 0)
+	forkTimestamp500     = time.Unix(1546272500, 0)
+	forkSnapshotBlockMap = map[uint64]*ledger.SnapshotBlock{
+		100: {Height: 100, Timestamp: &forkTimestamp100},
+		200: {Height: 200, Timestamp: &forkTimestamp200},
+		250: {Height: 250, Timestamp: &forkTimestamp250},
+		300: {Height: 300, Timestamp: &forkTimestamp300},
+		400: {Height: 400, Timestamp: &forkTimestamp400},
+		500: {Height: 500, Timestamp: &forkTimestamp500},
+	}
+)
+
+// initForkPoints sets the fork points of the vm test cases, the forks are activated by snapshot height only
+func initForkPoints() {
+	fork.SetForkPoints(&config.ForkPoints{
+		SeedFork:      &config.ForkPoint{Height: 100, Version: 1},
+		DexFork:       &config.ForkPoint{Height: 200, Version: 2},
+		DexFeeFork:    &config.ForkPoint{Height: 250, Version: 3},
+		StemFork:      &config.ForkPoint{Height: 300, Version: 4},
+		LeafFork:      &config.ForkPoint{Height: 400, Version: 5},
+		EarthFork:     &config.ForkPoint{Height: 500, Version: 6},
+		DexMiningFork: &config.ForkPoint{Height: 600, Version: 7},
+		DexRobotFork:  &config.ForkPoint{Height: 700, Version: 8},
+		CryptoFork:    &config.ForkPoint{Height: 800, Version: 9}})
+	fork.SetActiveChecker(activeChecker{})
+}
+
+type activeChecker struct {
+}
+
+func (m activeChecker) IsForkActive(point fork.ForkPointItem) bool {
+	return true
+}
+
+// mockDB is the in-memory vm db returned by vm.NewMockDB
+type mockDB interface {
+	vm_db.VmDb
+	GetBalanceMap() (map[types.TokenTypeId]*big.Int, error)
+}
+
+type runOutput struct {
+	addr    types.Address
+	db      mockDB
+	vmBlock *vm_db.VmAccountBlock
+	isRetry bool
+	err     error
+	// the contract meta before the block is executed
+	preContractMetaMap map[types.Address]*ledger.ContractMeta
+}
+
+func parseHexAmount(name, s string) (*big.Int, error) {
+	if len(s) == 0 {
+		return big.NewInt(0), nil
+	}
+	amount, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		return nil, errors.New("invalid " + name + " " + s)
+	}
+	return amount, nil
+}
+
+// run the test case on the latest block prev of the account, the default one is used if prev is nil.
+// The returned error means the test case is invalid, the error of vm is in the output.
+func (tc *testCase) run(prev *ledger.HashHeight) (*runOutput, error) {
+	var currentTime time.Time
+	if tc.SbTime > 0 {
+		currentTime = time.Unix(tc.SbTime, 0)
+	} else {
+		currentTime = time.Now()
+	}
+	latestSnapshotBlock := &ledger.SnapshotBlock{
+		Height:    tc.SbHeight,
+		Timestamp: &currentTime,
+	}
+	if len(tc.SbHash) > 0 {
+		sbHash, err := types.HexToHash(tc.SbHash)
+		if err != nil {
+			return nil, errors.New("invalid sbHash " + tc.SbHash)
+		}
+		latestSnapshotBlock.Hash = sbHash
+	}
+	pledgeBeneficialAmount, err := parseHexAmount("pledgeBeneficialAmount", tc.PledgeBeneficialAmount)
+	if err != nil {
+		return nil, err
+	}
+	code, err := hex.DecodeString(tc.Code)
+	if err != nil {
+		return nil, errors.New("invalid code " + tc.Code)
+	}
+
+	sendBlock := &ledger.AccountBlock{
+		TokenId: tc.TokenId,
+	}
+	if sendBlock.Fee, err = parseHexAmount("fee", tc.Fee); err != nil {
+		return nil, err
+	}
+	if sendBlock.Amount, err = parseHexAmount("amount", tc.Amount); err != nil {
+		return nil, err
+	}
+	if len(tc.Data) > 0 {
+		if sendBlock.Data, err = hex.DecodeString(tc.Data); err != nil {
+			return nil, errors.New("invalid data " + tc.Data)
+		}
+	}
+
+	out := &runOutput{preContractMetaMap: tc.PreContractMetaMap}
+	if ledger.IsSendBlock(tc.BlockType) {
+		prevBlock := &ledger.AccountBlock{
+			BlockType:      ledger.BlockTypeReceive,
+			Height:         1,
+			Hash:           prevHash,
+			PrevHash:       types.ZERO_HASH,
+			AccountAddress: tc.FromAddress,
+		}
+		if prev != nil {
+			prevBlock.Height, prevBlock.Hash = prev.Height, prev.Hash
+		}
+		sendBlock.PrevHash = prevBlock.Hash
+		sendBlock.Height = prevBlock.Height + 1
+		sendBlock.BlockType = tc.BlockType
+		sendBlock.AccountAddress = tc.FromAddress
+		sendBlock.ToAddress = tc.ToAddress
+		out.addr = tc.FromAddress
+		if out.db, err = vm.NewMockDB(&tc.FromAddress, latestSnapshotBlock, prevBlock, quotaInfoList, pledgeBeneficialAmount, tc.PreBalanceMap, tc.PreStorage, tc.PreContractMetaMap, code, genesisTimestamp, forkSnapshotBlockMap); err != nil {
+			return nil, err
+		}
+		out.vmBlock, out.isRetry, out.err = vm.NewVM(nil).RunV2(out.db, sendBlock, nil, nil)
+	} else if ledger.IsReceiveBlock(tc.BlockType) {
+		sendBlock.BlockType = tc.SendBlockType
+		sendBlock.AccountAddress = tc.FromAddress
+		sendBlock.ToAddress = tc.ToAddress
+		if len(tc.SendBlockHash) > 0 {
+			if sendBlock.Hash, err = types.HexToHash(tc.SendBlockHash); err != nil {
+				return nil, errors.New("invalid sendBlockHash " + tc.SendBlockHash)
+			}
+		}
+		var prevBlock, receiveBlock *ledger.AccountBlock
+		if tc.SendBlockType == ledger.BlockTypeSendCreate {
+			receiveBlock = &ledger.AccountBlock{
+				BlockType:      tc.BlockType,
+				PrevHash:       types.Hash{},
+				Height:         1,
+				AccountAddress: tc.ToAddress,
+			}
+		} else {
+			prevBlock = &ledger.AccountBlock{
+				BlockType:      ledger.BlockTypeReceive,
+				Height:         1,
+				Hash:           prevHash,
+				PrevHash:       types.ZERO_HASH,
+				AccountAddress: tc.ToAddress,
+			}
+			if prev != nil {
+				prevBlock.Height, prevBlock.Hash = prev.Height, prev.Hash
+			} else if tc.BlockHeight > 1 {
+				prevBlock.Height = tc.BlockHeight - 1
+			}
+			receiveBlock = &ledger.AccountBlock{
+				BlockType:      tc.BlockType,
+				PrevHash:       prevBlock.Hash,
+				Height:         prevBlock.Height + 1,
+				AccountAddress: tc.ToAddress,
+			}
+		}
+		out.addr = tc.ToAddress
+		if out.db, err = vm.NewMockDB(&tc.ToAddress, latestSnapshotBlock, prevBlock, quotaInfoList, pledgeBeneficialAmount, tc.PreBalanceMap, tc.PreStorage, tc.PreContractMetaMap, code, genesisTimestamp, forkSnapshotBlockMap); err != nil {
+			return nil, err
+		}
+		cs := util.NewVMConsensusReader(newConsensusReader(genesisTimestamp, csInterval, tc.CsDetail))
+		var status util.GlobalStatus
+		if tc.NeedGlobalStatus {
+			status = newGlobalStatus(0, latestSnapshotBlock)
+		}
+		out.vmBlock, out.isRetry, out.err = vm.NewVM(cs).RunV2(out.db, receiveBlock, sendBlock, status)
+	} else {
+		return nil, errors.New("invalid blockType")
+	}
+	return out, nil
+}
+
+// Run the account block of the test case on the in-memory state built from the environment of it
+func (tc *testCase) Run() (*runResult, error) {
+	out, err := tc.run(nil)
+	if err != nil {
+		return nil, err
+	}
+	return out.result(), nil
+}
+
+func (out *runOutput) result() *runResult {
+	result := &runResult{
+		IsRetry: out.isRetry,
+		Success: out.vmBlock != nil,
+	}
+	if out.err != nil {
+		result.Err = out.err.Error()
+	}
+	if out.vmBlock == nil {
+		return result
+	}
+
+	block := out.vmBlock.AccountBlock
+	hash := block.ComputeHash()
+	result.BlockType = block.BlockType
+	result.Hash = &hash
+	result.Quota = block.Quota
+	result.QuotaUsed = block.QuotaUsed
+	result.BlockData = hex.EncodeToString(block.Data)
+	for _, sendBlock := range block.SendBlockList {
+		result.SendBlockList = append(result.SendBlockList, &testCaseSendBlock{
+			BlockType: sendBlock.BlockType,
+			ToAddress: sendBlock.ToAddress,
+			Amount:    hex.EncodeToString(sendBlock.Amount.Bytes()),
+			TokenID:   sendBlock.TokenId,
+			Data:      hex.EncodeToString(sendBlock.Data),
+		})
+	}
+	for _, log := range out.db.GetLogList() {
+		l := testLog{Data: hex.EncodeToString(log.Data)}
+		for _, topic := range log.Topics {
+			l.Topics = append(l.Topics, topic.String())
+		}
+		result.LogList = append(result.LogList, l)
+	}
+	result.LogHash = out.db.GetLogListHash()
+
+	result.Storage = make(map[string]string)
+	if iter, err := out.db.NewStorageIterator(nil); err == nil {
+		for iter.Next() {
+			result.Storage[hex.EncodeToString(iter.Key())] = hex.EncodeToString(iter.Value())
+		}
+		iter.Release()
+	}
+	result.BalanceMap = make(map[types.TokenTypeId]string)
+	balanceMap, _ := out.db.GetBalanceMap()
+	for tid, amount := range balanceMap {
+		if amount.Sign() > 0 {
+			result.BalanceMap[tid] = amount.Text(16)
+		}
+	}
+
+	// the contract meta is only set when the contract of the account is created
+	result.ContractMetaMap = make(map[types.Address]*ledger.ContractMeta, len(out.preContractMetaMap)+1)
+	for addr, meta := range out.preContractMetaMap {
+		result.ContractMetaMap[addr] = meta
+	}
+	if ledger.GetBuiltinContractMeta(out.addr) == nil {
+		if meta, _ := out.db.GetContractMeta(); meta != nil {
+			result.ContractMetaMap[out.addr] = meta
+		}
+	}
+	code, _ := out.db.GetContractCode()
+	result.Code = hex.EncodeToString(code)
+	return result
+}
+
+// vmState is the in-memory state of accounts, blocks are applied to it by transition
+type vmState struct {
+	// global status of the blocks
+	SbHeight uint64                                 `json:"sbHeight"`
+	SbTime   int64                                  `json:"sbTime"`
+	SbHash   string                                 `json:"sbHash,omitempty"`
+	CsDetail map[uint64]map[string]*consensusDetail `json:"csDetail,omitempty"`
+
+	Accounts        map[types.Address]*accountState        `json:"accounts"`
+	ContractMetaMap map[types.Address]*ledger.ContractMeta `json:"contractMetaMap,omitempty"`
+}
+
+// accountState is the state of an account, the account does not exist if Height is 0
+type accountState struct {
+	Height                 uint64                       `json:"height"`
+	Hash                   types.Hash                   `json:"hash"`
+	PledgeBeneficialAmount string                       `json:"pledgeBeneficialAmount,omitempty"`
+	Code                   string                       `json:"code,omitempty"`
+	BalanceMap             map[types.TokenTypeId]string `json:"balanceMap,omitempty"`
+	Storage                map[string]string            `json:"storage,omitempty"`
+}
+
+// transition applies the blocks to the state in order, only the block fields of the test cases are used.
+// The account state is updated if the block is generated, even if the vm returns an error.
+func (s *vmState) transition(blocks []*testCase) ([]*runResult, error) {
+	if s.Accounts == nil {
+		s.Accounts = make(map[types.Address]*accountState)
+	}
+	results := make([]*runResult, 0, len(blocks))
+	for i, block := range blocks {
+		tc := *block
+		tc.SbHeight, tc.SbTime, tc.SbHash, tc.CsDetail = s.SbHeight, s.SbTime, s.SbHash, s.CsDetail
+
+		addr := tc.FromAddress
+		if ledger.IsReceiveBlock(tc.BlockType) {
+			addr = tc.ToAddress
+		}
+		account, ok := s.Accounts[addr]
+		if !ok {
+			account = &accountState{}
+		}
+		var prev *ledger.HashHeight
+		if account.Height > 0 {
+			prev = &ledger.HashHeight{Height: account.Height, Hash: account.Hash}
+		} else if ledger.IsSendBlock(tc.BlockType) || tc.SendBlockType != ledger.BlockTypeSendCreate {
+			return nil, errors.New("account " + addr.String() + " not exist, block index " + strconv.Itoa(i))
+		}
+		tc.PledgeBeneficialAmount = account.PledgeBeneficialAmount
+		tc.Code = account.Code
+		tc.PreBalanceMap = account.BalanceMap
+		tc.PreStorage = account.Storage
+		tc.PreContractMetaMap = s.ContractMetaMap
+
+		out, err := tc.run(prev)
+		if err != nil {
+			return nil, errors.New(err.Error() + ", block index " + strconv.Itoa(i))
+		}
+		result := out.result()
+		results = append(results, result)
+		if !result.Success {
+			continue
+		}
+
+		s.Accounts[addr] = &accountState{
+			Height:                 out.vmBlock.AccountBlock.Height,
+			Hash:                   *result.Hash,
+			PledgeBeneficialAmount: account.PledgeBeneficialAmount,
+			Code:                   result.Code,
+			BalanceMap:             result.BalanceMap,
+			Storage:                result.Storage,
+		}
+		s.ContractMetaMap = result.ContractMetaMap
+	}
+	return results, nil
+}
+
+type consensusDetail struct {
+	BlockNum         uint64
+	ExpectedBlockNum uint64
+	VoteCount        *big.Int
+}
+
+// consensusReader serves the sbp stats of the days in detailMap
+type consensusReader struct {
+	ti        timeIndex
+	detailMap map[uint64]map[string]*consensusDetail
+}
+
+func newConsensusReader(genesisTime int64, interval int64, detailMap map[uint64]map[string]*consensusDetail) *consensusReader {
+	return &consensusReader{timeIndex{time.Unix(genesisTime, 0), time.Second * time.Duration(interval)}, detailMap}
+}
+
+func (r *consensusReader) DayStats(startIndex uint64, endIndex uint64) ([]*core.DayStats, error) {
+	list := make([]*core.DayStats, 0)
+	if len(r.detailMap) == 0 {
+		return list, nil
+	}
+	for i := startIndex; i <= endIndex; i++ {
+		m, ok := r.detailMap[i]
+		if !ok {
+			continue
+		}
+		blockNum := uint64(0)
+		voteCount := big.NewInt(0)
+		statusMap := make(map[string]*core.SbpStats, len(m))
+		for name, detail := range m {
+			blockNum = blockNum + detail.BlockNum
+			voteCount.Add(voteCount, detail.VoteCount)
+			statusMap[name] = &core.SbpStats{Index: i, BlockNum: detail.BlockNum, ExceptedBlockNum: detail.ExpectedBlockNum, VoteCnt: &core.BigInt{Int: detail.VoteCount}, Name: name}
+		}
+		list = append(list, &core.DayStats{Index: i, Stats: statusMap, VoteSum: &core.BigInt{Int: voteCount}, BlockTotal: blockNum})
+	}
+	return list, nil
+}
+func (r *consensusReader) GetDayTimeIndex() core.TimeIndex {
+	return r.ti
+}
+
+type timeIndex struct {
+	GenesisTime time.Time
+	Interval    time.Duration
+}
+
+func (ti timeIndex) Index2Time(index uint64) (time.Time, time.Time) {
+	sTime := ti.GenesisTime.Add(ti.Interval * time.Duration(index))
+	eTime := ti.GenesisTime.Add(ti.Interval * time.Duration(index+1))
+	return sTime, eTime
+}
+func (ti timeIndex) Time2Index(t time.Time) uint64 {
+	subSec := int64(t.Sub(ti.GenesisTime).Seconds())
+	i := uint64(subSec) / uint64(ti.Interval.Seconds())
+	return i
+}
+
+// globalStatus serves the seed and the random numbers generated from it
+type globalStatus struct {
+	seed          uint64
+	snapshotBlock *ledger.SnapshotBlock
+	randSource    helper.Source64
+	setRandSeed   bool
+}
+
+func newGlobalStatus(seed uint64, snapshotBlock *ledger.SnapshotBlock) *globalStatus {
+	return &globalStatus{seed: seed, snapshotBlock: snapshotBlock}
+}
+func (g *globalStatus) Seed() (uint64, error) {
+	return g.seed, nil
+}
+func (g *globalStatus) Random() (uint64, error) {
+	if g.setRandSeed {
+		return g.randSource.Uint64(), nil
+	}
+	g.randSource = helper.NewSource64(int64(g.seed))
+	g.setRandSeed = true
+	return g.randSource.Uint64(), nil
+}
+func (g *globalStatus) SnapshotBlock() *ledger.SnapshotBlock {
+	return g.snapshotBlock
+}
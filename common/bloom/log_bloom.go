@@ -0,0 +1,94 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package bloom
+
+import (
+	"errors"
+
+	"github.com/vitelabs/go-vite/crypto"
+)
+
+const (
+	// LogBloomLength is the byte length of LogBloom
+	LogBloomLength = 256
+
+	logBloomBits = LogBloomLength * 8
+	logBloomK    = 3
+)
+
+// LogBloom is a fixed-size Bloom filter which can be serialized and merged,
+// it is used to record the addresses and topics of vm logs.
+// Unlike Filter, it is not thread-safe.
+type LogBloom [LogBloomLength]byte
+
+func BytesToLogBloom(b []byte) (*LogBloom, error) {
+	if len(b) != LogBloomLength {
+		return nil, errors.New("invalid log bloom length")
+	}
+	var bloom LogBloom
+	copy(bloom[:], b)
+	return &bloom, nil
+}
+
+// Add sets the k bits of data
+func (b *LogBloom) Add(data []byte) {
+	for _, bit := range logBloomPositions(data) {
+		b[LogBloomLength-1-bit/8] |= 1 << (bit % 8)
+	}
+}
+
+// Test returns false if data is definitely not in the bloom
+func (b *LogBloom) Test(data []byte) bool {
+	for _, bit := range logBloomPositions(data) {
+		if b[LogBloomLength-1-bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Or merges other into b
+func (b *LogBloom) Or(other *LogBloom) {
+	for i := range b {
+		b[i] |= other[i]
+	}
+}
+
+func (b *LogBloom) IsEmpty() bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *LogBloom) Bytes() []byte {
+	return b[:]
+}
+
+func logBloomPositions(data []byte) [logBloomK]uint {
+	hash := crypto.Hash256(data)
+
+	var positions [logBloomK]uint
+	for i := 0; i < logBloomK; i++ {
+		positions[i] = (uint(hash[2*i])<<8 | uint(hash[2*i+1])) % logBloomBits
+	}
+	return positions
+}
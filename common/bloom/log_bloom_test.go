@@ -0,0 +1,41 @@
+package bloom
+
+import (
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+func TestLogBloom(t *testing.T) {
+	var b LogBloom
+	if !b.IsEmpty() {
+		t.Fatal("new bloom should be empty")
+	}
+
+	topic := types.DataHash([]byte("Transfer"))
+	b.Add(types.AddressAsset.Bytes())
+	b.Add(topic.Bytes())
+
+	if !b.Test(types.AddressAsset.Bytes()) || !b.Test(topic.Bytes()) {
+		t.Fatal("bloom should contain added data")
+	}
+	if b.Test(types.DataHash([]byte("Approve")).Bytes()) {
+		t.Fatal("unexpected false positive")
+	}
+
+	restored, err := BytesToLogBloom(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var other LogBloom
+	other.Add(types.AddressQuota.Bytes())
+	restored.Or(&other)
+	if !restored.Test(types.AddressQuota.Bytes()) || !restored.Test(topic.Bytes()) {
+		t.Fatal("merged bloom should contain data of both")
+	}
+
+	if _, err := BytesToLogBloom(b.Bytes()[1:]); err == nil {
+		t.Fatal("expected length error")
+	}
+}
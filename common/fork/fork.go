@@ -189,6 +189,19 @@ func IsDexRobotFork(snapshotHeight uint64) bool {
 	return snapshotHeight >= dexRobotForkPoint.Height && IsForkActive(*dexRobotForkPoint)
 }
 
+/*
+IsCryptoFork checks whether current snapshot block height is over crypto hard fork.
+Contents:
+  1. Vm interpreters add SHA256, KECCAK256 and ED25519VERIFY opcodes since crypto fork.
+*/
+func IsCryptoFork(snapshotHeight uint64) bool {
+	cryptoForkPoint, ok := forkPointMap["CryptoFork"]
+	if !ok {
+		panic("check crypto fork failed. CryptoFork is not existed.")
+	}
+	return snapshotHeight >= cryptoForkPoint.Height && IsForkActive(*cryptoForkPoint)
+}
+
 func GetLeafForkPoint() *ForkPointItem {
 	leafForkPoint, ok := forkPointMap["LeafFork"]
 	if !ok {
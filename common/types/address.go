@@ -245,12 +245,13 @@ func (a Address) MarshalText() ([]byte, error) {
 }
 
 // UnmarshalText implements encoding.TextUnmarshaler.
+// The input is the text without quotation, quoted input is accepted for compatibility.
 func (a *Address) UnmarshalText(input []byte) error {
-	if !isString(input) {
-		return ErrJsonNotString
+	if isString(input) {
+		input = trimLeftRightQuotation(input)
 	}
 
-	addresses, e := HexToAddress(strings.Trim(string(input), "\""))
+	addresses, e := HexToAddress(string(input))
 	if e != nil {
 		return e
 	}
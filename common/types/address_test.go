@@ -108,6 +108,29 @@ func TestAddress_UnmarshalJSON(t *testing.T) {
 	assert.Equal(t, addr0.String(), addr.String())
 }
 
+func TestAddress_UnmarshalText(t *testing.T) {
+	addr0, _, _ := CreateAddress()
+
+	var addr Address
+	assert.NoError(t, addr.UnmarshalText([]byte(addr0.String())))
+	assert.Equal(t, addr0, addr)
+
+	// quoted input is accepted for compatibility
+	addr = Address{}
+	assert.NoError(t, addr.UnmarshalText([]byte("\""+addr0.String()+"\"")))
+	assert.Equal(t, addr0, addr)
+
+	assert.Error(t, addr.UnmarshalText([]byte("vite_123")))
+
+	// the address as a map key is unmarshaled by UnmarshalText
+	m := map[Address]string{addr0: "1"}
+	data, err := json.Marshal(m)
+	assert.NoError(t, err)
+	var m2 map[Address]string
+	assert.NoError(t, json.Unmarshal(data, &m2))
+	assert.Equal(t, m, m2)
+}
+
 func TestPubkeyToAddress(t *testing.T) {
 	byt, err := base64.StdEncoding.DecodeString("meHN+pdEEN1yp34IV8JZRFYqYMB+znhxvSTMRufmeoc=")
 	if err != nil {
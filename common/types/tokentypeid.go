@@ -116,12 +116,13 @@ func (tid TokenTypeId) MarshalText() ([]byte, error) {
 }
 
 // UnmarshalText implements encoding.TextUnmarshaler.
+// The input is the text without quotation, quoted input is accepted for compatibility.
 func (tid *TokenTypeId) UnmarshalText(input []byte) error {
-	if !isString(input) {
-		return ErrJsonNotString
+	if isString(input) {
+		input = trimLeftRightQuotation(input)
 	}
 
-	tti, e := HexToTokenTypeId(string(trimLeftRightQuotation(input)))
+	tti, e := HexToTokenTypeId(string(input))
 	if e != nil {
 		return e
 	}
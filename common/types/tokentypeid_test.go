@@ -1,6 +1,7 @@
 package types
 
 import (
+	"encoding/json"
 	"testing"
 	"fmt"
 )
@@ -43,3 +44,21 @@ func TestHexToTokenTypeId(t *testing.T) {
 		t.Fatal("WrongTTIPre expect wrong but correct")
 	}
 }
+
+func TestTokenTypeId_mapKey(t *testing.T) {
+	m := map[TokenTypeId]string{
+		CreateTokenTypeId([]byte{1}): "1",
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m2 map[TokenTypeId]string
+	if err = json.Unmarshal(data, &m2); err != nil {
+		t.Fatal(err)
+	}
+	if len(m2) != 1 || m2[CreateTokenTypeId([]byte{1})] != "1" {
+		t.Fatalf("wrong map %s", data)
+	}
+}
@@ -8,6 +8,7 @@ type Chain struct {
 	GenesisFile    string // genesis file path
 	LedgerGc       bool   // open or close ledger garbage collector
 	OpenPlugins    bool   // open or close chain plugins. eg, filter account blocks by token.
+	ArchiveMode    bool   // keep the state redo log of every snapshot block forever, it will cost more disk space
 
 	VmLogWhiteList []types.Address // contract address white list which save VM logs
 	VmLogAll       bool            // save all VM logs, it will cost more disk space
@@ -83,6 +83,12 @@ func makeForkPointsConfig(genesisConfig *config.Genesis) *config.ForkPoints {
 				Height:  31305900,
 				Version: 8,
 			},
+
+			// not scheduled yet
+			CryptoFork: &config.ForkPoint{
+				Height:  1000000000,
+				Version: 9,
+			},
 		}
 	}
 }
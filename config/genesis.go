@@ -88,6 +88,7 @@ type ForkPoints struct {
 	EarthFork     *ForkPoint
 	DexMiningFork *ForkPoint
 	DexRobotFork  *ForkPoint
+	CryptoFork    *ForkPoint
 }
 
 type GenesisVmLog struct {
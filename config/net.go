@@ -31,6 +31,9 @@ const (
 
 	DefaultForwardStrategy = "cross"
 	DefaultAccessControl   = "any"
+	AccessControlPrivate   = "private"
+
+	DefaultEncryptTransport = true
 )
 
 type Net struct {
@@ -53,6 +56,11 @@ type Net struct {
 
 	FilePublicAddress string
 
+	// NAT is the mechanism to map the ports on the gateway, and find the external IP if PublicAddress or
+	// FilePublicAddress is not set. It can be "none", "any", "upnp", "pmp", "pmp:<gateway IP>" or
+	// "extip:<external IP>", default "none"
+	NAT string
+
 	// DataDir is the directory to storing p2p data, if is null-string, will use memory as database
 	DataDir string
 
@@ -69,9 +77,16 @@ type Net struct {
 	// BootSeeds are the address where can query BootNodes, is a more flexible option than BootNodes
 	BootSeeds []string
 
+	// BootTrees are urls of node lists published in DNS, like vtree://<public key hex>@nodes.example.org
+	BootTrees []string
+
 	// StaticNodes will be connect directly
 	StaticNodes []string
 
+	// TrustedNodes will be connect directly like StaticNodes, and can connect even if the peers are full.
+	// They can be removed by the rpc net_removePeer until restart.
+	TrustedNodes []string
+
 	MaxPeers int
 
 	MaxInboundRatio int
@@ -86,6 +101,10 @@ type Net struct {
 
 	ForwardStrategy string
 
+	// AccessControl is `any` or `private`. Any node can connect in mode `any` if the peers are not full.
+	// In mode `private`, the discovery is disabled, only StaticNodes, TrustedNodes and AccessAllowKeys can connect,
+	// even the producers can not connect if not listed.
+	// Otherwise only AccessAllowKeys and the producers can connect.
 	AccessControl   string
 	AccessAllowKeys []string
 	AccessDenyKeys  []string
@@ -93,6 +112,26 @@ type Net struct {
 	BlackBlockHashList []string
 	WhiteBlockList     []string
 
+	// ServeRateLimit is the max bytes per second of the responses to the block queries of one peer,
+	// the queries are refused after exceeding, default 0 means no limit
+	ServeRateLimit int64
+
+	// UploadRateLimit is the max bytes per second of the file sync uploads to one peer, default 0 means no limit
+	UploadRateLimit int64
+
+	// EncryptTransport means whether encrypt the messages and the file sync after handshake if the peer supports it,
+	// default true
+	EncryptTransport bool
+
+	// Light means run as a light node, only sync snapshot headers and verify them by the consensus group schedule,
+	// account blocks of specific addresses are fetched on demand and served by the rpc namespace `light`, default false
+	Light bool
+
+	// LightProducers are the registered producers of the snapshot consensus group at the light node checkpoint,
+	// which is the highest of WhiteBlockList, like: "sbpName/vite_xxxxxx". The producers are read from the genesis
+	// if there is no checkpoint.
+	LightProducers []string
+
 	MineKey ed25519.PrivateKey
 }
 
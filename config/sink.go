@@ -0,0 +1,13 @@
+package config
+
+// sink config, push chain events to external systems
+type Sink struct {
+	SinkTargets   []SinkTarget
+	SinkQueueSize int // max number of events waiting for delivery, the dropped events are replayed from the chain later
+}
+
+type SinkTarget struct {
+	Type    string `json:"Type"`    // webhook, file or nats
+	Target  string `json:"Target"`  // url of the webhook, path of the file or host:port of the nats server
+	Subject string `json:"Subject"` // subject to publish to, only used by nats
+}
@@ -129,7 +129,7 @@ func newFullForwardStrategy(ps *peerSet) forwardStrategy {
 }
 
 func (d *fullForward) choosePeers(sender *Peer) (l peers) {
-	ourPeers := d.ps.peers()
+	ourPeers := d.ps.scores.sort(d.ps.peers())
 
 	for _, p := range ourPeers {
 		if p.Id == sender.Id {
@@ -167,7 +167,8 @@ func newCrossForwardStrategy(ps *peerSet, commonMax int, commonRatio int) forwar
 
 func (d *crossForward) choosePeers(sender *Peer) (l peers) {
 	ppMap := sender.peers()
-	ourPeers := d.ps.peers()
+	// prefer the common peers with higher scores
+	ourPeers := d.ps.scores.sort(d.ps.peers())
 
 	return commonPeers(ourPeers, ppMap, sender.Id, d.commonMax, d.commonRatio)
 }
@@ -399,7 +400,11 @@ type broadcaster struct {
 
 	verifier Verifier
 	feed     blockNotifier
-	filter   *bloom.Filter
+	filter   *bloom.Filter // dedupe snapshot blocks
+
+	recent    *recentAccountBlocks // dedupe account blocks, and serve the pulls
+	announcer *announcer
+	puller    *puller
 
 	rings *ringStatic
 
@@ -409,6 +414,9 @@ type broadcaster struct {
 	statistic circle.List // statistic latency of block propagation
 	chain     broadChainReader
 
+	term chan struct{}
+	wg   sync.WaitGroup
+
 	log log15.Logger
 }
 
@@ -421,6 +429,9 @@ func newBroadcaster(peers *peerSet, verifier Verifier, feed blockNotifier,
 		feed:      feed,
 		store:     store,
 		filter:    bloom.New(filterCap, rt),
+		recent:    newRecentAccountBlocks(maxRecentBlocks),
+		announcer: newAnnouncer(),
+		puller:    newPuller(),
 		strategy:  strategy,
 		chain:     chain,
 		rings:     newRingStatic(8, 2),
@@ -428,12 +439,53 @@ func newBroadcaster(peers *peerSet, verifier Verifier, feed blockNotifier,
 	}
 }
 
+func (b *broadcaster) start() {
+	b.term = make(chan struct{})
+
+	b.wg.Add(1)
+	go b.loop()
+}
+
+func (b *broadcaster) stop() {
+	if b.term == nil {
+		return
+	}
+
+	select {
+	case <-b.term:
+	default:
+		close(b.term)
+		b.wg.Wait()
+	}
+}
+
+// loop sends the pending announcements, and pulls the blocks not received in time from other announcers
+func (b *broadcaster) loop() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(announceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.term:
+			return
+		case now := <-ticker.C:
+			b.announcer.flush()
+
+			for p, hashes := range b.puller.expired(now) {
+				b.pull(p, hashes)
+			}
+		}
+	}
+}
+
 func (b *broadcaster) name() string {
 	return "broadcaster"
 }
 
 func (b *broadcaster) codes() []Code {
-	return []Code{CodeNewAccountBlock, CodeNewSnapshotBlock}
+	return []Code{CodeNewAccountBlock, CodeNewSnapshotBlock, CodeNewAccountBlockHashes, CodeGetNewAccountBlocks}
 }
 
 func (b *broadcaster) handle(msg Msg) (err error) {
@@ -476,6 +528,7 @@ func (b *broadcaster) handle(msg Msg) (err error) {
 
 		if err = b.verifier.VerifyNetSnapshotBlock(block); err != nil {
 			b.log.Error(fmt.Sprintf("verify new snapshotblock %s/%d from %s error: %v", hash, block.Height, msg.Sender, err))
+			b.peers.scores.invalidBlock(msg.Sender.Id)
 			return err
 		}
 
@@ -507,16 +560,19 @@ func (b *broadcaster) handle(msg Msg) (err error) {
 
 		b.log.Info(fmt.Sprintf("receive new accountblock %s from %s", block.Hash, msg.Sender))
 
+		// full blocks are accepted from any peer whether pulled or not, old nodes still flood them.
+		msg.Sender.knownBlocks.testAndAdd(block.Hash)
+
 		// check if block has exist first
-		if exist := b.filter.Test(block.Hash[:]); exist {
+		if b.recent.has(block.Hash) {
 			return nil
 		}
 
 		// use the compute hash, because computeHash can`t be forged
 		hash := block.ComputeHash()
+		b.puller.received(hash)
 
-		// check if has exist or record, return true if has exist
-		if exist := b.filter.TestAndAdd(hash[:]); exist {
+		if b.recent.has(hash) {
 			return nil
 		}
 
@@ -541,11 +597,20 @@ func (b *broadcaster) handle(msg Msg) (err error) {
 
 		if err = b.verifier.VerifyNetAccountBlock(block); err != nil {
 			b.log.Error(fmt.Sprintf("verify new accountblock %s from %s error: %v", hash, msg.Sender, err))
+			b.peers.scores.invalidBlock(msg.Sender.Id)
 			return err
 		}
 
 		if nb.TTL > 0 {
 			nb.TTL--
+		}
+
+		// received from other peers concurrently
+		if !b.recent.add(hash, nb) {
+			return nil
+		}
+
+		if nb.TTL > 0 {
 			b.forwardAccountBlock(nb, msg.Sender)
 		}
 
@@ -555,11 +620,92 @@ func (b *broadcaster) handle(msg Msg) (err error) {
 			b.store.enqueueAccountBlock(block)
 			b.log.Info(fmt.Sprintf("syncing, don`t give %s/%d to pool", hash, block.Height))
 		}
+
+	case CodeNewAccountBlockHashes:
+		ab := &AccountBlockHashes{}
+		if err = ab.Deserialize(msg.Payload); err != nil {
+			msg.Recycle()
+			return err
+		}
+		msg.Recycle()
+
+		var unknown []types.Hash
+		for _, hash := range ab.Hashes {
+			msg.Sender.knownBlocks.testAndAdd(hash)
+			if !b.recent.has(hash) {
+				unknown = append(unknown, hash)
+			}
+		}
+
+		if want := b.puller.announced(msg.Sender, unknown, time.Now()); len(want) > 0 {
+			b.pull(msg.Sender, want)
+		}
+
+	case CodeGetNewAccountBlocks:
+		ab := &AccountBlockHashes{}
+		if err = ab.Deserialize(msg.Payload); err != nil {
+			msg.Recycle()
+			return err
+		}
+		msg.Recycle()
+
+		if len(ab.Hashes) > maxAnnounceHashes {
+			ab.Hashes = ab.Hashes[:maxAnnounceHashes]
+		}
+
+		if false == msg.Sender.serveLimiter.Allow() {
+			b.log.Warn(fmt.Sprintf("pull from %s exceeds the rate limit", msg.Sender))
+			return nil
+		}
+
+		b.servePull(msg.Sender, ab.Hashes)
 	}
 
 	return nil
 }
 
+// pull the blocks from p, the blocks will be responded as NewAccountBlock
+func (b *broadcaster) pull(p *Peer, hashes []types.Hash) {
+	for len(hashes) > 0 {
+		n := len(hashes)
+		if n > maxAnnounceHashes {
+			n = maxAnnounceHashes
+		}
+
+		if err := p.send(CodeGetNewAccountBlocks, 0, &AccountBlockHashes{Hashes: hashes[:n]}); err != nil {
+			b.log.Warn(fmt.Sprintf("failed to pull %d accountblocks from %s: %v", n, p, err))
+			return
+		}
+		hashes = hashes[n:]
+	}
+}
+
+// servePull responds the blocks we have by priority, the blocks we don`t have are ignored
+func (b *broadcaster) servePull(p *Peer, hashes []types.Hash) {
+	var ttl = make(map[*ledger.AccountBlock]int32, len(hashes))
+	var blocks = make([]*ledger.AccountBlock, 0, len(hashes))
+	for _, hash := range hashes {
+		if nb := b.recent.get(hash); nb != nil {
+			ttl[nb.Block] = nb.TTL
+			blocks = append(blocks, nb.Block)
+		}
+	}
+
+	sortByPriority(blocks)
+
+	for _, block := range blocks {
+		p.knownBlocks.testAndAdd(block.Hash)
+		err := p.send(CodeNewAccountBlock, 0, &NewAccountBlock{
+			Block: block,
+			TTL:   ttl[block],
+		})
+		if err != nil {
+			b.log.Warn(fmt.Sprintf("failed to respond accountblock %s to %s: %v", block.Hash, p, err))
+			return
+		}
+	}
+}
+
 const records1h = 3600
 const records12h = 12 * records1h
 const records24h = 24 * records1h
@@ -684,33 +830,18 @@ func (b *broadcaster) BroadcastAccountBlock(block *ledger.AccountBlock) {
 		TTL:   defaultBroadcastTTL,
 	}
 
-	data, err := msg.Serialize()
-	if err != nil {
-		b.log.Error(fmt.Sprintf("failed to broadcast new accountblock %d: %v", block.Hash, err))
+	if !b.recent.add(block.Hash, msg) {
 		return
 	}
 
-	b.filter.Add(block.Hash.Bytes())
-
-	var rawMsg = Msg{
-		Code:    CodeNewAccountBlock,
-		Id:      0,
-		Payload: data,
-	}
-
-	ps := b.peers.peers()
-	for _, p := range ps {
-		err = p.WriteMsg(rawMsg)
-		if err != nil {
-			p.catch(err)
-			b.log.Error(fmt.Sprintf("failed to broadcast accountblock %s to %s: %v", block.Hash, p, err))
-		} else {
-			b.log.Info(fmt.Sprintf("broadcast accountblock %s to %s", block.Hash, p))
-		}
-	}
+	b.propagateAccountBlock(msg, b.peers.scores.sort(b.peers.peers()), "broadcast")
 }
 
+// BroadcastAccountBlocks broadcast blocks by priority
 func (b *broadcaster) BroadcastAccountBlocks(blocks []*ledger.AccountBlock) {
+	blocks = append([]*ledger.AccountBlock(nil), blocks...)
+	sortByPriority(blocks)
+
 	for _, block := range blocks {
 		b.BroadcastAccountBlock(block)
 	}
@@ -731,7 +862,7 @@ func (b *broadcaster) forwardSnapshotBlock(msg *NewSnapshotBlock, sender *Peer)
 
 	pl := b.strategy.choosePeers(sender)
 	for _, p := range pl {
-		if p.knownBlocks.TestAndAdd(msg.Block.Hash.Bytes()) {
+		if p.knownBlocks.testAndAdd(msg.Block.Hash) {
 			continue
 		} else {
 			if err = p.WriteMsg(rawMsg); err != nil {
@@ -756,31 +887,53 @@ func (b *broadcaster) forwardSnapshotBlock(msg *NewSnapshotBlock, sender *Peer)
 }
 
 func (b *broadcaster) forwardAccountBlock(msg *NewAccountBlock, sender *Peer) {
-	data, err := msg.Serialize()
-	if err != nil {
-		b.log.Error(fmt.Sprintf("failed to forward accountblock %d: %v", msg.Block.Hash, err))
-		return
-	}
+	b.propagateAccountBlock(msg, b.strategy.choosePeers(sender), "forward")
+}
 
-	var rawMsg = Msg{
-		Code:    CodeNewAccountBlock,
-		Id:      0,
-		Payload: data,
+// propagateAccountBlock push the full block to sqrt(n) peers of pl, and announce the hash to the others.
+// the peers have known the block are skipped.
+func (b *broadcaster) propagateAccountBlock(msg *NewAccountBlock, pl peers, action string) {
+	var unknown peers
+	for _, p := range pl {
+		if !p.knownBlocks.has(msg.Block.Hash) {
+			unknown = append(unknown, p)
+		}
 	}
 
-	pl := b.strategy.choosePeers(sender)
-	for _, p := range pl {
-		if p.knownBlocks.TestAndAdd(msg.Block.Hash.Bytes()) {
-			continue
-		} else {
+	push, announce := splitPeers(unknown)
+
+	if len(push) > 0 {
+		data, err := msg.Serialize()
+		if err != nil {
+			b.log.Error(fmt.Sprintf("failed to %s accountblock %s: %v", action, msg.Block.Hash, err))
+			return
+		}
+
+		var rawMsg = Msg{
+			Code:    CodeNewAccountBlock,
+			Id:      0,
+			Payload: data,
+		}
+
+		for _, p := range push {
+			if p.knownBlocks.testAndAdd(msg.Block.Hash) {
+				continue
+			}
 			if err = p.WriteMsg(rawMsg); err != nil {
 				p.catch(err)
-				b.log.Error(fmt.Sprintf("failed to forward accountblock %s to %s: %v", msg.Block.Hash, p, err))
+				b.log.Error(fmt.Sprintf("failed to %s accountblock %s to %s: %v", action, msg.Block.Hash, p, err))
 			} else {
-				b.log.Info(fmt.Sprintf("forward accountblock %s to %s", msg.Block.Hash, p))
+				b.log.Info(fmt.Sprintf("%s accountblock %s to %s", action, msg.Block.Hash, p))
 			}
 		}
 	}
+
+	for _, p := range announce {
+		if p.knownBlocks.testAndAdd(msg.Block.Hash) {
+			continue
+		}
+		b.announcer.add(p, msg.Block)
+	}
 }
 
 type broadcastStatus struct {
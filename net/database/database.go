@@ -48,6 +48,11 @@ var (
 
 	nodeBlockIPPrefix = []byte("node:block:ip:") // block expiration
 	nodeBlockIDPrefix = []byte("node:block:id:") // block expiration
+
+	nodeTrustedPrefix = []byte("node:trusted:") // trusted node added at runtime
+
+	lightHeaderPrefix = []byte("light:header:")  // verified snapshot header of the light node
+	lightScheduleKey  = []byte("light:schedule") // producer schedule of the light node
 )
 
 func New(path string, version int, id vnode.NodeID) (db *DB, err error) {
@@ -178,6 +183,116 @@ func (db *DB) BlockId(id vnode.NodeID, expiration int64) {
 	db.StoreInt64(key, expiration)
 }
 
+func (db *DB) UnblockId(id vnode.NodeID) {
+	key := append(nodeBlockIDPrefix, id.Bytes()...)
+	_ = db.Delete(key, nil)
+}
+
+// ReadBlockedIds return the expiration of blocked nodes, the expired records will be removed
+func (db *DB) ReadBlockedIds() map[vnode.NodeID]int64 {
+	itr := db.NewIterator(util.BytesPrefix(nodeBlockIDPrefix), nil)
+	defer itr.Release()
+
+	now := time.Now().Unix()
+	prefixLen := len(nodeBlockIDPrefix)
+	ret := make(map[vnode.NodeID]int64)
+
+	for itr.Next() {
+		key := itr.Key()
+		id, err := vnode.Bytes2NodeID(key[prefixLen:])
+		if err != nil {
+			_ = db.Delete(key, nil)
+			continue
+		}
+
+		expiration := decodeVarint(itr.Value())
+		if expiration < now {
+			_ = db.Delete(key, nil)
+			continue
+		}
+
+		ret[id] = expiration
+	}
+
+	return ret
+}
+
+// StoreTrustedNode persist the trusted node, it will be loaded after restart
+func (db *DB) StoreTrustedNode(node *vnode.Node) (err error) {
+	data, err := node.Serialize()
+	if err != nil {
+		return
+	}
+
+	key := append(nodeTrustedPrefix, node.ID.Bytes()...)
+	return db.Put(key, data, nil)
+}
+
+func (db *DB) RemoveTrustedNode(id vnode.NodeID) {
+	key := append(nodeTrustedPrefix, id.Bytes()...)
+	_ = db.Delete(key, nil)
+}
+
+func (db *DB) ReadTrustedNodes() (nodes []*vnode.Node) {
+	itr := db.NewIterator(util.BytesPrefix(nodeTrustedPrefix), nil)
+	defer itr.Release()
+
+	for itr.Next() {
+		node := new(vnode.Node)
+		if err := node.Deserialize(itr.Value()); err != nil {
+			_ = db.Delete(itr.Key(), nil)
+			continue
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	return nodes
+}
+
+func lightHeaderKey(height uint64) []byte {
+	key := make([]byte, len(lightHeaderPrefix)+8)
+	copy(key, lightHeaderPrefix)
+	binary.BigEndian.PutUint64(key[len(lightHeaderPrefix):], height)
+	return key
+}
+
+// StoreLightHeader persist the serialized snapshot header verified by the light node
+func (db *DB) StoreLightHeader(height uint64, data []byte) error {
+	return db.Put(lightHeaderKey(height), data, nil)
+}
+
+func (db *DB) RemoveLightHeader(height uint64) {
+	_ = db.Delete(lightHeaderKey(height), nil)
+}
+
+// ReadLightHeaders return the serialized snapshot headers from low to high
+func (db *DB) ReadLightHeaders() (headers [][]byte) {
+	itr := db.NewIterator(util.BytesPrefix(lightHeaderPrefix), nil)
+	defer itr.Release()
+
+	for itr.Next() {
+		data := make([]byte, len(itr.Value()))
+		copy(data, itr.Value())
+		headers = append(headers, data)
+	}
+
+	return headers
+}
+
+func (db *DB) StoreLightSchedule(data []byte) error {
+	return db.Put(lightScheduleKey, data, nil)
+}
+
+// ReadLightSchedule return nil if the schedule has not been stored
+func (db *DB) ReadLightSchedule() []byte {
+	data, err := db.Get(lightScheduleKey, nil)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
 // RetrieveNode Node according to the special nodeID
 func (db *DB) RetrieveNode(id vnode.NodeID) (node *vnode.Node, err error) {
 	key := append(nodeDataPrefix, id.Bytes()...)
@@ -58,3 +58,54 @@ func TestNodeDB_Store(t *testing.T) {
 		t.Error("diff net")
 	}
 }
+
+func TestDB_TrustedNodes(t *testing.T) {
+	mdb, err := New("", 1, id)
+	if err != nil {
+		panic(err)
+	}
+
+	node := vnode.MockNode(false, true)
+	if err = mdb.StoreTrustedNode(node); err != nil {
+		panic(err)
+	}
+
+	nodes := mdb.ReadTrustedNodes()
+	if len(nodes) != 1 || nodes[0].ID != node.ID || nodes[0].EndPoint.String() != node.EndPoint.String() {
+		t.Fatal("failed to read trusted nodes")
+	}
+
+	mdb.RemoveTrustedNode(node.ID)
+	if nodes = mdb.ReadTrustedNodes(); len(nodes) != 0 {
+		t.Fatal("trusted node should be removed")
+	}
+}
+
+func TestDB_LightHeaders(t *testing.T) {
+	mdb, err := New("", 1, id)
+	if err != nil {
+		panic(err)
+	}
+
+	for _, height := range []uint64{30, 2, 25, 1} {
+		if err = mdb.StoreLightHeader(height, []byte{byte(height)}); err != nil {
+			panic(err)
+		}
+	}
+	mdb.RemoveLightHeader(25)
+
+	headers := mdb.ReadLightHeaders()
+	if len(headers) != 3 || headers[0][0] != 1 || headers[1][0] != 2 || headers[2][0] != 30 {
+		t.Fatalf("failed to read light headers from low to high: %v", headers)
+	}
+
+	if mdb.ReadLightSchedule() != nil {
+		t.Fatal("schedule should not be stored")
+	}
+	if err = mdb.StoreLightSchedule([]byte("schedule")); err != nil {
+		panic(err)
+	}
+	if string(mdb.ReadLightSchedule()) != "schedule" {
+		t.Fatal("failed to read light schedule")
+	}
+}
@@ -6,8 +6,11 @@ import (
 	"io"
 	"math/rand"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/net/discovery/dnsdisc"
 	"github.com/vitelabs/go-vite/net/vnode"
 )
 
@@ -199,3 +202,105 @@ func (n *netBooter) getBootNodes(count int) (nodes []*Node) {
 
 	return nodes
 }
+
+// dnsSyncInterval is the minimal interval between two syncs of node trees
+const dnsSyncInterval = 30 * time.Minute
+
+// maxTreeLinks is the maximum count of trees can be retrieved through links
+const maxTreeLinks = 20
+
+// dnsBooter supply bootNodes from node trees published in DNS, the trees are synced in background,
+// and the nodes of the last successful sync are supplied.
+type dnsBooter struct {
+	self   *vnode.Node
+	urls   []string
+	client *dnsdisc.Client
+
+	mu       sync.Mutex
+	nodes    []*Node // replaced by sync, never modified
+	syncTime time.Time
+	syncing  bool
+
+	log log15.Logger
+}
+
+func newDNSBooter(self *vnode.Node, urls []string, resolver dnsdisc.Resolver) (booter, error) {
+	for _, url := range urls {
+		if _, _, err := dnsdisc.ParseURL(url); err != nil {
+			return nil, fmt.Errorf("failed to parse node tree %s: %v", url, err)
+		}
+	}
+
+	return &dnsBooter{
+		self:   self,
+		urls:   urls,
+		client: dnsdisc.NewClient(resolver),
+		log:    discvLog.New("module", "dnsBooter"),
+	}, nil
+}
+
+// getBootNodes start syncing the trees if no node has been synced or the nodes are stale,
+// it does not wait for the sync, the nodes will be supplied next time.
+func (d *dnsBooter) getBootNodes(count int) []*Node {
+	d.mu.Lock()
+	if !d.syncing && (len(d.nodes) == 0 || time.Since(d.syncTime) > dnsSyncInterval) {
+		d.syncing = true
+		go d.sync()
+	}
+	all := d.nodes
+	d.mu.Unlock()
+
+	if count <= 0 || count >= len(all) {
+		return all
+	}
+
+	nodes := make([]*Node, count)
+	for i, j := range rand.Perm(len(all))[:count] {
+		nodes[i] = all[j]
+	}
+
+	return nodes
+}
+
+// sync all trees and the trees linked by them
+func (d *dnsBooter) sync() {
+	var nodes []*Node
+	var visited = make(map[string]struct{})
+	var urls = append([]string(nil), d.urls...)
+
+	for len(urls) > 0 && len(visited) < len(d.urls)+maxTreeLinks {
+		url := urls[0]
+		urls = urls[1:]
+		if _, ok := visited[url]; ok {
+			continue
+		}
+		visited[url] = struct{}{}
+
+		tree, err := d.client.SyncTree(url)
+		if err != nil {
+			d.log.Warn(fmt.Sprintf("failed to sync node tree %s: %v", url, err))
+			continue
+		}
+
+		for _, n := range tree.Nodes() {
+			if n.ID == d.self.ID || (n.Net != 0 && n.Net != d.self.Net) {
+				continue
+			}
+			n.Net = d.self.Net
+			nodes = append(nodes, &Node{
+				Node: *n,
+			})
+		}
+		urls = append(urls, tree.Links()...)
+
+		d.log.Info(fmt.Sprintf("sync node tree %s seq %d", url, tree.Seq()))
+	}
+
+	d.mu.Lock()
+	d.syncTime = time.Now()
+	d.syncing = false
+	if len(nodes) > 0 {
+		d.nodes = nodes
+	}
+	d.mu.Unlock()
+}
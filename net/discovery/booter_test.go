@@ -19,12 +19,17 @@
 package discovery
 
 import (
+	"context"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"testing"
 	"time"
 
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/net/discovery/dnsdisc"
 	"github.com/vitelabs/go-vite/net/vnode"
 )
 
@@ -126,3 +131,69 @@ func TestNetBooter(t *testing.T) {
 		}
 	}
 }
+
+// blockingResolver serves the records after release is closed
+type blockingResolver struct {
+	records map[string]string
+	release chan struct{}
+}
+
+func (r *blockingResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	<-r.release
+	if txt, ok := r.records[name]; ok {
+		return []string{txt}, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func TestDNSBooter(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	self := vnode.MockNode(false, true)
+	nodes := make([]*vnode.Node, 10)
+	for i := range nodes {
+		nodes[i] = vnode.MockNode(false, true)
+		nodes[i].Net = self.Net
+	}
+	tree, err := dnsdisc.MakeTree(1, nodes, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := tree.Sign(key, "nodes.vite.org")
+	records, err := tree.Records("nodes.vite.org")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resolver := &blockingResolver{
+		records: records,
+		release: make(chan struct{}),
+	}
+	boot, err := newDNSBooter(self, []string{url}, resolver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the sync does not block
+	if bootNodes := boot.getBootNodes(5); len(bootNodes) != 0 {
+		t.Fatalf("no node should be synced: %v", bootNodes)
+	}
+	close(resolver.release)
+
+	for i := 0; i < 100; i++ {
+		if bootNodes := boot.getBootNodes(5); len(bootNodes) > 0 {
+			if len(bootNodes) != 5 {
+				t.Fatalf("should get 5 nodes, but get %d", len(bootNodes))
+			}
+			if all := boot.getBootNodes(0); len(all) != len(nodes) {
+				t.Fatalf("should get %d nodes, but get %d", len(nodes), len(all))
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("nodes should be synced in background")
+}
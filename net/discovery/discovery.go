@@ -104,6 +104,7 @@ type Discovery struct {
 
 	bootNodes []string
 	bootSeeds []string
+	bootTrees []string
 
 	booters []booter
 
@@ -181,11 +182,12 @@ func (d *Discovery) Delete(id vnode.NodeID, reason error) {
 }
 
 // New create a Discovery implementation
-func New(peerKey ed25519.PrivateKey, node *vnode.Node, bootNodes, bootSeeds []string, listenAddress string, db NodeDB) *Discovery {
+func New(peerKey ed25519.PrivateKey, node *vnode.Node, bootNodes, bootSeeds, bootTrees []string, listenAddress string, db NodeDB) *Discovery {
 	d := &Discovery{
 		node:       node,
 		bootNodes:  bootNodes,
 		bootSeeds:  bootSeeds,
+		bootTrees:  bootTrees,
 		booters:    nil,
 		table:      nil,
 		finder:     nil,
@@ -212,6 +214,11 @@ func New(peerKey ed25519.PrivateKey, node *vnode.Node, bootNodes, bootSeeds []st
 	return d
 }
 
+// SetEndPoint change the endpoint advertised to other nodes, for example the external address mapped by NAT
+func (d *Discovery) SetEndPoint(e vnode.EndPoint) {
+	d.socket.setEndPoint(e)
+}
+
 func (d *Discovery) Start() (err error) {
 	if !atomic.CompareAndSwapInt32(&d.running, 0, 1) {
 		return errDiscoveryIsRunning
@@ -232,6 +239,14 @@ func (d *Discovery) Start() (err error) {
 		}
 		d.booters = append(d.booters, bt)
 	}
+	if len(d.bootTrees) > 0 {
+		var bt booter
+		bt, err = newDNSBooter(d.node, d.bootTrees, nil)
+		if err != nil {
+			return err
+		}
+		d.booters = append(d.booters, bt)
+	}
 
 	// open socket
 	err = d.socket.start()
@@ -13,34 +13,36 @@ import (
 type mockSocket struct {
 }
 
-func (m *mockSocket) ping(n *Node, ch chan<- *Node) (err error) {
+func (m *mockSocket) ping(n *Node, callback func(*Node, error)) {
 	go func() {
-		n.ID = vnode.RandomNodeID()
-		ch <- n
+		n2 := &Node{
+			Node: n.Node,
+		}
+		n2.ID = vnode.RandomNodeID()
+		callback(n2, nil)
 	}()
-
-	return nil
 }
 
 func (m *mockSocket) pong(echo []byte, n *Node) (err error) {
 	panic("implement me")
 }
 
-func (m *mockSocket) findNode(target vnode.NodeID, count int, n *Node, ch chan<- []*vnode.EndPoint) (err error) {
-	go func() {
-		var eps = make([]*vnode.EndPoint, count)
-		for i := 0; i < count; i++ {
-			eps[i] = &vnode.EndPoint{
-				Host: []byte{0, 0, 0, 0},
-				Port: int(i),
-				Typ:  vnode.HostIPv4,
-			}
+func (m *mockSocket) findNode(target vnode.NodeID, count int, n *Node) (<-chan []*vnode.EndPoint, error) {
+	ch := make(chan []*vnode.EndPoint, 1)
+
+	var eps = make([]*vnode.EndPoint, count)
+	for i := 0; i < count; i++ {
+		eps[i] = &vnode.EndPoint{
+			Host: []byte{0, 0, 0, 0},
+			Port: i,
+			Typ:  vnode.HostIPv4,
 		}
+	}
 
-		ch <- eps
-	}()
+	ch <- eps
+	close(ch)
 
-	return nil
+	return ch, nil
 }
 
 func (m *mockSocket) sendNodes(eps []*vnode.EndPoint, addr *net.UDPAddr) (err error) {
@@ -55,6 +57,9 @@ func (m *mockSocket) stop() error {
 	return nil
 }
 
+func (m *mockSocket) setEndPoint(e vnode.EndPoint) {
+}
+
 func TestFindNode(t *testing.T) {
 	tab := newTable(vnode.ZERO, self.Net, newListBucket, nil)
 	tab.add(&Node{
@@ -83,13 +88,15 @@ func TestFindNode(t *testing.T) {
 		},
 		table:  tab,
 		finder: nil,
-		stage:  make(map[string]*Node),
+		stage:  make(map[string]*checkEndPointResult),
 		socket: &mockSocket{},
 	}
 
-	nodes := d.lookup(vnode.ZERO, 32)
-	if len(nodes) != tab.size() {
-		t.Errorf("should not find %d nodes", len(nodes))
+	// the endpoints found are pinged and added to the table
+	d.lookup(vnode.ZERO, 32)
+	time.Sleep(100 * time.Millisecond)
+	if tab.size() <= 1 {
+		t.Errorf("should not find %d nodes", tab.size()-1)
 	}
 }
 
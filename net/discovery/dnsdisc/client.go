@@ -0,0 +1,199 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dnsdisc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const lookupTimeout = 10 * time.Second
+
+// maxEntries of a tree, protect client from huge trees
+const maxEntries = 10000
+
+// maxCacheEntries of client, entries are content-addressed, so they can be cached until the cache is full
+const maxCacheEntries = 5000
+
+var errNoRoot = errors.New("no root entry found")
+var errTooManyEntries = errors.New("too many entries")
+
+// Resolver look up TXT records, net.Resolver is the default implementation
+type Resolver interface {
+	LookupTXT(ctx context.Context, domain string) ([]string, error)
+}
+
+// Client retrieve and verify trees from DNS
+type Client struct {
+	resolver Resolver
+
+	mu    sync.Mutex
+	cache map[string]entry
+}
+
+// NewClient create a Client, use net.DefaultResolver if resolver is nil
+func NewClient(resolver Resolver) *Client {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	return &Client{
+		resolver: resolver,
+		cache:    make(map[string]entry),
+	}
+}
+
+// SyncTree retrieve the whole tree referenced by url, the root signature and hashes of all entries are verified
+func (c *Client) SyncTree(url string) (*Tree, error) {
+	domain, pub, err := ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := c.resolveRoot(domain)
+	if err != nil {
+		return nil, err
+	}
+	if !root.verify(pub) {
+		return nil, errInvalidSignature
+	}
+
+	t := &Tree{
+		root:    root,
+		entries: make(map[string]entry),
+	}
+
+	if err = c.syncSubtree(t, domain, root.eroot, false); err != nil {
+		return nil, err
+	}
+	if err = c.syncSubtree(t, domain, root.lroot, true); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (c *Client) syncSubtree(t *Tree, domain, hash string, link bool) error {
+	if _, ok := t.entries[hash]; ok {
+		return nil
+	}
+	if len(t.entries) >= maxEntries {
+		return errTooManyEntries
+	}
+
+	e, err := c.resolveEntry(domain, hash)
+	if err != nil {
+		return err
+	}
+
+	switch e := e.(type) {
+	case *branchEntry:
+		t.entries[hash] = e
+		for _, h := range e.children {
+			if err = c.syncSubtree(t, domain, h, link); err != nil {
+				return err
+			}
+		}
+	case *nodeEntry:
+		if link {
+			return errors.New(fmt.Sprintf("node entry %s in link subtree", hash))
+		}
+		t.entries[hash] = e
+	case *linkEntry:
+		if !link {
+			return errors.New(fmt.Sprintf("link entry %s in node subtree", hash))
+		}
+		t.entries[hash] = e
+	default:
+		return errors.New(fmt.Sprintf("unexpected entry %s", hash))
+	}
+
+	return nil
+}
+
+func (c *Client) resolveRoot(domain string) (*rootEntry, error) {
+	txts, err := c.lookup(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, txt := range txts {
+		if strings.HasPrefix(txt, rootPrefix) {
+			return parseRoot(txt)
+		}
+	}
+
+	return nil, errNoRoot
+}
+
+func (c *Client) resolveEntry(domain, hash string) (entry, error) {
+	c.mu.Lock()
+	e, ok := c.cache[hash]
+	c.mu.Unlock()
+	if ok {
+		return e, nil
+	}
+
+	name := hash + "." + domain
+	txts, err := c.lookup(name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, txt := range txts {
+		e, err = parseEntry(txt)
+		if err != nil {
+			continue
+		}
+		if _, ok = e.(*rootEntry); ok {
+			continue
+		}
+		if hashEntry(e) != hash {
+			continue
+		}
+
+		c.mu.Lock()
+		if len(c.cache) >= maxCacheEntries {
+			c.cache = make(map[string]entry)
+		}
+		c.cache[hash] = e
+		c.mu.Unlock()
+
+		return e, nil
+	}
+
+	return nil, errors.New(fmt.Sprintf("no valid entry found at %s", name))
+}
+
+func (c *Client) lookup(name string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+
+	txts, err := c.resolver.LookupTXT(ctx, name)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("failed to lookup TXT of %s: %v", name, err))
+	}
+
+	return txts, nil
+}
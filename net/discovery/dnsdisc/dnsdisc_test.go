@@ -0,0 +1,158 @@
+package dnsdisc
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/net/vnode"
+)
+
+// mapResolver is an in-process DNS stub
+type mapResolver map[string]string
+
+func (m mapResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	if txt, ok := m[name]; ok {
+		return []string{txt}, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+func (m mapResolver) add(records map[string]string) {
+	for name, txt := range records {
+		m[name] = txt
+	}
+}
+
+func makeSignedTree(t *testing.T, domain string, count int, links []string) (tree *Tree, url string, records map[string]string) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes := make([]*vnode.Node, count)
+	for i := range nodes {
+		nodes[i] = vnode.MockNode(false, true)
+	}
+
+	tree, err = MakeTree(1, nodes, links)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url = tree.Sign(key, domain)
+
+	records, err = tree.Records(domain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return
+}
+
+func TestParseURL(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url := MakeURL(pub, "nodes.vite.org")
+	domain, pub2, err := ParseURL(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if domain != "nodes.vite.org" || pub2.Hex() != pub.Hex() {
+		t.Fatalf("wrong url: %s", url)
+	}
+
+	for _, str := range []string{"nodes.vite.org", "vtree://nodes.vite.org", "vtree://" + pub.Hex() + "@", "vtree://abcd@nodes.vite.org"} {
+		if _, _, err = ParseURL(str); err == nil {
+			t.Fatalf("url %s should be invalid", str)
+		}
+	}
+}
+
+func TestClient_SyncTree(t *testing.T) {
+	resolver := make(mapResolver)
+
+	// more than maxChildren*maxChildren nodes, so the tree has three levels
+	tree, url, records := makeSignedTree(t, "nodes.vite.org", 200, nil)
+	resolver.add(records)
+
+	_, linkURL, linkRecords := makeSignedTree(t, "more.vite.org", 3, nil)
+	resolver.add(linkRecords)
+	_, url2, records2 := makeSignedTree(t, "links.vite.org", 0, []string{linkURL})
+	resolver.add(records2)
+
+	c := NewClient(resolver)
+	tree2, err := c.SyncTree(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nodes, nodes2 := tree.Nodes(), tree2.Nodes()
+	if len(nodes2) != 200 || len(nodes) != len(nodes2) {
+		t.Fatalf("should retrieve 200 nodes, but get %d", len(nodes2))
+	}
+	for i := range nodes {
+		if !nodes[i].Equal(nodes2[i]) {
+			t.Fatalf("node %s is not equal to %s", nodes2[i], nodes[i])
+		}
+	}
+	if tree2.Seq() != 1 {
+		t.Fatalf("wrong seq %d", tree2.Seq())
+	}
+
+	tree3, err := c.SyncTree(url2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if links := tree3.Links(); len(links) != 1 || links[0] != linkURL {
+		t.Fatalf("wrong links: %v", links)
+	}
+	if len(tree3.Nodes()) != 0 {
+		t.Fatal("tree should have no nodes")
+	}
+}
+
+func TestClient_SyncTree_invalid(t *testing.T) {
+	resolver := make(mapResolver)
+	_, url, records := makeSignedTree(t, "nodes.vite.org", 20, nil)
+	resolver.add(records)
+
+	// signed by another key
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	if _, err := NewClient(resolver).SyncTree(MakeURL(pub, "nodes.vite.org")); err != errInvalidSignature {
+		t.Fatalf("should be invalid signature: %v", err)
+	}
+
+	// tamper a node entry
+	var tampered = make(mapResolver)
+	_, _, other := makeSignedTree(t, "nodes.vite.org", 1, nil)
+	var fake string
+	for name, txt := range other {
+		if name != "nodes.vite.org" {
+			fake = txt
+		}
+	}
+	for name, txt := range records {
+		if name != "nodes.vite.org" && txt[:len(nodePrefix)] == nodePrefix {
+			tampered[name] = fake
+		} else {
+			tampered[name] = txt
+		}
+	}
+	if _, err := NewClient(tampered).SyncTree(url); err == nil {
+		t.Fatal("tampered tree should be rejected")
+	}
+
+	// unsigned tree can not be published
+	tree, err := MakeTree(1, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = tree.Records("nodes.vite.org"); err != errUnsigned {
+		t.Fatalf("should be unsigned: %v", err)
+	}
+}
@@ -0,0 +1,411 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package dnsdisc implements node lists published in DNS for bootstrapping discovery.
+//
+// The list is a merkle tree of TXT records under a domain, the root record is signed by the publisher:
+//
+//	domain            vite-root:v1 e=<hash> l=<hash> seq=<n> sig=<signature>
+//	<hash>.domain     vite-branch:<hash>,<hash>,...
+//	<hash>.domain     vite-node:<base64 of serialized vnode.Node>
+//	<hash>.domain     vite-link:vtree://<public key>@<other domain>
+//
+// e= is the root of node subtree, l= is the root of link subtree, links point to lists of other publishers.
+// A list is referenced by the url vtree://<public key hex>@domain.
+package dnsdisc
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vitelabs/go-vite/crypto"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/net/vnode"
+)
+
+const (
+	rootPrefix   = "vite-root:v1"
+	branchPrefix = "vite-branch:"
+	nodePrefix   = "vite-node:"
+	linkPrefix   = "vite-link:"
+	treePrefix   = "vtree://"
+)
+
+// hashLength is the bytes of entry hash, the hash is encoded by base32 to 26 chars
+const hashLength = 16
+
+// maxChildren of a branch, keep the TXT record in a single UDP packet
+const maxChildren = 13
+
+var b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+var b64 = base64.RawURLEncoding
+
+var errInvalidURL = errors.New("invalid tree url")
+var errInvalidSignature = errors.New("invalid root signature")
+var errUnsigned = errors.New("tree is not signed")
+
+type entry interface {
+	String() string
+}
+
+type rootEntry struct {
+	eroot string
+	lroot string
+	seq   uint
+	sig   []byte
+}
+
+func (e *rootEntry) signedContent() string {
+	return fmt.Sprintf("%s e=%s l=%s seq=%d", rootPrefix, e.eroot, e.lroot, e.seq)
+}
+
+func (e *rootEntry) String() string {
+	return e.signedContent() + " sig=" + b64.EncodeToString(e.sig)
+}
+
+func (e *rootEntry) verify(pub ed25519.PublicKey) bool {
+	return len(pub) == ed25519.PublicKeySize && ed25519.Verify(pub, []byte(e.signedContent()), e.sig)
+}
+
+type branchEntry struct {
+	children []string
+}
+
+func (e *branchEntry) String() string {
+	return branchPrefix + strings.Join(e.children, ",")
+}
+
+type nodeEntry struct {
+	node *vnode.Node
+	text string
+}
+
+func newNodeEntry(node *vnode.Node) (*nodeEntry, error) {
+	data, err := node.Serialize()
+	if err != nil {
+		return nil, err
+	}
+
+	return &nodeEntry{
+		node: node,
+		text: nodePrefix + b64.EncodeToString(data),
+	}, nil
+}
+
+func (e *nodeEntry) String() string {
+	return e.text
+}
+
+type linkEntry struct {
+	url    string
+	domain string
+	pub    ed25519.PublicKey
+}
+
+func (e *linkEntry) String() string {
+	return linkPrefix + e.url
+}
+
+// hashEntry is the subdomain of entry
+func hashEntry(e entry) string {
+	return b32.EncodeToString(crypto.Hash(hashLength, []byte(e.String())))
+}
+
+// ParseURL parse the tree url vtree://<public key hex>@domain
+func ParseURL(url string) (domain string, pub ed25519.PublicKey, err error) {
+	if !strings.HasPrefix(url, treePrefix) {
+		return "", nil, errInvalidURL
+	}
+	url = url[len(treePrefix):]
+
+	index := strings.IndexRune(url, '@')
+	if index < 0 || index == len(url)-1 {
+		return "", nil, errInvalidURL
+	}
+
+	pub, err = ed25519.HexToPublicKey(url[:index])
+	if err != nil {
+		return "", nil, errors.New(fmt.Sprintf("invalid public key of tree url: %v", err))
+	}
+
+	return url[index+1:], pub, nil
+}
+
+// MakeURL return the url of tree under domain signed by pub
+func MakeURL(pub ed25519.PublicKey, domain string) string {
+	return treePrefix + pub.Hex() + "@" + domain
+}
+
+func parseEntry(text string) (entry, error) {
+	switch {
+	case strings.HasPrefix(text, rootPrefix):
+		return parseRoot(text)
+	case strings.HasPrefix(text, branchPrefix):
+		return parseBranch(text[len(branchPrefix):])
+	case strings.HasPrefix(text, nodePrefix):
+		return parseNode(text)
+	case strings.HasPrefix(text, linkPrefix):
+		return parseLink(text[len(linkPrefix):])
+	default:
+		return nil, errors.New(fmt.Sprintf("unknown entry: %s", text))
+	}
+}
+
+func parseRoot(text string) (e *rootEntry, err error) {
+	e = new(rootEntry)
+
+	fields := strings.Fields(text)
+	if len(fields) != 5 || fields[0] != rootPrefix {
+		return nil, errors.New(fmt.Sprintf("invalid root entry: %s", text))
+	}
+
+	for _, f := range fields[1:] {
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.New(fmt.Sprintf("invalid root entry: %s", text))
+		}
+
+		switch kv[0] {
+		case "e":
+			e.eroot = kv[1]
+		case "l":
+			e.lroot = kv[1]
+		case "seq":
+			var seq uint64
+			seq, err = strconv.ParseUint(kv[1], 10, 32)
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("invalid seq of root entry: %v", err))
+			}
+			e.seq = uint(seq)
+		case "sig":
+			e.sig, err = b64.DecodeString(kv[1])
+			if err != nil {
+				return nil, errors.New(fmt.Sprintf("invalid signature of root entry: %v", err))
+			}
+		default:
+			return nil, errors.New(fmt.Sprintf("invalid root entry: %s", text))
+		}
+	}
+
+	if !isHash(e.eroot) || !isHash(e.lroot) {
+		return nil, errors.New(fmt.Sprintf("invalid root entry: %s", text))
+	}
+
+	return e, nil
+}
+
+func isHash(str string) bool {
+	buf, err := b32.DecodeString(str)
+	return err == nil && len(buf) == hashLength
+}
+
+func parseBranch(text string) (*branchEntry, error) {
+	e := new(branchEntry)
+	if text == "" {
+		return e, nil
+	}
+
+	e.children = strings.Split(text, ",")
+	if len(e.children) > maxChildren {
+		return nil, errors.New(fmt.Sprintf("too many children of branch: %d", len(e.children)))
+	}
+	for _, h := range e.children {
+		if !isHash(h) {
+			return nil, errors.New(fmt.Sprintf("invalid child of branch: %s", h))
+		}
+	}
+
+	return e, nil
+}
+
+func parseNode(text string) (*nodeEntry, error) {
+	data, err := b64.DecodeString(text[len(nodePrefix):])
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("invalid node entry: %v", err))
+	}
+
+	node := new(vnode.Node)
+	if err = node.Deserialize(data); err != nil {
+		return nil, errors.New(fmt.Sprintf("invalid node entry: %v", err))
+	}
+
+	return &nodeEntry{
+		node: node,
+		text: text,
+	}, nil
+}
+
+func parseLink(url string) (*linkEntry, error) {
+	domain, pub, err := ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	return &linkEntry{
+		url:    url,
+		domain: domain,
+		pub:    pub,
+	}, nil
+}
+
+// Tree is a node list can be published to DNS
+type Tree struct {
+	root    *rootEntry
+	entries map[string]entry
+}
+
+// MakeTree build an unsigned tree from nodes and links to other trees
+func MakeTree(seq uint, nodes []*vnode.Node, links []string) (*Tree, error) {
+	t := &Tree{
+		entries: make(map[string]entry),
+	}
+
+	nodes = append([]*vnode.Node(nil), nodes...)
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID.String() < nodes[j].ID.String()
+	})
+
+	nodeEntries := make([]entry, 0, len(nodes))
+	for _, n := range nodes {
+		e, err := newNodeEntry(n)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("failed to serialize node %s: %v", n, err))
+		}
+		nodeEntries = append(nodeEntries, e)
+	}
+
+	links = append([]string(nil), links...)
+	sort.Strings(links)
+
+	linkEntries := make([]entry, 0, len(links))
+	for _, url := range links {
+		e, err := parseLink(url)
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("invalid link %s: %v", url, err))
+		}
+		linkEntries = append(linkEntries, e)
+	}
+
+	t.root = &rootEntry{
+		eroot: t.build(nodeEntries),
+		lroot: t.build(linkEntries),
+		seq:   seq,
+	}
+
+	return t, nil
+}
+
+// build the subtree of entries, store all entries and return the hash of subtree root
+func (t *Tree) build(entries []entry) string {
+	if len(entries) == 1 {
+		return t.store(entries[0])
+	}
+
+	if len(entries) <= maxChildren {
+		b := &branchEntry{
+			children: make([]string, len(entries)),
+		}
+		for i, e := range entries {
+			b.children[i] = t.store(e)
+		}
+		return t.store(b)
+	}
+
+	var subtrees []entry
+	for len(entries) > 0 {
+		n := maxChildren
+		if n > len(entries) {
+			n = len(entries)
+		}
+		subtrees = append(subtrees, t.entries[t.build(entries[:n])])
+		entries = entries[n:]
+	}
+
+	return t.build(subtrees)
+}
+
+func (t *Tree) store(e entry) string {
+	h := hashEntry(e)
+	t.entries[h] = e
+	return h
+}
+
+// Sign the tree root, return the url of tree
+func (t *Tree) Sign(key ed25519.PrivateKey, domain string) (url string) {
+	t.root.sig = ed25519.Sign(key, []byte(t.root.signedContent()))
+	return MakeURL(key.PubByte(), domain)
+}
+
+// Seq is the sequence number of tree, should be increased when the tree is updated
+func (t *Tree) Seq() uint {
+	return t.root.seq
+}
+
+// Nodes return all nodes of the tree
+func (t *Tree) Nodes() (nodes []*vnode.Node) {
+	t.walk(t.root.eroot, func(e entry) {
+		if n, ok := e.(*nodeEntry); ok {
+			nodes = append(nodes, n.node)
+		}
+	})
+	return
+}
+
+// Links return urls of all linked trees
+func (t *Tree) Links() (links []string) {
+	t.walk(t.root.lroot, func(e entry) {
+		if l, ok := e.(*linkEntry); ok {
+			links = append(links, l.url)
+		}
+	})
+	return
+}
+
+func (t *Tree) walk(hash string, fn func(e entry)) {
+	e, ok := t.entries[hash]
+	if !ok {
+		return
+	}
+
+	fn(e)
+	if b, ok := e.(*branchEntry); ok {
+		for _, h := range b.children {
+			t.walk(h, fn)
+		}
+	}
+}
+
+// Records return the TXT records should be published, key is the domain name, value is the TXT content
+func (t *Tree) Records(domain string) (map[string]string, error) {
+	if len(t.root.sig) == 0 {
+		return nil, errUnsigned
+	}
+
+	records := make(map[string]string, len(t.entries)+1)
+	records[domain] = t.root.String()
+	for h, e := range t.entries {
+		records[h+"."+domain] = e.String()
+	}
+
+	return records, nil
+}
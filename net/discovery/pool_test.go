@@ -55,7 +55,9 @@ func TestPool_rec(t *testing.T) {
 		expectCode: codePong,
 		handler: &pingRequest{
 			hash: []byte("hello"),
-			done: ch,
+			done: func(n *Node, err error) {
+				ch <- n
+			},
 		},
 		expiration: time.Now().Add(time.Second),
 	})
@@ -113,7 +115,6 @@ func TestPool_rec2(t *testing.T) {
 		expectCode: codeNeighbors,
 		handler: &findNodeRequest{
 			count: total,
-			rec:   nil,
 			ch:    received,
 		},
 		expiration: time.Now().Add(time.Second),
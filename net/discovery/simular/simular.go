@@ -90,7 +90,7 @@ func main() {
 
 	var discovers []*discovery.Discovery
 	for _, cfg := range configs {
-		d := discovery.New(cfg.peerKey, cfg.node, cfg.bootNodes, nil, cfg.listenAddress, nil)
+		d := discovery.New(cfg.peerKey, cfg.node, cfg.bootNodes, nil, nil, cfg.listenAddress, nil)
 		discovers = append(discovers, d)
 		go start(d)
 	}
@@ -62,6 +62,8 @@ type receiver interface {
 type socket interface {
 	sender
 	receiver
+	// setEndPoint change the endpoint advertised to other nodes
+	setEndPoint(e vnode.EndPoint)
 }
 
 // packet is a parsed message received from socket
@@ -73,6 +75,8 @@ type packet struct {
 
 type agent struct {
 	node          *vnode.Node
+	epMu          sync.RWMutex
+	endPoint      vnode.EndPoint // advertised to other nodes
 	listenAddress string
 	socket        *net.UDPConn
 	peerKey       ed25519.PrivateKey
@@ -88,6 +92,7 @@ type agent struct {
 func newAgent(peerKey ed25519.PrivateKey, self *vnode.Node, listenAddress string, handler func(*packet)) *agent {
 	return &agent{
 		node:          self,
+		endPoint:      self.EndPoint,
 		listenAddress: listenAddress,
 		peerKey:       peerKey,
 		handler:       handler,
@@ -96,6 +101,21 @@ func newAgent(peerKey ed25519.PrivateKey, self *vnode.Node, listenAddress string
 	}
 }
 
+func (a *agent) setEndPoint(e vnode.EndPoint) {
+	a.epMu.Lock()
+	defer a.epMu.Unlock()
+
+	a.endPoint = e
+}
+
+func (a *agent) from() *vnode.EndPoint {
+	a.epMu.RLock()
+	defer a.epMu.RUnlock()
+
+	e := a.endPoint
+	return &e
+}
+
 func (a *agent) start() (err error) {
 	if atomic.CompareAndSwapInt32(&a.running, 0, 1) {
 		var udp *net.UDPAddr
@@ -148,7 +168,7 @@ func (a *agent) ping(n *Node, callback func(*Node, error)) {
 		c:  codePing,
 		id: a.node.ID,
 		body: &ping{
-			from: &a.node.EndPoint,
+			from: a.from(),
 			to:   &n.EndPoint,
 			net:  a.node.Net,
 			ext:  a.node.Ext,
@@ -183,7 +203,7 @@ func (a *agent) pong(echo []byte, n *Node) (err error) {
 		c:  codePong,
 		id: a.node.ID,
 		body: &pong{
-			from: &a.node.EndPoint,
+			from: a.from(),
 			to:   &n.EndPoint,
 			net:  a.node.Net,
 			ext:  a.node.Ext,
@@ -74,7 +74,6 @@ func TestSocket_sendNeighbors(t *testing.T) {
 			expectCode: codeNeighbors,
 			handler: &findNodeRequest{
 				count: total,
-				rec:   nil,
 				ch:    received,
 			},
 			expiration: time.Now().Add(expiration * 2),
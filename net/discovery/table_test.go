@@ -14,12 +14,13 @@ type mockPinger struct {
 	fail bool
 }
 
-func (mp *mockPinger) ping(n *Node) error {
+func (mp *mockPinger) ping(n *Node, callback func(err error)) {
 	if mp.fail {
-		return errors.New("mock error")
+		callback(errors.New("mock error"))
+		return
 	}
 
-	return nil
+	callback(nil)
 }
 
 func TestTable_add(t *testing.T) {
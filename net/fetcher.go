@@ -36,6 +36,7 @@ const expiration = 30   // 30s
 type peerFetchResult struct {
 	status reqState
 	t      int64
+	sentAt time.Time // when the request is sent to the peer, the latency of the response is scored
 }
 
 type record struct {
@@ -124,6 +125,13 @@ func (f *fetcher) clean(t int64) {
 
 			r.done(nil, Msg{}, errFetchTimeout)
 
+			// the peers have not responded
+			for id, ret := range r.targets {
+				if ret.status == reqPending {
+					f.peers.scores.timeout(id)
+				}
+			}
+
 			// recycle
 			for _, ret := range r.targets {
 				f.peerFetchResultPool.Put(ret)
@@ -197,8 +205,10 @@ func (f *fetcher) pending(id MsgId, peer *Peer) {
 	if r, ok := f.recordsById[id]; ok {
 		if peer != nil {
 			result := r.targets[peer.Id]
+			now := time.Now()
 			result.status = reqPending
-			result.t = time.Now().Unix()
+			result.t = now.Unix()
+			result.sentAt = now
 		}
 	}
 }
@@ -208,6 +218,12 @@ func (f *fetcher) done(id MsgId, peer *Peer, msg Msg, err error) {
 	defer f.mu.Unlock()
 
 	if r, ok := f.recordsById[id]; ok {
+		if peer != nil && err == nil {
+			if result, ok := r.targets[peer.Id]; ok && result.status == reqPending {
+				f.peers.scores.delivered(peer.Id, time.Since(result.sentAt))
+			}
+		}
+
 		r.done(peer, msg, err)
 
 		if err != nil {
@@ -384,6 +400,7 @@ func (f *fetcher) handle(msg Msg) (err error) {
 
 		for _, block := range bs.Blocks {
 			if err = f.receiver.receiveSnapshotBlock(block, types.RemoteFetch); err != nil {
+				f.peers.scores.invalidBlock(msg.Sender.Id)
 				return err
 			}
 		}
@@ -403,6 +420,7 @@ func (f *fetcher) handle(msg Msg) (err error) {
 
 		for _, block := range bs.Blocks {
 			if err = f.receiver.receiveAccountBlock(block, types.RemoteFetch); err != nil {
+				f.peers.scores.invalidBlock(msg.Sender.Id)
 				return err
 			}
 		}
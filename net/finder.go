@@ -48,7 +48,9 @@ type finder struct {
 	targets     map[types.Address]*vnode.Node
 	subId       int // table sub
 	minPeers    int
-	staticNodes []*vnode.Node
+	staticNodes map[peerId]*vnode.Node // static and trusted nodes will be always reconnected
+	trusted     map[peerId]struct{}    // trusted nodes can connect even if the peers are full
+	private     bool                   // only connect to static and trusted nodes
 	resolver    interface {
 		GetNodes(n int) []*vnode.Node
 	}
@@ -93,28 +95,45 @@ func (f *finder) UnSub(sub discovery.Subscriber) {
 	sub.UnSub(f.subId)
 }
 
-func newFinder(self types.Address, peers *peerSet, minPeers int, staticNodes []string, db *database.DB, connect Connector, consensus Consensus) (f *finder, err error) {
+func newFinder(self types.Address, peers *peerSet, minPeers int, staticNodes, trustedNodes []string, private bool, db *database.DB, connect Connector, consensus Consensus) (f *finder, err error) {
 	f = &finder{
-		self:      self,
-		targets:   make(map[types.Address]*vnode.Node),
-		peers:     peers,
-		minPeers:  minPeers,
-		connect:   connect,
-		consensus: consensus,
-		dialing:   make(map[peerId]struct{}),
-		sbps:      make(map[types.Address]int64),
-		observers: make(map[int]func(_selfIsSBP bool)),
-		db:        db,
+		self:        self,
+		targets:     make(map[types.Address]*vnode.Node),
+		peers:       peers,
+		minPeers:    minPeers,
+		staticNodes: make(map[peerId]*vnode.Node),
+		trusted:     make(map[peerId]struct{}),
+		private:     private,
+		connect:     connect,
+		consensus:   consensus,
+		dialing:     make(map[peerId]struct{}),
+		sbps:        make(map[types.Address]int64),
+		observers:   make(map[int]func(_selfIsSBP bool)),
+		db:          db,
 	}
 
-	f.staticNodes = make([]*vnode.Node, 0, len(staticNodes))
+	var node *vnode.Node
 	for _, str := range staticNodes {
-		var node *vnode.Node
 		node, err = vnode.ParseNode(str)
 		if err != nil {
 			return
 		}
-		f.staticNodes = append(f.staticNodes, node)
+		f.staticNodes[node.ID] = node
+	}
+
+	for _, str := range trustedNodes {
+		node, err = vnode.ParseNode(str)
+		if err != nil {
+			return
+		}
+		f.staticNodes[node.ID] = node
+		f.trusted[node.ID] = struct{}{}
+	}
+
+	// trusted nodes added at runtime
+	for _, node = range db.ReadTrustedNodes() {
+		f.staticNodes[node.ID] = node
+		f.trusted[node.ID] = struct{}{}
 	}
 
 	consensus.SubscribeProducers(types.SNAPSHOT_GID, "sbpn", f.receiveProducers)
@@ -189,6 +208,42 @@ func (f *finder) isSBP(addr types.Address) bool {
 	return ok
 }
 
+// addStaticNode add the node to reconnect, trusted node can connect even if the peers are full
+func (f *finder) addStaticNode(node *vnode.Node, trusted bool) {
+	f.rw.Lock()
+	defer f.rw.Unlock()
+
+	f.staticNodes[node.ID] = node
+	if trusted {
+		f.trusted[node.ID] = struct{}{}
+	}
+	f.dial(node)
+}
+
+func (f *finder) removeStaticNode(id peerId) {
+	f.rw.Lock()
+	defer f.rw.Unlock()
+
+	delete(f.staticNodes, id)
+	delete(f.trusted, id)
+}
+
+func (f *finder) isStatic(id peerId) bool {
+	f.rw.RLock()
+	defer f.rw.RUnlock()
+
+	_, ok := f.staticNodes[id]
+	return ok
+}
+
+func (f *finder) isTrusted(id peerId) bool {
+	f.rw.RLock()
+	defer f.rw.RUnlock()
+
+	_, ok := f.trusted[id]
+	return ok
+}
+
 func (f *finder) clean() {
 	f.consensus.UnSubscribe(types.SNAPSHOT_GID, "sbpn")
 }
@@ -215,7 +270,7 @@ func (f *finder) receiveProducers(event consensus.ProducersEvent) {
 				continue
 			}
 
-			if f._selfIsSBP {
+			if f._selfIsSBP && !f.private {
 				f.dial(node)
 			}
 		}
@@ -250,7 +305,7 @@ func (f *finder) receiveNode(node *vnode.Node) {
 		f.rw.Unlock()
 	}
 
-	if f.total() < f.minPeers {
+	if !f.private && f.total() < f.minPeers {
 		f.rw.Lock()
 		f.dial(node)
 		f.rw.Unlock()
@@ -268,12 +323,15 @@ func (f *finder) loop() {
 	checkTicker := time.NewTicker(5 * time.Second)
 	defer checkTicker.Stop()
 
-	f.rw.Lock()
-	nodes := f.db.ReadMarkNodes(30) // more than sbp count
-	for _, node := range nodes {
-		f.dial(node)
+	var nodes []*vnode.Node
+	if !f.private {
+		f.rw.Lock()
+		nodes = f.db.ReadMarkNodes(30) // more than sbp count
+		for _, node := range nodes {
+			f.dial(node)
+		}
+		f.rw.Unlock()
 	}
-	f.rw.Unlock()
 
 	for {
 		select {
@@ -283,6 +341,11 @@ func (f *finder) loop() {
 				f.dial(n)
 			}
 
+			if f.private {
+				f.rw.Unlock()
+				break
+			}
+
 			if f._selfIsSBP {
 				for _, t := range f.targets {
 					f.dial(t)
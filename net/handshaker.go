@@ -23,6 +23,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	_net "net"
+	"sync"
 	"time"
 
 	"github.com/vitelabs/go-vite/net/netool"
@@ -57,6 +58,8 @@ type HandshakeMsg struct {
 
 	FileAddress   []byte
 	PublicAddress []byte
+
+	EphemeralKey []byte // the transport is encrypted if both sides send an ephemeral key
 }
 
 func (b *HandshakeMsg) Serialize() (data []byte, err error) {
@@ -73,6 +76,7 @@ func (b *HandshakeMsg) Serialize() (data []byte, err error) {
 		Key:           b.Key,
 		Token:         b.Token,
 		PublicAddress: b.PublicAddress,
+		EphemeralKey:  b.EphemeralKey,
 	}
 
 	return proto.Marshal(pb)
@@ -106,6 +110,7 @@ func (b *HandshakeMsg) Deserialize(data []byte) (err error) {
 	}
 	b.FileAddress = pb.FileAddress
 	b.PublicAddress = pb.PublicAddress
+	b.EphemeralKey = pb.EphemeralKey
 
 	b.Key = pb.Key
 	b.Token = pb.Token
@@ -119,6 +124,7 @@ type handshaker struct {
 	name          string
 	id            vnode.NodeID
 	genesis       types.Hash
+	addrMu        sync.RWMutex
 	fileAddress   []byte
 	publicAddress []byte
 
@@ -127,6 +133,9 @@ type handshaker struct {
 
 	codecFactory CodecFactory
 
+	// encrypt the transport if the peer supports it
+	encrypt bool
+
 	chain chainReader
 
 	blackList netool.BlackList
@@ -139,6 +148,19 @@ func (h *handshaker) setChain(chain chainReader) {
 	h.chain = chain
 }
 
+// setAddress change the addresses told to peers, nil means no change
+func (h *handshaker) setAddress(publicAddress, fileAddress []byte) {
+	h.addrMu.Lock()
+	defer h.addrMu.Unlock()
+
+	if publicAddress != nil {
+		h.publicAddress = publicAddress
+	}
+	if fileAddress != nil {
+		h.fileAddress = fileAddress
+	}
+}
+
 func (h *handshaker) banAddr(addr _net.Addr, t int64) {
 	addr2, ok := addr.(*_net.TCPAddr)
 	var ip _net.IP
@@ -211,7 +233,8 @@ func (h *handshaker) getSecret(theirId peerId) (secret []byte, err error) {
 func (h *handshaker) verifyHandshake(their *HandshakeMsg, secret []byte) (err error) {
 	t := make([]byte, 8)
 	binary.BigEndian.PutUint64(t, uint64(their.Timestamp))
-	hash := crypto.Hash256(t)
+	// the ephemeral key is signed too, so it can not be removed to downgrade the transport
+	hash := crypto.Hash256(t, their.EphemeralKey)
 	token := xor(hash, secret)
 	if len(their.Key) != 0 {
 		if false == ed25519.Verify(their.Key, token, their.Token) {
@@ -228,8 +251,10 @@ func (h *handshaker) verifyHandshake(their *HandshakeMsg, secret []byte) (err er
 	return
 }
 
-func (h *handshaker) makeHandshake(secret []byte) (our *HandshakeMsg) {
+func (h *handshaker) makeHandshake(secret []byte, ephemeral *ephemeralKey) (our *HandshakeMsg) {
 	latestBlock := h.chain.GetLatestSnapshotBlock()
+	h.addrMu.RLock()
+	defer h.addrMu.RUnlock()
 	our = &HandshakeMsg{
 		Version:       int64(h.version),
 		NetID:         int64(h.netId),
@@ -243,11 +268,12 @@ func (h *handshaker) makeHandshake(secret []byte) (our *HandshakeMsg) {
 		Token:         nil,
 		FileAddress:   h.fileAddress,
 		PublicAddress: h.publicAddress,
+		EphemeralKey:  ephemeral.publicKey(),
 	}
 
 	t := make([]byte, 8)
 	binary.BigEndian.PutUint64(t, uint64(our.Timestamp))
-	hash := crypto.Hash256(t)
+	hash := crypto.Hash256(t, our.EphemeralKey)
 
 	our.Token = xor(hash, secret)
 	if h.key != nil {
@@ -318,8 +344,21 @@ func (h *handshaker) ReceiveHandshake(conn _net.Conn) (c Codec, their *Handshake
 		return
 	}
 
-	our := h.makeHandshake(secret)
+	var ephemeral *ephemeralKey
+	if h.encrypt && len(their.EphemeralKey) != 0 {
+		ephemeral = newEphemeralKey()
+	}
+
+	our := h.makeHandshake(secret, ephemeral)
 	err = h.sendHandshake(c, our, msgId)
+	if err != nil {
+		return
+	}
+
+	// the messages after the handshake are encrypted
+	if ephemeral != nil {
+		c, err = h.secureCodec(conn, ephemeral, secret, their, false)
+	}
 	return
 }
 
@@ -337,7 +376,12 @@ func (h *handshaker) InitiateHandshake(conn _net.Conn, id vnode.NodeID) (c Codec
 		}
 	}()
 
-	our := h.makeHandshake(secret)
+	var ephemeral *ephemeralKey
+	if h.encrypt {
+		ephemeral = newEphemeralKey()
+	}
+
+	our := h.makeHandshake(secret, ephemeral)
 	err = h.sendHandshake(c, our, 0)
 	if err != nil {
 		return
@@ -353,6 +397,14 @@ func (h *handshaker) InitiateHandshake(conn _net.Conn, id vnode.NodeID) (c Codec
 		return
 	}
 
+	// the messages after the handshake are encrypted
+	if ephemeral != nil && len(their.EphemeralKey) != 0 {
+		c, err = h.secureCodec(conn, ephemeral, secret, their, true)
+		if err != nil {
+			return
+		}
+	}
+
 	err = h.doHandshake(c, PeerFlagOutbound, their)
 	if err != nil {
 		return
@@ -366,6 +418,22 @@ func (h *handshaker) InitiateHandshake(conn _net.Conn, id vnode.NodeID) (c Codec
 	return
 }
 
+func (h *handshaker) secureCodec(conn _net.Conn, ephemeral *ephemeralKey, secret []byte, their *HandshakeMsg, initiator bool) (c Codec, err error) {
+	writeKey, readKey, err := ephemeral.sessionKeys(secret, their.EphemeralKey, initiator)
+	if err != nil {
+		err = PeerInvalidEphemeralKey
+		return
+	}
+
+	sconn, err := newSecureConn(conn, writeKey, readKey)
+	if err != nil {
+		err = PeerInvalidEphemeralKey
+		return
+	}
+
+	return h.codecFactory.CreateCodec(sconn), nil
+}
+
 func (h *handshaker) doHandshake(c Codec, flag PeerFlag, their *HandshakeMsg) (err error) {
 	if their.NetID != int64(h.netId) {
 		err = PeerDifferentNetwork
@@ -210,6 +210,7 @@ func TestHandshake(t *testing.T) {
 			peerKey:       priv1,
 			key:           priv2,
 			codecFactory:  codecFac,
+			encrypt:       true,
 			chain:         nil,
 			blackList: netool.NewBlackList(func(t int64, count int) bool {
 				return false
@@ -233,7 +234,14 @@ func TestHandshake(t *testing.T) {
 			panic(err)
 		}
 
-		_, _, _, err = hk.ReceiveHandshake(conn)
+		c, _, _, err := hk.ReceiveHandshake(conn)
+		if err != nil {
+			panic(err)
+		}
+		if _, ok := c.(*transport).Conn.(*secureConn); !ok {
+			panic("codec should be encrypted")
+		}
+		err = c.WriteMsg(Msg{Code: CodeDisconnect, Payload: []byte("encrypted")})
 		if err != nil {
 			panic(err)
 		}
@@ -266,6 +274,7 @@ func TestHandshake(t *testing.T) {
 			peerKey:       priv3,
 			key:           priv4,
 			codecFactory:  codecFac,
+			encrypt:       true,
 			chain:         nil,
 			blackList: netool.NewBlackList(func(t int64, count int) bool {
 				return false
@@ -285,10 +294,17 @@ func TestHandshake(t *testing.T) {
 			panic(err)
 		}
 
-		_, _, _, err = hk.InitiateHandshake(conn, id1)
+		c, _, _, err := hk.InitiateHandshake(conn, id1)
 		if err != nil {
 			panic(err)
 		}
+		msg, err := c.ReadMsg()
+		if err != nil {
+			panic(err)
+		}
+		if msg.Code != CodeDisconnect || string(msg.Payload) != "encrypted" {
+			panic("wrong message after handshake")
+		}
 	}()
 
 	wg.Wait()
@@ -327,7 +343,7 @@ check:
 		panic(err)
 	}
 
-	our := hkr.makeHandshake(secret)
+	our := hkr.makeHandshake(secret, nil)
 	err = hkr.verifyHandshake(our, secret)
 	if err != nil {
 		panic(err)
@@ -2,6 +2,8 @@ package net
 
 import (
 	"fmt"
+	"math/big"
+	"time"
 
 	"github.com/vitelabs/go-vite/crypto/ed25519"
 
@@ -50,12 +52,19 @@ type syncChain interface {
 	GetSnapshotBlockByHeight(height uint64) (*ledger.SnapshotBlock, error)
 }
 
+// genesisReader start the producer schedule and the balances of the light node from the genesis
+type genesisReader interface {
+	GetGenesisBalanceMap(addr types.Address) map[types.TokenTypeId]*big.Int
+	GetAllRegisterList(snapshotHash types.Hash, gid types.Gid) ([]*types.Registration, error)
+}
+
 type Chain interface {
 	snapshotBlockReader
 	accountBockReader
 	chainReader
 	ledgerReader
 	syncCacher
+	genesisReader
 }
 
 type IrreversibleReader interface {
@@ -256,4 +265,48 @@ type Net interface {
 	Nodes() []*vnode.Node
 	PeerCount() int
 	PeerKey() ed25519.PrivateKey
+	// PeerScores return the scores of peers from high to low
+	PeerScores() []PeerScore
+	// BanNode disconnect the node and refuse it until duration later, duration 0 means permanently
+	BanNode(id vnode.NodeID, duration time.Duration)
+	UnbanNode(id vnode.NodeID)
+	// AddTrustedPeer connect to the node and reconnect it always, the node can connect even if the peers are full
+	AddTrustedPeer(node *vnode.Node) error
+	// RemovePeer disconnect the peer, and do not reconnect it any more. The trusted nodes added by AddTrustedPeer
+	// are removed permanently, but StaticNodes and TrustedNodes in the config are reconnected after restart.
+	RemovePeer(id vnode.NodeID)
+	// Light return nil if the node is not running in light mode
+	Light() LightClient
+}
+
+// LightClient only syncs snapshot headers, account blocks of specific addresses are fetched on demand,
+// and proved by the SnapshotContent of a verified snapshot header.
+type LightClient interface {
+	// LatestHeader return the latest verified snapshot header, return nil if no header has been verified
+	LatestHeader() *ledger.SnapshotBlock
+	GetHeader(height uint64) *ledger.SnapshotBlock
+	// GetAccountBlocks fetch at most count account blocks backward from the latest confirmed account block of addr
+	GetAccountBlocks(addr types.Address, count uint64) ([]*ledger.AccountBlock, *AccountProof, error)
+	// GetAccountState fetch the latest confirmed account block of addr, and replay the balances from the account chain
+	GetAccountState(addr types.Address) (*AccountState, error)
+}
+
+// AccountProof means the account block Account is in the SnapshotContent of the verified snapshot header Snapshot,
+// the earlier account blocks are proved by the PrevHash chain.
+type AccountProof struct {
+	Snapshot ledger.HashHeight `json:"snapshot"`
+	Account  ledger.HashHeight `json:"account"`
+}
+
+// AccountState is the latest confirmed account block of an account and the balances after it.
+// The balances are replayed from the whole proved account chain, receive blocks add the amount of the proved
+// send blocks, so Balances is nil if the account chain is longer than lightStateMaxBlocks. The builtin contracts
+// may change their own balances without blocks, so only the balances of user accounts are exact.
+// Snapshot blocks have no state root, and the storage of a contract is the result of running its code,
+// so the storage can not be proved without the whole ledger.
+type AccountState struct {
+	Address  types.Address                  `json:"address"`
+	Block    *ledger.AccountBlock           `json:"block"`
+	Balances map[types.TokenTypeId]*big.Int `json:"balances"`
+	Proof    *AccountProof                  `json:"proof"`
 }
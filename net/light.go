@@ -0,0 +1,833 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+)
+
+const lightSyncInterval = 5 * time.Second
+const lightRequestTimeout = 10 * time.Second
+
+// try so many peers when fetch account blocks
+const lightFetchPeers = 3
+
+// the balances are replayed only if the account chain is not longer than it
+const lightStateMaxBlocks = 10 * syncTaskSize
+
+var errLightNotReady = errors.New("no snapshot header has been verified")
+var errAccountNotConfirmed = errors.New("account is not confirmed by any verified snapshot header")
+var errHeaderForked = errors.New("snapshot header is not linked to the latest header")
+
+// lightStore persists the verified headers and the producer schedule, they are loaded after restart
+type lightStore interface {
+	StoreLightHeader(height uint64, data []byte) error
+	RemoveLightHeader(height uint64)
+	ReadLightHeaders() [][]byte
+	StoreLightSchedule(data []byte) error
+	ReadLightSchedule() []byte
+}
+
+// lightClient syncs snapshot headers from the genesis or a trusted checkpoint, every header is verified by hash,
+// signature and the producer schedule proved by the headers. Account blocks are fetched on demand, and must be
+// linked to the account HashHeight in the SnapshotContent of a verified header.
+type lightClient struct {
+	*requester
+	peers    *peerSet
+	schedule *lightSchedule
+	chain    genesisReader
+	store    lightStore
+
+	mu         sync.RWMutex
+	headers    map[uint64]*ledger.SnapshotBlock
+	confirms   map[types.Address][]uint64 // heights of the headers confirm the account, from low to high
+	head       *ledger.SnapshotBlock
+	tail       uint64             // height of the trusted header, headers lower than it are not synced
+	checkpoint *ledger.HashHeight // the trusted header has not been fetched if not nil
+
+	term chan struct{}
+	wg   sync.WaitGroup
+
+	log log15.Logger
+}
+
+// newLightClient sync headers from genesis, or from checkpoint if it is higher than genesis,
+// the schedule must start from the same header.
+func newLightClient(genesis *ledger.SnapshotBlock, checkpoint *ledger.HashHeight, peers *peerSet, schedule *lightSchedule, chain genesisReader) *lightClient {
+	lc := &lightClient{
+		requester: newRequester(lightRequestTimeout),
+		peers:     peers,
+		schedule:  schedule,
+		chain:     chain,
+		headers:   make(map[uint64]*ledger.SnapshotBlock),
+		confirms:  make(map[types.Address][]uint64),
+		log:       netLog.New("module", "light"),
+	}
+
+	if checkpoint != nil && checkpoint.Height > genesis.Height {
+		lc.checkpoint = checkpoint
+		lc.tail = checkpoint.Height
+	} else {
+		lc.addHeaderLocked(genesis)
+		lc.head = genesis
+		lc.tail = genesis.Height
+	}
+
+	return lc
+}
+
+// setStore load the stored headers linked to the trusted header and the schedule, it must be called before start
+func (lc *lightClient) setStore(store lightStore) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	for _, data := range store.ReadLightHeaders() {
+		block := new(ledger.SnapshotBlock)
+		if err := block.Deserialize(data); err != nil {
+			continue
+		}
+
+		switch {
+		case lc.head == nil && block.Height == lc.checkpoint.Height && block.Hash == lc.checkpoint.Hash:
+			lc.checkpoint = nil
+		case lc.head != nil && block.Height == lc.head.Height+1 && block.PrevHash == lc.head.Hash:
+		case lc.head != nil && block.Height == lc.tail && block.Hash == lc.head.Hash:
+			continue
+		default:
+			// forked or trusted by another checkpoint
+			store.RemoveLightHeader(block.Height)
+			continue
+		}
+
+		lc.addHeaderLocked(block)
+		lc.head = block
+	}
+
+	if data := store.ReadLightSchedule(); data != nil && lc.head != nil {
+		schedule := new(lightSchedule)
+		if err := schedule.deserialize(data); err != nil {
+			lc.log.Warn(fmt.Sprintf("failed to load producer schedule: %v", err))
+		} else if snapshot, _ := schedule.applied(); snapshot >= lc.tail && snapshot <= lc.head.Height {
+			lc.schedule = schedule
+		}
+	}
+
+	lc.store = store
+}
+
+func (lc *lightClient) start() {
+	lc.term = make(chan struct{})
+
+	lc.wg.Add(1)
+	go lc.loop()
+}
+
+func (lc *lightClient) stop() {
+	if lc.term == nil {
+		return
+	}
+
+	select {
+	case <-lc.term:
+	default:
+		close(lc.term)
+		lc.wg.Wait()
+	}
+}
+
+func (lc *lightClient) loop() {
+	defer lc.wg.Done()
+
+	ticker := time.NewTicker(lightSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lc.term:
+			return
+		case <-ticker.C:
+			lc.sync()
+		}
+	}
+}
+
+func (lc *lightClient) name() string {
+	return "light"
+}
+
+func (lc *lightClient) codes() []Code {
+	return []Code{CodeSnapshotBlocks, CodeAccountBlocks, CodeException}
+}
+
+func (lc *lightClient) handle(msg Msg) error {
+	lc.deliver(msg)
+	return nil
+}
+
+func (lc *lightClient) getSnapshotBlocks(p *Peer, req *GetSnapshotBlocks) ([]*ledger.SnapshotBlock, error) {
+	msg, err := lc.request(p, CodeGetSnapshotBlocks, req, lc.term)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Code != CodeSnapshotBlocks {
+		msg.Recycle()
+		return nil, errors.New(fmt.Sprintf("unexpected response %d to %s", msg.Code, req))
+	}
+
+	bs := new(SnapshotBlocks)
+	err = bs.Deserialize(msg.Payload)
+	msg.Recycle()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(bs.Blocks, func(i, j int) bool {
+		return bs.Blocks[i].Height < bs.Blocks[j].Height
+	})
+	return bs.Blocks, nil
+}
+
+func (lc *lightClient) getAccountBlocks(p *Peer, req *GetAccountBlocks) ([]*ledger.AccountBlock, error) {
+	msg, err := lc.request(p, CodeGetAccountBlocks, req, lc.term)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Code != CodeAccountBlocks {
+		msg.Recycle()
+		return nil, errors.New(fmt.Sprintf("unexpected response %d to %s", msg.Code, req))
+	}
+
+	bs := new(AccountBlocks)
+	err = bs.Deserialize(msg.Payload)
+	msg.Recycle()
+	if err != nil {
+		return nil, err
+	}
+
+	return bs.Blocks, nil
+}
+
+// sync headers from the sync peer until catch up with it
+func (lc *lightClient) sync() {
+	p := lc.peers.syncPeer()
+	if p == nil {
+		return
+	}
+
+	if lc.LatestHeader() == nil {
+		if err := lc.syncCheckpoint(p); err != nil {
+			lc.log.Warn(fmt.Sprintf("failed to sync checkpoint from %s: %v", p, err))
+			return
+		}
+	}
+
+	for {
+		select {
+		case <-lc.term:
+			return
+		default:
+		}
+
+		head := lc.LatestHeader()
+		if head.Height >= p.Height {
+			return
+		}
+
+		count := p.Height - head.Height
+		if count > syncTaskSize {
+			count = syncTaskSize
+		}
+
+		blocks, err := lc.getSnapshotBlocks(p, &GetSnapshotBlocks{
+			From:    ledger.HashHeight{Height: head.Height + 1},
+			Count:   count,
+			Forward: true,
+		})
+		if err != nil {
+			lc.log.Warn(fmt.Sprintf("failed to get snapshot headers from %s: %v", p, err))
+			return
+		}
+
+		if err = lc.appendHeaders(blocks); err != nil {
+			if err == errUnknownProducer {
+				// the producer may be registered by the governance blocks confirmed by the appended headers
+				updated, err2 := lc.updateSchedule()
+				if err2 != nil {
+					lc.log.Warn(fmt.Sprintf("failed to update producer schedule: %v", err2))
+					return
+				}
+				if updated {
+					continue
+				}
+			}
+
+			if err == errHeaderForked {
+				lc.rollback()
+				lc.log.Warn(fmt.Sprintf("snapshot headers from %s are forked at %s/%d", p, head.Hash, head.Height))
+			} else {
+				lc.log.Error(fmt.Sprintf("invalid snapshot headers from %s: %v", p, err))
+				p.catch(PeerInvalidBlock)
+			}
+			return
+		}
+
+		lc.log.Info(fmt.Sprintf("sync snapshot headers [%d-%d] from %s", blocks[0].Height, blocks[len(blocks)-1].Height, p))
+
+		// apply the revocations before the next headers, syncTaskSize is less than lightRevokeDelay
+		if _, err = lc.updateSchedule(); err != nil {
+			lc.log.Warn(fmt.Sprintf("failed to update producer schedule: %v", err))
+			return
+		}
+	}
+}
+
+func (lc *lightClient) syncCheckpoint(p *Peer) error {
+	lc.mu.RLock()
+	checkpoint := lc.checkpoint
+	lc.mu.RUnlock()
+
+	blocks, err := lc.getSnapshotBlocks(p, &GetSnapshotBlocks{
+		From:  ledger.HashHeight{Hash: checkpoint.Hash},
+		Count: 1,
+	})
+	if err != nil {
+		return err
+	}
+	if len(blocks) == 0 {
+		return errNoResource
+	}
+
+	block := blocks[len(blocks)-1]
+	// the hash of checkpoint is trusted, so the producer need not be verified
+	if block.Timestamp == nil || block.Height != checkpoint.Height || block.Hash != checkpoint.Hash || block.ComputeHash() != block.Hash {
+		p.catch(PeerInvalidBlock)
+		return errors.New(fmt.Sprintf("snapshot block %s/%d is not the checkpoint %s/%d", block.Hash, block.Height, checkpoint.Hash, checkpoint.Height))
+	}
+
+	lc.mu.Lock()
+	lc.addHeaderLocked(block)
+	lc.head = block
+	lc.checkpoint = nil
+	lc.mu.Unlock()
+
+	// the trusted producers are the registrations after the governance blocks confirmed by the checkpoint
+	if hh, ok := block.SnapshotContent[types.AddressGovernance]; ok {
+		lc.schedule.apply(block.Height, *hh, nil)
+	}
+	lc.storeSchedule()
+
+	return nil
+}
+
+func (lc *lightClient) verifyHeader(block *ledger.SnapshotBlock) error {
+	if block.Timestamp == nil {
+		return errors.New(fmt.Sprintf("snapshot block %s/%d missing timestamp", block.Hash, block.Height))
+	}
+	if block.ComputeHash() != block.Hash {
+		return errors.New(fmt.Sprintf("snapshot block %s/%d has wrong hash", block.Hash, block.Height))
+	}
+	if !block.VerifySignature() {
+		return errors.New(fmt.Sprintf("snapshot block %s/%d has wrong signature", block.Hash, block.Height))
+	}
+
+	ok, err := lc.schedule.VerifySnapshotProducer(block)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errUnknownProducer
+	}
+
+	return nil
+}
+
+// appendHeaders verify blocks sorted by height and append them after the latest header,
+// return errHeaderForked if the first block is valid but not linked to the latest header.
+// If a block is produced by an unknown producer, the blocks before it are appended and errUnknownProducer is returned,
+// the producer may be registered by the governance blocks confirmed by the appended headers.
+func (lc *lightClient) appendHeaders(blocks []*ledger.SnapshotBlock) (err error) {
+	if len(blocks) == 0 {
+		return errNoResource
+	}
+
+	prev := lc.LatestHeader()
+	for i, block := range blocks {
+		if err = lc.verifyHeader(block); err != nil {
+			if err == errUnknownProducer && i > 0 {
+				blocks = blocks[:i]
+				break
+			}
+			if err == errUnknownProducer {
+				lc.log.Warn(fmt.Sprintf("snapshot block %s/%d is produced by unknown producer %s", block.Hash, block.Height, block.Producer()))
+			}
+			return err
+		}
+		if block.Height != prev.Height+1 {
+			return errors.New(fmt.Sprintf("snapshot block %s/%d is not next to %s/%d", block.Hash, block.Height, prev.Hash, prev.Height))
+		}
+		if block.PrevHash != prev.Hash {
+			if i == 0 {
+				return errHeaderForked
+			}
+			return errors.New(fmt.Sprintf("snapshot block %s/%d is not linked to %s/%d", block.Hash, block.Height, prev.Hash, prev.Height))
+		}
+		prev = block
+	}
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	// head may be changed by another routine
+	if lc.head.Hash != blocks[0].PrevHash {
+		return errHeaderForked
+	}
+	for _, block := range blocks {
+		lc.addHeaderLocked(block)
+	}
+	lc.head = prev
+
+	return err
+}
+
+// addHeaderLocked index the accounts confirmed by the header, and persist the header
+func (lc *lightClient) addHeaderLocked(block *ledger.SnapshotBlock) {
+	lc.headers[block.Height] = block
+	for addr := range block.SnapshotContent {
+		lc.confirms[addr] = append(lc.confirms[addr], block.Height)
+	}
+
+	if lc.store != nil {
+		if data, err := block.Serialize(); err != nil {
+			lc.log.Error(fmt.Sprintf("failed to serialize snapshot header %s/%d: %v", block.Hash, block.Height, err))
+		} else if err = lc.store.StoreLightHeader(block.Height, data); err != nil {
+			lc.log.Error(fmt.Sprintf("failed to store snapshot header %s/%d: %v", block.Hash, block.Height, err))
+		}
+	}
+}
+
+// rollback remove the latest header, the trusted header can not be removed
+func (lc *lightClient) rollback() {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if lc.head == nil || lc.head.Height <= lc.tail {
+		return
+	}
+
+	block := lc.head
+	for addr := range block.SnapshotContent {
+		heights := lc.confirms[addr]
+		if len(heights) > 0 && heights[len(heights)-1] == block.Height {
+			heights = heights[:len(heights)-1]
+		}
+		if len(heights) == 0 {
+			delete(lc.confirms, addr)
+		} else {
+			lc.confirms[addr] = heights
+		}
+	}
+	delete(lc.headers, block.Height)
+	if lc.store != nil {
+		lc.store.RemoveLightHeader(block.Height)
+	}
+	lc.head = lc.headers[block.Height-1]
+
+	// undo the governance blocks confirmed by the removed header
+	if snapshot, _ := lc.schedule.applied(); snapshot >= block.Height {
+		snapshot, account := lc.tail, ledger.HashHeight{}
+		if header, hh := lc.confirmedLocked(types.AddressGovernance); header != nil {
+			snapshot, account = header.Height, *hh
+		}
+		lc.schedule.rollback(lc.head.Height, snapshot, account)
+		lc.storeScheduleLocked()
+	}
+}
+
+func (lc *lightClient) storeSchedule() {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+
+	lc.storeScheduleLocked()
+}
+
+func (lc *lightClient) storeScheduleLocked() {
+	if lc.store == nil {
+		return
+	}
+
+	if data, err := lc.schedule.serialize(); err != nil {
+		lc.log.Error(fmt.Sprintf("failed to serialize producer schedule: %v", err))
+	} else if err = lc.store.StoreLightSchedule(data); err != nil {
+		lc.log.Error(fmt.Sprintf("failed to store producer schedule: %v", err))
+	}
+}
+
+// updateSchedule apply the governance blocks confirmed by the headers higher than the schedule,
+// return false if there is no such header.
+func (lc *lightClient) updateSchedule() (updated bool, err error) {
+	snapshot, _ := lc.schedule.applied()
+
+	lc.mu.RLock()
+	var headers []*ledger.SnapshotBlock
+	for _, height := range lc.confirms[types.AddressGovernance] {
+		if height > snapshot {
+			headers = append(headers, lc.headers[height])
+		}
+	}
+	lc.mu.RUnlock()
+
+	for _, header := range headers {
+		hh := *header.SnapshotContent[types.AddressGovernance]
+		_, prev := lc.schedule.applied()
+
+		// only the confirmed block is known if the schedule starts from a checkpoint not confirms the governance
+		count := uint64(1)
+		if prev.Height > 0 {
+			if hh.Height <= prev.Height {
+				return updated, errors.New(fmt.Sprintf("governance block %s/%d is lower than the applied %s/%d", hh.Hash, hh.Height, prev.Hash, prev.Height))
+			}
+			count = hh.Height - prev.Height
+		}
+
+		var sendBlocks []*ledger.AccountBlock
+		err = lc.tryPeers(header.Height, func(p *Peer) (err error) {
+			var blocks []*ledger.AccountBlock
+			if blocks, err = lc.fetchAccountBlocks(p, types.AddressGovernance, hh, count); err != nil {
+				return
+			}
+			if prev.Height > 0 && blocks[len(blocks)-1].PrevHash != prev.Hash {
+				return errors.New(fmt.Sprintf("governance block %s/%d is not linked to the applied %s/%d", hh.Hash, hh.Height, prev.Hash, prev.Height))
+			}
+
+			sendBlocks = sendBlocks[:0]
+			for i := len(blocks) - 1; i >= 0; i-- {
+				block := blocks[i]
+				// the last byte of the builtin contract receive data is the result, 0 means success
+				if block.BlockType != ledger.BlockTypeReceive || len(block.Data) == 0 || block.Data[len(block.Data)-1] != 0 {
+					continue
+				}
+				var sendBlock *ledger.AccountBlock
+				if sendBlock, err = lc.getSendBlock(p, block.FromBlockHash); err != nil {
+					return
+				}
+				sendBlocks = append(sendBlocks, sendBlock)
+			}
+			return nil
+		})
+		if err != nil {
+			return
+		}
+
+		lc.schedule.apply(header.Height, hh, sendBlocks)
+		updated = true
+	}
+
+	if updated {
+		lc.storeSchedule()
+	}
+	return
+}
+
+func (lc *lightClient) LatestHeader() *ledger.SnapshotBlock {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+
+	return lc.head
+}
+
+func (lc *lightClient) GetHeader(height uint64) *ledger.SnapshotBlock {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+
+	return lc.headers[height]
+}
+
+// confirmed return the latest header confirms addr, and the account HashHeight in its SnapshotContent
+func (lc *lightClient) confirmed(addr types.Address) (*ledger.SnapshotBlock, *ledger.HashHeight) {
+	lc.mu.RLock()
+	defer lc.mu.RUnlock()
+
+	return lc.confirmedLocked(addr)
+}
+
+func (lc *lightClient) confirmedLocked(addr types.Address) (*ledger.SnapshotBlock, *ledger.HashHeight) {
+	heights := lc.confirms[addr]
+	if len(heights) == 0 {
+		return nil, nil
+	}
+
+	header := lc.headers[heights[len(heights)-1]]
+	return header, header.SnapshotContent[addr]
+}
+
+// tryPeers call fn with at most lightFetchPeers peers not lower than height until it succeeds
+func (lc *lightClient) tryPeers(height uint64, fn func(p *Peer) error) error {
+	err := errNoSuitablePeer
+	for i, p := range lc.peers.pick(height) {
+		if i == lightFetchPeers {
+			break
+		}
+		if err = fn(p); err == nil {
+			return nil
+		}
+		lc.log.Warn(fmt.Sprintf("failed to fetch from %s: %v", p, err))
+	}
+
+	return err
+}
+
+// fetchAccountBlocks fetch count account blocks of addr backward from the confirmed block hh in several requests,
+// the blocks are verified and sorted from high to low.
+func (lc *lightClient) fetchAccountBlocks(p *Peer, addr types.Address, hh ledger.HashHeight, count uint64) ([]*ledger.AccountBlock, error) {
+	var blocks []*ledger.AccountBlock
+	for count > 0 {
+		n := count
+		if n > syncTaskSize {
+			n = syncTaskSize
+		}
+
+		bs, err := lc.getAccountBlocks(p, &GetAccountBlocks{
+			Address: addr,
+			From:    hh,
+			Count:   n,
+			Forward: false,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err = verifyAccountBlocks(addr, &hh, bs); err != nil {
+			lc.log.Error(fmt.Sprintf("invalid account blocks of %s from %s: %v", addr, p, err))
+			p.catch(PeerInvalidBlock)
+			return nil, err
+		}
+		blocks = append(blocks, bs...)
+
+		last := bs[len(bs)-1]
+		if last.Height <= 1 || uint64(len(bs)) >= count {
+			break
+		}
+		count -= uint64(len(bs))
+		hh = ledger.HashHeight{Height: last.Height - 1, Hash: last.PrevHash}
+	}
+
+	return blocks, nil
+}
+
+// getSendBlock fetch the send block by hash and verify it,
+// the send block of a contract is in the SendBlockList of the receive block responded.
+func (lc *lightClient) getSendBlock(p *Peer, hash types.Hash) (*ledger.AccountBlock, error) {
+	blocks, err := lc.getAccountBlocks(p, &GetAccountBlocks{
+		From:  ledger.HashHeight{Hash: hash},
+		Count: 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, block := range blocks {
+		if block.Hash == hash && block.IsSendBlock() && block.ComputeHash() == hash {
+			return block, nil
+		}
+		for i, sendBlock := range block.SendBlockList {
+			if sendBlock.Hash == hash && sendBlock.ComputeSendHash(block, uint8(i)) == hash {
+				return sendBlock, nil
+			}
+		}
+	}
+
+	p.catch(PeerInvalidBlock)
+	return nil, errors.New(fmt.Sprintf("send block %s is not responded", hash))
+}
+
+func (lc *lightClient) GetAccountBlocks(addr types.Address, count uint64) ([]*ledger.AccountBlock, *AccountProof, error) {
+	if lc.LatestHeader() == nil {
+		return nil, nil, errLightNotReady
+	}
+
+	header, hh := lc.confirmed(addr)
+	if header == nil {
+		return nil, nil, errAccountNotConfirmed
+	}
+
+	if count == 0 {
+		count = 1
+	} else if count > syncTaskSize {
+		count = syncTaskSize
+	}
+
+	var blocks []*ledger.AccountBlock
+	err := lc.tryPeers(header.Height, func(p *Peer) (err error) {
+		blocks, err = lc.fetchAccountBlocks(p, addr, *hh, count)
+		return
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return blocks, &AccountProof{
+		Snapshot: ledger.HashHeight{Height: header.Height, Hash: header.Hash},
+		Account:  *hh,
+	}, nil
+}
+
+func (lc *lightClient) GetAccountState(addr types.Address) (*AccountState, error) {
+	if lc.LatestHeader() == nil {
+		return nil, errLightNotReady
+	}
+
+	header, hh := lc.confirmed(addr)
+	if header == nil {
+		return nil, errAccountNotConfirmed
+	}
+
+	var blocks []*ledger.AccountBlock
+	var balances map[types.TokenTypeId]*big.Int
+	err := lc.tryPeers(header.Height, func(p *Peer) (err error) {
+		if hh.Height > lightStateMaxBlocks {
+			blocks, err = lc.fetchAccountBlocks(p, addr, *hh, 1)
+			return
+		}
+
+		if blocks, err = lc.fetchAccountBlocks(p, addr, *hh, hh.Height); err != nil {
+			return
+		}
+		balances, err = lc.replayBalances(addr, blocks, func(hash types.Hash) (*ledger.AccountBlock, error) {
+			return lc.getSendBlock(p, hash)
+		})
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AccountState{
+		Address:  addr,
+		Block:    blocks[0],
+		Balances: balances,
+		Proof: &AccountProof{
+			Snapshot: ledger.HashHeight{Height: header.Height, Hash: header.Hash},
+			Account:  *hh,
+		},
+	}, nil
+}
+
+// replayBalances replay the whole account chain sorted from high to low, a receive block adds the amount of
+// its send block, a send block subtracts the amount and the fee, the genesis balances are read from the genesis.
+func (lc *lightClient) replayBalances(addr types.Address, blocks []*ledger.AccountBlock,
+	getSendBlock func(hash types.Hash) (*ledger.AccountBlock, error)) (map[types.TokenTypeId]*big.Int, error) {
+	if len(blocks) == 0 || blocks[len(blocks)-1].Height != 1 {
+		return nil, errors.New(fmt.Sprintf("account chain of %s is not complete", addr))
+	}
+
+	balances := make(map[types.TokenTypeId]*big.Int)
+	change := func(tokenId types.TokenTypeId, amount *big.Int, add bool) {
+		if amount == nil || amount.Sign() == 0 {
+			return
+		}
+		balance, ok := balances[tokenId]
+		if !ok {
+			balance = new(big.Int)
+			balances[tokenId] = balance
+		}
+		if add {
+			balance.Add(balance, amount)
+		} else {
+			balance.Sub(balance, amount)
+		}
+	}
+
+	for i := len(blocks) - 1; i >= 0; i-- {
+		block := blocks[i]
+
+		switch {
+		case block.BlockType == ledger.BlockTypeGenesisReceive:
+			// the genesis balances are the results of all the genesis blocks of the account
+			for tokenId, balance := range lc.chain.GetGenesisBalanceMap(addr) {
+				balances[tokenId] = new(big.Int).Set(balance)
+			}
+		case block.IsReceiveBlock():
+			sendBlock, err := getSendBlock(block.FromBlockHash)
+			if err != nil {
+				return nil, err
+			}
+			if sendBlock.ToAddress != addr {
+				return nil, errors.New(fmt.Sprintf("send block %s is not sent to %s", sendBlock.Hash, addr))
+			}
+			change(sendBlock.TokenId, sendBlock.Amount, true)
+		default:
+			change(block.TokenId, block.Amount, false)
+			change(ledger.ViteTokenId, block.Fee, false)
+		}
+
+		for _, sendBlock := range block.SendBlockList {
+			change(sendBlock.TokenId, sendBlock.Amount, false)
+			change(ledger.ViteTokenId, sendBlock.Fee, false)
+		}
+	}
+
+	return balances, nil
+}
+
+// verifyAccountBlocks sort blocks from high to low, the highest block must be the confirmed block hh,
+// and the others must be linked to it by PrevHash.
+func verifyAccountBlocks(addr types.Address, hh *ledger.HashHeight, blocks []*ledger.AccountBlock) error {
+	if len(blocks) == 0 {
+		return errNoResource
+	}
+
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].Height > blocks[j].Height
+	})
+
+	if blocks[0].Hash != hh.Hash || blocks[0].Height != hh.Height {
+		return errors.New(fmt.Sprintf("account block %s/%d is not the confirmed block %s/%d", blocks[0].Hash, blocks[0].Height, hh.Hash, hh.Height))
+	}
+
+	for i, block := range blocks {
+		if block.AccountAddress != addr {
+			return errors.New(fmt.Sprintf("account block %s/%d belongs to %s not %s", block.Hash, block.Height, block.AccountAddress, addr))
+		}
+		if block.ComputeHash() != block.Hash {
+			return errors.New(fmt.Sprintf("account block %s/%d has wrong hash", block.Hash, block.Height))
+		}
+		for j, sendBlock := range block.SendBlockList {
+			if sendBlock.ComputeSendHash(block, uint8(j)) != sendBlock.Hash {
+				return errors.New(fmt.Sprintf("send block %s of account block %s/%d has wrong hash", sendBlock.Hash, block.Hash, block.Height))
+			}
+		}
+
+		if i > 0 {
+			next := blocks[i-1]
+			if next.PrevHash != block.Hash || next.Height != block.Height+1 {
+				return errors.New(fmt.Sprintf("account block %s/%d is not linked to %s/%d", block.Hash, block.Height, next.Hash, next.Height))
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,258 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+)
+
+// a revoked or replaced producing address is still valid in so many snapshot heights,
+// the producers of the current and the next round may have been elected before the revocation.
+const lightRevokeDelay = 2 * 75
+
+var errUnknownProducer = errors.New("snapshot block is not produced by a registered producer")
+
+// lightProducer is a producing address registered in the snapshot consensus group,
+// it is valid in the snapshot heights [From, Revoked+lightRevokeDelay).
+type lightProducer struct {
+	Name    string        `json:"name"`
+	Address types.Address `json:"address"`
+	From    uint64        `json:"from"`
+	Revoked uint64        `json:"revoked"` // 0 means not revoked
+}
+
+func (p *lightProducer) valid(height uint64) bool {
+	return p.From <= height && (p.Revoked == 0 || height < p.Revoked+lightRevokeDelay)
+}
+
+// lightSchedule tracks the producing addresses registered in the snapshot consensus group without the local ledger.
+// It starts from the genesis registrations or the trusted producers at the checkpoint, then it is updated by
+// the successful receive blocks of the governance contract confirmed by the verified headers.
+// The producers are elected from the registrations by the votes, which can not be proved by headers,
+// so a header produced by any registered producer is accepted, it is weaker than the consensus.
+type lightSchedule struct {
+	mu        sync.RWMutex
+	producers []*lightProducer
+	snapshot  uint64            // the governance blocks confirmed by headers not higher than it have been applied
+	account   ledger.HashHeight // the latest applied governance block, zero if it is unknown at the checkpoint
+}
+
+type lightScheduleJSON struct {
+	Producers []*lightProducer  `json:"producers"`
+	Snapshot  uint64            `json:"snapshot"`
+	Account   ledger.HashHeight `json:"account"`
+}
+
+func newLightSchedule(snapshot uint64, account ledger.HashHeight, producers []*lightProducer) *lightSchedule {
+	return &lightSchedule{
+		producers: producers,
+		snapshot:  snapshot,
+		account:   account,
+	}
+}
+
+// newGenesisSchedule start from the registrations of the snapshot consensus group in the genesis
+func newGenesisSchedule(chain genesisReader, genesis *ledger.SnapshotBlock) (*lightSchedule, error) {
+	list, err := chain.GetAllRegisterList(genesis.Hash, types.SNAPSHOT_GID)
+	if err != nil {
+		return nil, err
+	}
+
+	var producers []*lightProducer
+	for _, registration := range list {
+		if registration.IsActive() {
+			producers = append(producers, &lightProducer{
+				Name:    registration.Name,
+				Address: registration.BlockProducingAddress,
+				From:    genesis.Height,
+			})
+		}
+	}
+
+	var account ledger.HashHeight
+	if hh, ok := genesis.SnapshotContent[types.AddressGovernance]; ok {
+		account = *hh
+	}
+
+	return newLightSchedule(genesis.Height, account, producers), nil
+}
+
+// newCheckpointSchedule start from the trusted producers at the checkpoint, like: "sbpName/vite_xxxxxx"
+func newCheckpointSchedule(checkpoint *ledger.HashHeight, list []string) (*lightSchedule, error) {
+	if len(list) == 0 {
+		return nil, errors.New(fmt.Sprintf("missing light producers at the checkpoint %s/%d", checkpoint.Hash, checkpoint.Height))
+	}
+
+	var producers []*lightProducer
+	for _, str := range list {
+		strs := strings.Split(str, "/")
+		if len(strs) != 2 {
+			return nil, errors.New(fmt.Sprintf("invalid light producer %s", str))
+		}
+		addr, err := types.HexToAddress(strs[1])
+		if err != nil {
+			return nil, err
+		}
+		producers = append(producers, &lightProducer{
+			Name:    strs[0],
+			Address: addr,
+			From:    checkpoint.Height,
+		})
+	}
+
+	return newLightSchedule(checkpoint.Height, ledger.HashHeight{}, producers), nil
+}
+
+func (s *lightSchedule) VerifySnapshotProducer(block *ledger.SnapshotBlock) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	producer := block.Producer()
+	for _, p := range s.producers {
+		if p.Address == producer && p.valid(block.Height) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// applied return the snapshot height and the governance block the schedule has applied
+func (s *lightSchedule) applied() (uint64, ledger.HashHeight) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.snapshot, s.account
+}
+
+// apply the send blocks of the successful governance receive blocks confirmed by the header at height,
+// the changes take effect from the next height.
+func (s *lightSchedule) apply(height uint64, account ledger.HashHeight, sendBlocks []*ledger.AccountBlock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sendBlock := range sendBlocks {
+		if len(sendBlock.Data) < 4 {
+			continue
+		}
+		method, err := abi.ABIGovernance.MethodById(sendBlock.Data[:4])
+		if err != nil {
+			continue
+		}
+
+		switch method.Name {
+		case abi.MethodNameRegister, abi.MethodNameRegisterV3,
+			abi.MethodNameUpdateBlockProducingAddress, abi.MethodNameUpdateBlockProducintAddressV2, abi.MethodNameUpdateBlockProducintAddressV3:
+			param := new(abi.ParamRegister)
+			if err = abi.ABIGovernance.UnpackMethod(param, method.Name, sendBlock.Data); err != nil {
+				continue
+			}
+			if method.Name == abi.MethodNameRegisterV3 || method.Name == abi.MethodNameUpdateBlockProducintAddressV3 {
+				param.Gid = types.SNAPSHOT_GID
+			}
+			if param.Gid == types.SNAPSHOT_GID {
+				s.revokeLocked(param.SbpName, height)
+				s.producers = append(s.producers, &lightProducer{
+					Name:    param.SbpName,
+					Address: param.BlockProducingAddress,
+					From:    height + 1,
+				})
+			}
+		case abi.MethodNameRevoke, abi.MethodNameRevokeV2, abi.MethodNameRevokeV3:
+			param := new(abi.ParamCancelRegister)
+			if err = abi.ABIGovernance.UnpackMethod(param, method.Name, sendBlock.Data); err != nil {
+				continue
+			}
+			if method.Name == abi.MethodNameRevokeV3 {
+				param.Gid = types.SNAPSHOT_GID
+			}
+			if param.Gid == types.SNAPSHOT_GID {
+				s.revokeLocked(param.SbpName, height)
+			}
+		}
+	}
+
+	s.snapshot = height
+	s.account = account
+}
+
+func (s *lightSchedule) revokeLocked(name string, height uint64) {
+	for _, p := range s.producers {
+		if p.Name == name && p.Revoked == 0 {
+			p.Revoked = height
+		}
+	}
+}
+
+// rollback undo the changes confirmed by the headers higher than height,
+// the schedule has applied the governance block account confirmed by the header at snapshot.
+func (s *lightSchedule) rollback(height uint64, snapshot uint64, account ledger.HashHeight) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	producers := s.producers[:0]
+	for _, p := range s.producers {
+		if p.From > height+1 {
+			continue
+		}
+		if p.Revoked > height {
+			p.Revoked = 0
+		}
+		producers = append(producers, p)
+	}
+	s.producers = producers
+
+	s.snapshot = snapshot
+	s.account = account
+}
+
+func (s *lightSchedule) serialize() ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return json.Marshal(&lightScheduleJSON{
+		Producers: s.producers,
+		Snapshot:  s.snapshot,
+		Account:   s.account,
+	})
+}
+
+func (s *lightSchedule) deserialize(data []byte) error {
+	sj := new(lightScheduleJSON)
+	if err := json.Unmarshal(data, sj); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.producers = sj.Producers
+	s.snapshot = sj.Snapshot
+	s.account = sj.Account
+
+	return nil
+}
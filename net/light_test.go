@@ -0,0 +1,417 @@
+package net
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/net/database"
+	"github.com/vitelabs/go-vite/net/vnode"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+)
+
+func initForkPointsForLightTest() {
+	fork.SetForkPoints(&config.ForkPoints{
+		SeedFork:      &config.ForkPoint{Height: 100, Version: 1},
+		DexFork:       &config.ForkPoint{Height: 200, Version: 2},
+		DexFeeFork:    &config.ForkPoint{Height: 250, Version: 3},
+		StemFork:      &config.ForkPoint{Height: 300, Version: 4},
+		LeafFork:      &config.ForkPoint{Height: 400, Version: 5},
+		EarthFork:     &config.ForkPoint{Height: 500, Version: 6},
+		DexMiningFork: &config.ForkPoint{Height: 600, Version: 7},
+		DexRobotFork:  &config.ForkPoint{Height: 700, Version: 8},
+		CryptoFork:    &config.ForkPoint{Height: 800, Version: 9},
+	})
+}
+
+func newSignedSnapshotBlock(prev *ledger.SnapshotBlock, key ed25519.PrivateKey, content ledger.SnapshotContent) *ledger.SnapshotBlock {
+	t := prev.Timestamp.Add(time.Second)
+	block := &ledger.SnapshotBlock{
+		PrevHash:        prev.Hash,
+		Height:          prev.Height + 1,
+		PublicKey:       key.PubByte(),
+		Timestamp:       &t,
+		SnapshotContent: content,
+	}
+	block.Hash = block.ComputeHash()
+	block.Signature = ed25519.Sign(key, block.Hash.Bytes())
+	return block
+}
+
+func newLightClientForTest(t *testing.T) (lc *lightClient, key ed25519.PrivateKey) {
+	initForkPointsForLightTest()
+
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1500000000, 0)
+	genesis := &ledger.SnapshotBlock{
+		Height:    1,
+		Timestamp: &now,
+	}
+	genesis.Hash = genesis.ComputeHash()
+
+	lc = newLightClient(genesis, nil, newPeerSet(), newLightSchedule(genesis.Height, ledger.HashHeight{}, []*lightProducer{
+		{Name: "s1", Address: types.PubkeyToAddress(key.PubByte()), From: genesis.Height},
+	}), nil)
+	return
+}
+
+func TestLightClient_appendHeaders(t *testing.T) {
+	lc, key := newLightClientForTest(t)
+
+	var blocks []*ledger.SnapshotBlock
+	prev := lc.LatestHeader()
+	for i := 0; i < 10; i++ {
+		prev = newSignedSnapshotBlock(prev, key, nil)
+		blocks = append(blocks, prev)
+	}
+
+	if err := lc.appendHeaders(blocks); err != nil {
+		t.Fatal(err)
+	}
+	if head := lc.LatestHeader(); head.Hash != prev.Hash || lc.GetHeader(5) == nil {
+		t.Fatalf("wrong latest header %d", head.Height)
+	}
+
+	// wrong signature
+	block := newSignedSnapshotBlock(prev, key, nil)
+	block.Signature[0] ^= 1
+	if err := lc.appendHeaders([]*ledger.SnapshotBlock{block}); err == nil {
+		t.Fatal("header with wrong signature should not be appended")
+	}
+
+	// wrong producer
+	_, key2, _ := ed25519.GenerateKey(nil)
+	block = newSignedSnapshotBlock(prev, key2, nil)
+	if err := lc.appendHeaders([]*ledger.SnapshotBlock{block}); err == nil {
+		t.Fatal("header produced by wrong producer should not be appended")
+	}
+
+	// not linked
+	block = newSignedSnapshotBlock(prev, key, nil)
+	block2 := newSignedSnapshotBlock(prev, key, nil)
+	block2.Height++
+	block2.Hash = block2.ComputeHash()
+	block2.Signature = ed25519.Sign(key, block2.Hash.Bytes())
+	if err := lc.appendHeaders([]*ledger.SnapshotBlock{block, block2}); err == nil {
+		t.Fatal("headers not linked should not be appended")
+	}
+
+	// forked
+	block = newSignedSnapshotBlock(blocks[8], key, nil)
+	block.Height = prev.Height + 1
+	block.Hash = block.ComputeHash()
+	block.Signature = ed25519.Sign(key, block.Hash.Bytes())
+	if err := lc.appendHeaders([]*ledger.SnapshotBlock{block}); err != errHeaderForked {
+		t.Fatalf("error should be %v, but got %v", errHeaderForked, err)
+	}
+	lc.rollback()
+	if head := lc.LatestHeader(); head.Hash != blocks[8].Hash {
+		t.Fatalf("wrong latest header %d after rollback", head.Height)
+	}
+}
+
+func newAccountBlocksForTest(addr types.Address, count int) (blocks []*ledger.AccountBlock) {
+	var prev types.Hash
+	for i := 1; i <= count; i++ {
+		block := &ledger.AccountBlock{
+			BlockType:      ledger.BlockTypeSendCall,
+			PrevHash:       prev,
+			Height:         uint64(i),
+			AccountAddress: addr,
+			Amount:         big.NewInt(int64(i)),
+			Fee:            big.NewInt(0),
+		}
+		block.Hash = block.ComputeHash()
+		prev = block.Hash
+		blocks = append(blocks, block)
+	}
+	return
+}
+
+func TestVerifyAccountBlocks(t *testing.T) {
+	addr, _, _ := types.CreateAddress()
+	blocks := newAccountBlocksForTest(addr, 5)
+	hh := &ledger.HashHeight{Height: 5, Hash: blocks[4].Hash}
+
+	if err := verifyAccountBlocks(addr, hh, blocks[2:]); err != nil {
+		t.Fatal(err)
+	}
+	if blocks[2].Height != 5 {
+		t.Fatal("blocks should be sorted from high to low")
+	}
+
+	blocks = newAccountBlocksForTest(addr, 5)
+	if err := verifyAccountBlocks(addr, hh, blocks[:4]); err == nil {
+		t.Fatal("blocks not confirmed should not be verified")
+	}
+
+	blocks = newAccountBlocksForTest(addr, 5)
+	blocks[3].Amount = big.NewInt(100)
+	if err := verifyAccountBlocks(addr, hh, blocks); err == nil {
+		t.Fatal("tampered block should not be verified")
+	}
+
+	blocks = newAccountBlocksForTest(addr, 5)
+	if err := verifyAccountBlocks(addr, hh, []*ledger.AccountBlock{blocks[4], blocks[2]}); err == nil {
+		t.Fatal("blocks not linked should not be verified")
+	}
+}
+
+func TestLightClient_confirmed(t *testing.T) {
+	lc, key := newLightClientForTest(t)
+
+	addr, _, _ := types.CreateAddress()
+	addr2, _, _ := types.CreateAddress()
+
+	var blocks []*ledger.SnapshotBlock
+	prev := lc.LatestHeader()
+	for i := uint64(1); i <= 10; i++ {
+		content := ledger.SnapshotContent{}
+		if i%3 == 0 {
+			content[addr] = &ledger.HashHeight{Height: i, Hash: types.Hash{byte(i)}}
+		}
+		prev = newSignedSnapshotBlock(prev, key, content)
+		blocks = append(blocks, prev)
+	}
+	if err := lc.appendHeaders(blocks); err != nil {
+		t.Fatal(err)
+	}
+
+	header, hh := lc.confirmed(addr)
+	if header == nil || header.Hash != blocks[8].Hash || hh.Height != 9 {
+		t.Fatal("wrong confirmed header")
+	}
+
+	// the confirmation of the removed header is removed
+	lc.rollback()
+	lc.rollback()
+	if header, hh = lc.confirmed(addr); header == nil || header.Hash != blocks[5].Hash || hh.Height != 6 {
+		t.Fatal("wrong confirmed header after rollback")
+	}
+
+	if header, _ = lc.confirmed(addr2); header != nil {
+		t.Fatal("account should not be confirmed")
+	}
+	if _, _, err := lc.GetAccountBlocks(addr2, 1); err != errAccountNotConfirmed {
+		t.Fatalf("error should be %v, but got %v", errAccountNotConfirmed, err)
+	}
+}
+
+func TestLightClient_unknownProducer(t *testing.T) {
+	lc, key := newLightClientForTest(t)
+
+	_, key2, _ := ed25519.GenerateKey(nil)
+	block1 := newSignedSnapshotBlock(lc.LatestHeader(), key, nil)
+	block2 := newSignedSnapshotBlock(block1, key, nil)
+	block3 := newSignedSnapshotBlock(block2, key2, nil)
+
+	// the headers before the unknown producer are appended
+	if err := lc.appendHeaders([]*ledger.SnapshotBlock{block1, block2, block3}); err != errUnknownProducer {
+		t.Fatalf("error should be %v, but got %v", errUnknownProducer, err)
+	}
+	if head := lc.LatestHeader(); head.Hash != block2.Hash {
+		t.Fatalf("wrong latest header %d", head.Height)
+	}
+
+	// the producer is registered by a governance block confirmed by block2
+	data, err := abi.ABIGovernance.PackMethod(abi.MethodNameRegisterV3, "s2", types.PubkeyToAddress(key2.PubByte()), types.Address{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lc.schedule.apply(block2.Height, ledger.HashHeight{Height: 1}, []*ledger.AccountBlock{{Data: data}})
+	if err = lc.appendHeaders([]*ledger.SnapshotBlock{block3}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLightSchedule(t *testing.T) {
+	var keys []ed25519.PrivateKey
+	var addrs []types.Address
+	for i := 0; i < 3; i++ {
+		_, key, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+		addrs = append(addrs, types.PubkeyToAddress(key.PubByte()))
+	}
+
+	valid := func(s *lightSchedule, i int, height uint64) bool {
+		ok, _ := s.VerifySnapshotProducer(&ledger.SnapshotBlock{Height: height, PublicKey: keys[i].PubByte()})
+		return ok
+	}
+	send := func(name string, args ...interface{}) *ledger.AccountBlock {
+		data, err := abi.ABIGovernance.PackMethod(name, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &ledger.AccountBlock{Data: data}
+	}
+
+	s := newLightSchedule(1, ledger.HashHeight{}, []*lightProducer{{Name: "s0", Address: addrs[0], From: 1}})
+
+	// register s1, the registration of another consensus group is ignored
+	s.apply(10, ledger.HashHeight{Height: 2}, []*ledger.AccountBlock{
+		send(abi.MethodNameRegisterV3, "s1", addrs[1], addrs[1]),
+		send(abi.MethodNameRegister, types.DELEGATE_GID, "s2", addrs[2]),
+	})
+	if valid(s, 1, 10) || !valid(s, 1, 11) || valid(s, 2, 11) {
+		t.Fatal("wrong registration")
+	}
+
+	// replace the producing address of s0
+	s.apply(20, ledger.HashHeight{Height: 3}, []*ledger.AccountBlock{
+		send(abi.MethodNameUpdateBlockProducintAddressV3, "s0", addrs[2]),
+	})
+	if !valid(s, 0, 20+lightRevokeDelay-1) || valid(s, 0, 20+lightRevokeDelay) || !valid(s, 2, 21) {
+		t.Fatal("wrong update")
+	}
+
+	s.apply(30, ledger.HashHeight{Height: 4}, []*ledger.AccountBlock{
+		send(abi.MethodNameRevokeV3, "s1"),
+	})
+	if !valid(s, 1, 30+lightRevokeDelay-1) || valid(s, 1, 30+lightRevokeDelay) {
+		t.Fatal("wrong revocation")
+	}
+
+	data, err := s.serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2 := new(lightSchedule)
+	if err = s2.deserialize(data); err != nil {
+		t.Fatal(err)
+	}
+	if snapshot, account := s2.applied(); snapshot != 30 || account.Height != 4 || valid(s2, 1, 1000) || !valid(s2, 2, 1000) {
+		t.Fatal("wrong deserialized schedule")
+	}
+
+	// undo the revocation of s1
+	s.rollback(25, 20, ledger.HashHeight{Height: 3})
+	if snapshot, account := s.applied(); snapshot != 20 || account.Height != 3 || !valid(s, 1, 1000) || !valid(s, 2, 1000) {
+		t.Fatal("wrong schedule after rollback")
+	}
+
+	// undo the update of s0
+	s.rollback(15, 10, ledger.HashHeight{Height: 2})
+	if !valid(s, 0, 1000) || valid(s, 2, 1000) || !valid(s, 1, 1000) {
+		t.Fatal("wrong schedule after rollback")
+	}
+}
+
+func TestLightClient_store(t *testing.T) {
+	lc, key := newLightClientForTest(t)
+	genesis := lc.LatestHeader()
+
+	db, err := database.New("", 1, vnode.RandomNodeID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	lc.setStore(db)
+
+	addr, _, _ := types.CreateAddress()
+	var blocks []*ledger.SnapshotBlock
+	prev := genesis
+	for i := uint64(1); i <= 6; i++ {
+		prev = newSignedSnapshotBlock(prev, key, ledger.SnapshotContent{
+			addr: &ledger.HashHeight{Height: i, Hash: types.Hash{byte(i)}},
+		})
+		blocks = append(blocks, prev)
+	}
+	if err = lc.appendHeaders(blocks); err != nil {
+		t.Fatal(err)
+	}
+	lc.rollback()
+	lc.schedule.apply(5, ledger.HashHeight{Height: 1}, nil)
+	lc.storeSchedule()
+
+	// restart from the genesis
+	lc2 := newLightClient(genesis, nil, newPeerSet(), newLightSchedule(genesis.Height, ledger.HashHeight{}, nil), nil)
+	lc2.setStore(db)
+	if head := lc2.LatestHeader(); head == nil || head.Hash != blocks[4].Hash {
+		t.Fatal("failed to load the stored headers")
+	}
+	if header, hh := lc2.confirmed(addr); header == nil || header.Hash != blocks[4].Hash || hh.Height != 5 {
+		t.Fatal("wrong confirmed header after restart")
+	}
+	if snapshot, _ := lc2.schedule.applied(); snapshot != 5 {
+		t.Fatal("failed to load the stored schedule")
+	}
+
+	// restart from a checkpoint, the lower headers are removed
+	lc3 := newLightClient(genesis, &ledger.HashHeight{Height: blocks[2].Height, Hash: blocks[2].Hash}, newPeerSet(),
+		newLightSchedule(blocks[2].Height, ledger.HashHeight{}, nil), nil)
+	lc3.setStore(db)
+	if head := lc3.LatestHeader(); head == nil || head.Hash != blocks[4].Hash || lc3.GetHeader(blocks[1].Height) != nil {
+		t.Fatal("failed to load the stored headers from the checkpoint")
+	}
+	if headers := db.ReadLightHeaders(); len(headers) != 3 {
+		t.Fatalf("the headers lower than the checkpoint should be removed, %d left", len(headers))
+	}
+}
+
+type mockGenesisReader struct {
+	balances map[types.TokenTypeId]*big.Int
+}
+
+func (m mockGenesisReader) GetGenesisBalanceMap(addr types.Address) map[types.TokenTypeId]*big.Int {
+	return m.balances
+}
+
+func (m mockGenesisReader) GetAllRegisterList(snapshotHash types.Hash, gid types.Gid) ([]*types.Registration, error) {
+	return nil, nil
+}
+
+func TestLightClient_replayBalances(t *testing.T) {
+	lc, _ := newLightClientForTest(t)
+	lc.chain = mockGenesisReader{balances: map[types.TokenTypeId]*big.Int{ledger.ViteTokenId: big.NewInt(1000)}}
+
+	addr, _, _ := types.CreateAddress()
+	other, _, _ := types.CreateAddress()
+	token := types.TokenTypeId{1}
+
+	sendBlocks := map[types.Hash]*ledger.AccountBlock{
+		{1}: {BlockType: ledger.BlockTypeSendCall, AccountAddress: other, ToAddress: addr, TokenId: ledger.ViteTokenId, Amount: big.NewInt(100)},
+		{2}: {BlockType: ledger.BlockTypeSendCall, AccountAddress: other, ToAddress: addr, TokenId: token, Amount: big.NewInt(5)},
+		{3}: {BlockType: ledger.BlockTypeSendCall, AccountAddress: other, ToAddress: other, TokenId: token, Amount: big.NewInt(5)},
+	}
+	getSendBlock := func(hash types.Hash) (*ledger.AccountBlock, error) {
+		return sendBlocks[hash], nil
+	}
+
+	blocks := []*ledger.AccountBlock{
+		{Height: 4, BlockType: ledger.BlockTypeReceive, FromBlockHash: types.Hash{2}, SendBlockList: []*ledger.AccountBlock{
+			{BlockType: ledger.BlockTypeSendCall, ToAddress: other, TokenId: token, Amount: big.NewInt(2), Fee: big.NewInt(0)},
+		}},
+		{Height: 3, BlockType: ledger.BlockTypeSendCall, ToAddress: other, TokenId: ledger.ViteTokenId, Amount: big.NewInt(30), Fee: big.NewInt(1)},
+		{Height: 2, BlockType: ledger.BlockTypeReceive, FromBlockHash: types.Hash{1}},
+		{Height: 1, BlockType: ledger.BlockTypeGenesisReceive},
+	}
+	balances, err := lc.replayBalances(addr, blocks, getSendBlock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(balances) != 2 || balances[ledger.ViteTokenId].Int64() != 1069 || balances[token].Int64() != 3 {
+		t.Fatalf("wrong balances %v", balances)
+	}
+
+	// the account chain is not complete
+	if _, err = lc.replayBalances(addr, blocks[:3], getSendBlock); err == nil {
+		t.Fatal("balances of an incomplete account chain should not be replayed")
+	}
+
+	// the send block is not sent to the account
+	blocks[0].FromBlockHash = types.Hash{3}
+	if _, err = lc.replayBalances(addr, blocks, getSendBlock); err == nil {
+		t.Fatal("send block to another account should not be received")
+	}
+}
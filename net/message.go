@@ -44,6 +44,9 @@ const (
 	CodeNewSnapshotBlock  Code = 31
 	CodeNewAccountBlock   Code = 32
 
+	CodeNewAccountBlockHashes Code = 33
+	CodeGetNewAccountBlocks   Code = 34
+
 	CodeSyncHandshake   Code = 60
 	CodeSyncHandshakeOK Code = 61
 	CodeSyncRequest     Code = 62
@@ -53,7 +56,14 @@ const (
 	CodeTrace     Code = 128
 )
 
-const version = iota
+// protocol versions exchanged in the handshake
+const (
+	versionBase = iota
+	// peers can announce and pull new account blocks by hashes, CodeNewAccountBlockHashes and CodeGetNewAccountBlocks
+	versionAnnounce
+)
+
+const version = versionAnnounce
 
 type Code = byte
 type MsgId = uint32
@@ -398,6 +408,39 @@ func (b *NewAccountBlock) Deserialize(buf []byte) error {
 	return nil
 }
 
+// AccountBlockHashes announces the hashes of new account blocks, or pulls the new account blocks by hashes.
+// the pulled blocks are responded as NewAccountBlock
+type AccountBlockHashes struct {
+	Hashes []types.Hash
+}
+
+func (a *AccountBlockHashes) Serialize() ([]byte, error) {
+	pb := &vitepb.AccountBlockHashes{
+		Hashes: make([][]byte, len(a.Hashes)),
+	}
+	for i, hash := range a.Hashes {
+		pb.Hashes[i] = hash.Bytes()
+	}
+
+	return proto.Marshal(pb)
+}
+
+func (a *AccountBlockHashes) Deserialize(buf []byte) (err error) {
+	pb := new(vitepb.AccountBlockHashes)
+	if err = proto.Unmarshal(buf, pb); err != nil {
+		return err
+	}
+
+	a.Hashes = make([]types.Hash, len(pb.Hashes))
+	for i, hash := range pb.Hashes {
+		if a.Hashes[i], err = types.BytesToHash(hash); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 var errMissingPoints = errors.New("missing from points")
 var errNilPoint = errors.New("nil HashHeightPoint")
 
@@ -1,6 +1,8 @@
 package net
 
 import (
+	"math/big"
+
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/interfaces"
 	"github.com/vitelabs/go-vite/ledger"
@@ -63,3 +65,11 @@ func (mc mockChain) GetLedgerReaderByHeight(startHeight uint64, endHeight uint64
 func (mc mockChain) GetSyncCache() interfaces.SyncCache {
 	panic("implement me")
 }
+
+func (mc mockChain) GetGenesisBalanceMap(addr types.Address) map[types.TokenTypeId]*big.Int {
+	panic("implement me")
+}
+
+func (mc mockChain) GetAllRegisterList(snapshotHash types.Hash, gid types.Gid) ([]*types.Registration, error) {
+	panic("implement me")
+}
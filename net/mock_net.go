@@ -1,6 +1,8 @@
 package net
 
 import (
+	"time"
+
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/crypto/ed25519"
 	"github.com/vitelabs/go-vite/ledger"
@@ -101,6 +103,27 @@ func (n *mockNet) Nodes() []*vnode.Node {
 	return nil
 }
 
+func (n *mockNet) Light() LightClient {
+	return nil
+}
+
+func (n *mockNet) PeerScores() []PeerScore {
+	return nil
+}
+
+func (n *mockNet) BanNode(id vnode.NodeID, duration time.Duration) {
+}
+
+func (n *mockNet) UnbanNode(id vnode.NodeID) {
+}
+
+func (n *mockNet) AddTrustedPeer(node *vnode.Node) error {
+	return nil
+}
+
+func (n *mockNet) RemovePeer(id vnode.NodeID) {
+}
+
 func (n *mockNet) PeerCount() int {
 	return 0
 }
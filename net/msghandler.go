@@ -182,6 +182,11 @@ func (q *queryHandler) loop() {
 					q.log.Warn(fmt.Sprintf("fetch message from %s is expired", msg.Sender))
 					continue
 				}
+				if false == msg.Sender.serveLimiter.Allow() {
+					q.log.Warn(fmt.Sprintf("query from %s exceeds the rate limit", msg.Sender))
+					_ = msg.Sender.send(CodeException, msg.Id, ExpTooManyRequests)
+					continue
+				}
 				// allocate to handlers
 				if err := q.msgHandlers.handle(msg); err != nil {
 					msg.Sender.catch(err)
@@ -191,6 +196,15 @@ func (q *queryHandler) loop() {
 	}
 }
 
+// isQueryResponse return true if the message is response to the query handled by queryHandler
+func isQueryResponse(code Code) bool {
+	switch code {
+	case CodeHashList, CodeSnapshotBlocks, CodeAccountBlocks:
+		return true
+	}
+	return false
+}
+
 type checkHandler struct {
 	chain interface {
 		GetSnapshotBlockByHeight(height uint64) (*ledger.SnapshotBlock, error)
@@ -0,0 +1,248 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package nat maps the ports of this host on the gateway by UPnP or NAT-PMP, so the host behind the router
+// can be reached from the Internet.
+package nat
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/log15"
+)
+
+var natLog = log15.New("module", "nat")
+
+// the lifetime of port mappings, the mappings are renewed before expiration
+const mapLifetime = 20 * time.Minute
+const mapRenewInterval = 15 * time.Minute
+
+// retry to discover the gateway after the interval if it is not found
+const rediscoverInterval = time.Minute
+
+// Interface maps the ports of this host on the gateway
+type Interface interface {
+	// AddMapping maps the external port of gateway to the internal port of this host for lifetime,
+	// protocol is "tcp" or "udp"
+	AddMapping(protocol string, extport, intport int, name string, lifetime time.Duration) error
+	DeleteMapping(protocol string, extport, intport int) error
+
+	// ExternalIP return the IP of the gateway on the Internet
+	ExternalIP() (net.IP, error)
+
+	String() string
+}
+
+// Parse the NAT mechanism, spec can be:
+//
+//	""  or "none"    no NAT traversal
+//	"any"            discover the UPnP or NAT-PMP gateway automatically
+//	"upnp"           discover the UPnP gateway
+//	"pmp"            discover the NAT-PMP gateway
+//	"pmp:<IP>"       NAT-PMP gateway at IP
+//	"extip:<IP>"     the external IP is known, no port mapping
+//
+// The returned Interface is nil if spec is "" or "none".
+func Parse(spec string) (Interface, error) {
+	var ip net.IP
+	parts := strings.SplitN(spec, ":", 2)
+	mech := strings.ToLower(parts[0])
+	if len(parts) > 1 {
+		ip = net.ParseIP(parts[1])
+		if ip == nil {
+			return nil, errors.New(fmt.Sprintf("invalid IP address %s", parts[1]))
+		}
+	}
+
+	switch mech {
+	case "", "none":
+		return nil, nil
+	case "any":
+		return Any(), nil
+	case "upnp":
+		return UPnP(), nil
+	case "pmp":
+		return PMP(ip), nil
+	case "extip":
+		if ip == nil {
+			return nil, errors.New("missing IP address of extip")
+		}
+		return ExtIP(ip), nil
+	default:
+		return nil, errors.New(fmt.Sprintf("unknown NAT mechanism %s", parts[0]))
+	}
+}
+
+// Map adds the port mapping and renews it until term is closed, then the mapping is deleted
+func Map(m Interface, term <-chan struct{}, protocol string, extport, intport int, name string) {
+	log := natLog.New("protocol", protocol, "extport", extport, "intport", intport, "interface", m.String())
+
+	refresh := time.NewTimer(mapRenewInterval)
+	defer func() {
+		refresh.Stop()
+		if err := m.DeleteMapping(protocol, extport, intport); err != nil {
+			log.Warn(fmt.Sprintf("failed to delete port mapping: %v", err))
+		} else {
+			log.Info("port mapping deleted")
+		}
+	}()
+
+	if err := m.AddMapping(protocol, extport, intport, name, mapLifetime); err != nil {
+		log.Warn(fmt.Sprintf("failed to map port: %v", err))
+	} else {
+		log.Info("port mapped")
+	}
+
+	for {
+		select {
+		case <-term:
+			return
+		case <-refresh.C:
+			if err := m.AddMapping(protocol, extport, intport, name, mapLifetime); err != nil {
+				log.Warn(fmt.Sprintf("failed to renew port mapping: %v", err))
+			}
+			refresh.Reset(mapRenewInterval)
+		}
+	}
+}
+
+// ExtIP means the external IP is known, the ports are mapped manually
+type ExtIP net.IP
+
+func (ip ExtIP) ExternalIP() (net.IP, error) {
+	return net.IP(ip), nil
+}
+
+func (ip ExtIP) String() string {
+	return fmt.Sprintf("ExtIP(%s)", net.IP(ip))
+}
+
+func (ExtIP) AddMapping(protocol string, extport, intport int, name string, lifetime time.Duration) error {
+	return nil
+}
+
+func (ExtIP) DeleteMapping(protocol string, extport, intport int) error {
+	return nil
+}
+
+// Any discover the UPnP or NAT-PMP gateway at the first use
+func Any() Interface {
+	return newAutoDisc("UPnP or NAT-PMP", func() Interface {
+		found := make(chan Interface, 2)
+		go func() {
+			found <- discoverUPnP()
+		}()
+		go func() {
+			found <- discoverPMP()
+		}()
+
+		for i := 0; i < cap(found); i++ {
+			if m := <-found; m != nil {
+				return m
+			}
+		}
+		return nil
+	})
+}
+
+// UPnP discover the UPnP gateway at the first use
+func UPnP() Interface {
+	return newAutoDisc("UPnP", discoverUPnP)
+}
+
+// PMP use the NAT-PMP gateway, the gateway will be discovered at the first use if it is nil
+func PMP(gateway net.IP) Interface {
+	if gateway != nil {
+		return newPMP(&net.UDPAddr{IP: gateway, Port: pmpPort})
+	}
+
+	return newAutoDisc("NAT-PMP", discoverPMP)
+}
+
+// autoDisc discover the gateway when it is used, and rediscover if the gateway is not found
+type autoDisc struct {
+	what       string
+	discover   func() Interface
+	mu         sync.Mutex
+	found      Interface
+	discoverAt time.Time
+}
+
+func newAutoDisc(what string, discover func() Interface) *autoDisc {
+	return &autoDisc{
+		what:     what,
+		discover: discover,
+	}
+}
+
+func (a *autoDisc) get() (Interface, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.found == nil && time.Since(a.discoverAt) > rediscoverInterval {
+		a.discoverAt = time.Now()
+		if a.found = a.discover(); a.found != nil {
+			natLog.Info(fmt.Sprintf("found gateway %s", a.found))
+		}
+	}
+
+	if a.found == nil {
+		return nil, errors.New(fmt.Sprintf("no %s gateway found", a.what))
+	}
+
+	return a.found, nil
+}
+
+func (a *autoDisc) AddMapping(protocol string, extport, intport int, name string, lifetime time.Duration) error {
+	m, err := a.get()
+	if err != nil {
+		return err
+	}
+	return m.AddMapping(protocol, extport, intport, name, lifetime)
+}
+
+func (a *autoDisc) DeleteMapping(protocol string, extport, intport int) error {
+	m, err := a.get()
+	if err != nil {
+		return err
+	}
+	return m.DeleteMapping(protocol, extport, intport)
+}
+
+func (a *autoDisc) ExternalIP() (net.IP, error) {
+	m, err := a.get()
+	if err != nil {
+		return nil, err
+	}
+	return m.ExternalIP()
+}
+
+func (a *autoDisc) String() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.found == nil {
+		return a.what
+	}
+	return a.found.String()
+}
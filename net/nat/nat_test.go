@@ -0,0 +1,54 @@
+package nat
+
+import (
+	"net"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for spec, want := range map[string]string{
+		"":              "",
+		"none":          "",
+		"any":           "UPnP or NAT-PMP",
+		"upnp":          "UPnP",
+		"pmp":           "NAT-PMP",
+		"pmp:10.0.0.1":  "NAT-PMP(10.0.0.1)",
+		"extip:1.2.3.4": "ExtIP(1.2.3.4)",
+	} {
+		m, err := Parse(spec)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", spec, err)
+		}
+		if want == "" {
+			if m != nil {
+				t.Fatalf("%q should be nil", spec)
+			}
+			continue
+		}
+		if m.String() != want {
+			t.Fatalf("%q should be %s, but got %s", spec, want, m)
+		}
+	}
+
+	for _, spec := range []string{"extip", "extip:1.2.3", "foo"} {
+		if _, err := Parse(spec); err == nil {
+			t.Fatalf("%q should be invalid", spec)
+		}
+	}
+
+	m, _ := Parse("extip:1.2.3.4")
+	if ip, err := m.ExternalIP(); err != nil || !ip.Equal(net.IP{1, 2, 3, 4}) {
+		t.Fatalf("wrong external IP %s %v", ip, err)
+	}
+}
+
+func TestParseRouteTable(t *testing.T) {
+	const table = `Iface	Destination	Gateway 	Flags	RefCnt	Use	Metric	Mask		MTU	Window	IRTT
+eth0	00000000	0101A8C0	0003	0	0	100	00000000	0	0	0
+eth0	0001A8C0	00000000	0001	0	0	100	00FFFFFF	0	0	0
+`
+	gateways := parseRouteTable(table)
+	if len(gateways) != 1 || !gateways[0].Equal(net.IP{192, 168, 1, 1}) {
+		t.Fatalf("wrong gateways %v", gateways)
+	}
+}
@@ -0,0 +1,286 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nat
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const pmpPort = 5351
+
+// the request is resent with doubled timeout if no response, RFC 6886 section 3.1
+const pmpInitialTimeout = 250 * time.Millisecond
+const pmpMaxTries = 4
+
+// opcodes of NAT-PMP
+const (
+	pmpOpExternalAddress byte = 0
+	pmpOpMapUDP          byte = 1
+	pmpOpMapTCP          byte = 2
+)
+
+var errPMPTimeout = errors.New("NAT-PMP request timeout")
+
+// pmp is a NAT-PMP gateway
+type pmp struct {
+	gateway *net.UDPAddr
+	mu      sync.Mutex // the requests are sent one by one
+}
+
+func newPMP(gateway *net.UDPAddr) *pmp {
+	return &pmp{
+		gateway: gateway,
+	}
+}
+
+func (p *pmp) String() string {
+	return fmt.Sprintf("NAT-PMP(%s)", p.gateway.IP)
+}
+
+// discoverPMP ask the potential gateways concurrently, return the first one responds, or nil
+func discoverPMP() Interface {
+	gateways := potentialGateways()
+	found := make(chan *pmp, len(gateways))
+	for _, ip := range gateways {
+		go func(ip net.IP) {
+			p := newPMP(&net.UDPAddr{IP: ip, Port: pmpPort})
+			if _, err := p.ExternalIP(); err != nil {
+				found <- nil
+			} else {
+				found <- p
+			}
+		}(ip)
+	}
+
+	for range gateways {
+		if p := <-found; p != nil {
+			return p
+		}
+	}
+
+	return nil
+}
+
+// request send msg to the gateway, and return the response of resLen bytes with result code 0
+func (p *pmp) request(msg []byte, resLen int) ([]byte, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	conn, err := net.DialUDP("udp", nil, p.gateway)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	buf := make([]byte, 16)
+	timeout := pmpInitialTimeout
+	for i := 0; i < pmpMaxTries; i++ {
+		if _, err = conn.Write(msg); err != nil {
+			return nil, err
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(timeout))
+
+		for {
+			var n int
+			n, err = conn.Read(buf)
+			if err != nil {
+				break
+			}
+
+			// version 0, response opcode is 128 + request opcode
+			if n < resLen || buf[0] != 0 || buf[1] != msg[1]+128 {
+				continue
+			}
+			if code := binary.BigEndian.Uint16(buf[2:4]); code != 0 {
+				return nil, errors.New(fmt.Sprintf("NAT-PMP result code %d", code))
+			}
+			return buf[:n], nil
+		}
+
+		if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+			return nil, err
+		}
+		timeout *= 2
+	}
+
+	return nil, errPMPTimeout
+}
+
+func (p *pmp) ExternalIP() (net.IP, error) {
+	res, err := p.request([]byte{0, pmpOpExternalAddress}, 12)
+	if err != nil {
+		return nil, err
+	}
+
+	return net.IPv4(res[8], res[9], res[10], res[11]), nil
+}
+
+func (p *pmp) mapPort(protocol string, extport, intport int, lifetime time.Duration) (mapped int, err error) {
+	var op byte
+	switch strings.ToLower(protocol) {
+	case "udp":
+		op = pmpOpMapUDP
+	case "tcp":
+		op = pmpOpMapTCP
+	default:
+		return 0, errors.New(fmt.Sprintf("unknown protocol %s", protocol))
+	}
+
+	msg := make([]byte, 12)
+	msg[1] = op
+	binary.BigEndian.PutUint16(msg[4:6], uint16(intport))
+	binary.BigEndian.PutUint16(msg[6:8], uint16(extport))
+	binary.BigEndian.PutUint32(msg[8:12], uint32(lifetime/time.Second))
+
+	res, err := p.request(msg, 16)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(binary.BigEndian.Uint16(res[10:12])), nil
+}
+
+func (p *pmp) AddMapping(protocol string, extport, intport int, name string, lifetime time.Duration) error {
+	mapped, err := p.mapPort(protocol, extport, intport, lifetime)
+	if err != nil {
+		return err
+	}
+
+	// the advertised port would be wrong
+	if mapped != extport {
+		_ = p.DeleteMapping(protocol, mapped, intport)
+		return errors.New(fmt.Sprintf("port %d is mapped to %d but not %d", intport, mapped, extport))
+	}
+
+	return nil
+}
+
+// DeleteMapping request a mapping with lifetime 0 and external port 0, RFC 6886 section 3.4
+func (p *pmp) DeleteMapping(protocol string, extport, intport int) error {
+	_, err := p.mapPort(protocol, 0, intport, 0)
+	return err
+}
+
+// potentialGateways return the default gateway in route table, and the first address of the private networks
+// this host belongs to
+func potentialGateways() (gateways []net.IP) {
+	gateways = defaultGateways()
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return
+	}
+
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			ipnet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+
+			ip := ipnet.IP.To4()
+			if ip == nil || !isLAN(ip) {
+				continue
+			}
+
+			gateway := ip.Mask(ipnet.Mask)
+			gateway[3] |= 1
+			if !containsIP(gateways, gateway) {
+				gateways = append(gateways, gateway)
+			}
+		}
+	}
+
+	return
+}
+
+// defaultGateways read the default gateways from the route table of linux
+func defaultGateways() (gateways []net.IP) {
+	data, err := ioutil.ReadFile("/proc/net/route")
+	if err != nil {
+		return
+	}
+
+	return parseRouteTable(string(data))
+}
+
+// parseRouteTable return the gateways of the default routes, the addresses are hex of little endian
+func parseRouteTable(table string) (gateways []net.IP) {
+	lines := strings.Split(table, "\n")
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[1] != "00000000" {
+			continue
+		}
+
+		n, err := strconv.ParseUint(fields[2], 16, 32)
+		if err != nil || n == 0 {
+			continue
+		}
+
+		ip := make(net.IP, 4)
+		binary.LittleEndian.PutUint32(ip, uint32(n))
+		if !containsIP(gateways, ip) {
+			gateways = append(gateways, ip)
+		}
+	}
+
+	return
+}
+
+var lanNets = []*net.IPNet{
+	{IP: net.IP{10, 0, 0, 0}, Mask: net.CIDRMask(8, 32)},
+	{IP: net.IP{172, 16, 0, 0}, Mask: net.CIDRMask(12, 32)},
+	{IP: net.IP{192, 168, 0, 0}, Mask: net.CIDRMask(16, 32)},
+}
+
+func isLAN(ip net.IP) bool {
+	for _, n := range lanNets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsIP(list []net.IP, ip net.IP) bool {
+	for _, ip2 := range list {
+		if ip2.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package nat
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakePMP is a NAT-PMP gateway maps external port to internal port + 1, except the same port is requested
+func fakePMP(t *testing.T) (addr *net.UDPAddr, stop func()) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		buf := make([]byte, 16)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < 2 || buf[0] != 0 {
+				continue
+			}
+
+			res := make([]byte, 16)
+			res[1] = buf[1] + 128
+			binary.BigEndian.PutUint32(res[4:8], 100)
+			switch buf[1] {
+			case pmpOpExternalAddress:
+				copy(res[8:12], []byte{203, 0, 113, 9})
+				res = res[:12]
+			case pmpOpMapUDP, pmpOpMapTCP:
+				intport := binary.BigEndian.Uint16(buf[4:6])
+				extport := binary.BigEndian.Uint16(buf[6:8])
+				if extport != 0 && extport != intport {
+					extport = intport + 1
+				}
+				copy(res[8:10], buf[4:6])
+				binary.BigEndian.PutUint16(res[10:12], extport)
+				copy(res[12:16], buf[8:12])
+			default:
+				binary.BigEndian.PutUint16(res[2:4], 5) // unsupported opcode
+			}
+			_, _ = conn.WriteTo(res, from)
+		}
+	}()
+
+	return conn.LocalAddr().(*net.UDPAddr), func() {
+		_ = conn.Close()
+	}
+}
+
+func TestPMP(t *testing.T) {
+	addr, stop := fakePMP(t)
+	defer stop()
+
+	p := newPMP(addr)
+	ip, err := p.ExternalIP()
+	if err != nil || !ip.Equal(net.IP{203, 0, 113, 9}) {
+		t.Fatalf("wrong external IP %s %v", ip, err)
+	}
+
+	if err = p.AddMapping("udp", 8483, 8483, "vite", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.AddMapping("tcp", 8483, 8484, "vite", time.Minute); err == nil {
+		t.Fatal("mapped to a different port should fail")
+	}
+	if err = p.DeleteMapping("udp", 8483, 8483); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = p.request([]byte{0, 9}, 8); err == nil {
+		t.Fatal("unsupported opcode should fail")
+	}
+}
+
+func TestPMP_timeout(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	p := newPMP(conn.LocalAddr().(*net.UDPAddr))
+	if _, err = p.ExternalIP(); err != errPMPTimeout {
+		t.Fatalf("error should be %v, but got %v", errPMPTimeout, err)
+	}
+}
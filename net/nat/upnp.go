@@ -0,0 +1,373 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package nat
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const ssdpAddress = "239.255.255.250:1900"
+const ssdpTimeout = 3 * time.Second
+const soapTimeout = 5 * time.Second
+
+// the UPnP error code means the gateway only supports permanent port mappings
+const upnpOnlyPermanentLeases = 725
+
+var igdDeviceTypes = []string{
+	"urn:schemas-upnp-org:device:InternetGatewayDevice:1",
+	"urn:schemas-upnp-org:device:InternetGatewayDevice:2",
+}
+
+// the services can map ports, from high priority to low
+var wanServiceTypes = []string{
+	"urn:schemas-upnp-org:service:WANIPConnection:2",
+	"urn:schemas-upnp-org:service:WANIPConnection:1",
+	"urn:schemas-upnp-org:service:WANPPPConnection:1",
+}
+
+// upnp is the WANIPConnection or WANPPPConnection service of an Internet Gateway Device
+type upnp struct {
+	serviceType string
+	controlURL  string
+	internalIP  net.IP // the IP of this host in the LAN of gateway
+	client      *http.Client
+}
+
+func (u *upnp) String() string {
+	return "UPnP " + u.serviceType
+}
+
+type upnpRoot struct {
+	URLBase string     `xml:"URLBase"`
+	Device  upnpDevice `xml:"device"`
+}
+
+type upnpDevice struct {
+	DeviceType string        `xml:"deviceType"`
+	Services   []upnpService `xml:"serviceList>service"`
+	Devices    []upnpDevice  `xml:"deviceList>device"`
+}
+
+type upnpService struct {
+	ServiceType string `xml:"serviceType"`
+	ControlURL  string `xml:"controlURL"`
+}
+
+// findService return the service of serviceType in the device tree
+func (d *upnpDevice) findService(serviceType string) *upnpService {
+	for i := range d.Services {
+		if d.Services[i].ServiceType == serviceType {
+			return &d.Services[i]
+		}
+	}
+
+	for i := range d.Devices {
+		if s := d.Devices[i].findService(serviceType); s != nil {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// discoverUPnP search the gateways by SSDP, return the first one works, or nil
+func discoverUPnP() Interface {
+	locations, err := ssdpSearch(ssdpAddress, igdDeviceTypes, ssdpTimeout)
+	if err != nil {
+		natLog.Warn(fmt.Sprintf("failed to search UPnP devices: %v", err))
+		return nil
+	}
+
+	for _, location := range locations {
+		u, err := newUPnP(location)
+		if err != nil {
+			natLog.Info(fmt.Sprintf("skip UPnP device %s: %v", location, err))
+			continue
+		}
+		return u
+	}
+
+	return nil
+}
+
+// ssdpSearch send M-SEARCH of the search targets to addr, return the locations of devices responded in timeout
+func ssdpSearch(addr string, targets []string, timeout time.Duration) (locations []string, err error) {
+	raddr, err := net.ResolveUDPAddr("udp4", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	for _, st := range targets {
+		req := "M-SEARCH * HTTP/1.1\r\n" +
+			"HOST: " + addr + "\r\n" +
+			"ST: " + st + "\r\n" +
+			"MAN: \"ssdp:discover\"\r\n" +
+			"MX: 2\r\n\r\n"
+		if _, err = conn.WriteTo([]byte(req), raddr); err != nil {
+			return nil, err
+		}
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(timeout))
+	seen := make(map[string]struct{})
+	buf := make([]byte, 2048)
+	for {
+		var n int
+		n, _, err = conn.ReadFrom(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				return locations, nil
+			}
+			return locations, err
+		}
+
+		res, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(buf[:n])), nil)
+		if err != nil {
+			continue
+		}
+		location := res.Header.Get("Location")
+		_ = res.Body.Close()
+		if location == "" {
+			continue
+		}
+		if _, ok := seen[location]; !ok {
+			seen[location] = struct{}{}
+			locations = append(locations, location)
+		}
+	}
+}
+
+// newUPnP read the device description at location, and check the port mapping service works
+func newUPnP(location string) (*upnp, error) {
+	client := &http.Client{Timeout: soapTimeout}
+
+	res, err := client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("failed to get device description: %s", res.Status))
+	}
+
+	root := new(upnpRoot)
+	if err = xml.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(root); err != nil {
+		return nil, err
+	}
+
+	var service *upnpService
+	for _, st := range wanServiceTypes {
+		if service = root.Device.findService(st); service != nil {
+			break
+		}
+	}
+	if service == nil {
+		return nil, errors.New("no port mapping service")
+	}
+
+	base, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	if root.URLBase != "" {
+		if base, err = url.Parse(root.URLBase); err != nil {
+			return nil, err
+		}
+	}
+	control, err := base.Parse(service.ControlURL)
+	if err != nil {
+		return nil, err
+	}
+
+	internalIP, err := localIPTo(control.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &upnp{
+		serviceType: service.ServiceType,
+		controlURL:  control.String(),
+		internalIP:  internalIP,
+		client:      client,
+	}
+
+	if _, err = u.ExternalIP(); err != nil {
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// localIPTo return the local IP used to connect host
+func localIPTo(host string) (net.IP, error) {
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "80")
+	}
+
+	conn, err := net.Dial("udp4", host)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return conn.LocalAddr().(*net.UDPAddr).IP, nil
+}
+
+func (u *upnp) AddMapping(protocol string, extport, intport int, name string, lifetime time.Duration) error {
+	args := func(lifetime time.Duration) [][2]string {
+		return [][2]string{
+			{"NewRemoteHost", ""},
+			{"NewExternalPort", strconv.Itoa(extport)},
+			{"NewProtocol", strings.ToUpper(protocol)},
+			{"NewInternalPort", strconv.Itoa(intport)},
+			{"NewInternalClient", u.internalIP.String()},
+			{"NewEnabled", "1"},
+			{"NewPortMappingDescription", name},
+			{"NewLeaseDuration", strconv.Itoa(int(lifetime / time.Second))},
+		}
+	}
+
+	_, err := u.soap("AddPortMapping", args(lifetime))
+	if e, ok := err.(*upnpError); ok && e.Code == upnpOnlyPermanentLeases {
+		_, err = u.soap("AddPortMapping", args(0))
+	}
+
+	return err
+}
+
+func (u *upnp) DeleteMapping(protocol string, extport, intport int) error {
+	_, err := u.soap("DeletePortMapping", [][2]string{
+		{"NewRemoteHost", ""},
+		{"NewExternalPort", strconv.Itoa(extport)},
+		{"NewProtocol", strings.ToUpper(protocol)},
+	})
+
+	return err
+}
+
+func (u *upnp) ExternalIP() (net.IP, error) {
+	res, err := u.soap("GetExternalIPAddress", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	ip := net.ParseIP(strings.TrimSpace(res.ExternalIP))
+	if ip == nil {
+		return nil, errors.New(fmt.Sprintf("invalid external IP %q", res.ExternalIP))
+	}
+
+	return ip, nil
+}
+
+type upnpError struct {
+	Action      string
+	Status      string
+	Code        int
+	Description string
+}
+
+func (e *upnpError) Error() string {
+	return fmt.Sprintf("%s failed: %s, UPnP error %d %s", e.Action, e.Status, e.Code, e.Description)
+}
+
+type soapResponse struct {
+	ExternalIP string `xml:"NewExternalIPAddress"`
+}
+
+type soapFault struct {
+	Code        int    `xml:"detail>UPnPError>errorCode"`
+	Description string `xml:"detail>UPnPError>errorDescription"`
+}
+
+type soapEnvelope struct {
+	Body struct {
+		Fault    *soapFault   `xml:"Fault"`
+		Response soapResponse `xml:",any"`
+	} `xml:"Body"`
+}
+
+// soap invoke the action of service with args
+func (u *upnp) soap(action string, args [][2]string) (*soapResponse, error) {
+	body := new(bytes.Buffer)
+	body.WriteString(`<?xml version="1.0"?>`)
+	body.WriteString(`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><s:Body>`)
+	fmt.Fprintf(body, `<u:%s xmlns:u="%s">`, action, u.serviceType)
+	for _, arg := range args {
+		fmt.Fprintf(body, "<%s>", arg[0])
+		_ = xml.EscapeText(body, []byte(arg[1]))
+		fmt.Fprintf(body, "</%s>", arg[0])
+	}
+	fmt.Fprintf(body, `</u:%s></s:Body></s:Envelope>`, action)
+
+	req, err := http.NewRequest("POST", u.controlURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", `"`+u.serviceType+"#"+action+`"`)
+
+	res, err := u.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	env := new(soapEnvelope)
+	err = xml.Unmarshal(data, env)
+
+	if res.StatusCode != http.StatusOK || env.Body.Fault != nil {
+		e := &upnpError{
+			Action: action,
+			Status: res.Status,
+		}
+		if env.Body.Fault != nil {
+			e.Code, e.Description = env.Body.Fault.Code, env.Body.Fault.Description
+		}
+		return nil, e
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &env.Body.Response, nil
+}
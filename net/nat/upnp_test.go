@@ -0,0 +1,169 @@
+package nat
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const fakeDeviceDescription = `<?xml version="1.0"?>
+<root xmlns="urn:schemas-upnp-org:device-1-0">
+  <device>
+    <deviceType>urn:schemas-upnp-org:device:InternetGatewayDevice:1</deviceType>
+    <deviceList>
+      <device>
+        <deviceType>urn:schemas-upnp-org:device:WANDevice:1</deviceType>
+        <deviceList>
+          <device>
+            <deviceType>urn:schemas-upnp-org:device:WANConnectionDevice:1</deviceType>
+            <serviceList>
+              <service>
+                <serviceType>urn:schemas-upnp-org:service:WANIPConnection:1</serviceType>
+                <controlURL>/ctl/IPConn</controlURL>
+              </service>
+            </serviceList>
+          </device>
+        </deviceList>
+      </device>
+    </deviceList>
+  </device>
+</root>`
+
+// fakeIGD is an Internet Gateway Device only supports permanent port mappings
+type fakeIGD struct {
+	mu       sync.Mutex
+	mappings map[string]string // protocol/port: client:port
+}
+
+func (f *fakeIGD) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/desc.xml":
+		_, _ = w.Write([]byte(fakeDeviceDescription))
+		return
+	case "/ctl/IPConn":
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	data, _ := ioutil.ReadAll(r.Body)
+	body := string(data)
+	arg := func(name string) string {
+		start := strings.Index(body, "<"+name+">")
+		end := strings.Index(body, "</"+name+">")
+		if start < 0 || end < 0 {
+			return ""
+		}
+		return body[start+len(name)+2 : end]
+	}
+
+	action := r.Header.Get("SOAPAction")
+	action = strings.Trim(action[strings.Index(action, "#")+1:], `"`)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var res string
+	switch action {
+	case "GetExternalIPAddress":
+		res = "<NewExternalIPAddress>203.0.113.7</NewExternalIPAddress>"
+	case "AddPortMapping":
+		if arg("NewLeaseDuration") != "0" {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><s:Fault><faultcode>s:Client</faultcode><faultstring>UPnPError</faultstring><detail><UPnPError xmlns="urn:schemas-upnp-org:control-1-0"><errorCode>725</errorCode><errorDescription>OnlyPermanentLeasesSupported</errorDescription></UPnPError></detail></s:Fault></s:Body></s:Envelope>`))
+			return
+		}
+		f.mappings[arg("NewProtocol")+"/"+arg("NewExternalPort")] = arg("NewInternalClient") + ":" + arg("NewInternalPort")
+	case "DeletePortMapping":
+		delete(f.mappings, arg("NewProtocol")+"/"+arg("NewExternalPort"))
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, `<?xml version="1.0"?><s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/"><s:Body><u:%sResponse xmlns:u="urn:schemas-upnp-org:service:WANIPConnection:1">%s</u:%sResponse></s:Body></s:Envelope>`, action, res, action)
+}
+
+// fakeSSDP responds the M-SEARCH with location
+func fakeSSDP(t *testing.T, location string) (addr string, stop func()) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		buf := make([]byte, 2048)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if !strings.HasPrefix(string(buf[:n]), "M-SEARCH") || !strings.Contains(string(buf[:n]), "InternetGatewayDevice:1") {
+				continue
+			}
+			res := "HTTP/1.1 200 OK\r\nCACHE-CONTROL: max-age=120\r\nST: urn:schemas-upnp-org:device:InternetGatewayDevice:1\r\nLOCATION: " + location + "\r\n\r\n"
+			_, _ = conn.WriteTo([]byte(res), from)
+		}
+	}()
+
+	return conn.LocalAddr().String(), func() {
+		_ = conn.Close()
+	}
+}
+
+func TestUPnP(t *testing.T) {
+	igd := &fakeIGD{
+		mappings: make(map[string]string),
+	}
+	server := httptest.NewServer(igd)
+	defer server.Close()
+
+	addr, stop := fakeSSDP(t, server.URL+"/desc.xml")
+	defer stop()
+
+	locations, err := ssdpSearch(addr, igdDeviceTypes, 500*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(locations) != 1 || locations[0] != server.URL+"/desc.xml" {
+		t.Fatalf("wrong locations %v", locations)
+	}
+
+	u, err := newUPnP(locations[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.serviceType != "urn:schemas-upnp-org:service:WANIPConnection:1" || u.controlURL != server.URL+"/ctl/IPConn" {
+		t.Fatalf("wrong service %s %s", u.serviceType, u.controlURL)
+	}
+
+	ip, err := u.ExternalIP()
+	if err != nil || !ip.Equal(net.IP{203, 0, 113, 7}) {
+		t.Fatalf("wrong external IP %s %v", ip, err)
+	}
+
+	// retry with permanent lease
+	if err = u.AddMapping("tcp", 8483, 8484, "vite", mapLifetime); err != nil {
+		t.Fatal(err)
+	}
+	if client := igd.mappings["TCP/8483"]; client != "127.0.0.1:8484" {
+		t.Fatalf("wrong mapping %s", client)
+	}
+
+	if err = u.DeleteMapping("tcp", 8483, 8484); err != nil {
+		t.Fatal(err)
+	}
+	if len(igd.mappings) != 0 {
+		t.Fatal("mapping should be deleted")
+	}
+
+	if _, err = u.soap("Unknown", nil); err == nil {
+		t.Fatal("unknown action should fail")
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/vitelabs/go-vite/ledger"
 
+	"github.com/vitelabs/go-vite/net/nat"
 	"github.com/vitelabs/go-vite/net/netool"
 
 	"github.com/vitelabs/go-vite/vitepb"
@@ -34,6 +35,7 @@ var errNetIsRunning = errors.New("network is already running")
 var errNetIsNotRunning = errors.New("network is not running")
 
 const maxNeighbors = 100
+const natCheckInterval = 5 * time.Minute
 const DBDirName = "db"
 
 type net struct {
@@ -45,6 +47,9 @@ type net struct {
 
 	discover *discovery.Discovery
 
+	nat     nat.Interface // nil if NAT traversal is disabled
+	natTerm chan struct{}
+
 	db *database.DB
 
 	dialer       _net.Dialer
@@ -69,6 +74,7 @@ type net struct {
 	handlers *msgHandlers
 	query    *queryHandler
 	hb       *heartBeater
+	light    *lightClient // not nil in light mode
 
 	blackList netool.BlackList
 
@@ -125,7 +131,7 @@ func (n *net) ConnectNode(node *vnode.Node) (err error) {
 		return
 	}
 
-	if n.blackList.Banned(node.ID.Bytes()) {
+	if n.blackList.Banned(node.ID.Bytes()) || n.peers.scores.banned(node.ID) {
 		return fmt.Errorf("node %s has been banned", node.ID)
 	}
 
@@ -161,14 +167,22 @@ func (n *net) onConnection(conn _net.Conn, id peerId, inbound bool) {
 		return
 	}
 
+	if n.finder.isStatic(their.ID) {
+		flag |= PeerFlagStatic
+	}
+	if n.finder.isTrusted(their.ID) {
+		flag |= PeerFlagTrusted
+	}
+
 	var fileAddress, publicAddress string
 	addr := conn.RemoteAddr()
 	if tcpAddr, ok := addr.(*_net.TCPAddr); ok {
-		publicAddress = extractAddress(tcpAddr, their.FileAddress, 8483)
+		publicAddress = extractAddress(tcpAddr, their.PublicAddress, 8483)
 		fileAddress = extractAddress(tcpAddr, their.FileAddress, 8484)
 	}
 
 	peer := newPeer(c, their, publicAddress, fileAddress, superior, flag, n.peers, n.handlers)
+	peer.serveLimiter = netool.NewRateLimiter(n.config.ServeRateLimit, n.config.ServeRateLimit)
 
 	// run peer
 	_ = n.onPeerAdded(peer)
@@ -187,6 +201,11 @@ func (n *net) authorize(c Codec, flag PeerFlag, msg *HandshakeMsg) (superior boo
 		return
 	}
 
+	if n.peers.scores.banned(msg.ID) {
+		err = PeerBanned
+		return
+	}
+
 	// is deny
 	var id = msg.ID.String()
 	var key string
@@ -208,6 +227,11 @@ func (n *net) authorize(c Codec, flag PeerFlag, msg *HandshakeMsg) (superior boo
 		}
 	}
 
+	// trusted
+	if n.finder.isTrusted(msg.ID) {
+		return
+	}
+
 	// whitelist
 	for _, key2 := range n.config.AccessAllowKeys {
 		if key2 == id || key2 == key {
@@ -215,6 +239,12 @@ func (n *net) authorize(c Codec, flag PeerFlag, msg *HandshakeMsg) (superior boo
 		}
 	}
 
+	// private network, only static nodes can connect besides the trusted nodes and whitelist
+	if n.config.AccessControl == config.AccessControlPrivate && false == n.finder.isStatic(msg.ID) {
+		err = PeerNoPermission
+		return
+	}
+
 	// producer
 	if superior {
 		return
@@ -231,7 +261,7 @@ func (n *net) authorize(c Codec, flag PeerFlag, msg *HandshakeMsg) (superior boo
 		return
 	}
 
-	if n.config.AccessControl == "any" {
+	if n.config.AccessControl == "any" || n.config.AccessControl == config.AccessControlPrivate {
 		return
 	}
 
@@ -305,6 +335,12 @@ func (n *net) onPeerAdded(peer *Peer) (err error) {
 
 	if err = peer.run(); err != nil {
 		n.blackList.Ban(peer.Id.Bytes(), 10)
+		switch err {
+		case PeerInvalidBlock:
+			n.peers.scores.invalidBlock(peer.Id)
+		case PeerResponseTimeout:
+			n.peers.scores.timeout(peer.Id)
+		}
 		n.log.Warn(fmt.Sprintf("peer %s run done: %v", peer, err))
 	} else {
 		n.log.Info(fmt.Sprintf("peer %s run done", peer))
@@ -405,8 +441,9 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		id:      id,
 		peerKey: peerKey,
 		mineKey: cfg.MineKey,
+		encrypt: cfg.EncryptTransport,
 	}
-	downloader := newExecutor(50, 10, peers, syncConnFac)
+	downloader := newExecutor(50, 10, peers, chain, syncConnFac)
 
 	reader := newCacheReader(chain, verifier, downloader, irreader, blackHashList)
 
@@ -434,7 +471,7 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		fetcher:         fetcher,
 		broadcaster:     broadcaster,
 		downloader:      downloader,
-		syncServer:      newSyncServer(cfg.ListenInterface+":"+strconv.Itoa(cfg.FilePort), chain, syncConnFac),
+		syncServer:      newSyncServer(cfg.ListenInterface+":"+strconv.Itoa(cfg.FilePort), chain, syncConnFac, cfg.UploadRateLimit),
 		handlers:        newHandlers("vite"),
 		hb:              newHeartBeater(peers, chain),
 		blackList: netool.NewBlackList(func(t int64, count int) bool {
@@ -474,6 +511,7 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 			readTimeout:       readMsgTimeout,
 			writeTimeout:      writeMsgTimeout,
 		},
+		encrypt:      cfg.EncryptTransport,
 		chain:        chain,
 		blackList:    n.blackList,
 		onHandshaker: n.authorize,
@@ -484,8 +522,20 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		return nil, err
 	}
 
-	if cfg.Discover {
-		n.discover = discovery.New(peerKey, n.node, cfg.BootNodes, cfg.BootSeeds, cfg.ListenInterface+":"+strconv.Itoa(cfg.Port), n.db)
+	// load the bans, and disconnect the peer once it is banned
+	peers.scores.setStore(n.db)
+	peers.scores.onBan = func(id peerId) {
+		if p := peers.get(id); p != nil {
+			p.catch(PeerBanned)
+		}
+	}
+
+	if n.nat, err = nat.Parse(cfg.NAT); err != nil {
+		return nil, err
+	}
+
+	if cfg.Discover && cfg.AccessControl != config.AccessControlPrivate {
+		n.discover = discovery.New(peerKey, n.node, cfg.BootNodes, cfg.BootSeeds, cfg.BootTrees, cfg.ListenInterface+":"+strconv.Itoa(cfg.Port), n.db)
 	}
 
 	var addr types.Address
@@ -493,7 +543,7 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		addr = types.PubkeyToAddress(n.config.MineKey.PubByte())
 	}
 
-	n.finder, err = newFinder(addr, n.peers, cfg.MinPeers, cfg.StaticNodes, n.db, n, consensus)
+	n.finder, err = newFinder(addr, n.peers, cfg.MinPeers, cfg.StaticNodes, cfg.TrustedNodes, cfg.AccessControl == config.AccessControlPrivate, n.db, n, consensus)
 	if err != nil {
 		return nil, err
 	}
@@ -528,7 +578,37 @@ func New(cfg *config.Net, chain Chain, verifier Verifier, consensus Consensus, i
 		panic(fmt.Errorf("cannot register handler: query: %v", err))
 	}
 
-	// CodeNewSnapshotBlock, CodeNewAccountBlock
+	if cfg.Light {
+		// sync headers from the highest trusted block
+		var checkpoint *ledger.HashHeight
+		if len(confirmedHashList) > 0 {
+			checkpoint = confirmedHashList[0]
+		}
+
+		// the producers are proved by the headers from the genesis registrations or the trusted producers
+		genesis := chain.GetGenesisSnapshotBlock()
+		var schedule *lightSchedule
+		if checkpoint != nil && checkpoint.Height > genesis.Height {
+			schedule, err = newCheckpointSchedule(checkpoint, cfg.LightProducers)
+		} else {
+			schedule, err = newGenesisSchedule(chain, genesis)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		n.light = newLightClient(genesis, checkpoint, peers, schedule, chain)
+		n.light.setStore(n.db)
+
+		// CodeSnapshotBlocks, CodeAccountBlocks, CodeException
+		if err = n.handlers.register(n.light); err != nil {
+			panic(fmt.Errorf("cannot register handler: light: %v", err))
+		}
+
+		return n, nil
+	}
+
+	// CodeNewSnapshotBlock, CodeNewAccountBlock, CodeNewAccountBlockHashes, CodeGetNewAccountBlocks
 	if err = n.handlers.register(broadcaster); err != nil {
 		panic(fmt.Errorf("cannot register handler: broadcaster: %v", err))
 	}
@@ -677,24 +757,38 @@ func (n *net) Start() (err error) {
 		n.wg.Add(1)
 		go n.listenLoop()
 
-		if err = n.syncServer.start(); err != nil {
-			return
+		if n.nat != nil {
+			n.natTerm = make(chan struct{})
+			n.wg.Add(1)
+			go n.natLoop(n.natTerm)
 		}
 
 		n.finder.start()
 
+		n.query.start()
+
+		n.wg.Add(1)
+		go n.beatLoop()
+
+		// light node has no ledger to serve, and only sync headers
+		if n.light != nil {
+			n.light.start()
+			return
+		}
+
+		if err = n.syncServer.start(); err != nil {
+			return
+		}
+
 		n.downloader.start()
 
 		n.reader.start()
 
-		n.query.start()
-
 		n.fetcher.start()
 
-		go n.syncer.checkLoop(&n.running)
+		n.broadcaster.start()
 
-		n.wg.Add(1)
-		go n.beatLoop()
+		go n.syncer.checkLoop(&n.running)
 
 		return
 	}
@@ -710,20 +804,30 @@ func (n *net) Stop() error {
 
 		_ = n.listener.Close()
 
-		n.reader.stop()
+		if n.natTerm != nil {
+			close(n.natTerm)
+		}
 
-		n.syncer.stop()
+		if n.light != nil {
+			n.light.stop()
+		} else {
+			n.reader.stop()
 
-		n.downloader.stop()
+			n.syncer.stop()
 
-		_ = n.syncServer.stop()
+			n.downloader.stop()
+
+			_ = n.syncServer.stop()
+
+			n.fetcher.stop()
+
+			n.broadcaster.stop()
+		}
 
 		n.finder.stop()
 
 		n.query.stop()
 
-		n.fetcher.stop()
-
 		n.finder.clean()
 
 		n.wg.Wait()
@@ -733,6 +837,79 @@ func (n *net) Stop() error {
 	return errNetIsNotRunning
 }
 
+// natLoop map the ports on the gateway until term is closed, and advertise the external IP to other nodes.
+// The addresses set in config will not be mapped or changed.
+func (n *net) natLoop(term <-chan struct{}) {
+	defer n.wg.Done()
+
+	var wg sync.WaitGroup
+	mapPort := func(protocol string, port int, name string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nat.Map(n.nat, term, protocol, port, port, name)
+		}()
+	}
+
+	if n.config.PublicAddress == "" {
+		mapPort("tcp", n.config.Port, "vite p2p")
+		if n.discover != nil {
+			mapPort("udp", n.config.Port, "vite discovery")
+		}
+	}
+	if n.config.FilePublicAddress == "" && n.light == nil {
+		mapPort("tcp", n.config.FilePort, "vite file sync")
+	}
+
+	// the external IP of gateway may change
+	ticker := time.NewTicker(natCheckInterval)
+	defer ticker.Stop()
+
+	var extIP _net.IP
+	for {
+		if ip, err := n.nat.ExternalIP(); err != nil {
+			n.log.Warn(fmt.Sprintf("failed to get external IP by %s: %v", n.nat, err))
+		} else if !ip.Equal(extIP) {
+			extIP = ip
+			n.setExternalIP(ip)
+		}
+
+		select {
+		case <-term:
+			wg.Wait()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// setExternalIP advertise the external IP in discovery and handshake
+func (n *net) setExternalIP(ip _net.IP) {
+	n.log.Info(fmt.Sprintf("external IP is %s", ip))
+
+	var ep vnode.EndPoint
+	if ip4 := ip.To4(); ip4 != nil {
+		ep.Host, ep.Typ = ip4, vnode.HostIPv4
+	} else {
+		ep.Host, ep.Typ = ip, vnode.HostIPv6
+	}
+
+	var publicAddress, fileAddress []byte
+	if n.config.PublicAddress == "" {
+		ep.Port = n.config.Port
+		publicAddress, _ = ep.Serialize()
+		if n.discover != nil {
+			n.discover.SetEndPoint(ep)
+		}
+	}
+	if n.config.FilePublicAddress == "" {
+		ep.Port = n.config.FilePort
+		fileAddress, _ = ep.Serialize()
+	}
+
+	n.hkr.setAddress(publicAddress, fileAddress)
+}
+
 func (n *net) Nodes() []*vnode.Node {
 	return n.discover.Nodes()
 }
@@ -741,6 +918,48 @@ func (n *net) PeerKey() ed25519.PrivateKey {
 	return n.peerKey
 }
 
+func (n *net) Light() LightClient {
+	if n.light == nil {
+		return nil
+	}
+	return n.light
+}
+
+func (n *net) PeerScores() []PeerScore {
+	return n.peers.scores.list()
+}
+
+func (n *net) BanNode(id vnode.NodeID, duration time.Duration) {
+	n.peers.scores.ban(id, duration)
+}
+
+func (n *net) UnbanNode(id vnode.NodeID) {
+	n.peers.scores.unban(id)
+	n.blackList.UnBan(id.Bytes())
+}
+
+func (n *net) AddTrustedPeer(node *vnode.Node) error {
+	if node.ID == n.node.ID {
+		return PeerConnectSelf
+	}
+
+	if err := n.db.StoreTrustedNode(node); err != nil {
+		return err
+	}
+	n.finder.addStaticNode(node, true)
+
+	return nil
+}
+
+func (n *net) RemovePeer(id vnode.NodeID) {
+	n.finder.removeStaticNode(id)
+	n.db.RemoveTrustedNode(id)
+
+	if p := n.peers.get(id); p != nil {
+		p.catch(PeerQuitting)
+	}
+}
+
 func (n *net) PeerCount() int {
 	return n.peers.count()
 }
@@ -762,7 +981,12 @@ func (n *net) Info() NodeInfo {
 		Server:                FileServerStatus{},
 	}
 
-	if n.syncServer != nil {
+	if n.light != nil {
+		info.Mode = vnode.Light.String()
+		if head := n.light.LatestHeader(); head != nil {
+			info.Height = head.Height
+		}
+	} else if n.syncServer != nil {
 		info.Server = n.syncServer.status()
 	}
 
@@ -782,4 +1006,5 @@ type NodeInfo struct {
 	Latency               []int64          `json:"latency"` // [0,1,12,24]
 	BroadCheckFailedRatio float32          `json:"broadCheckFailedRatio"`
 	Server                FileServerStatus `json:"server"`
+	Mode                  string           `json:"mode,omitempty"`
 }
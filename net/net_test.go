@@ -0,0 +1,70 @@
+package net
+
+import (
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/net/vnode"
+)
+
+func newAuthorizeTestNet(accessControl string, maxPeers int) *net {
+	return &net{
+		config: &config.Net{
+			AccessControl:   accessControl,
+			MaxPeers:        maxPeers,
+			MaxInboundRatio: 2,
+		},
+		node:  &vnode.Node{ID: vnode.RandomNodeID()},
+		peers: newPeerSet(),
+		finder: &finder{
+			staticNodes: make(map[peerId]*vnode.Node),
+			trusted:     make(map[peerId]struct{}),
+			sbps:        make(map[types.Address]int64),
+		},
+	}
+}
+
+func TestNet_authorize_private(t *testing.T) {
+	n := newAuthorizeTestNet(config.AccessControlPrivate, 10)
+
+	static, trusted, allowed := vnode.RandomNodeID(), vnode.RandomNodeID(), vnode.RandomNodeID()
+	n.finder.staticNodes[static] = &vnode.Node{ID: static}
+	n.finder.staticNodes[trusted] = &vnode.Node{ID: trusted}
+	n.finder.trusted[trusted] = struct{}{}
+	n.config.AccessAllowKeys = []string{allowed.String()}
+
+	for _, id := range []peerId{static, trusted, allowed} {
+		if _, err := n.authorize(nil, PeerFlagInbound, &HandshakeMsg{ID: id}); err != nil {
+			t.Fatalf("node %s should be authorized: %v", id, err)
+		}
+	}
+
+	if _, err := n.authorize(nil, PeerFlagInbound, &HandshakeMsg{ID: vnode.RandomNodeID()}); err != PeerNoPermission {
+		t.Fatalf("unknown node should not be authorized in private network: %v", err)
+	}
+}
+
+func TestNet_authorize_trusted(t *testing.T) {
+	n := newAuthorizeTestNet(config.DefaultAccessControl, 2)
+
+	for i := 0; i < 2; i++ {
+		_ = n.peers.add(&Peer{Id: vnode.RandomNodeID(), Flag: PeerFlagOutbound})
+	}
+	if _, err := n.authorize(nil, PeerFlagInbound, &HandshakeMsg{ID: vnode.RandomNodeID()}); err != PeerTooManyPeers {
+		t.Fatalf("node should not be authorized if the peers are full: %v", err)
+	}
+
+	trusted := vnode.RandomNodeID()
+	n.finder.staticNodes[trusted] = &vnode.Node{ID: trusted}
+	n.finder.trusted[trusted] = struct{}{}
+	if _, err := n.authorize(nil, PeerFlagInbound, &HandshakeMsg{ID: trusted}); err != nil {
+		t.Fatalf("trusted node should be authorized even if the peers are full: %v", err)
+	}
+
+	// the trusted peers are not counted
+	_ = n.peers.add(&Peer{Id: trusted, Flag: PeerFlagInbound | PeerFlagTrusted})
+	if c := n.peers.countWithoutSBP(); c != 2 {
+		t.Fatalf("trusted peer should not be counted: %d", c)
+	}
+}
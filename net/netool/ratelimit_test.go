@@ -0,0 +1,30 @@
+package netool
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	var nl *RateLimiter
+	if !nl.Allow() || nl.Take(1000) != 0 {
+		t.Fatal("nil limiter should not limit")
+	}
+
+	l := NewRateLimiter(1000, 2000)
+	if d := l.Take(1500); d != 0 {
+		t.Fatalf("should not wait for burst tokens, but wait %s", d)
+	}
+	if !l.Allow() {
+		t.Fatal("should allow when tokens left")
+	}
+
+	// 1000 tokens in debt
+	d := l.Take(1500)
+	if d < 900*time.Millisecond || d > time.Second {
+		t.Fatalf("wrong wait duration %s", d)
+	}
+	if l.Allow() {
+		t.Fatal("should not allow in debt")
+	}
+}
@@ -11,8 +11,6 @@ import (
 	"sync/atomic"
 	"time"
 
-	"github.com/vitelabs/go-vite/common/bloom"
-
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
@@ -81,6 +79,9 @@ type PeerInfo struct {
 	ReadQueue  int      `json:"readQueue"`
 	WriteQueue int      `json:"writeQueue"`
 	Peers      []string `json:"peers"`
+
+	Traffic     TrafficInfo            `json:"traffic"`
+	CodeTraffic map[string]TrafficInfo `json:"codeTraffic"` // key is the message code
 }
 
 type PeerFlag byte
@@ -89,6 +90,7 @@ const (
 	PeerFlagInbound  PeerFlag = 0
 	PeerFlagOutbound PeerFlag = 1
 	PeerFlagStatic   PeerFlag = 1 << 1
+	PeerFlagTrusted  PeerFlag = 1 << 2
 )
 
 func (f PeerFlag) is(f2 PeerFlag) bool {
@@ -132,7 +134,10 @@ type Peer struct {
 	manager PeerManager
 	handler msgHandler
 
-	knownBlocks *bloom.Filter
+	knownBlocks *knownHashes // hashes of blocks the peer has, no need to send them again
+
+	traffic      *trafficMeter
+	serveLimiter *netool.RateLimiter // limit the responses to the queries of peer, nil means no limit
 
 	m  map[peerId]struct{}
 	m2 map[peerId]struct{} // MUST NOT write m2, only read, for cross peers
@@ -209,6 +214,8 @@ func (p *Peer) Info() PeerInfo {
 		ps = ps[:i]
 	}
 
+	traffic, codeTraffic := p.traffic.info()
+
 	return PeerInfo{
 		Id:         p.Id.String(),
 		Name:       p.Name,
@@ -222,6 +229,9 @@ func (p *Peer) Info() PeerInfo {
 		ReadQueue:  len(p.readQueue),
 		WriteQueue: len(p.writeQueue),
 		Peers:      ps,
+
+		Traffic:     traffic,
+		CodeTraffic: codeTraffic,
 	}
 }
 
@@ -251,7 +261,8 @@ func newPeer(c Codec, their *HandshakeMsg, publicAddress, fileAddress string, su
 		wg:            sync.WaitGroup{},
 		manager:       manager,
 		handler:       handler,
-		knownBlocks:   bloom.New(filterCap, rt),
+		knownBlocks:   newKnownHashes(maxKnownHashes),
+		traffic:       newTrafficMeter(),
 		m:             make(map[peerId]struct{}),
 		m2:            nil,
 		once:          sync.Once{},
@@ -297,6 +308,7 @@ func (p *Peer) readLoop() (err error) {
 
 		msg.ReceivedAt = time.Now().Unix()
 		msg.Sender = p
+		p.traffic.in(msg.Code, len(msg.Payload))
 
 		switch msg.Code {
 		case CodeDisconnect:
@@ -322,6 +334,11 @@ func (p *Peer) writeLoop() (err error) {
 			p.stopWrite(fmt.Errorf("failed to write msg %d %d bytes: %v", msg.Code, len(msg.Payload), err))
 			return
 		}
+
+		p.traffic.out(msg.Code, len(msg.Payload))
+		if isQueryResponse(msg.Code) {
+			p.serveLimiter.Take(int64(len(msg.Payload)))
+		}
 	}
 
 	return nil
@@ -495,6 +512,8 @@ type peerSet struct {
 	prw sync.RWMutex
 
 	subs []chan<- peerEvent
+
+	scores *peerScores
 }
 
 func (m *peerSet) reliable() (l peers) {
@@ -540,7 +559,8 @@ func (m *peerSet) pickDownloadPeers(height uint64) (m2 map[peerId]*Peer) {
 
 func newPeerSet() *peerSet {
 	return &peerSet{
-		m: make(map[peerId]*Peer),
+		m:      make(map[peerId]*Peer),
+		scores: newPeerScores(),
 	}
 }
 
@@ -661,17 +681,17 @@ func (m *peerSet) pick(height uint64) (ps peers) {
 	return
 }
 
+// pickReliable pick reliable peers satisfy p.height() >= height, sort by score from high to low
 func (m *peerSet) pickReliable(height uint64) (ps peers) {
 	m.prw.RLock()
-	defer m.prw.RUnlock()
-
 	for _, p := range m.m {
 		if p.Height >= height && atomic.LoadInt32(&p.reliable) == 1 {
 			ps = append(ps, p)
 		}
 	}
+	m.prw.RUnlock()
 
-	return
+	return m.scores.sort(ps)
 }
 
 // peers return all peers sort from low to high
@@ -754,12 +774,13 @@ func (m *peerSet) count() int {
 	return len(m.m)
 }
 
+// countWithoutSBP count the peers without producers and trusted peers
 func (m *peerSet) countWithoutSBP() (n int) {
 	m.prw.RLock()
 	defer m.prw.RUnlock()
 
 	for _, p := range m.m {
-		if p.Superior {
+		if p.Superior || p.Flag.is(PeerFlagTrusted) {
 			continue
 		}
 		n++
@@ -773,7 +794,7 @@ func (m *peerSet) inboundWithoutSBP() (n int) {
 	defer m.prw.RUnlock()
 
 	for _, p := range m.m {
-		if p.Superior {
+		if p.Superior || p.Flag.is(PeerFlagTrusted) {
 			continue
 		}
 		if p.Flag.is(PeerFlagInbound) {
@@ -41,6 +41,7 @@ const (
 	PeerInvalidMessage
 	PeerResponseTimeout
 	PeerInvalidToken
+	PeerInvalidEphemeralKey
 	PeerUnknownReason PeerError = 255
 )
 
@@ -65,6 +66,7 @@ var peerErrStr = map[PeerError]string{
 	PeerInvalidMessage:      "invalid message",
 	PeerResponseTimeout:     "response timeout",
 	PeerInvalidToken:        "invalid token",
+	PeerInvalidEphemeralKey: "invalid ephemeral key",
 	PeerUnknownReason:       "unknown reason",
 }
 
@@ -96,15 +98,17 @@ const (
 	ExpServerError
 	ExpChunkNotMatch
 	ExpOther
+	ExpTooManyRequests // the requests exceed the rate limit
 )
 
 var exception = map[Exception]string{
-	ExpMissing:       "missing resource",
-	ExpUnsolicited:   "unsolicited request",
-	ExpUnauthorized:  "unauthorized",
-	ExpServerError:   "server error",
-	ExpOther:         "other exception",
-	ExpChunkNotMatch: "chunk not match",
+	ExpMissing:         "missing resource",
+	ExpUnsolicited:     "unsolicited request",
+	ExpUnauthorized:    "unauthorized",
+	ExpServerError:     "server error",
+	ExpOther:           "other exception",
+	ExpChunkNotMatch:   "chunk not match",
+	ExpTooManyRequests: "too many requests",
 }
 
 func (exp Exception) String() string {
@@ -0,0 +1,351 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/net/vnode"
+)
+
+// score changes of peer behaviours
+const (
+	scoreInvalidBlock = -50
+	scoreTimeout      = -5
+	scoreDelivered    = 1
+)
+
+const maxPeerScore = 100
+
+// peer will be banned temporarily when the score is not higher than it
+const banPeerScore = -80
+
+// score decays to zero, the half of it is left after scoreHalfLife
+const scoreHalfLife = 30 * time.Minute
+
+// the temporary ban duration is doubled every time the peer is banned, until maxTempBanDuration
+const tempBanDuration = time.Hour
+const maxTempBanDuration = 24 * time.Hour
+
+// permanentBanExpiration is the expiration of permanent bans
+const permanentBanExpiration = math.MaxInt64
+
+// the scores kept in memory, the neutral scores are evicted when it is full,
+// the banned peers are never evicted.
+const maxPeerScores = 10000
+
+// a score whose absolute value is less than it is neutral
+const neutralPeerScore = 1
+
+// PeerScore is the reputation of a peer, combined from invalid blocks, timeouts, latency and bandwidth
+type PeerScore struct {
+	ID            vnode.NodeID `json:"id"`
+	Score         float64      `json:"score"`
+	InvalidBlocks uint64       `json:"invalidBlocks"`
+	Timeouts      uint64       `json:"timeouts"`
+	Delivered     uint64       `json:"delivered"`
+	Latency       string       `json:"latency"`   // average latency of responses
+	Bandwidth     string       `json:"bandwidth"` // download speed of the sync connection
+	Bans          int          `json:"bans"`
+	BannedUntil   int64        `json:"bannedUntil,omitempty"` // unix timestamp, 0 means not banned
+	Permanent     bool         `json:"permanent,omitempty"`
+}
+
+type peerScore struct {
+	score    float64
+	updateAt time.Time
+
+	invalidBlocks uint64
+	timeouts      uint64
+	delivered     uint64
+	latency       time.Duration // exponential moving average
+	bandwidth     uint64        // byte/s
+
+	bans        int
+	bannedUntil int64
+}
+
+// decay the score by the time since last update
+func (s *peerScore) decay(now time.Time) {
+	if elapsed := now.Sub(s.updateAt); elapsed > 0 && s.score != 0 {
+		s.score *= math.Pow(0.5, float64(elapsed)/float64(scoreHalfLife))
+	}
+	s.updateAt = now
+}
+
+type banStore interface {
+	BlockId(id vnode.NodeID, expiration int64)
+	UnblockId(id vnode.NodeID)
+	ReadBlockedIds() map[vnode.NodeID]int64
+}
+
+// peerScores keeps the scores of peers in memory, and the bans in banStore.
+// onBan is invoked when a peer is banned, to disconnect it.
+type peerScores struct {
+	mu    sync.Mutex
+	m     map[peerId]*peerScore
+	store banStore
+	onBan func(id peerId)
+
+	log log15.Logger
+}
+
+func newPeerScores() *peerScores {
+	return &peerScores{
+		m:   make(map[peerId]*peerScore),
+		log: netLog.New("module", "score"),
+	}
+}
+
+// setStore load the bans from store, and persist bans to it later
+func (ps *peerScores) setStore(store banStore) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	ps.store = store
+	for id, expiration := range store.ReadBlockedIds() {
+		s := ps.getLocked(id)
+		s.bannedUntil = expiration
+	}
+}
+
+func (ps *peerScores) getLocked(id peerId) *peerScore {
+	s, ok := ps.m[id]
+	if !ok {
+		now := time.Now()
+		if len(ps.m) >= maxPeerScores {
+			ps.evictLocked(now)
+		}
+
+		s = &peerScore{
+			updateAt: now,
+		}
+		ps.m[id] = s
+	}
+	return s
+}
+
+// evictLocked remove the neutral scores of the peers not banned, the scores decay to neutral in a few scoreHalfLife.
+// If there is no neutral score, the score closest to neutral is removed.
+func (ps *peerScores) evictLocked(now time.Time) {
+	var weakest peerId
+	var weakestScore = math.Inf(1)
+	var evicted bool
+
+	for id, s := range ps.m {
+		if s.bannedUntil >= now.Unix() {
+			continue
+		}
+
+		s.decay(now)
+		if abs := math.Abs(s.score); abs < neutralPeerScore {
+			delete(ps.m, id)
+			evicted = true
+		} else if abs < weakestScore {
+			weakest, weakestScore = id, abs
+		}
+	}
+
+	if !evicted && !math.IsInf(weakestScore, 1) {
+		delete(ps.m, weakest)
+	}
+}
+
+func (ps *peerScores) add(id peerId, delta float64, fn func(s *peerScore)) {
+	now := time.Now()
+
+	ps.mu.Lock()
+	s := ps.getLocked(id)
+	s.decay(now)
+	s.score += delta
+	if s.score > maxPeerScore {
+		s.score = maxPeerScore
+	}
+	if fn != nil {
+		fn(s)
+	}
+
+	var banned bool
+	if s.score <= banPeerScore && s.bannedUntil < now.Unix() {
+		duration := tempBanDuration << uint(s.bans)
+		if duration > maxTempBanDuration || duration <= 0 {
+			duration = maxTempBanDuration
+		}
+		ps.banLocked(id, s, now.Add(duration).Unix())
+		// the peer is still untrusted after the ban
+		s.score = banPeerScore / 2
+		banned = true
+	}
+	ps.mu.Unlock()
+
+	if banned {
+		ps.log.Warn(fmt.Sprintf("ban peer %s temporarily", id))
+		if ps.onBan != nil {
+			ps.onBan(id)
+		}
+	}
+}
+
+func (ps *peerScores) banLocked(id peerId, s *peerScore, expiration int64) {
+	s.bans++
+	s.bannedUntil = expiration
+	if ps.store != nil {
+		ps.store.BlockId(id, expiration)
+	}
+}
+
+func (ps *peerScores) invalidBlock(id peerId) {
+	ps.add(id, scoreInvalidBlock, func(s *peerScore) {
+		s.invalidBlocks++
+	})
+}
+
+func (ps *peerScores) timeout(id peerId) {
+	ps.add(id, scoreTimeout, func(s *peerScore) {
+		s.timeouts++
+	})
+}
+
+// delivered means the peer responds in latency
+func (ps *peerScores) delivered(id peerId, latency time.Duration) {
+	ps.add(id, scoreDelivered, func(s *peerScore) {
+		s.delivered++
+		if s.latency == 0 {
+			s.latency = latency
+		} else {
+			s.latency = (s.latency*7 + latency) / 8
+		}
+	})
+}
+
+// bandwidth record the download speed, faster peers get higher scores
+func (ps *peerScores) bandwidth(id peerId, speed uint64) {
+	var delta float64
+	if speed > 1024*1024 {
+		delta = scoreDelivered
+	}
+
+	ps.add(id, delta, func(s *peerScore) {
+		s.bandwidth = speed
+	})
+}
+
+// ban the peer until now + duration, duration 0 means permanently
+func (ps *peerScores) ban(id peerId, duration time.Duration) {
+	var expiration int64 = permanentBanExpiration
+	if duration > 0 {
+		expiration = time.Now().Add(duration).Unix()
+	}
+
+	ps.mu.Lock()
+	ps.banLocked(id, ps.getLocked(id), expiration)
+	ps.mu.Unlock()
+
+	if ps.onBan != nil {
+		ps.onBan(id)
+	}
+}
+
+func (ps *peerScores) unban(id peerId) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if s, ok := ps.m[id]; ok {
+		s.bannedUntil = 0
+		s.decay(time.Now())
+		if s.score < 0 {
+			s.score = 0
+		}
+	}
+	if ps.store != nil {
+		ps.store.UnblockId(id)
+	}
+}
+
+func (ps *peerScores) banned(id peerId) bool {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if s, ok := ps.m[id]; ok {
+		return s.bannedUntil >= time.Now().Unix()
+	}
+	return false
+}
+
+func (ps *peerScores) score(id peerId) float64 {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if s, ok := ps.m[id]; ok {
+		s.decay(time.Now())
+		return s.score
+	}
+	return 0
+}
+
+// sort peers by score from high to low
+func (ps *peerScores) sort(l peers) peers {
+	scores := make(map[peerId]float64, len(l))
+	for _, p := range l {
+		scores[p.Id] = ps.score(p.Id)
+	}
+
+	sort.SliceStable(l, func(i, j int) bool {
+		return scores[l[i].Id] > scores[l[j].Id]
+	})
+	return l
+}
+
+// list the scores from high to low
+func (ps *peerScores) list() []PeerScore {
+	now := time.Now()
+
+	ps.mu.Lock()
+	list := make([]PeerScore, 0, len(ps.m))
+	for id, s := range ps.m {
+		s.decay(now)
+		info := PeerScore{
+			ID:            id,
+			Score:         s.score,
+			InvalidBlocks: s.invalidBlocks,
+			Timeouts:      s.timeouts,
+			Delivered:     s.delivered,
+			Latency:       s.latency.String(),
+			Bandwidth:     speedToString(float64(s.bandwidth)),
+			Bans:          s.bans,
+		}
+		if s.bannedUntil == permanentBanExpiration {
+			info.Permanent = true
+		} else if s.bannedUntil >= now.Unix() {
+			info.BannedUntil = s.bannedUntil
+		}
+		list = append(list, info)
+	}
+	ps.mu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Score > list[j].Score
+	})
+	return list
+}
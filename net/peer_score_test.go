@@ -0,0 +1,120 @@
+package net
+
+import (
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/net/database"
+	"github.com/vitelabs/go-vite/net/vnode"
+)
+
+func TestPeerScores_ban(t *testing.T) {
+	db, err := database.New("", 1, vnode.ZERO)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ps := newPeerScores()
+	ps.setStore(db)
+
+	var bannedId peerId
+	ps.onBan = func(id peerId) {
+		bannedId = id
+	}
+
+	id := vnode.RandomNodeID()
+	ps.invalidBlock(id)
+	if ps.banned(id) {
+		t.Fatal("peer should not be banned")
+	}
+	ps.invalidBlock(id)
+	if !ps.banned(id) || bannedId != id {
+		t.Fatal("peer should be banned")
+	}
+
+	// the ban is persisted
+	ps2 := newPeerScores()
+	ps2.setStore(db)
+	if !ps2.banned(id) {
+		t.Fatal("peer should be banned after restart")
+	}
+
+	ps2.unban(id)
+	if ps2.banned(id) {
+		t.Fatal("peer should be unbanned")
+	}
+	if _, ok := db.ReadBlockedIds()[id]; ok {
+		t.Fatal("ban should be removed from database")
+	}
+
+	id2 := vnode.RandomNodeID()
+	ps2.ban(id2, 0)
+	list := ps2.list()
+	for _, s := range list {
+		if s.ID == id2 && !s.Permanent {
+			t.Fatal("peer should be banned permanently")
+		}
+	}
+}
+
+func TestPeerScores_sort(t *testing.T) {
+	ps := newPeerScores()
+
+	l := peers{
+		{Id: vnode.RandomNodeID()},
+		{Id: vnode.RandomNodeID()},
+		{Id: vnode.RandomNodeID()},
+	}
+
+	ps.timeout(l[0].Id)
+	ps.delivered(l[2].Id, time.Second)
+
+	good, bad := l[2], l[0]
+	l = ps.sort(l)
+	if l[0] != good || l[2] != bad {
+		t.Fatal("wrong order")
+	}
+}
+
+func TestPeerScore_decay(t *testing.T) {
+	now := time.Now()
+	s := &peerScore{
+		score:    -80,
+		updateAt: now.Add(-scoreHalfLife),
+	}
+
+	s.decay(now)
+	if s.score < -40.01 || s.score > -39.99 {
+		t.Fatalf("wrong score after decay: %f", s.score)
+	}
+}
+
+func TestPeerScores_evict(t *testing.T) {
+	ps := newPeerScores()
+
+	banned, bad := vnode.RandomNodeID(), vnode.RandomNodeID()
+	ps.ban(banned, time.Hour)
+	ps.invalidBlock(bad)
+	for len(ps.m) < maxPeerScores {
+		ps.bandwidth(vnode.RandomNodeID(), 0)
+	}
+
+	// the neutral scores are evicted
+	id := vnode.RandomNodeID()
+	ps.timeout(id)
+	if len(ps.m) != 3 || !ps.banned(banned) || ps.score(bad) >= 0 || ps.score(id) >= 0 {
+		t.Fatalf("wrong scores after eviction: %d", len(ps.m))
+	}
+
+	// the score closest to neutral is evicted
+	for len(ps.m) < maxPeerScores {
+		ps.invalidBlock(vnode.RandomNodeID())
+	}
+	ps.timeout(vnode.RandomNodeID())
+	if len(ps.m) != maxPeerScores || !ps.banned(banned) {
+		t.Fatalf("wrong scores after eviction: %d", len(ps.m))
+	}
+	if _, ok := ps.m[id]; ok {
+		t.Fatal("the weakest score should be evicted")
+	}
+}
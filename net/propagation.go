@@ -0,0 +1,342 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// new account blocks are pushed to sqrt(n) peers, and announced to the other peers by hashes,
+// peers pull the blocks they don`t know from the announcers.
+const (
+	maxKnownHashes      = 20000 // hashes known by one peer
+	maxRecentBlocks     = 20000 // account blocks kept to dedupe and serve pulls
+	maxAnnounceHashes   = 256   // hashes in one announcement or pull
+	maxPendingAnnounces = 4096  // announcements waiting to be sent to one peer
+	maxAnnouncers       = 4     // announcers kept for one pulling hash
+	announceInterval    = 100 * time.Millisecond
+	pullTimeout         = 3 * time.Second // pull from the next announcer if the block is not received in time
+	maxPullAge          = time.Minute
+)
+
+// knownHashes is a bounded set of hashes, the oldest hash is evicted when the set is full
+type knownHashes struct {
+	mu    sync.Mutex
+	m     map[types.Hash]struct{}
+	queue []types.Hash
+	index int
+	max   int
+}
+
+func newKnownHashes(max int) *knownHashes {
+	return &knownHashes{
+		m:   make(map[types.Hash]struct{}),
+		max: max,
+	}
+}
+
+func (k *knownHashes) has(hash types.Hash) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	_, ok := k.m[hash]
+	return ok
+}
+
+// testAndAdd return true if the hash exists, or add the hash and return false
+func (k *knownHashes) testAndAdd(hash types.Hash) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, ok := k.m[hash]; ok {
+		return true
+	}
+
+	if len(k.queue) < k.max {
+		k.queue = append(k.queue, hash)
+	} else {
+		delete(k.m, k.queue[k.index])
+		k.queue[k.index] = hash
+		k.index = (k.index + 1) % len(k.queue)
+	}
+	k.m[hash] = struct{}{}
+
+	return false
+}
+
+// recentAccountBlocks keeps the latest verified account blocks with their forward TTL,
+// the oldest block is evicted when it is full
+type recentAccountBlocks struct {
+	mu    sync.RWMutex
+	m     map[types.Hash]*NewAccountBlock
+	queue []types.Hash
+	index int
+	max   int
+}
+
+func newRecentAccountBlocks(max int) *recentAccountBlocks {
+	return &recentAccountBlocks{
+		m:   make(map[types.Hash]*NewAccountBlock),
+		max: max,
+	}
+}
+
+func (r *recentAccountBlocks) has(hash types.Hash) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, ok := r.m[hash]
+	return ok
+}
+
+func (r *recentAccountBlocks) get(hash types.Hash) *NewAccountBlock {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.m[hash]
+}
+
+// add return false if the block exists
+func (r *recentAccountBlocks) add(hash types.Hash, nb *NewAccountBlock) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.m[hash]; ok {
+		return false
+	}
+
+	if len(r.queue) < r.max {
+		r.queue = append(r.queue, hash)
+	} else {
+		delete(r.m, r.queue[r.index])
+		r.queue[r.index] = hash
+		r.index = (r.index + 1) % len(r.queue)
+	}
+	r.m[hash] = nb
+
+	return true
+}
+
+// higherPriority return true if block a should be propagated before block b:
+// blocks paying more fee first, then blocks using staked quota, blocks using PoW quota at last.
+func higherPriority(a, b *ledger.AccountBlock) bool {
+	if c := compareFee(a, b); c != 0 {
+		return c > 0
+	}
+
+	return a.Difficulty == nil && b.Difficulty != nil
+}
+
+func compareFee(a, b *ledger.AccountBlock) int {
+	if a.Fee == nil || b.Fee == nil {
+		if a.Fee != nil && a.Fee.Sign() > 0 {
+			return 1
+		}
+		if b.Fee != nil && b.Fee.Sign() > 0 {
+			return -1
+		}
+		return 0
+	}
+
+	return a.Fee.Cmp(b.Fee)
+}
+
+func sortByPriority(blocks []*ledger.AccountBlock) {
+	sort.SliceStable(blocks, func(i, j int) bool {
+		return higherPriority(blocks[i], blocks[j])
+	})
+}
+
+// splitPeers choose sqrt(n) peers to push the full block, and the other peers to announce the hash.
+// l should be sorted by score, the first peers get the full block.
+// superior, static and trusted peers always get the full block, they are producers or the peers we rely on.
+// The peers of versions before versionAnnounce can not pull the announced blocks, they always get the full block too.
+func splitPeers(l peers) (push, announce peers) {
+	if len(l) == 0 {
+		return
+	}
+
+	n := int(math.Sqrt(float64(len(l))))
+	if n == 0 {
+		n = 1
+	}
+
+	for _, p := range l {
+		if len(push) < n || p.Superior || p.Flag.is(PeerFlagStatic) || p.Flag.is(PeerFlagTrusted) || p.Version < versionAnnounce {
+			push = append(push, p)
+		} else {
+			announce = append(announce, p)
+		}
+	}
+
+	return
+}
+
+type pendingAnnounces struct {
+	peer   *Peer
+	blocks []*ledger.AccountBlock
+}
+
+// announcer batches the hashes of new account blocks to every peer, and sends them by priority
+type announcer struct {
+	mu      sync.Mutex
+	pending map[peerId]*pendingAnnounces
+}
+
+func newAnnouncer() *announcer {
+	return &announcer{
+		pending: make(map[peerId]*pendingAnnounces),
+	}
+}
+
+func (a *announcer) add(p *Peer, block *ledger.AccountBlock) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	pa, ok := a.pending[p.Id]
+	if !ok || pa.peer != p {
+		pa = &pendingAnnounces{
+			peer: p,
+		}
+		a.pending[p.Id] = pa
+	}
+	pa.blocks = append(pa.blocks, block)
+}
+
+// flush sends at most maxAnnounceHashes hashes of the highest priority to every peer,
+// the remains are sent next time, the lowest priority hashes are dropped if too many are pending.
+func (a *announcer) flush() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for id, pa := range a.pending {
+		blocks := pa.blocks
+		sortByPriority(blocks)
+		if len(blocks) > maxPendingAnnounces {
+			blocks = blocks[:maxPendingAnnounces]
+		}
+
+		n := len(blocks)
+		if n > maxAnnounceHashes {
+			n = maxAnnounceHashes
+		}
+
+		msg := &AccountBlockHashes{
+			Hashes: make([]types.Hash, n),
+		}
+		for i, block := range blocks[:n] {
+			msg.Hashes[i] = block.Hash
+		}
+
+		if err := pa.peer.send(CodeNewAccountBlockHashes, 0, msg); err != nil {
+			// the peer is disconnected
+			delete(a.pending, id)
+			continue
+		}
+
+		if n == len(blocks) {
+			delete(a.pending, id)
+		} else {
+			pa.blocks = blocks[n:]
+		}
+	}
+}
+
+type pullState struct {
+	at         time.Time // time of the last pull
+	announcers peers     // announcers not pulled yet
+}
+
+// puller pulls the announced blocks, one announcer at a time
+type puller struct {
+	mu    sync.Mutex
+	pulls map[types.Hash]*pullState
+}
+
+func newPuller() *puller {
+	return &puller{
+		pulls: make(map[types.Hash]*pullState),
+	}
+}
+
+// announced return the hashes should be pulled from sender now, the others are being pulled from other peers
+func (pl *puller) announced(sender *Peer, hashes []types.Hash, now time.Time) (want []types.Hash) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	for _, hash := range hashes {
+		if st, ok := pl.pulls[hash]; ok {
+			if len(st.announcers) < maxAnnouncers {
+				st.announcers = append(st.announcers, sender)
+			}
+			continue
+		}
+
+		pl.pulls[hash] = &pullState{
+			at: now,
+		}
+		want = append(want, hash)
+	}
+
+	return
+}
+
+func (pl *puller) received(hash types.Hash) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	delete(pl.pulls, hash)
+}
+
+// expired return the hashes should be pulled again from the next announcers, grouped by announcer
+func (pl *puller) expired(now time.Time) map[*Peer][]types.Hash {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	var retry map[*Peer][]types.Hash
+	for hash, st := range pl.pulls {
+		if now.Sub(st.at) < pullTimeout {
+			continue
+		}
+
+		if len(st.announcers) == 0 {
+			if now.Sub(st.at) > maxPullAge {
+				delete(pl.pulls, hash)
+			}
+			continue
+		}
+
+		p := st.announcers[0]
+		st.announcers = st.announcers[1:]
+		st.at = now
+
+		if retry == nil {
+			retry = make(map[*Peer][]types.Hash)
+		}
+		retry[p] = append(retry[p], hash)
+	}
+
+	return retry
+}
@@ -0,0 +1,321 @@
+package net
+
+import (
+	"math/big"
+	"math/rand"
+	_net "net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/net/vnode"
+	"github.com/vitelabs/go-vite/tools/mock_conn"
+)
+
+func TestKnownHashes(t *testing.T) {
+	k := newKnownHashes(3)
+
+	var hashes []types.Hash
+	for i := 0; i < 5; i++ {
+		hash := types.Hash{byte(i + 1)}
+		hashes = append(hashes, hash)
+		if k.testAndAdd(hash) {
+			t.Fatalf("hash %d should not exist", i)
+		}
+		if !k.testAndAdd(hash) {
+			t.Fatalf("hash %d should exist", i)
+		}
+	}
+
+	// the oldest hashes are evicted
+	for i, hash := range hashes {
+		if k.has(hash) != (i >= 2) {
+			t.Fatalf("wrong existence of hash %d", i)
+		}
+	}
+}
+
+func TestHigherPriority(t *testing.T) {
+	fee := &ledger.AccountBlock{Hash: types.Hash{1}, Fee: big.NewInt(10)}
+	staked := &ledger.AccountBlock{Hash: types.Hash{2}}
+	pow := &ledger.AccountBlock{Hash: types.Hash{3}, Fee: new(big.Int), Difficulty: big.NewInt(1)}
+
+	blocks := []*ledger.AccountBlock{pow, staked, fee}
+	sortByPriority(blocks)
+
+	if blocks[0] != fee || blocks[1] != staked || blocks[2] != pow {
+		t.Fatalf("wrong order: %s %s %s", blocks[0].Hash, blocks[1].Hash, blocks[2].Hash)
+	}
+}
+
+func TestSplitPeers(t *testing.T) {
+	var l peers
+	for i := 0; i < 16; i++ {
+		l = append(l, &Peer{Id: vnode.RandomNodeID(), Version: version})
+	}
+	l[10].Superior = true
+	l[12].Flag = PeerFlagTrusted
+	l[14].Version = versionBase
+
+	push, announce := splitPeers(l)
+	if len(push) != 7 || len(announce) != 9 {
+		t.Fatalf("wrong split: %d %d", len(push), len(announce))
+	}
+	for i := 0; i < 4; i++ {
+		if push[i] != l[i] {
+			t.Fatalf("the peers with higher score should be pushed")
+		}
+	}
+	if push[4] != l[10] || push[5] != l[12] {
+		t.Fatalf("superior and trusted peers should be pushed")
+	}
+	if push[6] != l[14] {
+		t.Fatalf("the peer can not pull announced blocks should be pushed")
+	}
+}
+
+type acceptVerifier struct{}
+
+func (acceptVerifier) VerifyNetSnapshotBlock(block *ledger.SnapshotBlock) error {
+	return nil
+}
+
+func (acceptVerifier) VerifyNetAccountBlock(block *ledger.AccountBlock) error {
+	return nil
+}
+
+type mockBroadChain struct{}
+
+func (mockBroadChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return &ledger.SnapshotBlock{}
+}
+
+func (mockBroadChain) GetConfirmedTimes(blockHash types.Hash) (uint64, error) {
+	return 0, nil
+}
+
+type mockAccountFeed struct {
+	mu sync.Mutex
+	m  map[types.Hash]int
+}
+
+func (f *mockAccountFeed) notifySnapshotBlock(block *ledger.SnapshotBlock, source types.BlockSource) {
+}
+
+func (f *mockAccountFeed) notifyAccountBlock(block *ledger.AccountBlock, source types.BlockSource) {
+	f.mu.Lock()
+	f.m[block.Hash]++
+	f.mu.Unlock()
+}
+
+func (f *mockAccountFeed) count(hash types.Hash) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.m[hash]
+}
+
+type propagationNode struct {
+	id    peerId
+	peers *peerSet
+	b     *broadcaster
+	feed  *mockAccountFeed
+}
+
+func newPropagationNode() *propagationNode {
+	ps := newPeerSet()
+	feed := &mockAccountFeed{
+		m: make(map[types.Hash]int),
+	}
+	b := newBroadcaster(ps, acceptVerifier{}, feed, newMemBlockStore(10), newFullForwardStrategy(ps), mockBroadChain{})
+	b.st = SyncDone
+	b.start()
+
+	return &propagationNode{
+		id:    vnode.RandomNodeID(),
+		peers: ps,
+		b:     b,
+		feed:  feed,
+	}
+}
+
+// connect nodes a and b through mock conn, return the conns to close
+func connectNodes(a, b *propagationNode) (c1, c2 _net.Conn) {
+	c1, c2 = mock_conn.Pipe()
+
+	pa := newPeer(NewTransport(c1, 100, readMsgTimeout, writeMsgTimeout), &HandshakeMsg{ID: b.id, Version: version}, "", "", false, PeerFlagOutbound, nil, a.b)
+	pb := newPeer(NewTransport(c2, 100, readMsgTimeout, writeMsgTimeout), &HandshakeMsg{ID: a.id, Version: version}, "", "", false, PeerFlagInbound, nil, b.b)
+	_ = a.peers.add(pa)
+	_ = b.peers.add(pb)
+
+	go pa.run()
+	go pb.run()
+
+	return
+}
+
+func newTestAccountBlock(i int) *ledger.AccountBlock {
+	block := &ledger.AccountBlock{
+		BlockType: ledger.BlockTypeSendCall,
+		Height:    uint64(i + 1),
+		Amount:    big.NewInt(int64(i)),
+		Fee:       new(big.Int),
+	}
+	block.Hash = block.ComputeHash()
+
+	return block
+}
+
+func waitReceived(nodes []*propagationNode, blocks []*ledger.AccountBlock, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		done := true
+	Loop:
+		for _, node := range nodes {
+			for _, block := range blocks {
+				if node.feed.count(block.Hash) == 0 {
+					done = false
+					break Loop
+				}
+			}
+		}
+		if done {
+			return true
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	return false
+}
+
+func TestBroadcaster_propagation(t *testing.T) {
+	const total = 16
+	const extraEdges = 3
+
+	var nodes []*propagationNode
+	for i := 0; i < total; i++ {
+		nodes = append(nodes, newPropagationNode())
+	}
+
+	var conns []_net.Conn
+	defer func() {
+		for _, c := range conns {
+			_ = c.Close()
+		}
+		for _, node := range nodes {
+			node.b.stop()
+		}
+	}()
+
+	// a ring with random chords
+	r := rand.New(rand.NewSource(1))
+	edges := make(map[[2]int]struct{})
+	connect := func(i, j int) {
+		if i == j {
+			return
+		}
+		if i > j {
+			i, j = j, i
+		}
+		if _, ok := edges[[2]int{i, j}]; ok {
+			return
+		}
+		edges[[2]int{i, j}] = struct{}{}
+		c1, c2 := connectNodes(nodes[i], nodes[j])
+		conns = append(conns, c1, c2)
+	}
+	for i := 0; i < total; i++ {
+		connect(i, (i+1)%total)
+		for k := 0; k < extraEdges; k++ {
+			connect(i, r.Intn(total))
+		}
+	}
+
+	var blocks []*ledger.AccountBlock
+	for i := 0; i < 10; i++ {
+		block := newTestAccountBlock(i)
+		blocks = append(blocks, block)
+		// the block is produced by the origin node
+		origin := nodes[r.Intn(total)]
+		origin.feed.notifyAccountBlock(block, types.Local)
+		origin.b.BroadcastAccountBlock(block)
+	}
+
+	if !waitReceived(nodes, blocks, 30*time.Second) {
+		t.Fatal("blocks are not propagated to all nodes")
+	}
+	// wait for the duplicate messages
+	time.Sleep(500 * time.Millisecond)
+
+	for i, node := range nodes {
+		for _, block := range blocks {
+			if c := node.feed.count(block.Hash); c != 1 {
+				t.Fatalf("node %d receive block %s %d times", i, block.Hash, c)
+			}
+		}
+	}
+
+	var pushed, announced uint64
+	for _, node := range nodes {
+		for _, p := range node.peers.peers() {
+			_, codes := p.traffic.info()
+			pushed += codes[strconv.Itoa(int(CodeNewAccountBlock))].OutMsgs
+			announced += codes[strconv.Itoa(int(CodeNewAccountBlockHashes))].OutMsgs
+		}
+	}
+
+	// every node sends the block to all peers except the sender when flooding
+	flood := uint64(len(blocks) * (2*len(edges) - total + 1))
+	t.Logf("edges %d, pushed %d, announced %d, flood %d", len(edges), pushed, announced, flood)
+	if pushed >= flood*2/3 {
+		t.Fatalf("too many full blocks are sent: %d, flood: %d", pushed, flood)
+	}
+	if announced == 0 {
+		t.Fatal("blocks should be announced")
+	}
+}
+
+func TestBroadcaster_tolerateFlood(t *testing.T) {
+	node := newPropagationNode()
+	defer node.b.stop()
+
+	c1, c2 := mock_conn.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+
+	// the legacy node floods full blocks without announcement
+	legacy := vnode.RandomNodeID()
+	p := newPeer(NewTransport(c1, 100, readMsgTimeout, writeMsgTimeout), &HandshakeMsg{ID: legacy, Version: versionBase}, "", "", false, PeerFlagStatic, nil, node.b)
+	_ = node.peers.add(p)
+	go p.run()
+
+	block := newTestAccountBlock(0)
+	codec := NewTransport(c2, 100, readMsgTimeout, writeMsgTimeout)
+	for i := 0; i < 2; i++ {
+		data, err := (&NewAccountBlock{Block: block, TTL: defaultBroadcastTTL}).Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = codec.WriteMsg(Msg{Code: CodeNewAccountBlock, Payload: data}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !waitReceived([]*propagationNode{node}, []*ledger.AccountBlock{block}, 5*time.Second) {
+		t.Fatal("flooded block should be accepted")
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	if c := node.feed.count(block.Hash); c != 1 {
+		t.Fatalf("block should be notified once, but %d times", c)
+	}
+	if !p.knownBlocks.has(block.Hash) {
+		t.Fatal("the block should be known by the sender")
+	}
+	if node.peers.scores.score(legacy) < 0 {
+		t.Fatal("flooding peer should not be punished")
+	}
+}
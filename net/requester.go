@@ -0,0 +1,96 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var errRequestStopped = errors.New("requester is stopped")
+
+// requester sends a request to a peer and waits for the response with the same message id
+type requester struct {
+	idGen   MsgIder
+	timeout time.Duration
+
+	mu      sync.Mutex
+	pending map[MsgId]chan Msg
+}
+
+func newRequester(timeout time.Duration) *requester {
+	return &requester{
+		idGen:   new(gid),
+		timeout: timeout,
+		pending: make(map[MsgId]chan Msg),
+	}
+}
+
+// deliver the response to the waiting request
+func (r *requester) deliver(msg Msg) {
+	r.mu.Lock()
+	ch, ok := r.pending[msg.Id]
+	if ok {
+		delete(r.pending, msg.Id)
+	}
+	r.mu.Unlock()
+
+	if ok {
+		ch <- msg
+	} else {
+		msg.Recycle()
+	}
+}
+
+// request return errRequestStopped if term is closed before the response
+func (r *requester) request(p *Peer, code Code, m Serializable, term <-chan struct{}) (msg Msg, err error) {
+	id := r.idGen.MsgID()
+	ch := make(chan Msg, 1)
+
+	r.mu.Lock()
+	r.pending[id] = ch
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		delete(r.pending, id)
+		r.mu.Unlock()
+	}()
+
+	if err = p.send(code, id, m); err != nil {
+		return
+	}
+
+	timer := time.NewTimer(r.timeout)
+	defer timer.Stop()
+
+	select {
+	case msg = <-ch:
+		if msg.Code == CodeException {
+			msg.Recycle()
+			return Msg{}, errNoResource
+		}
+		return msg, nil
+	case <-timer.C:
+		return Msg{}, errFetchTimeout
+	case <-term:
+		return Msg{}, errRequestStopped
+	}
+}
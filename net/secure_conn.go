@@ -0,0 +1,226 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	_net "net"
+	"sync"
+
+	"golang.org/x/crypto/curve25519"
+
+	"github.com/vitelabs/go-vite/crypto"
+)
+
+const maxFrameSize = 16 * 1024
+
+// keys of both directions are rotated after so many frames, both sides rotate at the same frame, so no message is needed
+const keyRotationFrames = 1 << 16
+
+var errInvalidEphemeralKey = errors.New("invalid ephemeral key")
+
+/*
+ * frame structure
+ *  +------------------+----------------------------------+
+ *  |  Length 2 bytes  |  Ciphertext (payload + 16 tag)   |
+ *  +------------------+----------------------------------+
+ * The ciphertext is sealed by AES-256-GCM, the nonce is the count of frames sealed by the same key,
+ * so the frames replayed, reordered or dropped can not be opened. The length is the additional data.
+ */
+
+// ephemeralKey is a X25519 key pair used once for a connection
+type ephemeralKey struct {
+	priv [32]byte
+	pub  [32]byte
+}
+
+func newEphemeralKey() *ephemeralKey {
+	k := new(ephemeralKey)
+	copy(k.priv[:], crypto.GetEntropyCSPRNG(32))
+	curve25519.ScalarBaseMult(&k.pub, &k.priv)
+	return k
+}
+
+func (k *ephemeralKey) publicKey() []byte {
+	if k == nil {
+		return nil
+	}
+	return k.pub[:]
+}
+
+// sessionKeys derives the keys of both directions from the static secret of the node keys and the ephemeral keys
+func (k *ephemeralKey) sessionKeys(secret []byte, their []byte, initiator bool) (writeKey, readKey []byte, err error) {
+	if len(their) != 32 {
+		return nil, nil, errInvalidEphemeralKey
+	}
+
+	var pub, shared [32]byte
+	copy(pub[:], their)
+	curve25519.ScalarMult(&shared, &k.priv, &pub)
+
+	initiatorKey, receiverKey := k.pub[:], their
+	if !initiator {
+		initiatorKey, receiverKey = their, k.pub[:]
+	}
+	master := crypto.Hash256(secret, shared[:], initiatorKey, receiverKey)
+	initiatorWriteKey := crypto.Hash256(master, []byte("initiator"))
+	receiverWriteKey := crypto.Hash256(master, []byte("receiver"))
+
+	if initiator {
+		return initiatorWriteKey, receiverWriteKey, nil
+	}
+	return receiverWriteKey, initiatorWriteKey, nil
+}
+
+type frameCipher struct {
+	key   []byte
+	aead  cipher.AEAD
+	count uint64
+	nonce [12]byte
+}
+
+func newFrameCipher(key []byte) (*frameCipher, error) {
+	fc := &frameCipher{}
+	if err := fc.setKey(key); err != nil {
+		return nil, err
+	}
+	return fc, nil
+}
+
+func (fc *frameCipher) setKey(key []byte) error {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	fc.aead, err = cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	fc.key = key
+	fc.count = 0
+	return nil
+}
+
+// next returns the nonce of the next frame, the key is rotated if it has sealed keyRotationFrames frames
+func (fc *frameCipher) next() ([]byte, error) {
+	if fc.count == keyRotationFrames {
+		if err := fc.setKey(crypto.Hash256(fc.key, []byte("rotate"))); err != nil {
+			return nil, err
+		}
+	}
+	binary.BigEndian.PutUint64(fc.nonce[4:], fc.count)
+	fc.count++
+	return fc.nonce[:], nil
+}
+
+// secureConn encrypts all bytes written to the connection, the messages of the codec and the chunks of the file sync
+type secureConn struct {
+	_net.Conn
+
+	rmu      sync.Mutex
+	reader   *frameCipher
+	readHead [2]byte
+	readBuf  []byte
+	plain    []byte // the plaintext of the last frame not read yet
+
+	wmu      sync.Mutex
+	writer   *frameCipher
+	writeBuf []byte
+}
+
+func newSecureConn(conn _net.Conn, writeKey, readKey []byte) (*secureConn, error) {
+	writer, err := newFrameCipher(writeKey)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := newFrameCipher(readKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &secureConn{
+		Conn:     conn,
+		reader:   reader,
+		readBuf:  make([]byte, maxFrameSize+16),
+		writer:   writer,
+		writeBuf: make([]byte, 2, maxFrameSize+18),
+	}, nil
+}
+
+func (s *secureConn) Read(b []byte) (n int, err error) {
+	s.rmu.Lock()
+	defer s.rmu.Unlock()
+
+	if len(s.plain) == 0 {
+		if _, err = io.ReadFull(s.Conn, s.readHead[:]); err != nil {
+			return
+		}
+		length := int(binary.BigEndian.Uint16(s.readHead[:]))
+		if length > len(s.readBuf) {
+			return 0, fmt.Errorf("frame is too large: %d", length)
+		}
+		if _, err = io.ReadFull(s.Conn, s.readBuf[:length]); err != nil {
+			return
+		}
+
+		var nonce []byte
+		if nonce, err = s.reader.next(); err != nil {
+			return
+		}
+		if s.plain, err = s.reader.aead.Open(s.readBuf[:0], nonce, s.readBuf[:length], s.readHead[:]); err != nil {
+			return 0, fmt.Errorf("failed to open frame: %v", err)
+		}
+	}
+
+	n = copy(b, s.plain)
+	s.plain = s.plain[n:]
+	return
+}
+
+func (s *secureConn) Write(b []byte) (n int, err error) {
+	s.wmu.Lock()
+	defer s.wmu.Unlock()
+
+	for len(b) > 0 {
+		size := len(b)
+		if size > maxFrameSize {
+			size = maxFrameSize
+		}
+
+		var nonce []byte
+		if nonce, err = s.writer.next(); err != nil {
+			return
+		}
+		head := s.writeBuf[:2]
+		binary.BigEndian.PutUint16(head, uint16(size+s.writer.aead.Overhead()))
+		frame := s.writer.aead.Seal(head, nonce, b[:size], head)
+		if _, err = s.Conn.Write(frame); err != nil {
+			return
+		}
+
+		n += size
+		b = b[size:]
+	}
+	return
+}
@@ -0,0 +1,141 @@
+package net
+
+import (
+	"bytes"
+	_net "net"
+	"testing"
+	"time"
+)
+
+// bufConn is a connection writes to and reads from a buffer
+type bufConn struct {
+	_net.Conn
+	buf bytes.Buffer
+}
+
+func (b *bufConn) Read(p []byte) (int, error) {
+	return b.buf.Read(p)
+}
+
+func (b *bufConn) Write(p []byte) (int, error) {
+	return b.buf.Write(p)
+}
+
+func (b *bufConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func (b *bufConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+func newSecureConnPair(t *testing.T) (initiator, receiver *secureConn, conn *bufConn) {
+	secret := []byte("static secret of the node keys..")
+	k1, k2 := newEphemeralKey(), newEphemeralKey()
+	w1, r1, err := k1.sessionKeys(secret, k2.publicKey(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w2, r2, err := k2.sessionKeys(secret, k1.publicKey(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(w1, r2) || !bytes.Equal(w2, r1) {
+		t.Fatal("different session keys")
+	}
+
+	conn = &bufConn{}
+	if initiator, err = newSecureConn(conn, w1, r1); err != nil {
+		t.Fatal(err)
+	}
+	if receiver, err = newSecureConn(conn, w2, r2); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestSecureConn(t *testing.T) {
+	initiator, receiver, conn := newSecureConnPair(t)
+
+	c := NewTransport(initiator, 100, readMsgTimeout, writeMsgTimeout)
+	msgs := []Msg{
+		{Code: CodeHandshake, Id: 1, Payload: []byte("hello")},
+		{Code: CodeException, Id: 70000, Payload: bytes.Repeat([]byte{1, 2, 3}, 3*maxFrameSize)},
+		{Code: CodeDisconnect},
+	}
+	for _, msg := range msgs {
+		if err := c.WriteMsg(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if bytes.Contains(conn.buf.Bytes(), []byte("hello")) {
+		t.Fatal("plaintext is sent")
+	}
+
+	c2 := NewTransport(receiver, 100, readMsgTimeout, writeMsgTimeout)
+	for _, msg := range msgs {
+		msg2, err := c2.ReadMsg()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg2.Code != msg.Code || msg2.Id != msg.Id || !bytes.Equal(msg2.Payload, msg.Payload) {
+			t.Fatalf("different message: %d %d", msg2.Code, msg.Code)
+		}
+	}
+}
+
+func TestSecureConn_tamper(t *testing.T) {
+	initiator, receiver, conn := newSecureConnPair(t)
+
+	if _, err := initiator.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	data := conn.buf.Bytes()
+	data[len(data)-1] ^= 1
+
+	if _, err := receiver.Read(make([]byte, 10)); err == nil {
+		t.Fatal("tampered frame should not be opened")
+	}
+}
+
+func TestSecureConn_replay(t *testing.T) {
+	initiator, receiver, conn := newSecureConnPair(t)
+
+	if _, err := initiator.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	frame := append([]byte(nil), conn.buf.Bytes()...)
+	conn.buf.Write(frame)
+
+	buf := make([]byte, 10)
+	if n, err := receiver.Read(buf); err != nil || string(buf[:n]) != "hello" {
+		t.Fatalf("failed to read frame: %v", err)
+	}
+	if _, err := receiver.Read(buf); err == nil {
+		t.Fatal("replayed frame should not be opened")
+	}
+}
+
+func TestSecureConn_rotate(t *testing.T) {
+	initiator, receiver, conn := newSecureConnPair(t)
+
+	key := initiator.writer.key
+	buf := make([]byte, 1)
+	for i := 0; i < keyRotationFrames+10; i++ {
+		if _, err := initiator.Write([]byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := receiver.Read(buf); err != nil {
+			t.Fatalf("failed to read frame %d: %v", i, err)
+		}
+		if buf[0] != byte(i) {
+			t.Fatalf("wrong frame %d", i)
+		}
+	}
+	if bytes.Equal(key, initiator.writer.key) {
+		t.Fatal("key is not rotated")
+	}
+	if conn.buf.Len() != 0 {
+		t.Fatal("frames are not read")
+	}
+}
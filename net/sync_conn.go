@@ -44,20 +44,26 @@ var errWriteTooShort = errors.New("write too short")
 var errHandshakeError = errors.New("sync handshake error")
 var errServerNotReady = errors.New("server not ready")
 var errIncompleteChunk = errors.New("incomplete chunk")
+var errSyncConnBusy = errors.New("sync connection is busy")
+
+// minChunkTimeout is the minimal duration to receive a chunk
+const minChunkTimeout = 10 * time.Second
 
 type syncHandshake struct {
-	id    peerId
-	key   []byte
-	time  int64
-	token []byte
+	id           peerId
+	key          []byte
+	time         int64
+	token        []byte
+	ephemeralKey []byte
 }
 
 func (s *syncHandshake) Serialize() ([]byte, error) {
 	pb := &vitepb.SyncConnHandshake{
-		ID:        s.id.Bytes(),
-		Timestamp: s.time,
-		Key:       s.key,
-		Token:     s.token,
+		ID:           s.id.Bytes(),
+		Timestamp:    s.time,
+		Key:          s.key,
+		Token:        s.token,
+		EphemeralKey: s.ephemeralKey,
 	}
 	return proto.Marshal(pb)
 }
@@ -76,6 +82,7 @@ func (s *syncHandshake) deserialize(data []byte) error {
 	s.key = pb.Key
 	s.time = pb.Timestamp
 	s.token = pb.Token
+	s.ephemeralKey = pb.EphemeralKey
 	return nil
 }
 
@@ -175,6 +182,7 @@ type defaultSyncConnectionFactory struct {
 	id      peerId
 	peerKey ed25519.PrivateKey
 	mineKey ed25519.PrivateKey
+	encrypt bool
 }
 
 func (d *defaultSyncConnectionFactory) makeSyncConn(conn net2.Conn) *syncConn {
@@ -184,6 +192,21 @@ func (d *defaultSyncConnectionFactory) makeSyncConn(conn net2.Conn) *syncConn {
 	}
 }
 
+// makeSecureSyncConn encrypts the messages and the chunks after the handshake
+func (d *defaultSyncConnectionFactory) makeSecureSyncConn(conn net2.Conn, ephemeral *ephemeralKey, secret, their []byte, initiator bool) (*syncConn, error) {
+	writeKey, readKey, err := ephemeral.sessionKeys(secret, their, initiator)
+	if err != nil {
+		return nil, err
+	}
+
+	sconn, err := newSecureConn(conn, writeKey, readKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return d.makeSyncConn(sconn), nil
+}
+
 func (d *defaultSyncConnectionFactory) initiate(conn net2.Conn, peer *Peer) (*syncConn, error) {
 	c := d.makeSyncConn(conn)
 
@@ -191,6 +214,11 @@ func (d *defaultSyncConnectionFactory) initiate(conn net2.Conn, peer *Peer) (*sy
 		id:   d.id,
 		time: time.Now().Unix(),
 	}
+	var ephemeral *ephemeralKey
+	if d.encrypt {
+		ephemeral = newEphemeralKey()
+		hk.ephemeralKey = ephemeral.publicKey()
+	}
 	pub := ed25519.PublicKey(peer.Id.Bytes()).ToX25519Pk()
 	priv := d.peerKey.ToX25519Sk()
 	secret, err := crypto.X25519ComputeSecret(priv, pub)
@@ -200,7 +228,7 @@ func (d *defaultSyncConnectionFactory) initiate(conn net2.Conn, peer *Peer) (*sy
 
 	t := make([]byte, 8)
 	binary.BigEndian.PutUint64(t, uint64(hk.time))
-	hash := crypto.Hash256(t)
+	hash := crypto.Hash256(t, hk.ephemeralKey)
 	hk.token = xor(hash, secret)
 	if len(d.mineKey) != 0 {
 		hk.key = d.mineKey.PubByte()
@@ -230,6 +258,14 @@ func (d *defaultSyncConnectionFactory) initiate(conn net2.Conn, peer *Peer) (*sy
 		return nil, errHandshakeError
 	}
 
+	// the payload is the ephemeral key of the server if it supports encryption
+	if ephemeral != nil && len(msg.Payload) != 0 {
+		c, err = d.makeSecureSyncConn(conn, ephemeral, secret, msg.Payload, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	c.peer = peer
 	c.cacher = d.chain
 
@@ -267,7 +303,7 @@ func (d *defaultSyncConnectionFactory) receive(conn net2.Conn) (*syncConn, error
 
 	t := make([]byte, 8)
 	binary.BigEndian.PutUint64(t, uint64(hk.time))
-	hash := crypto.Hash256(t)
+	hash := crypto.Hash256(t, hk.ephemeralKey)
 	token := xor(hash, secret)
 	if len(hk.key) != 0 {
 		if false == ed25519.Verify(hk.key, token, hk.token) {
@@ -294,13 +330,26 @@ func (d *defaultSyncConnectionFactory) receive(conn net2.Conn) (*syncConn, error
 		return nil, PeerNoPermission
 	}
 
+	var ephemeral *ephemeralKey
+	if d.encrypt && len(hk.ephemeralKey) != 0 {
+		ephemeral = newEphemeralKey()
+	}
+
 	err = c.c.WriteMsg(Msg{
-		Code: CodeSyncHandshakeOK,
+		Code:    CodeSyncHandshakeOK,
+		Payload: ephemeral.publicKey(),
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	if ephemeral != nil {
+		c, err = d.makeSecureSyncConn(conn, ephemeral, secret, hk.ephemeralKey, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	c.peer = p
 	c.cacher = d.chain
 
@@ -393,9 +442,11 @@ func isRightChunk(msg *syncResponse, t *syncTask) (seg interfaces.Segment, err e
 	return t.Segment, err
 }
 
-func (f *syncConn) download(t *syncTask) (fatal bool, err error) {
+// download the chunk of t to sync cache, the snapshot blocks are verified against the hashes of t as they arrive.
+// minSpeed is the minimal download speed expected, the connection will be timeout if it is slower.
+func (f *syncConn) download(t *syncTask, minSpeed uint64) (fatal bool, err error) {
 	if false == atomic.CompareAndSwapInt32(&f.busy, 0, 1) {
-		err = fmt.Errorf("task %s is downloading", f.task.String())
+		err = errSyncConnBusy
 		return
 	}
 	defer atomic.StoreInt32(&f.busy, 0)
@@ -448,16 +499,25 @@ func (f *syncConn) download(t *syncTask) (fatal bool, err error) {
 		return false, err
 	}
 
+	verifier := newPieceVerifier(segment)
+
 	start := time.Now().Unix()
 	var nr, nw int
 	var total, count uint64
-	var rerr, werr error
+	var rerr, werr, verr error
 
 	if f._speed < 10240 { // 10k/s
 		f._speed = 10240
 	}
 
-	timeout := time.Duration(2*chunkInfo.size/f._speed) * time.Second
+	speed := f._speed
+	if speed < minSpeed {
+		speed = minSpeed
+	}
+	timeout := time.Duration(2*chunkInfo.size/speed) * time.Second
+	if timeout < minChunkTimeout {
+		timeout = minChunkTimeout
+	}
 	_ = f.conn.SetReadDeadline(time.Now().Add(timeout))
 	for {
 		count = chunkInfo.size - total
@@ -469,6 +529,9 @@ func (f *syncConn) download(t *syncTask) (fatal bool, err error) {
 		total += uint64(nr)
 
 		nw, werr = writer.Write(f.buf[:nr])
+		if werr == nil && nw == nr {
+			verr = verifier.write(f.buf[:nr])
+		}
 
 		if rerr != nil {
 			break
@@ -477,6 +540,8 @@ func (f *syncConn) download(t *syncTask) (fatal bool, err error) {
 		} else if nw != nr {
 			werr = errWriteTooShort
 			break
+		} else if verr != nil {
+			break
 		}
 
 		if total == chunkInfo.size {
@@ -484,6 +549,10 @@ func (f *syncConn) download(t *syncTask) (fatal bool, err error) {
 		}
 	}
 
+	if verr == nil && total == chunkInfo.size {
+		verr = verifier.done()
+	}
+
 	err = writer.Close()
 	if err != nil {
 		fatal = false
@@ -491,6 +560,11 @@ func (f *syncConn) download(t *syncTask) (fatal bool, err error) {
 		return
 	}
 
+	if verr != nil {
+		_ = cache.Delete(segment)
+		return true, verr
+	}
+
 	if rerr != nil {
 		fatal = true
 	}
@@ -514,6 +588,10 @@ func (f *syncConn) download(t *syncTask) (fatal bool, err error) {
 	return
 }
 
+func (f *syncConn) isClosed() bool {
+	return atomic.LoadInt32(&f.closed) == 1
+}
+
 func (f *syncConn) close() error {
 	if atomic.CompareAndSwapInt32(&f.closed, 0, 1) {
 		return f.conn.Close()
@@ -655,9 +733,12 @@ func (fp *downloadConnPool) sortLocked() {
 	}
 }
 
-// choose the fast fileConn, or create new conn randomly
-func (fp *downloadConnPool) chooseSource(t *syncTask) (*Peer, *syncConn, error) {
+// choose the fast fileConn, or create new conn randomly, peers in exclude will not be chosen
+func (fp *downloadConnPool) chooseSource(t *syncTask, exclude map[peerId]struct{}) (*Peer, *syncConn, error) {
 	peerMap := fp.peers.pickDownloadPeers(t.To)
+	for id := range exclude {
+		delete(peerMap, id)
+	}
 
 	if len(peerMap) == 0 {
 		return nil, nil, errNoSuitablePeer
@@ -689,6 +770,9 @@ func (fp *downloadConnPool) chooseSource(t *syncTask) (*Peer, *syncConn, error)
 		if c.isBusy() || c.peer.Height < t.To {
 			continue
 		}
+		if _, ok := exclude[c.peer.Id]; ok {
+			continue
+		}
 
 		if len(fp.l)+1 > 3*(i+1) {
 			// fast enough
@@ -696,19 +780,26 @@ func (fp *downloadConnPool) chooseSource(t *syncTask) (*Peer, *syncConn, error)
 		}
 
 		if createNew {
-			for _, p := range peerMap {
-				return p, nil, nil
-			}
+			return fp.bestPeer(peerMap), nil, nil
 		} else {
 			return nil, c, nil
 		}
 	}
 
+	return fp.bestPeer(peerMap), nil, nil
+}
+
+// bestPeer return the peer has the highest score, return nil if peerMap is empty
+func (fp *downloadConnPool) bestPeer(peerMap map[peerId]*Peer) (best *Peer) {
+	var bestScore float64
 	for _, p := range peerMap {
-		return p, nil, nil
+		score := fp.peers.scores.score(p.Id)
+		if best == nil || score > bestScore {
+			best, bestScore = p, score
+		}
 	}
 
-	return nil, nil, nil
+	return
 }
 
 func (fp *downloadConnPool) reset() {
@@ -123,12 +123,19 @@ type syncDownloader interface {
 
 type taskListener = func(t syncTask, err error)
 
+// maxConnsOneTask is the maximal peers download pieces of a task at the same time
+const maxConnsOneTask = 3
+
+// maxPieceFailures is the maximal failures of a piece in a task
+const maxPieceFailures = 3
+
 type executor struct {
 	mu         sync.Mutex
 	tasks      syncTasks
 	cond       *sync.Cond
 	max, batch int
 
+	cacher  syncCacher
 	pool    *downloadConnPool
 	factory syncConnInitiator
 	dialing map[string]struct{}
@@ -141,11 +148,12 @@ type executor struct {
 	log log15.Logger
 }
 
-func newExecutor(max, batch int, peers *peerSet, factory syncConnInitiator) *executor {
+func newExecutor(max, batch int, peers *peerSet, cacher syncCacher, factory syncConnInitiator) *executor {
 	e := &executor{
 		max:     max,
 		batch:   batch,
 		tasks:   make(syncTasks, 0, max),
+		cacher:  cacher,
 		pool:    newDownloadConnPool(peers),
 		factory: factory,
 		dialing: make(map[string]struct{}),
@@ -411,13 +419,22 @@ func (e *executor) run(t *syncTask) {
 	go e.do(t)
 }
 
-func (e *executor) doJob(c *syncConn, t *syncTask) error {
+func (e *executor) doJob(c *syncConn, t *syncTask, minSpeed uint64) error {
 	start := time.Now()
 
 	e.log.Info(fmt.Sprintf("download chunk %s from %s", t.String(), c.address()))
 
-	if fatal, err := c.download(t); err != nil {
+	if fatal, err := c.download(t, minSpeed); err != nil {
+		if err == errSyncConnBusy {
+			return err
+		}
+
 		e.log.Warn(fmt.Sprintf("failed to download chunk %s from %s: %v", t, c.address(), err))
+		if isInvalidChunk(err) {
+			e.addBlackList(c.peer.Id)
+		} else {
+			e.pool.peers.scores.timeout(c.peer.Id)
+		}
 
 		if fatal {
 			e.pool.delConn(c)
@@ -428,6 +445,7 @@ func (e *executor) doJob(c *syncConn, t *syncTask) error {
 	}
 
 	e.log.Info(fmt.Sprintf("download chunk %s from %s elapse %s", t, c.address(), time.Now().Sub(start)))
+	e.pool.peers.scores.bandwidth(c.peer.Id, c.speed())
 
 	return nil
 }
@@ -459,7 +477,7 @@ func (e *executor) createConn(p *Peer) (c *syncConn, err error) {
 
 	// dial error
 	if err != nil {
-		e.addBlackList(p.Id)
+		e.blockPeer(p.Id)
 		return
 	}
 
@@ -467,7 +485,7 @@ func (e *executor) createConn(p *Peer) (c *syncConn, err error) {
 	c, err = e.factory.initiate(tcp, p)
 	if err != nil {
 		_ = tcp.Close()
-		e.addBlackList(p.Id)
+		e.blockPeer(p.Id)
 		return
 	}
 
@@ -480,47 +498,203 @@ func (e *executor) createConn(p *Peer) (c *syncConn, err error) {
 	return
 }
 
-func (e *executor) do(t *syncTask) {
-	var p *Peer
-	var c *syncConn
-	var err error
+// pieces split the task, the pieces have been downloaded to cache will be skipped
+func (e *executor) pieces(t *syncTask) (pieces syncTasks) {
+	var cached interfaces.SegmentList
+	if e.cacher != nil {
+		cached = e.cacher.GetSyncCache().Chunks()
+	}
 
-	if p, c, err = e.pool.chooseSource(t); err != nil {
-		// no tall enough peers
-	} else if c != nil {
-		if err = e.doJob(c, t); err == nil {
-			// downloaded
-			t.done()
-		}
-	} else if p != nil {
-		if c, err = e.createConn(p); err == nil {
-			if err = e.doJob(c, t); err == nil {
-				// downloaded
-				t.done()
+Loop:
+	for _, seg := range splitTask(t.Segment) {
+		for _, c := range cached {
+			if c.Equal(seg) {
+				continue Loop
 			}
 		}
-	} else {
-		// no idle peers
+
+		pieces = append(pieces, &syncTask{
+			Segment: seg,
+		})
+	}
+
+	return
+}
+
+// pieceDownload is the state of downloading a task from several peers
+type pieceDownload struct {
+	mu        sync.Mutex
+	pieces    syncTasks // pieces waiting to download
+	failures  map[*syncTask]int
+	exclude   map[peerId]struct{} // slow or lying peers, will not be used by the task anymore
+	bestSpeed uint64
+	left      int
+	err       error // the task failed
+}
+
+func (d *pieceDownload) next() (piece *syncTask) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.pieces) == 0 || d.err != nil {
+		return nil
+	}
+
+	piece = d.pieces[0]
+	d.pieces = d.pieces[1:]
+	return
+}
+
+func (d *pieceDownload) excluded() map[peerId]struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	exclude := make(map[peerId]struct{}, len(d.exclude))
+	for id := range d.exclude {
+		exclude[id] = struct{}{}
+	}
+	return exclude
+}
+
+// minSpeed is a quarter of the fastest connection of the task, slower connections will be timeout
+func (d *pieceDownload) minSpeed() uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.bestSpeed / 4
+}
+
+func (d *pieceDownload) done(piece *syncTask, speed uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.left--
+	if speed > d.bestSpeed {
+		d.bestSpeed = speed
+	}
+}
+
+// retry put the piece back, the task will fail if the piece failed too many times
+func (d *pieceDownload) retry(piece *syncTask, err error, drop peerId) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if drop != (peerId{}) {
+		d.exclude[drop] = struct{}{}
+	}
+
+	if err != errSyncConnBusy && err != errPeerDialing {
+		d.failures[piece]++
+		if d.failures[piece] > maxPieceFailures {
+			d.err = err
+			return
+		}
+	}
+
+	d.pieces = append(d.pieces, piece)
+}
+
+// quit put the piece back when a worker can not find a source, other workers will download it
+func (d *pieceDownload) quit(piece *syncTask) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.pieces = append(d.pieces, piece)
+}
+
+func (e *executor) do(t *syncTask) {
+	pieces := e.pieces(t)
+
+	d := &pieceDownload{
+		pieces:   pieces,
+		failures: make(map[*syncTask]int),
+		exclude:  make(map[peerId]struct{}),
+		left:     len(pieces),
+	}
+
+	workers := len(pieces)
+	if workers > maxConnsOneTask {
+		workers = maxConnsOneTask
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.downloadPieces(d)
+		}()
+	}
+	wg.Wait()
+
+	if d.left == 0 {
+		// downloaded
+		t.done()
+	} else if d.err == nil {
+		// no idle peers, the downloaded pieces are in cache, the left pieces will be downloaded later
 		t.wait()
 	}
 
 	// only t.st == reqPending
 	t.error()
 
-	if t.st == reqDone {
-		e.notify(t, err)
-	} else {
+	if t.st != reqDone {
 		// maybe syncConn is busy, should wait
 		time.Sleep(time.Second)
 	}
 }
 
+// downloadPieces download pieces of the task one by one, until all pieces done or error
+func (e *executor) downloadPieces(d *pieceDownload) {
+	var p *Peer
+	var c *syncConn
+	var err error
+
+	for piece := d.next(); piece != nil; piece = d.next() {
+		if p, c, err = e.pool.chooseSource(piece, d.excluded()); err != nil || (c == nil && p == nil) {
+			// no tall enough peers or no idle peers
+			d.quit(piece)
+			return
+		} else if c == nil {
+			if c, err = e.createConn(p); err == errPeerDialing {
+				// the peer is dialing by another worker
+				d.quit(piece)
+				return
+			} else if err != nil {
+				d.retry(piece, err, p.Id)
+				continue
+			}
+		}
+
+		if err = e.doJob(c, piece, d.minSpeed()); err != nil {
+			var drop peerId
+			if err != errSyncConnBusy && (isInvalidChunk(err) || c.isClosed()) {
+				// lying or slow peer
+				drop = c.peer.Id
+			}
+			d.retry(piece, err, drop)
+			continue
+		}
+
+		d.done(piece, c.speed())
+		e.notify(piece, nil)
+	}
+}
+
 func (e *executor) notify(t *syncTask, err error) {
 	for _, listener := range e.listeners {
 		listener(*t, err)
 	}
 }
 
+// addBlackList block the peer provides invalid chunk
 func (e *executor) addBlackList(id peerId) {
 	e.pool.blockPeer(id, 60*time.Second)
+	e.pool.peers.scores.invalidBlock(id)
+}
+
+// blockPeer block the peer can not be connected
+func (e *executor) blockPeer(id peerId) {
+	e.pool.blockPeer(id, 60*time.Second)
+	e.pool.peers.scores.timeout(id)
 }
@@ -0,0 +1,199 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/golang/snappy"
+	"github.com/vitelabs/go-vite/chain/block"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+// syncPieceSize is the snapshot blocks of a piece, a task is split into pieces can be downloaded from different peers
+const syncPieceSize = 5 * syncTaskSize
+
+// maxBlockBytes is the maximal bytes of a block in chunk
+const maxBlockBytes = 16 << 20
+
+// invalidChunkError means the chunk does not match the snapshot hashes, the source peer is lying
+type invalidChunkError struct {
+	reason string
+}
+
+func (e invalidChunkError) Error() string {
+	return "invalid chunk: " + e.reason
+}
+
+func isInvalidChunk(err error) bool {
+	_, ok := err.(invalidChunkError)
+	return ok
+}
+
+// splitTask split the segment to pieces at the points, every piece has about syncPieceSize snapshot blocks.
+// the segment is the only piece if it has no enough points.
+func splitTask(seg interfaces.Segment) (pieces []interfaces.Segment) {
+	if len(seg.Points) < 2 || seg.Points[0].Height+1 != seg.From || seg.Points[0].Hash != seg.PrevHash {
+		return []interfaces.Segment{seg}
+	}
+
+	var piece = interfaces.Segment{
+		From:     seg.From,
+		PrevHash: seg.PrevHash,
+		Points:   []*ledger.HashHeight{seg.Points[0]},
+	}
+
+	for _, p := range seg.Points[1:] {
+		if p.Height < piece.From || p.Height > seg.To {
+			continue
+		}
+
+		piece.Points = append(piece.Points, p)
+		piece.To = p.Height
+		piece.Hash = p.Hash
+
+		if piece.To-piece.From+1 >= syncPieceSize && piece.To < seg.To {
+			pieces = append(pieces, piece)
+			piece = interfaces.Segment{
+				From:     p.Height + 1,
+				PrevHash: p.Hash,
+				Points:   []*ledger.HashHeight{p},
+			}
+		}
+	}
+
+	if piece.To != seg.To || piece.Hash != seg.Hash {
+		if piece.To == seg.To {
+			// the last point conflicts with the segment
+			return []interfaces.Segment{seg}
+		}
+
+		piece.To = seg.To
+		piece.Hash = seg.Hash
+		piece.Points = append(piece.Points, &ledger.HashHeight{
+			Height: seg.To,
+			Hash:   seg.Hash,
+		})
+	}
+
+	return append(pieces, piece)
+}
+
+// pieceVerifier parse the chunk stream, and verify the snapshot blocks against the hashes of the segment.
+// the stream is a sequence of blocks, every block is [4 bytes length][1 byte type][snappy encoded block]
+type pieceVerifier struct {
+	seg    interfaces.Segment
+	points map[uint64]types.Hash
+	last   ledger.HashHeight // the last verified snapshot block
+	buf    []byte            // bytes not parsed
+	decode []byte
+}
+
+func newPieceVerifier(seg interfaces.Segment) *pieceVerifier {
+	v := &pieceVerifier{
+		seg:    seg,
+		points: make(map[uint64]types.Hash, len(seg.Points)),
+		last: ledger.HashHeight{
+			Height: seg.From - 1,
+			Hash:   seg.PrevHash,
+		},
+	}
+
+	for _, p := range seg.Points {
+		v.points[p.Height] = p.Hash
+	}
+
+	return v
+}
+
+func (v *pieceVerifier) write(p []byte) (err error) {
+	v.buf = append(v.buf, p...)
+
+	var n int
+	for len(v.buf)-n >= 4 {
+		size := int(binary.BigEndian.Uint32(v.buf[n:]))
+		if size == 0 || size > maxBlockBytes {
+			return invalidChunkError{fmt.Sprintf("wrong block size %d", size)}
+		}
+		if len(v.buf)-n-4 < size {
+			break
+		}
+
+		item := v.buf[n+4 : n+4+size]
+		n += 4 + size
+
+		if item[0] != chain_block.BlockTypeSnapshotBlock {
+			continue
+		}
+
+		if err = v.verifySnapshotBlock(item[1:]); err != nil {
+			return
+		}
+	}
+
+	v.buf = append(v.buf[:0], v.buf[n:]...)
+
+	return nil
+}
+
+func (v *pieceVerifier) verifySnapshotBlock(data []byte) (err error) {
+	v.decode, err = snappy.Decode(v.decode[:cap(v.decode)], data)
+	if err != nil {
+		return invalidChunkError{fmt.Sprintf("failed to decode snapshot block: %v", err)}
+	}
+
+	sb := &ledger.SnapshotBlock{}
+	if err = sb.Deserialize(v.decode); err != nil {
+		return invalidChunkError{fmt.Sprintf("failed to deserialize snapshot block: %v", err)}
+	}
+
+	if sb.Height != v.last.Height+1 || sb.PrevHash != v.last.Hash {
+		return invalidChunkError{fmt.Sprintf("snapshot block %s/%d is not next to %s/%d", sb.Hash, sb.Height, v.last.Hash, v.last.Height)}
+	}
+	if sb.Height > v.seg.To {
+		return invalidChunkError{fmt.Sprintf("snapshot block %s/%d is out of %s", sb.Hash, sb.Height, v.seg)}
+	}
+	if hash := sb.ComputeHash(); hash != sb.Hash {
+		return invalidChunkError{fmt.Sprintf("snapshot block %s/%d has wrong hash %s", sb.Hash, sb.Height, hash)}
+	}
+	if hash, ok := v.points[sb.Height]; ok && hash != sb.Hash {
+		return invalidChunkError{fmt.Sprintf("snapshot block %s/%d is not %s", sb.Hash, sb.Height, hash)}
+	}
+
+	v.last.Height = sb.Height
+	v.last.Hash = sb.Hash
+
+	return nil
+}
+
+// done check the whole segment has been received
+func (v *pieceVerifier) done() error {
+	if len(v.buf) != 0 {
+		return invalidChunkError{fmt.Sprintf("%d bytes left", len(v.buf))}
+	}
+
+	if v.last.Height != v.seg.To || v.last.Hash != v.seg.Hash {
+		return invalidChunkError{fmt.Sprintf("end at %s/%d, but should be %s/%d", v.last.Hash, v.last.Height, v.seg.Hash, v.seg.To)}
+	}
+
+	return nil
+}
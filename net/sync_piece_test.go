@@ -0,0 +1,139 @@
+package net
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/vitelabs/go-vite/chain/block"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/interfaces"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func TestSplitTask(t *testing.T) {
+	seg := interfaces.Segment{
+		From:     101,
+		To:       1250,
+		PrevHash: types.Hash{1},
+		Hash:     types.Hash{2},
+	}
+	for h := uint64(100); h <= 1200; h += syncTaskSize {
+		seg.Points = append(seg.Points, &ledger.HashHeight{
+			Height: h,
+			Hash:   types.Hash{byte(h / syncTaskSize)},
+		})
+	}
+
+	pieces := splitTask(seg)
+	if len(pieces) != 3 {
+		t.Fatalf("should be 3 pieces, but get %d", len(pieces))
+	}
+	if pieces[0].From != 101 || pieces[0].To != 600 || pieces[0].PrevHash != seg.PrevHash || pieces[0].Hash != (types.Hash{6}) {
+		t.Fatalf("wrong piece %s", pieces[0])
+	}
+	if pieces[1].From != 601 || pieces[1].To != 1100 || pieces[1].PrevHash != (types.Hash{6}) || pieces[1].Hash != (types.Hash{11}) {
+		t.Fatalf("wrong piece %s", pieces[1])
+	}
+	if pieces[2].From != 1101 || pieces[2].To != 1250 || pieces[2].PrevHash != (types.Hash{11}) || pieces[2].Hash != seg.Hash {
+		t.Fatalf("wrong piece %s", pieces[2])
+	}
+
+	// no points
+	seg.Points = nil
+	if pieces = splitTask(seg); len(pieces) != 1 || !pieces[0].Equal(seg) {
+		t.Fatalf("segment without points should not be split")
+	}
+}
+
+func mockChunkStream(t *testing.T, prev ledger.HashHeight, count int) (data []byte, blocks []*ledger.SnapshotBlock) {
+	now := time.Unix(1500000000, 0)
+	for i := 0; i < count; i++ {
+		sb := &ledger.SnapshotBlock{
+			PrevHash:  prev.Hash,
+			Height:    prev.Height + 1,
+			Timestamp: &now,
+		}
+		sb.Hash = sb.ComputeHash()
+		prev = ledger.HashHeight{Height: sb.Height, Hash: sb.Hash}
+		blocks = append(blocks, sb)
+	}
+
+	return encodeChunkStream(t, blocks), blocks
+}
+
+func encodeChunkStream(t *testing.T, blocks []*ledger.SnapshotBlock) (data []byte) {
+	for _, sb := range blocks {
+		buf, err := sb.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		item := append([]byte{chain_block.BlockTypeSnapshotBlock}, snappy.Encode(nil, buf)...)
+		size := make([]byte, 4)
+		binary.BigEndian.PutUint32(size, uint32(len(item)))
+		data = append(data, size...)
+		data = append(data, item...)
+	}
+
+	return
+}
+
+func TestPieceVerifier(t *testing.T) {
+	initForkPointsForLightTest()
+
+	prev := ledger.HashHeight{Height: 100, Hash: types.Hash{1}}
+	data, blocks := mockChunkStream(t, prev, 10)
+	last := blocks[len(blocks)-1]
+
+	seg := interfaces.Segment{
+		From:     101,
+		To:       110,
+		PrevHash: prev.Hash,
+		Hash:     last.Hash,
+		Points: []*ledger.HashHeight{
+			&prev,
+			{Height: 105, Hash: blocks[4].Hash},
+			{Height: 110, Hash: last.Hash},
+		},
+	}
+
+	// write in small pieces
+	v := newPieceVerifier(seg)
+	for i := 0; i < len(data); i += 7 {
+		end := i + 7
+		if end > len(data) {
+			end = len(data)
+		}
+		if err := v.write(data[i:end]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := v.done(); err != nil {
+		t.Fatal(err)
+	}
+
+	// incomplete
+	v = newPieceVerifier(seg)
+	if err := v.write(data[:len(data)-1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.done(); !isInvalidChunk(err) {
+		t.Fatalf("incomplete chunk should be invalid: %v", err)
+	}
+
+	// wrong point
+	seg2 := seg
+	seg2.Points = []*ledger.HashHeight{{Height: 105, Hash: types.Hash{5}}}
+	v = newPieceVerifier(seg2)
+	if err := v.write(data); !isInvalidChunk(err) {
+		t.Fatalf("chunk should not match the point: %v", err)
+	}
+
+	// tampered block
+	blocks[3].Seed = 1
+	v = newPieceVerifier(seg)
+	if err := v.write(encodeChunkStream(t, blocks)); !isInvalidChunk(err) {
+		t.Fatalf("tampered block should be invalid: %v", err)
+	}
+}
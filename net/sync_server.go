@@ -29,6 +29,7 @@ import (
 
 	"github.com/vitelabs/go-vite/interfaces"
 	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/net/netool"
 )
 
 const fileTimeout = 5 * time.Minute
@@ -47,17 +48,19 @@ type syncServer struct {
 	sconnMap map[peerId]*syncConn // key is addr
 	chain    ledgerReader
 	factory  syncConnReceiver
+	upload   int64 // max upload bytes per second of one connection, 0 means no limit
 	running  int32
 	wg       sync.WaitGroup
 	log      log15.Logger
 }
 
-func newSyncServer(addr string, chain ledgerReader, factory syncConnReceiver) *syncServer {
+func newSyncServer(addr string, chain ledgerReader, factory syncConnReceiver, upload int64) *syncServer {
 	return &syncServer{
 		addr:     addr,
 		sconnMap: make(map[peerId]*syncConn),
 		chain:    chain,
 		factory:  factory,
+		upload:   upload,
 		log:      log15.New("module", "server"),
 	}
 }
@@ -164,6 +167,11 @@ func (s *syncServer) handleConn(conn net2.Conn) {
 	s.addConn(sconn)
 	defer s.deleteConn(sconn)
 
+	var w io.Writer = sconn.conn
+	if limiter := netool.NewRateLimiter(s.upload, s.upload); limiter != nil {
+		w = &rateLimitedWriter{sconn.conn, limiter}
+	}
+
 	var msg Msg
 	for {
 		msg, err = sconn.c.ReadMsg()
@@ -243,8 +251,12 @@ func (s *syncServer) handleConn(conn net2.Conn) {
 		}
 
 		var wn int64
-		_ = conn.SetWriteDeadline(time.Now().Add(fileTimeout))
-		wn, err = io.Copy(conn, reader)
+		timeout := fileTimeout
+		if s.upload > 0 {
+			timeout += time.Duration(int64(reader.Size())/s.upload) * time.Second
+		}
+		_ = sconn.conn.SetWriteDeadline(time.Now().Add(timeout))
+		wn, err = io.Copy(w, reader)
 		_ = reader.Close()
 
 		if wn != int64(reader.Size()) {
@@ -259,3 +271,17 @@ func (s *syncServer) handleConn(conn net2.Conn) {
 		}
 	}
 }
+
+// rateLimitedWriter waits for the tokens of limiter before write
+type rateLimitedWriter struct {
+	io.Writer
+	limiter *netool.RateLimiter
+}
+
+func (w *rateLimitedWriter) Write(p []byte) (n int, err error) {
+	if d := w.limiter.Take(int64(len(p))); d > 0 {
+		time.Sleep(d)
+	}
+
+	return w.Writer.Write(p)
+}
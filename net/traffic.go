@@ -0,0 +1,137 @@
+/*
+ * Copyright 2019 The go-vite Authors
+ * This file is part of the go-vite library.
+ *
+ * The go-vite library is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Lesser General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * The go-vite library is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Lesser General Public License for more details.
+ *
+ * You should have received a copy of the GNU Lesser General Public License
+ * along with the go-vite library. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package net
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/vitelabs/go-vite/metrics"
+)
+
+// TrafficInfo is the count of messages and payload bytes received and sent
+type TrafficInfo struct {
+	InMsgs   uint64 `json:"inMsgs"`
+	InBytes  uint64 `json:"inBytes"`
+	OutMsgs  uint64 `json:"outMsgs"`
+	OutBytes uint64 `json:"outBytes"`
+}
+
+// trafficMeter counts the traffic of a peer, in total and by message code
+type trafficMeter struct {
+	mu    sync.Mutex
+	total TrafficInfo
+	codes map[Code]*TrafficInfo
+}
+
+func newTrafficMeter() *trafficMeter {
+	return &trafficMeter{
+		codes: make(map[Code]*TrafficInfo),
+	}
+}
+
+func (t *trafficMeter) getLocked(code Code) *TrafficInfo {
+	info, ok := t.codes[code]
+	if !ok {
+		info = new(TrafficInfo)
+		t.codes[code] = info
+	}
+	return info
+}
+
+func (t *trafficMeter) in(code Code, size int) {
+	t.mu.Lock()
+	t.total.InMsgs++
+	t.total.InBytes += uint64(size)
+	info := t.getLocked(code)
+	info.InMsgs++
+	info.InBytes += uint64(size)
+	t.mu.Unlock()
+
+	inTraffic.mark(code, size)
+}
+
+func (t *trafficMeter) out(code Code, size int) {
+	t.mu.Lock()
+	t.total.OutMsgs++
+	t.total.OutBytes += uint64(size)
+	info := t.getLocked(code)
+	info.OutMsgs++
+	info.OutBytes += uint64(size)
+	t.mu.Unlock()
+
+	outTraffic.mark(code, size)
+}
+
+// info return the total traffic, and the traffic by message code
+func (t *trafficMeter) info() (total TrafficInfo, codes map[string]TrafficInfo) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	codes = make(map[string]TrafficInfo, len(t.codes))
+	for code, info := range t.codes {
+		codes[strconv.Itoa(int(code))] = *info
+	}
+
+	return t.total, codes
+}
+
+// trafficMetrics feed the traffic of all peers into metrics, the meters are registered as `prefix` and `prefix/code`
+type trafficMetrics struct {
+	prefix string
+	mu     sync.RWMutex
+	total  metrics.Meter
+	meters map[Code]metrics.Meter
+}
+
+var inTraffic = &trafficMetrics{
+	prefix: "net/traffic/in",
+	meters: make(map[Code]metrics.Meter),
+}
+
+var outTraffic = &trafficMetrics{
+	prefix: "net/traffic/out",
+	meters: make(map[Code]metrics.Meter),
+}
+
+func (t *trafficMetrics) mark(code Code, size int) {
+	if !metrics.MetricsEnabled {
+		return
+	}
+
+	t.mu.RLock()
+	total, meter := t.total, t.meters[code]
+	t.mu.RUnlock()
+
+	if meter == nil {
+		t.mu.Lock()
+		if t.total == nil {
+			t.total = metrics.GetOrRegisterMeter(t.prefix, nil)
+		}
+		if meter = t.meters[code]; meter == nil {
+			meter = metrics.GetOrRegisterMeter(t.prefix+"/"+strconv.Itoa(int(code)), nil)
+			t.meters[code] = meter
+		}
+		total = t.total
+		t.mu.Unlock()
+	}
+
+	total.Mark(int64(size))
+	meter.Mark(int64(size))
+}
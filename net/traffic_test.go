@@ -0,0 +1,23 @@
+package net
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestTrafficMeter(t *testing.T) {
+	tm := newTrafficMeter()
+	tm.in(CodeGetSnapshotBlocks, 10)
+	tm.out(CodeSnapshotBlocks, 100)
+	tm.out(CodeSnapshotBlocks, 50)
+
+	total, codes := tm.info()
+	if total.InMsgs != 1 || total.InBytes != 10 || total.OutMsgs != 2 || total.OutBytes != 150 {
+		t.Fatalf("wrong total traffic: %+v", total)
+	}
+
+	info := codes[strconv.Itoa(int(CodeSnapshotBlocks))]
+	if info.OutMsgs != 2 || info.OutBytes != 150 || info.InMsgs != 0 {
+		t.Fatalf("wrong traffic of code: %+v", info)
+	}
+}
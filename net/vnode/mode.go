@@ -5,6 +5,7 @@ package vnode
 // Relay nodes usually are the standby producers, and partial full nodes (like static nodes)
 // Regular nodes usually are the full nodes
 // Edge nodes usually are the light nodes
+// Light nodes only sync snapshot headers, and fetch account blocks of specific addresses on demand
 type NodeMode byte
 
 const (
@@ -12,6 +13,7 @@ const (
 	Regular
 	Relay
 	Core
+	Light
 )
 
 func (n NodeMode) String() string {
@@ -24,6 +26,8 @@ func (n NodeMode) String() string {
 		return "relay"
 	case Core:
 		return "core"
+	case Light:
+		return "light"
 	default:
 		return "unknown"
 	}
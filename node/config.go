@@ -32,6 +32,7 @@ type Config struct {
 	LedgerGcRetain uint64          `json:"LedgerGcRetain"`
 	LedgerGc       *bool           `json:"LedgerGc"`
 	OpenPlugins    *bool           `json:"OpenPlugins"`
+	ArchiveMode    *bool           `json:"ArchiveMode"`    // keep the state redo log of every snapshot block forever
 	VmLogWhiteList []types.Address `json:"vmLogWhiteList"` // contract address white list which save VM logs
 	VmLogAll       *bool           `json:"vmLogAll"`       // save all VM logs, it will cost more disk space
 
@@ -49,19 +50,27 @@ type Config struct {
 	NetID              int
 	PeerKey            string `json:"PrivateKey"`
 	Discover           bool
+	NAT                string
 	MaxPeers           int
 	MinPeers           int
 	MaxInboundRatio    int
 	MaxPendingPeers    int
 	BootNodes          []string
 	BootSeeds          []string
+	BootTrees          []string
 	StaticNodes        []string
+	TrustedNodes       []string
 	AccessControl      string
 	AccessAllowKeys    []string
 	AccessDenyKeys     []string
 	BlackBlockHashList []string // from high to low, like: "xxxxxx-11111"
 	WhiteBlockList     []string // from high to low, like: "xxxxxx-10001"
 	ForwardStrategy    string
+	EncryptTransport   bool
+	ServeRateLimit     int64
+	UploadRateLimit    int64
+	LightMode          bool
+	LightProducers     []string // like: "sbpName/vite_xxxxxx"
 
 	//producer
 	EntropyStorePath     string `json:"EntropyStorePath"`
@@ -106,6 +115,10 @@ type Config struct {
 	// subscribe
 	SubscribeEnabled bool `json:"SubscribeEnabled"`
 
+	// sink
+	Sinks         []config.SinkTarget `json:"Sinks"`
+	SinkQueueSize int                 `json:"SinkQueueSize"`
+
 	// dashboard
 	DashboardTargetURL string
 
@@ -134,6 +147,7 @@ func (c *Config) makeViteConfig() *config.Config {
 		Net:       c.makeNetConfig(),
 		Vm:        c.makeVmConfig(),
 		Subscribe: c.makeSubscribeConfig(),
+		Sink:      c.makeSinkConfig(),
 		Reward:    c.makeRewardConfig(),
 		Genesis:   config_gen.MakeGenesisConfig(c.GenesisFile),
 		LogLevel:  c.LogLevel,
@@ -155,9 +169,12 @@ func (c *Config) makeNetConfig() *config.Net {
 		DataDir:            datadir,
 		PeerKey:            c.PeerKey,
 		Discover:           c.Discover,
+		NAT:                c.NAT,
 		BootNodes:          c.BootNodes,
 		BootSeeds:          c.BootSeeds,
+		BootTrees:          c.BootTrees,
 		StaticNodes:        c.StaticNodes,
+		TrustedNodes:       c.TrustedNodes,
 		MaxPeers:           c.MaxPeers,
 		MaxInboundRatio:    c.MaxInboundRatio,
 		MinPeers:           c.MinPeers,
@@ -168,6 +185,11 @@ func (c *Config) makeNetConfig() *config.Net {
 		AccessDenyKeys:     c.AccessDenyKeys,
 		BlackBlockHashList: c.BlackBlockHashList,
 		WhiteBlockList:     c.WhiteBlockList,
+		EncryptTransport:   c.EncryptTransport,
+		ServeRateLimit:     c.ServeRateLimit,
+		UploadRateLimit:    c.UploadRateLimit,
+		Light:              c.LightMode,
+		LightProducers:     c.LightProducers,
 		MineKey:            nil,
 	}
 }
@@ -194,6 +216,13 @@ func (c *Config) makeSubscribeConfig() *config.Subscribe {
 	}
 }
 
+func (c *Config) makeSinkConfig() *config.Sink {
+	return &config.Sink{
+		SinkTargets:   c.Sinks,
+		SinkQueueSize: c.SinkQueueSize,
+	}
+}
+
 func (c *Config) makeMetricsConfig() *metrics.Config {
 	mc := &metrics.Config{
 		IsEnable:         false,
@@ -240,6 +269,12 @@ func (c *Config) makeChainConfig() *config.Chain {
 		openPlugins = *c.OpenPlugins
 	}
 
+	// is open archive mode
+	archiveMode := false
+	if c.ArchiveMode != nil {
+		archiveMode = *c.ArchiveMode
+	}
+
 	// save all VM logs, it will cost more disk space
 	vmLogAll := false
 	if c.VmLogAll != nil {
@@ -249,6 +284,7 @@ func (c *Config) makeChainConfig() *config.Chain {
 		LedgerGcRetain: c.LedgerGcRetain,
 		LedgerGc:       ledgerGc,
 		OpenPlugins:    openPlugins,
+		ArchiveMode:    archiveMode,
 		VmLogWhiteList: c.VmLogWhiteList,
 		VmLogAll:       vmLogAll,
 	}
@@ -23,19 +23,20 @@ var DefaultNodeConfig = Config{
 	WSExposeAll:   true,
 	HttpExposeAll: true,
 
-	Single:          config.DefaultSingle,
-	Identity:        config.DefaultNodeName,
-	NetID:           config.DefaultNetID,
-	ListenInterface: config.DefaultListenInterface,
-	Port:            config.DefaultPort,
-	FilePort:        config.DefaultFilePort,
-	Discover:        config.DefaultDiscover,
-	MaxPeers:        config.DefaultMaxPeers,
-	MaxInboundRatio: config.DefaultMaxInboundRatio,
-	MinPeers:        config.DefaultMinPeers,
-	MaxPendingPeers: config.DefaultMaxPendingPeers,
-	ForwardStrategy: config.DefaultForwardStrategy,
-	AccessControl:   config.DefaultAccessControl,
+	Single:           config.DefaultSingle,
+	Identity:         config.DefaultNodeName,
+	NetID:            config.DefaultNetID,
+	ListenInterface:  config.DefaultListenInterface,
+	Port:             config.DefaultPort,
+	FilePort:         config.DefaultFilePort,
+	Discover:         config.DefaultDiscover,
+	MaxPeers:         config.DefaultMaxPeers,
+	MaxInboundRatio:  config.DefaultMaxInboundRatio,
+	MinPeers:         config.DefaultMinPeers,
+	MaxPendingPeers:  config.DefaultMaxPendingPeers,
+	ForwardStrategy:  config.DefaultForwardStrategy,
+	AccessControl:    config.DefaultAccessControl,
+	EncryptTransport: config.DefaultEncryptTransport,
 }
 
 // DefaultDataDir is the default data directory to use for the databases and other persistence requirements.
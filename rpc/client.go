@@ -640,11 +640,20 @@ func (c *Client) handleNotification(msg *jsonrpcMessage) {
 	var subResult struct {
 		ID     string          `json:"subscription"`
 		Result json.RawMessage `json:"result"`
+		Error  *jsonError      `json:"error"`
 	}
 	if err := json.Unmarshal(msg.Params, &subResult); err != nil {
 		log.Debug("dropping invalid subscription message", "msg", msg)
 		return
 	}
+	// the subscription is ended by the server
+	if subResult.Error != nil {
+		if sub := c.subs[subResult.ID]; sub != nil {
+			delete(c.subs, subResult.ID)
+			sub.quitWithError(subResult.Error, false)
+		}
+		return
+	}
 	if c.subs[subResult.ID] != nil {
 		c.subs[subResult.ID].deliver(subResult.Result)
 	}
@@ -283,6 +283,30 @@ func TestClientSubscribeCustomNamespace(t *testing.T) {
 	}
 }
 
+func TestClientSubscribeServerClose(t *testing.T) {
+	server := newTestServer("eth", new(NotificationTestService))
+	defer server.Stop()
+	client := DialInProc(server)
+	defer client.Close()
+
+	nc := make(chan int)
+	sub, err := client.EthSubscribe(context.Background(), nc, "failingSubscription", "replay failed")
+	if err != nil {
+		t.Fatal("can't subscribe:", err)
+	}
+
+	select {
+	case v := <-nc:
+		t.Fatal("received value from the failed subscription:", v)
+	case err := <-sub.Err():
+		if err == nil || err.Error() != "replay failed" {
+			t.Fatalf("Err returned %v, want the error of the server", err)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatalf("subscription not closed within 1s after the server closed it")
+	}
+}
+
 // In this test, the connection drops while EthSubscribe is
 // waiting for chain response.
 func TestClientSubscribeClose(t *testing.T) {
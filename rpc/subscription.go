@@ -38,6 +38,7 @@ type Subscription struct {
 	ID        ID
 	namespace string
 	err       chan error // closed on unsubscribe
+	closeErr  error      // sent to the client when the subscription is activated if it is closed before that
 }
 
 // Err returns chain channel that is closed when the client send an unsubscribe request.
@@ -120,6 +121,32 @@ func (n *Notifier) unsubscribe(id ID) error {
 	return ErrSubscriptionNotFound
 }
 
+// Close sends err to the client and ends the subscription, it is used by the server callbacks when the
+// subscription can not continue. The error is sent after the subscription is activated if it is not active yet.
+func (n *Notifier) Close(id ID, err error) error {
+	n.subMu.Lock()
+	defer n.subMu.Unlock()
+	if s, found := n.inactive[id]; found {
+		s.closeErr = err
+		return nil
+	}
+	if s, found := n.active[id]; found {
+		close(s.err)
+		delete(n.active, id)
+		return n.writeError(s, err)
+	}
+	return ErrSubscriptionNotFound
+}
+
+func (n *Notifier) writeError(sub *Subscription, err error) error {
+	notification := n.codec.CreateErrorNotification(string(sub.ID), sub.namespace, &callbackError{err.Error()})
+	if err := n.codec.Write(notification); err != nil {
+		n.codec.Close()
+		return err
+	}
+	return nil
+}
+
 // activate enables chain subscription. Until chain subscription is enabled all
 // notifications are dropped. This method is called by the RPC server after
 // the subscription ID was sent to client. This prevents notifications being
@@ -129,7 +156,12 @@ func (n *Notifier) activate(id ID, namespace string) {
 	defer n.subMu.Unlock()
 	if sub, found := n.inactive[id]; found {
 		sub.namespace = namespace
-		n.active[id] = sub
 		delete(n.inactive, id)
+		if sub.closeErr != nil {
+			close(sub.err)
+			n.writeError(sub, sub.closeErr)
+			return
+		}
+		n.active[id] = sub
 	}
 }
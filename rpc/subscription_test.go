@@ -19,6 +19,7 @@ package rpc
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"sync"
@@ -105,6 +106,18 @@ func (s *NotificationTestService) HangSubscription(ctx context.Context, val int)
 	return subscription, nil
 }
 
+// FailingSubscription ends the subscription with an error before sending anything.
+func (s *NotificationTestService) FailingSubscription(ctx context.Context, msg string) (*Subscription, error) {
+	notifier, supported := NotifierFromContext(ctx)
+	if !supported {
+		return nil, ErrNotificationsUnsupported
+	}
+
+	subscription := notifier.CreateSubscription()
+	notifier.Close(subscription.ID, errors.New(msg))
+	return subscription, nil
+}
+
 func TestNotifications(t *testing.T) {
 	server := NewServer()
 	service := &NotificationTestService{}
@@ -214,7 +227,7 @@ func waitForMessages(t *testing.T, in *json.Decoder, successes chan<- jsonSucces
 				notifications <- jsonNotification{
 					Version: msg["jsonrpc"].(string),
 					Method:  msg["method"].(string),
-					Params:  jsonSubscription{params["subscription"].(string), params["result"]},
+					Params:  jsonSubscription{Subscription: params["subscription"].(string), Result: params["result"]},
 				}
 				continue
 			}
@@ -108,6 +108,8 @@ type ServerCodec interface {
 	CreateErrorResponseWithInfo(id interface{}, err Error, info interface{}) interface{}
 	// Create notification response
 	CreateNotification(id, namespace string, event interface{}) interface{}
+	// Create notification response which ends the subscription with an error
+	CreateErrorNotification(id, namespace string, err Error) interface{}
 	// Write msg to client.
 	Write(msg interface{}) error
 	// Close underlying data stream
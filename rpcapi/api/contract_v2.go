@@ -9,6 +9,7 @@ import (
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/vite"
 	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/asm"
 	"github.com/vitelabs/go-vite/vm/contracts"
 	"github.com/vitelabs/go-vite/vm/contracts/abi"
 	"github.com/vitelabs/go-vite/vm/quota"
@@ -82,6 +83,19 @@ func (c *ContractApi) GetContractInfo(addr types.Address) (*ContractInfo, error)
 	}, nil
 }
 
+// Disassemble disassembles the code of the contract, and analyzes it with the instruction set of the latest snapshot block
+func (c *ContractApi) Disassemble(addr types.Address) (*asm.Report, error) {
+	code, err := c.chain.GetContractCode(addr)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return nil, nil
+	}
+	_, code = util.UnpackContractCode(code)
+	return asm.Analyze(code, c.chain.GetLatestSnapshotBlock().Height), nil
+}
+
 type CallOffChainMethodParam struct {
 	SelfAddr          types.Address  `json:"selfAddr"` // Deprecated: use address field instead
 	Addr              *types.Address `json:"address"`
@@ -163,6 +177,28 @@ func (c *ContractApi) GetContractStorage(addr types.Address, prefix string) (map
 	}
 }
 
+// GetStorageAt returns the hex encoded storage value of key after the snapshot block of snapshotHeight was inserted
+func (c *ContractApi) GetStorageAt(addr types.Address, key string, snapshotHeight string) (*string, error) {
+	keyBytes, err := hex.DecodeString(key)
+	if err != nil {
+		return nil, err
+	}
+	height, err := StringToUint64(snapshotHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := c.chain.GetValueAt(addr, keyBytes, height)
+	if err != nil {
+		return nil, err
+	}
+	if len(value) == 0 {
+		return nil, nil
+	}
+	result := hex.EncodeToString(value)
+	return &result, nil
+}
+
 type QuotaInfo struct {
 	CurrentQuota string  `json:"currentQuota"`
 	MaxQuota     string  `json:"maxQuota"`
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+const maxStateDiffRange = 1000
+
+type AccountStateDiff struct {
+	Address types.Address  `json:"address"`
+	Balance []*BalanceDiff `json:"balance"`
+	Storage []*StorageDiff `json:"storage"`
+	Code    string         `json:"code,omitempty"`
+}
+
+type BalanceDiff struct {
+	TokenId types.TokenTypeId `json:"tokenId"`
+	From    string            `json:"from"`
+	To      string            `json:"to"`
+}
+
+// StorageDiff holds the hex values of a storage key, an empty value means the key is not existed
+type StorageDiff struct {
+	Key  string `json:"key"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// GetStateDiff returns the changes of the balances and storage of the accounts made by the snapshot blocks in [fromHeight, toHeight].
+// addrList is optional, all accounts changed are returned if it is empty.
+// The diff is built from the state redo logs, which are pruned for the old snapshot blocks unless the node runs
+// in the archive mode, so a range older than the retained redo logs returns an error.
+func (api DebugApi) GetStateDiff(fromHeightStr, toHeightStr string, addrList *[]types.Address) ([]*AccountStateDiff, error) {
+	fromHeight, err := StringToUint64(fromHeightStr)
+	if err != nil {
+		return nil, err
+	}
+	toHeight, err := StringToUint64(toHeightStr)
+	if err != nil {
+		return nil, err
+	}
+	if toHeight >= fromHeight && toHeight-fromHeight >= maxStateDiffRange {
+		return nil, errors.New(fmt.Sprintf("the snapshot height range should be less than %d", maxStateDiffRange))
+	}
+	var addresses []types.Address
+	if addrList != nil {
+		addresses = *addrList
+	}
+	diffs, err := api.v.Chain().GetStateDiff(fromHeight, toHeight, addresses)
+	if err != nil {
+		return nil, err
+	}
+	return ToAccountStateDiffList(diffs), nil
+}
+
+// ToAccountStateDiffList converts the state diffs of the chain, the list is sorted by the address
+func ToAccountStateDiffList(diffs map[types.Address]*chain.AccountStateDiff) []*AccountStateDiff {
+	list := make([]*AccountStateDiff, 0, len(diffs))
+	for addr, diff := range diffs {
+		result := &AccountStateDiff{
+			Address: addr,
+			Balance: make([]*BalanceDiff, 0, len(diff.Balance)),
+			Storage: make([]*StorageDiff, 0, len(diff.Storage)),
+			Code:    hex.EncodeToString(diff.Code),
+		}
+		for tokenId, balanceDiff := range diff.Balance {
+			result.Balance = append(result.Balance, &BalanceDiff{
+				TokenId: tokenId,
+				From:    balanceDiff.From.String(),
+				To:      balanceDiff.To.String(),
+			})
+		}
+		sort.Slice(result.Balance, func(i, j int) bool {
+			return result.Balance[i].TokenId.String() < result.Balance[j].TokenId.String()
+		})
+		for _, storageDiff := range diff.Storage {
+			result.Storage = append(result.Storage, &StorageDiff{
+				Key:  hex.EncodeToString(storageDiff.Key),
+				From: hex.EncodeToString(storageDiff.From),
+				To:   hex.EncodeToString(storageDiff.To),
+			})
+		}
+		sort.Slice(result.Storage, func(i, j int) bool {
+			return result.Storage[i].Key < result.Storage[j].Key
+		})
+		list = append(list, result)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Address.String() < list[j].Address.String()
+	})
+	return list
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"math/big"
+	"strconv"
+	"testing"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func TestToAccountStateDiffList(t *testing.T) {
+	tokenId := types.TokenTypeId{1}
+	diffs := map[types.Address]*chain.AccountStateDiff{
+		types.AddressAsset: {
+			Balance: map[types.TokenTypeId]*chain.BalanceDiff{
+				ledger.ViteTokenId: {From: big.NewInt(1), To: big.NewInt(3)},
+				tokenId:            {From: big.NewInt(0), To: big.NewInt(2)},
+			},
+			Storage: map[string]*chain.StorageDiff{
+				"b": {Key: []byte("b"), To: []byte{1}},
+				"a": {Key: []byte("a"), From: []byte{1}, To: []byte{3}},
+			},
+			Code: []byte{1, 2},
+		},
+		types.AddressQuota: {
+			Balance: map[types.TokenTypeId]*chain.BalanceDiff{},
+			Storage: map[string]*chain.StorageDiff{},
+		},
+	}
+
+	list := ToAccountStateDiffList(diffs)
+	if len(list) != 2 || list[0].Address != types.AddressQuota || list[1].Address != types.AddressAsset {
+		t.Fatalf("the list is not sorted by the address, %+v", list)
+	}
+	if len(list[0].Balance) != 0 || len(list[0].Storage) != 0 || list[0].Code != "" {
+		t.Fatalf("empty diff error, %+v", list[0])
+	}
+
+	diff := list[1]
+	if diff.Code != "0102" || len(diff.Balance) != 2 || len(diff.Storage) != 2 {
+		t.Fatalf("diff error, %+v", diff)
+	}
+	if diff.Balance[0].TokenId.String() > diff.Balance[1].TokenId.String() {
+		t.Fatalf("the balances are not sorted by the token id, %+v %+v", diff.Balance[0], diff.Balance[1])
+	}
+	for _, balance := range diff.Balance {
+		if (balance.TokenId == ledger.ViteTokenId && (balance.From != "1" || balance.To != "3")) ||
+			(balance.TokenId == tokenId && (balance.From != "0" || balance.To != "2")) {
+			t.Fatalf("balance diff error, %+v", balance)
+		}
+	}
+	if *diff.Storage[0] != (StorageDiff{Key: "61", From: "01", To: "03"}) ||
+		*diff.Storage[1] != (StorageDiff{Key: "62", From: "", To: "01"}) {
+		t.Fatalf("storage diff error, %+v %+v", diff.Storage[0], diff.Storage[1])
+	}
+}
+
+func TestDebugApi_GetStateDiff(t *testing.T) {
+	if _, err := (DebugApi{}).GetStateDiff("1", strconv.Itoa(maxStateDiffRange+1), nil); err == nil {
+		t.Fatal("the range is limited")
+	}
+	if _, err := (DebugApi{}).GetStateDiff("a", "1", nil); err == nil {
+		t.Fatal("the height should be a number")
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/generator"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/util"
+)
+
+const (
+	StructTracer = "structTracer"
+	CallTracer   = "callTracer"
+)
+
+type resultTracer interface {
+	vm.Tracer
+	GetResult() interface{}
+}
+
+var tracerMap = map[string]func() resultTracer{
+	StructTracer: func() resultTracer { return vm.NewStructTracer() },
+	CallTracer:   func() resultTracer { return vm.NewCallTracer() },
+}
+
+// TraceAccountBlock executes the account block again against the state before it was inserted and returns the trace.
+// The tracer is structTracer by default, which lists every opcode, or callTracer, which lists the send blocks and vm logs.
+// The state is rebuilt from the redo logs, so the blocks confirmed by the old snapshot blocks can be traced in the archive mode only.
+func (api DebugApi) TraceAccountBlock(hash types.Hash, tracer *string) (interface{}, error) {
+	tracerName := StructTracer
+	if tracer != nil {
+		tracerName = *tracer
+	}
+	newTracer, ok := tracerMap[tracerName]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("unknown tracer %s", tracerName))
+	}
+
+	c := api.v.Chain()
+	block, err := c.GetAccountBlockByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+	if block == nil {
+		return nil, errors.New("account block not exist")
+	}
+
+	var sendBlock *ledger.AccountBlock
+	if block.IsReceiveBlock() {
+		if sendBlock, err = c.GetAccountBlockByHash(block.FromBlockHash); err != nil {
+			return nil, err
+		}
+		if sendBlock == nil {
+			return nil, errors.New("send block not exist")
+		}
+	} else if block.BlockType == ledger.BlockTypeSendReward || block.BlockType == ledger.BlockTypeSendRefund {
+		return nil, errors.New("the send block is created by a contract, trace its receive block instead")
+	}
+
+	db, err := c.NewHistoryVmDb(block)
+	if err != nil {
+		return nil, err
+	}
+	sb, err := db.LatestSnapshotBlock()
+	if err != nil {
+		return nil, err
+	}
+
+	var status *generator.VMGlobalStatus
+	if block.IsReceiveBlock() {
+		if status, err = generator.GetVMGlobalStatus(c, block.AccountAddress, sendBlock.Hash, sb); err != nil {
+			return nil, err
+		}
+	}
+
+	t := newTracer()
+	v := vm.NewVM(util.NewVMConsensusReader(api.v.Consensus().SBPReader()))
+	v.SetTracer(t)
+	vmBlock, _, err := v.RunV2(db, block, sendBlock, status)
+	if err == util.ErrChainForked {
+		return nil, err
+	}
+	// the state before the block is rebuilt from the history, which may miss the unconfirmed blocks of the other accounts
+	if vmBlock == nil || !isSameExecution(vmBlock.AccountBlock, block) {
+		return nil, errors.New("the account block is executed with a different result, the state before it can not be rebuilt")
+	}
+	return t.GetResult(), nil
+}
+
+// isSameExecution checks the executed block against the block inserted, the hashes of the send blocks cover
+// their amount, token and data, so the execution made the same transfers, vm logs and result
+func isSameExecution(executed *ledger.AccountBlock, block *ledger.AccountBlock) bool {
+	if executed.BlockType != block.BlockType ||
+		executed.Quota != block.Quota ||
+		executed.QuotaUsed != block.QuotaUsed ||
+		!bytes.Equal(executed.Data, block.Data) ||
+		len(executed.SendBlockList) != len(block.SendBlockList) {
+		return false
+	}
+	if (executed.LogHash == nil) != (block.LogHash == nil) ||
+		(executed.LogHash != nil && *executed.LogHash != *block.LogHash) {
+		return false
+	}
+	for i, sendBlock := range executed.SendBlockList {
+		if sendBlock.ComputeSendHash(executed, uint8(i)) != block.SendBlockList[i].Hash {
+			return false
+		}
+	}
+	return true
+}
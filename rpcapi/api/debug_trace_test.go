@@ -0,0 +1,58 @@
+package api
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func TestIsSameExecution(t *testing.T) {
+	logHash := types.DataHash([]byte{1})
+	newBlock := func() *ledger.AccountBlock {
+		block := &ledger.AccountBlock{
+			BlockType:      ledger.BlockTypeReceive,
+			AccountAddress: types.AddressAsset,
+			Height:         2,
+			PrevHash:       types.DataHash([]byte{2}),
+			Quota:          21000,
+			QuotaUsed:      21000,
+			Data:           []byte{0},
+			LogHash:        &logHash,
+			SendBlockList: []*ledger.AccountBlock{{
+				BlockType:      ledger.BlockTypeSendCall,
+				AccountAddress: types.AddressAsset,
+				ToAddress:      types.AddressQuota,
+				Amount:         big.NewInt(1),
+				TokenId:        ledger.ViteTokenId,
+				Fee:            big.NewInt(0),
+			}},
+		}
+		block.SendBlockList[0].Hash = block.SendBlockList[0].ComputeSendHash(block, 0)
+		return block
+	}
+	block := newBlock()
+
+	if !isSameExecution(newBlock(), block) {
+		t.Fatal("the same execution is rejected")
+	}
+
+	executed := newBlock()
+	executed.Data = []byte{1}
+	if isSameExecution(executed, block) {
+		t.Fatal("the data is not compared")
+	}
+
+	executed = newBlock()
+	executed.LogHash = nil
+	if isSameExecution(executed, block) {
+		t.Fatal("the log hash is not compared")
+	}
+
+	executed = newBlock()
+	executed.SendBlockList[0].Amount = big.NewInt(2)
+	if isSameExecution(executed, block) {
+		t.Fatal("the send blocks are not compared")
+	}
+}
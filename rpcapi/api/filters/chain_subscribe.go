@@ -1,6 +1,7 @@
 package filters
 
 import (
+	"github.com/vitelabs/go-vite/chain/plugins"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/vite"
@@ -69,11 +70,15 @@ func (c *ChainSubscribe) PrepareInsertAccountBlocks(blocks []*vm_db.VmAccountBlo
 }
 
 func (c *ChainSubscribe) InsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
-	acEvents := make([]*AccountChainEvent, len(blocks))
-	for i, b := range blocks {
-		acEvents[i] = NewAccountChainEvent(b.AccountBlock, b.VmDb.GetLogList())
+	acEvents := make([]*AccountChainEvent, 0, len(blocks))
+	for _, b := range blocks {
+		if c.es.blockMayMatch(b.AccountBlock) {
+			acEvents = append(acEvents, NewAccountChainEvent(b.AccountBlock, b.VmDb.GetLogList()))
+		}
+	}
+	if len(acEvents) > 0 {
+		c.es.acCh <- acEvents
 	}
-	c.es.acCh <- acEvents
 	return nil
 }
 
@@ -91,6 +96,9 @@ func (c *ChainSubscribe) InsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) er
 func (c *ChainSubscribe) PrepareDeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
 	acEvents := make([]*AccountChainEvent, 0)
 	for _, b := range blocks {
+		if !c.es.blockMayMatch(b) {
+			continue
+		}
 		if b.LogHash != nil {
 			logList, err := c.vite.Chain().GetVmLogList(b.LogHash)
 			if err != nil {
@@ -111,10 +119,25 @@ func (c *ChainSubscribe) DeleteAccountBlocks(blocks []*ledger.AccountBlock) erro
 	return nil
 }
 func (c *ChainSubscribe) PrepareDeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	logBloomPlugin := getVmLogBloomPlugin(c.vite.Chain().Plugins())
+
 	acEvents := make([]*AccountChainEvent, 0)
 	for _, chunk := range chunks {
+		skipLogs := false
+		if logBloomPlugin != nil && chunk.SnapshotBlock != nil {
+			if logBloom, ok := logBloomPlugin.GetRolledBackBloom(chunk.SnapshotBlock.Height); ok {
+				// no vm log in the chunk or no installed log filter may match, skip loading vm logs
+				skipLogs = logBloom == nil || !c.es.logsMayMatch(logBloom)
+			}
+		}
+
 		for _, b := range chunk.AccountBlocks {
-			if b.LogHash != nil {
+			if !c.es.blockMayMatch(b) {
+				continue
+			}
+			if skipLogs {
+				acEvents = append(acEvents, NewAccountChainEvent(b, nil))
+			} else if b.LogHash != nil {
 				logList, err := c.vite.Chain().GetVmLogList(b.LogHash)
 				if err != nil {
 					c.es.log.Error("get log list failed when preDeleteSnapshotBlocks", "addr", b.AccountAddress, "hash", b.Hash, "height", b.Height, "err", err)
@@ -128,6 +151,18 @@ func (c *ChainSubscribe) PrepareDeleteSnapshotBlocks(chunks []*ledger.SnapshotCh
 	c.preDeleteAccountBlocks = append(c.preDeleteAccountBlocks, acEvents...)
 	return nil
 }
+
+func getVmLogBloomPlugin(plugins *chain_plugins.Plugins) *chain_plugins.VmLogBloom {
+	if plugins == nil {
+		return nil
+	}
+	logBloom, ok := plugins.GetPlugin("vmLogBloom").(*chain_plugins.VmLogBloom)
+	if !ok {
+		return nil
+	}
+	return logBloom
+}
+
 func (c *ChainSubscribe) DeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
 	sbEvents := make([]*SnapshotChainEvent, 0, len(chunks))
 	for _, b := range chunks {
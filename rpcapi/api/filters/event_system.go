@@ -1,6 +1,8 @@
 package filters
 
 import (
+	"github.com/vitelabs/go-vite/chain/plugins"
+	"github.com/vitelabs/go-vite/common/bloom"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/log15"
@@ -53,6 +55,16 @@ type EventSystem struct {
 	sbDelCh   chan []*SnapshotChainEvent
 	stop      chan struct{}
 	log       log15.Logger
+
+	// params of the installed log filters, read by ChainSubscribe to skip snapshot chunks by log bloom
+	logParams   map[rpc.ID]*api.FilterParam
+	logParamsMu sync.RWMutex
+
+	// addresses of the installed account block and onroad filters, read by ChainSubscribe to skip
+	// the account blocks which no filter is interested in
+	addrSubs     map[types.Address]int
+	allBlockSubs int
+	addrSubsMu   sync.RWMutex
 }
 
 const (
@@ -75,6 +87,8 @@ func NewEventSystem(v *vite.Vite) *EventSystem {
 		uninstall: make(chan *subscription, uninstallSize),
 		stop:      make(chan struct{}),
 		log:       log15.New("module", "rpc_api/event_system"),
+		logParams: make(map[rpc.ID]*api.FilterParam),
+		addrSubs:  make(map[types.Address]int),
 	}
 	return es
 }
@@ -109,10 +123,20 @@ func (es *EventSystem) eventLoop() {
 		case i := <-es.install:
 			es.log.Info("install ", "id", i.id)
 			index[i.typ][i.id] = i
+			if i.typ == LogsSubscription || i.typ == LogsSubscriptionV2 {
+				es.setLogParam(i.id, i.param)
+			} else {
+				es.updateAddrSub(i, 1)
+			}
 			close(i.installed)
 		case u := <-es.uninstall:
 			es.log.Info("uninstall ", "id", u.id)
 			delete(index[u.typ], u.id)
+			if u.typ == LogsSubscription || u.typ == LogsSubscriptionV2 {
+				es.setLogParam(u.id, nil)
+			} else {
+				es.updateAddrSub(u, -1)
+			}
 			close(u.err)
 
 		// system stopped
@@ -129,6 +153,90 @@ func (es *EventSystem) eventLoop() {
 	}
 }
 
+func (es *EventSystem) setLogParam(id rpc.ID, param *api.FilterParam) {
+	es.logParamsMu.Lock()
+	defer es.logParamsMu.Unlock()
+
+	if param == nil {
+		delete(es.logParams, id)
+	} else {
+		es.logParams[id] = param
+	}
+}
+
+// logsMayMatch returns false if no installed log filter matches logBloom
+func (es *EventSystem) logsMayMatch(logBloom *bloom.LogBloom) bool {
+	es.logParamsMu.RLock()
+	defer es.logParamsMu.RUnlock()
+
+	for _, param := range es.logParams {
+		if paramMayMatch(param, logBloom) {
+			return true
+		}
+	}
+	return false
+}
+
+func (es *EventSystem) updateAddrSub(sub *subscription, delta int) {
+	es.addrSubsMu.Lock()
+	defer es.addrSubsMu.Unlock()
+
+	switch sub.typ {
+	case AccountBlocksSubscription:
+		es.allBlockSubs += delta
+	case AccountBlocksWithHeightSubscription, AccountBlocksWithHeightSubscriptionV2,
+		OnroadBlocksSubscription, OnroadBlocksSubscriptionV2:
+		if es.addrSubs[sub.addr] += delta; es.addrSubs[sub.addr] <= 0 {
+			delete(es.addrSubs, sub.addr)
+		}
+	}
+}
+
+// blockMayMatch returns false if no installed filter is interested in the account block, the logs are
+// checked by the addresses of log filters only
+func (es *EventSystem) blockMayMatch(block *ledger.AccountBlock) bool {
+	es.addrSubsMu.RLock()
+	matched := es.allBlockSubs > 0 || es.addrSubs[block.AccountAddress] > 0 || es.addrSubs[block.ToAddress] > 0
+	if !matched {
+		for _, sendBlock := range block.SendBlockList {
+			if es.addrSubs[sendBlock.ToAddress] > 0 {
+				matched = true
+				break
+			}
+		}
+	}
+	es.addrSubsMu.RUnlock()
+
+	return matched || (block.LogHash != nil && es.logsMayMatchAddr(block.AccountAddress))
+}
+
+// logsMayMatchAddr returns false if no installed log filter matches the logs of addr
+func (es *EventSystem) logsMayMatchAddr(addr types.Address) bool {
+	es.logParamsMu.RLock()
+	defer es.logParamsMu.RUnlock()
+
+	for _, param := range es.logParams {
+		if param.AddrRange == nil {
+			return true
+		}
+		if _, ok := param.AddrRange[addr]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func paramMayMatch(param *api.FilterParam, logBloom *bloom.LogBloom) bool {
+	var addrList []types.Address
+	if len(param.AddrRange) > 0 {
+		addrList = make([]types.Address, 0, len(param.AddrRange))
+		for addr := range param.AddrRange {
+			addrList = append(addrList, addr)
+		}
+	}
+	return chain_plugins.BloomMatches(logBloom, addrList, param.Topics)
+}
+
 func (es *EventSystem) handleSbEvent(filters map[FilterType]map[rpc.ID]*subscription, sbEvent []*SnapshotChainEvent, removed bool) {
 	if len(sbEvent) == 0 {
 		return
@@ -0,0 +1,111 @@
+package filters
+
+import (
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/rpc"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+func newTestEventSystem() *EventSystem {
+	return &EventSystem{
+		logParams: make(map[rpc.ID]*api.FilterParam),
+		addrSubs:  make(map[types.Address]int),
+	}
+}
+
+func TestEventSystem_blockMayMatch(t *testing.T) {
+	es := newTestEventSystem()
+	subscribed := types.AddressAsset
+	other := types.AddressQuota
+	logHash := types.DataHash([]byte{1})
+
+	if es.blockMayMatch(&ledger.AccountBlock{AccountAddress: subscribed}) {
+		t.Fatal("no filter is installed")
+	}
+
+	sub := &subscription{typ: OnroadBlocksSubscriptionV2, addr: subscribed}
+	es.updateAddrSub(sub, 1)
+	for i, block := range []*ledger.AccountBlock{
+		{AccountAddress: subscribed},
+		{AccountAddress: other, ToAddress: subscribed},
+		{AccountAddress: other, SendBlockList: []*ledger.AccountBlock{{ToAddress: subscribed}}},
+	} {
+		if !es.blockMayMatch(block) {
+			t.Fatalf("block %d should match the address filter", i)
+		}
+	}
+	if es.blockMayMatch(&ledger.AccountBlock{AccountAddress: other, ToAddress: other, LogHash: &logHash}) {
+		t.Fatal("block of other address should not match")
+	}
+	es.updateAddrSub(sub, -1)
+	if es.blockMayMatch(&ledger.AccountBlock{AccountAddress: subscribed}) || len(es.addrSubs) != 0 {
+		t.Fatal("the address filter should be uninstalled")
+	}
+
+	// log filters are matched by address
+	es.setLogParam("1", &api.FilterParam{AddrRange: map[types.Address]api.HeightRange{subscribed: {}}})
+	if !es.blockMayMatch(&ledger.AccountBlock{AccountAddress: subscribed, LogHash: &logHash}) {
+		t.Fatal("block with logs should match the log filter")
+	}
+	if es.blockMayMatch(&ledger.AccountBlock{AccountAddress: subscribed}) {
+		t.Fatal("block without logs should not match the log filter")
+	}
+	if es.blockMayMatch(&ledger.AccountBlock{AccountAddress: other, LogHash: &logHash}) {
+		t.Fatal("logs of other address should not match")
+	}
+
+	// all the blocks are sent to the account block filter
+	es.updateAddrSub(&subscription{typ: AccountBlocksSubscription}, 1)
+	if !es.blockMayMatch(&ledger.AccountBlock{AccountAddress: other}) {
+		t.Fatal("block should match the account block filter")
+	}
+}
+
+type mockLogVmDb struct {
+	vm_db.VmDb
+	logList ledger.VmLogList
+}
+
+func (db *mockLogVmDb) GetLogList() ledger.VmLogList {
+	return db.logList
+}
+
+func TestChainSubscribe_InsertAccountBlocks(t *testing.T) {
+	es := newTestEventSystem()
+	es.acCh = make(chan []*AccountChainEvent, 1)
+	c := &ChainSubscribe{es: es}
+
+	subscribed := types.AddressAsset
+	other := types.AddressQuota
+	vmLog := &ledger.VmLog{Topics: []types.Hash{types.DataHash([]byte("Transfer"))}}
+	logHash := types.DataHash([]byte{1})
+
+	newVmBlock := func(addr types.Address, height uint64) *vm_db.VmAccountBlock {
+		return &vm_db.VmAccountBlock{
+			AccountBlock: &ledger.AccountBlock{AccountAddress: addr, Height: height, LogHash: &logHash},
+			VmDb:         &mockLogVmDb{logList: ledger.VmLogList{vmLog}},
+		}
+	}
+
+	// no filter is installed, no event is sent
+	if err := c.InsertAccountBlocks([]*vm_db.VmAccountBlock{newVmBlock(subscribed, 1)}); err != nil {
+		t.Fatal(err)
+	}
+	if len(es.acCh) != 0 {
+		t.Fatal("no event should be sent")
+	}
+
+	// only the blocks of the subscribed address are sent, with their logs
+	es.updateAddrSub(&subscription{typ: AccountBlocksWithHeightSubscriptionV2, addr: subscribed}, 1)
+	if err := c.InsertAccountBlocks([]*vm_db.VmAccountBlock{newVmBlock(other, 1), newVmBlock(subscribed, 2)}); err != nil {
+		t.Fatal(err)
+	}
+	events := <-es.acCh
+	if len(events) != 1 || events[0].Addr != subscribed || events[0].Height != 2 || len(events[0].Logs) != 1 {
+		t.Fatalf("unexpected events %+v", events)
+	}
+}
@@ -0,0 +1,377 @@
+package filters
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/chain/plugins"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/rpcapi/api"
+)
+
+const (
+	getAccountBlocksCount = 100 // snapshot chunks of account blocks read from the chain at a time
+
+	// the events of the latest snapshot blocks at the time of subscription may still be queued in the event system,
+	// so the blocks confirmed by them may be delivered by both the replay and the live events
+	replayTrackMargin = 100
+)
+
+type replayChain interface {
+	GetLatestSnapshotBlock() *ledger.SnapshotBlock
+	GetSubLedger(startHeight, endHeight uint64) ([]*ledger.SnapshotChunk, error)
+	GetUnconfirmedBlocks(addr types.Address) []*ledger.AccountBlock
+	GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error)
+	Plugins() *chain_plugins.Plugins
+}
+
+type replayBatch struct {
+	items  interface{}
+	hashes []types.Hash // hashes of the blocks which may be delivered again by the live events
+}
+
+// replay replays the historical events of a subscription from the chain, then switches to the live events.
+// The live events arrived during the replay are held back, and the blocks already replayed are skipped when they are released.
+// The removed events are never skipped. All methods can be called on a nil replay, which means no replay.
+type replay struct {
+	chain      replayChain
+	fromHeight uint64
+	trackFrom  uint64
+
+	replaying bool
+	pending   []interface{}
+	seen      map[types.Hash]struct{}
+
+	out  chan *replayBatch
+	err  chan error
+	quit chan struct{}
+}
+
+// newReplay returns nil if fromSnapshotHeight is nil. It must be called before subscribing the live events.
+func newReplay(c replayChain, fromSnapshotHeight *string) (*replay, error) {
+	if fromSnapshotHeight == nil {
+		return nil, nil
+	}
+
+	fromHeight, err := api.StringToUint64(*fromSnapshotHeight)
+	if err != nil {
+		return nil, err
+	}
+	if fromHeight < 1 {
+		fromHeight = 1
+	}
+
+	latestHeight := c.GetLatestSnapshotBlock().Height
+	if fromHeight > latestHeight+1 {
+		return nil, errors.New(fmt.Sprintf("fromSnapshotHeight %d is higher than the latest snapshot height %d", fromHeight, latestHeight))
+	}
+
+	trackFrom := uint64(1)
+	if latestHeight > replayTrackMargin {
+		trackFrom = latestHeight - replayTrackMargin
+	}
+
+	return &replay{
+		chain:      c,
+		fromHeight: fromHeight,
+		trackFrom:  trackFrom,
+		replaying:  true,
+		seen:       make(map[types.Hash]struct{}),
+		out:        make(chan *replayBatch),
+		err:        make(chan error, 1),
+		quit:       make(chan struct{}),
+	}, nil
+}
+
+// start runs fn in a new goroutine, the batches sent by fn are received from batches()
+func (r *replay) start(fn func() error) {
+	if r == nil {
+		return
+	}
+	go func() {
+		if err := fn(); err != nil {
+			r.err <- err
+			return
+		}
+		close(r.out)
+	}()
+}
+
+func (r *replay) stop() {
+	if r == nil {
+		return
+	}
+	close(r.quit)
+}
+
+func (r *replay) batches() <-chan *replayBatch {
+	if r == nil {
+		return nil
+	}
+	return r.out
+}
+
+func (r *replay) errors() <-chan error {
+	if r == nil {
+		return nil
+	}
+	return r.err
+}
+
+// send returns false if the replay is stopped
+func (r *replay) send(items interface{}, hashes []types.Hash) bool {
+	select {
+	case r.out <- &replayBatch{items: items, hashes: hashes}:
+		return true
+	case <-r.quit:
+		return false
+	}
+}
+
+// received records the blocks of a replayed batch
+func (r *replay) received(batch *replayBatch) {
+	for _, hash := range batch.hashes {
+		r.seen[hash] = struct{}{}
+	}
+}
+
+// hold keeps the live events back if the replay is not finished
+func (r *replay) hold(events interface{}) bool {
+	if r == nil || !r.replaying {
+		return false
+	}
+	r.pending = append(r.pending, events)
+	return true
+}
+
+// finish returns the live events held back during the replay
+func (r *replay) finish() []interface{} {
+	r.replaying = false
+	pending := r.pending
+	r.pending = nil
+	return pending
+}
+
+func (r *replay) skip(hash types.Hash, removed bool) bool {
+	if removed {
+		delete(r.seen, hash)
+		return false
+	}
+	_, ok := r.seen[hash]
+	return ok
+}
+
+func (r *replay) filterSnapshotBlocks(blocks []*SnapshotBlock) []*SnapshotBlock {
+	if r == nil {
+		return blocks
+	}
+	result := make([]*SnapshotBlock, 0, len(blocks))
+	for _, b := range blocks {
+		if !r.skip(b.Hash, b.Removed) {
+			result = append(result, b)
+		}
+	}
+	return result
+}
+
+func (r *replay) filterAccountBlocks(blocks []*AccountBlockWithHeight) []*AccountBlockWithHeight {
+	if r == nil {
+		return blocks
+	}
+	result := make([]*AccountBlockWithHeight, 0, len(blocks))
+	for _, b := range blocks {
+		if !r.skip(b.Hash, b.Removed) {
+			result = append(result, b)
+		}
+	}
+	return result
+}
+
+func (r *replay) filterLogs(logs []*Logs) []*Logs {
+	if r == nil {
+		return logs
+	}
+	result := make([]*Logs, 0, len(logs))
+	for _, l := range logs {
+		if !r.skip(l.AccountBlockHash, l.Removed) {
+			result = append(result, l)
+		}
+	}
+	return result
+}
+
+// GetHeightPage returns the last height and the count of the page from start, which has at most count heights,
+// and whether it is the last page of [start, end]
+func GetHeightPage(start uint64, end uint64, count uint64) (uint64, uint64, bool) {
+	gap := end - start + 1
+	if gap <= count {
+		return end, gap, true
+	}
+	return start + count - 1, count, false
+}
+
+// replayChunks calls handle with the snapshot chunks in [fromHeight, toHeight], getAccountBlocksCount chunks at a time.
+// It stops if handle returns false.
+func (r *replay) replayChunks(fromHeight, toHeight uint64, handle func(chunks []*ledger.SnapshotChunk) bool) (bool, error) {
+	for start := fromHeight; start <= toHeight; {
+		end, _, _ := GetHeightPage(start, toHeight, getAccountBlocksCount)
+
+		chunks, err := r.chain.GetSubLedger(start-1, end)
+		if err != nil {
+			return false, err
+		}
+		if len(chunks) > 0 && (chunks[0].SnapshotBlock == nil || chunks[0].SnapshotBlock.Height == start-1) {
+			chunks = chunks[1:]
+		}
+		if !handle(chunks) {
+			return false, nil
+		}
+		start = end + 1
+	}
+	return true, nil
+}
+
+func (r *replay) replaySnapshotBlocks() error {
+	_, err := r.replayChunks(r.fromHeight, r.chain.GetLatestSnapshotBlock().Height, func(chunks []*ledger.SnapshotChunk) bool {
+		blocks := make([]*SnapshotBlock, 0, len(chunks))
+		var hashes []types.Hash
+		for _, chunk := range chunks {
+			if chunk.SnapshotBlock == nil {
+				continue
+			}
+			sb := chunk.SnapshotBlock
+			blocks = append(blocks, &SnapshotBlock{Hash: sb.Hash, Height: sb.Height, HeightStr: api.Uint64ToString(sb.Height)})
+			if sb.Height >= r.trackFrom {
+				hashes = append(hashes, sb.Hash)
+			}
+		}
+		return len(blocks) == 0 || r.send(blocks, hashes)
+	})
+	return err
+}
+
+// replayAccountBlocks calls handle with the account blocks confirmed from fromHeight, then the unconfirmed blocks.
+// skipChunk is optional.
+func (r *replay) replayAccountBlocks(unconfirmed func() []*ledger.AccountBlock, skipChunk func(chunk *ledger.SnapshotChunk) bool,
+	handle func(blocks []*ledger.AccountBlock, track bool) bool) error {
+	handleChunks := func(chunks []*ledger.SnapshotChunk, sent map[types.Hash]struct{}) bool {
+		var blocks []*ledger.AccountBlock
+		track := false
+		for _, chunk := range chunks {
+			if skipChunk != nil && skipChunk(chunk) {
+				continue
+			}
+			for _, block := range chunk.AccountBlocks {
+				if _, ok := sent[block.Hash]; !ok {
+					blocks = append(blocks, block)
+				}
+			}
+			if chunk.SnapshotBlock != nil && chunk.SnapshotBlock.Height >= r.trackFrom {
+				track = true
+			}
+		}
+		return len(blocks) == 0 || handle(blocks, track)
+	}
+
+	latestHeight := r.chain.GetLatestSnapshotBlock().Height
+	ok, err := r.replayChunks(r.fromHeight, latestHeight, func(chunks []*ledger.SnapshotChunk) bool {
+		return handleChunks(chunks, nil)
+	})
+	if err != nil || !ok {
+		return err
+	}
+
+	unconfirmedBlocks := unconfirmed()
+	if len(unconfirmedBlocks) > 0 && !handle(unconfirmedBlocks, true) {
+		return nil
+	}
+
+	// the blocks inserted and confirmed after reading the snapshot chunks
+	if newLatestHeight := r.chain.GetLatestSnapshotBlock().Height; newLatestHeight > latestHeight {
+		sent := make(map[types.Hash]struct{}, len(unconfirmedBlocks))
+		for _, block := range unconfirmedBlocks {
+			sent[block.Hash] = struct{}{}
+		}
+		_, err = r.replayChunks(latestHeight+1, newLatestHeight, func(chunks []*ledger.SnapshotChunk) bool {
+			return handleChunks(chunks, sent)
+		})
+	}
+	return err
+}
+
+func (r *replay) replayAccountBlocksByAddr(addr types.Address) error {
+	unconfirmed := func() []*ledger.AccountBlock {
+		return r.chain.GetUnconfirmedBlocks(addr)
+	}
+	return r.replayAccountBlocks(unconfirmed, nil, func(blocks []*ledger.AccountBlock, track bool) bool {
+		msgs := make([]*AccountBlockWithHeight, 0, len(blocks))
+		var hashes []types.Hash
+		for _, block := range blocks {
+			if block.AccountAddress != addr {
+				continue
+			}
+			msgs = append(msgs, &AccountBlockWithHeight{Height: block.Height, HeightStr: api.Uint64ToString(block.Height), Hash: block.Hash})
+			if track {
+				hashes = append(hashes, block.Hash)
+			}
+		}
+		return len(msgs) == 0 || r.send(msgs, hashes)
+	})
+}
+
+func (r *replay) replayLogs(param *api.FilterParam) error {
+	unconfirmed := func() []*ledger.AccountBlock {
+		var blocks []*ledger.AccountBlock
+		for addr := range param.AddrRange {
+			blocks = append(blocks, r.chain.GetUnconfirmedBlocks(addr)...)
+		}
+		return blocks
+	}
+
+	// skip the chunks which the log bloom rules out
+	var skipChunk func(chunk *ledger.SnapshotChunk) bool
+	if logBloomPlugin := getVmLogBloomPlugin(r.chain.Plugins()); logBloomPlugin != nil {
+		skipChunk = func(chunk *ledger.SnapshotChunk) bool {
+			if chunk.SnapshotBlock == nil {
+				return false
+			}
+			logBloom, ok, err := logBloomPlugin.GetBloom(chunk.SnapshotBlock.Height)
+			if err != nil || !ok {
+				return false
+			}
+			return logBloom == nil || !paramMayMatch(param, logBloom)
+		}
+	}
+
+	var replayErr error
+	err := r.replayAccountBlocks(unconfirmed, skipChunk, func(blocks []*ledger.AccountBlock, track bool) bool {
+		var logs []*Logs
+		var hashes []types.Hash
+		for _, block := range blocks {
+			if block.LogHash == nil {
+				continue
+			}
+			if _, ok := param.AddrRange[block.AccountAddress]; !ok {
+				continue
+			}
+
+			logList, err := r.chain.GetVmLogList(block.LogHash)
+			if err != nil {
+				replayErr = err
+				return false
+			}
+			if matchedLogs := filterLogs(NewAccountChainEvent(block, logList), param, false); len(matchedLogs) > 0 {
+				logs = append(logs, matchedLogs...)
+				if track {
+					hashes = append(hashes, block.Hash)
+				}
+			}
+		}
+		return len(logs) == 0 || r.send(logs, hashes)
+	})
+	if replayErr != nil {
+		return replayErr
+	}
+	return err
+}
@@ -0,0 +1,181 @@
+package filters
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain/plugins"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"gotest.tools/assert"
+)
+
+type mockReplayChain struct {
+	chunks      []*ledger.SnapshotChunk
+	unconfirmed []*ledger.AccountBlock
+	err         error
+}
+
+func (c *mockReplayChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return c.chunks[len(c.chunks)-1].SnapshotBlock
+}
+
+func (c *mockReplayChain) GetSubLedger(startHeight, endHeight uint64) ([]*ledger.SnapshotChunk, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	var chunks []*ledger.SnapshotChunk
+	for _, chunk := range c.chunks {
+		if chunk.SnapshotBlock.Height >= startHeight && chunk.SnapshotBlock.Height <= endHeight {
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks, nil
+}
+
+func (c *mockReplayChain) GetUnconfirmedBlocks(addr types.Address) []*ledger.AccountBlock {
+	return c.unconfirmed
+}
+
+func (c *mockReplayChain) GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error) {
+	return nil, nil
+}
+
+func (c *mockReplayChain) Plugins() *chain_plugins.Plugins {
+	return nil
+}
+
+func newReplayTestChunk(height uint64, blocks ...*ledger.AccountBlock) *ledger.SnapshotChunk {
+	return &ledger.SnapshotChunk{
+		SnapshotBlock: &ledger.SnapshotBlock{Height: height, Hash: types.DataHash([]byte{byte(height)})},
+		AccountBlocks: blocks,
+	}
+}
+
+func newReplayTestBlock(addr types.Address, height uint64) *ledger.AccountBlock {
+	return &ledger.AccountBlock{
+		AccountAddress: addr,
+		Height:         height,
+		Hash:           types.DataHash(append(addr.Bytes(), byte(height))),
+	}
+}
+
+// receiveReplay receives the replayed batches until the replay is finished
+func receiveReplay(t *testing.T, r *replay) []interface{} {
+	var items []interface{}
+	for {
+		select {
+		case batch, ok := <-r.batches():
+			if !ok {
+				return items
+			}
+			r.received(batch)
+			items = append(items, batch.items)
+		case err := <-r.errors():
+			t.Fatal(err)
+		case <-time.After(time.Second):
+			t.Fatal("replay timeout")
+		}
+	}
+}
+
+func TestReplay_accountBlocksByAddr(t *testing.T) {
+	alice, bob := types.AddressGovernance, types.AddressAsset
+	alice1, alice2, alice3, bob1 := newReplayTestBlock(alice, 1), newReplayTestBlock(alice, 2), newReplayTestBlock(alice, 3), newReplayTestBlock(bob, 1)
+	c := &mockReplayChain{
+		chunks:      []*ledger.SnapshotChunk{newReplayTestChunk(1), newReplayTestChunk(2, alice1, bob1), newReplayTestChunk(3, alice2)},
+		unconfirmed: []*ledger.AccountBlock{alice3},
+	}
+
+	from := "2"
+	r, err := newReplay(c, &from)
+	assert.NilError(t, err)
+
+	// the live events arrived during the replay are held back
+	live := []*AccountBlockWithHeight{
+		{Hash: alice2.Hash, Height: 2, Removed: true},
+		{Hash: alice3.Hash, Height: 3},
+	}
+	assert.Assert(t, r.hold(live))
+
+	r.start(func() error {
+		return r.replayAccountBlocksByAddr(alice)
+	})
+	items := receiveReplay(t, r)
+	assert.Equal(t, len(items), 2)
+	confirmed, unconfirmed := items[0].([]*AccountBlockWithHeight), items[1].([]*AccountBlockWithHeight)
+	assert.Equal(t, len(confirmed), 2)
+	assert.Equal(t, confirmed[0].Hash, alice1.Hash)
+	assert.Equal(t, confirmed[1].Hash, alice2.Hash)
+	assert.Equal(t, len(unconfirmed), 1)
+	assert.Equal(t, unconfirmed[0].Hash, alice3.Hash)
+
+	// the replayed block is skipped, the removed one is kept
+	pending := r.finish()
+	assert.Equal(t, len(pending), 1)
+	blocks := r.filterAccountBlocks(pending[0].([]*AccountBlockWithHeight))
+	assert.Equal(t, len(blocks), 1)
+	assert.Equal(t, blocks[0].Hash, alice2.Hash)
+	assert.Assert(t, blocks[0].Removed)
+
+	// the live events are delivered after the switchover, alice2 is inserted again after the removal
+	assert.Assert(t, !r.hold(live))
+	blocks = r.filterAccountBlocks([]*AccountBlockWithHeight{{Hash: alice2.Hash, Height: 2}, {Hash: alice3.Hash, Height: 3}})
+	assert.Equal(t, len(blocks), 1)
+	assert.Equal(t, blocks[0].Hash, alice2.Hash)
+}
+
+func TestReplay_snapshotBlocks(t *testing.T) {
+	c := &mockReplayChain{}
+	for height := uint64(1); height <= getAccountBlocksCount+10; height++ {
+		c.chunks = append(c.chunks, newReplayTestChunk(height))
+	}
+
+	from := "5"
+	r, err := newReplay(c, &from)
+	assert.NilError(t, err)
+	r.start(r.replaySnapshotBlocks)
+
+	var heights []uint64
+	for _, items := range receiveReplay(t, r) {
+		for _, b := range items.([]*SnapshotBlock) {
+			heights = append(heights, b.Height)
+		}
+	}
+	assert.Equal(t, len(heights), getAccountBlocksCount+6)
+	for i, height := range heights {
+		assert.Equal(t, height, uint64(i+5))
+	}
+	assert.Equal(t, len(r.finish()), 0)
+}
+
+func TestReplay_error(t *testing.T) {
+	c := &mockReplayChain{chunks: []*ledger.SnapshotChunk{newReplayTestChunk(1)}}
+
+	from := "1"
+	r, err := newReplay(c, &from)
+	assert.NilError(t, err)
+
+	c.err = errors.New("get sub ledger failed")
+	r.start(r.replaySnapshotBlocks)
+	select {
+	case <-r.batches():
+		t.Fatal("the batches are closed after the replay failed")
+	case err := <-r.errors():
+		assert.Equal(t, err, c.err)
+	case <-time.After(time.Second):
+		t.Fatal("replay timeout")
+	}
+}
+
+func TestReplay_nil(t *testing.T) {
+	r, err := newReplay(&mockReplayChain{}, nil)
+	assert.NilError(t, err)
+	assert.Assert(t, r == nil)
+
+	blocks := []*SnapshotBlock{{Height: 1}}
+	assert.Assert(t, !r.hold(blocks))
+	assert.Equal(t, len(r.filterSnapshotBlocks(blocks)), 1)
+	assert.Assert(t, r.batches() == nil)
+}
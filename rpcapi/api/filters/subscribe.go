@@ -466,38 +466,73 @@ func (s *SubscribeApi) getChangesByFilterId(id rpc.ID) (interface{}, error) {
 
 // Deprecated: use subscribe_createSnapshotBlockSubscription instead
 func (s *SubscribeApi) NewSnapshotBlocks(ctx context.Context) (*rpc.Subscription, error) {
-	return s.createSnapshotBlockSubscription(ctx, SnapshotBlocksSubscription)
+	return s.createSnapshotBlockSubscription(ctx, SnapshotBlocksSubscription, nil)
 }
-func (s *SubscribeApi) CreateSnapshotBlockSubscription(ctx context.Context) (*rpc.Subscription, error) {
-	return s.createSnapshotBlockSubscription(ctx, SnapshotBlocksSubscriptionV2)
+
+// CreateSnapshotBlockSubscription replays the snapshot blocks from fromSnapshotHeight before the new ones if it is specified
+func (s *SubscribeApi) CreateSnapshotBlockSubscription(ctx context.Context, fromSnapshotHeight *string) (*rpc.Subscription, error) {
+	return s.createSnapshotBlockSubscription(ctx, SnapshotBlocksSubscriptionV2, fromSnapshotHeight)
 }
-func (s *SubscribeApi) createSnapshotBlockSubscription(ctx context.Context, ft FilterType) (*rpc.Subscription, error) {
+func (s *SubscribeApi) createSnapshotBlockSubscription(ctx context.Context, ft FilterType, fromSnapshotHeight *string) (*rpc.Subscription, error) {
 	s.log.Info("createSnapshotBlockSubscription")
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	replay, err := newReplay(s.vite.Chain(), fromSnapshotHeight)
+	if err != nil {
+		return nil, err
+	}
 	rpcSub := notifier.CreateSubscription()
 
 	go func() {
 		snapshotBlockHashChan := make(chan []*SnapshotBlock, 128)
 		sbSub := s.eventSystem.SubscribeSnapshotBlocks(snapshotBlockHashChan, ft)
+		replay.start(replay.replaySnapshotBlocks)
+
+		notify := func(h []*SnapshotBlock) {
+			if len(h) == 0 {
+				return
+			}
+			if ft == SnapshotBlocksSubscriptionV2 {
+				result := make([]*SnapshotBlockV2, len(h))
+				for i, b := range h {
+					result[i] = &SnapshotBlockV2{b.Hash, b.HeightStr, b.Removed}
+				}
+				notifier.Notify(rpcSub.ID, result)
+			} else {
+				notifier.Notify(rpcSub.ID, h)
+			}
+		}
+
+		replayBatches := replay.batches()
 		for {
 			select {
-			case h := <-snapshotBlockHashChan:
-				if ft == SnapshotBlocksSubscriptionV2 {
-					result := make([]*SnapshotBlockV2, len(h))
-					for i, b := range h {
-						result[i] = &SnapshotBlockV2{b.Hash, b.HeightStr, b.Removed}
+			case batch, ok := <-replayBatches:
+				if !ok {
+					replayBatches = nil
+					for _, h := range replay.finish() {
+						notify(replay.filterSnapshotBlocks(h.([]*SnapshotBlock)))
 					}
-					notifier.Notify(rpcSub.ID, result)
-				} else {
-					notifier.Notify(rpcSub.ID, h)
+					continue
+				}
+				replay.received(batch)
+				notify(batch.items.([]*SnapshotBlock))
+			case err := <-replay.errors():
+				s.log.Error("replay snapshot blocks failed", "err", err)
+				sbSub.Unsubscribe()
+				notifier.Close(rpcSub.ID, err)
+				return
+			case h := <-snapshotBlockHashChan:
+				if !replay.hold(h) {
+					notify(replay.filterSnapshotBlocks(h))
 				}
 			case <-rpcSub.Err():
+				replay.stop()
 				sbSub.Unsubscribe()
 				return
 			case <-notifier.Closed():
+				replay.stop()
 				sbSub.Unsubscribe()
 				return
 			}
@@ -544,38 +579,76 @@ func (s *SubscribeApi) createAccountBlockSubscription(ctx context.Context) (*rpc
 
 // Deprecated: use subscribe_createAccountBlockSubscriptionByAddress instead
 func (s *SubscribeApi) NewAccountBlocksByAddr(ctx context.Context, addr types.Address) (*rpc.Subscription, error) {
-	return s.createAccountBlockSubscriptionByAddress(ctx, addr, AccountBlocksWithHeightSubscription)
+	return s.createAccountBlockSubscriptionByAddress(ctx, addr, AccountBlocksWithHeightSubscription, nil)
 }
-func (s *SubscribeApi) CreateAccountBlockSubscriptionByAddress(ctx context.Context, addr types.Address) (*rpc.Subscription, error) {
-	return s.createAccountBlockSubscriptionByAddress(ctx, addr, AccountBlocksWithHeightSubscriptionV2)
+
+// CreateAccountBlockSubscriptionByAddress replays the account blocks confirmed from fromSnapshotHeight and
+// the unconfirmed ones before the new ones if it is specified
+func (s *SubscribeApi) CreateAccountBlockSubscriptionByAddress(ctx context.Context, addr types.Address, fromSnapshotHeight *string) (*rpc.Subscription, error) {
+	return s.createAccountBlockSubscriptionByAddress(ctx, addr, AccountBlocksWithHeightSubscriptionV2, fromSnapshotHeight)
 }
-func (s *SubscribeApi) createAccountBlockSubscriptionByAddress(ctx context.Context, addr types.Address, ft FilterType) (*rpc.Subscription, error) {
+func (s *SubscribeApi) createAccountBlockSubscriptionByAddress(ctx context.Context, addr types.Address, ft FilterType, fromSnapshotHeight *string) (*rpc.Subscription, error) {
 	s.log.Info("createAccountBlockSubscriptionByAddress")
 	notifier, supported := rpc.NotifierFromContext(ctx)
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	replay, err := newReplay(s.vite.Chain(), fromSnapshotHeight)
+	if err != nil {
+		return nil, err
+	}
 	rpcSub := notifier.CreateSubscription()
 
 	go func() {
 		accountBlockCh := make(chan []*AccountBlockWithHeight, 128)
 		acSub := s.eventSystem.SubscribeAccountBlocksByAddr(addr, accountBlockCh, ft)
+		replay.start(func() error {
+			return replay.replayAccountBlocksByAddr(addr)
+		})
+
+		notify := func(h []*AccountBlockWithHeight) {
+			if len(h) == 0 {
+				return
+			}
+			if ft == AccountBlocksWithHeightSubscriptionV2 {
+				result := make([]*AccountBlockWithHeightV2, len(h))
+				for i, b := range h {
+					result[i] = &AccountBlockWithHeightV2{b.Hash, b.HeightStr, b.Removed}
+				}
+				notifier.Notify(rpcSub.ID, result)
+			} else {
+				notifier.Notify(rpcSub.ID, h)
+			}
+		}
+
+		replayBatches := replay.batches()
 		for {
 			select {
-			case h := <-accountBlockCh:
-				if ft == AccountBlocksWithHeightSubscriptionV2 {
-					result := make([]*AccountBlockWithHeightV2, len(h))
-					for i, b := range h {
-						result[i] = &AccountBlockWithHeightV2{b.Hash, b.HeightStr, b.Removed}
+			case batch, ok := <-replayBatches:
+				if !ok {
+					replayBatches = nil
+					for _, h := range replay.finish() {
+						notify(replay.filterAccountBlocks(h.([]*AccountBlockWithHeight)))
 					}
-					notifier.Notify(rpcSub.ID, result)
-				} else {
-					notifier.Notify(rpcSub.ID, h)
+					continue
+				}
+				replay.received(batch)
+				notify(batch.items.([]*AccountBlockWithHeight))
+			case err := <-replay.errors():
+				s.log.Error("replay account blocks failed", "addr", addr, "err", err)
+				acSub.Unsubscribe()
+				notifier.Close(rpcSub.ID, err)
+				return
+			case h := <-accountBlockCh:
+				if !replay.hold(h) {
+					notify(replay.filterAccountBlocks(h))
 				}
 			case <-rpcSub.Err():
+				replay.stop()
 				acSub.Unsubscribe()
 				return
 			case <-notifier.Closed():
+				replay.stop()
 				acSub.Unsubscribe()
 				return
 			}
@@ -630,12 +703,15 @@ func (s *SubscribeApi) createUnreceivedBlockSubscriptionByAddress(ctx context.Co
 
 // Deprevated: use subscribe_createVmLogSubscription instead
 func (s *SubscribeApi) NewLogs(ctx context.Context, param RpcFilterParam) (*rpc.Subscription, error) {
-	return s.createVmLogSubscription(ctx, param.AddrRange, param.Topics, LogsSubscription)
+	return s.createVmLogSubscription(ctx, param.AddrRange, param.Topics, LogsSubscription, nil)
 }
-func (s *SubscribeApi) CreateVmlogSubscription(ctx context.Context, param api.VmLogFilterParam) (*rpc.Subscription, error) {
-	return s.createVmLogSubscription(ctx, param.AddrRange, param.Topics, LogsSubscriptionV2)
+
+// CreateVmlogSubscription replays the vm logs confirmed from fromSnapshotHeight and the unconfirmed ones
+// before the new ones if it is specified
+func (s *SubscribeApi) CreateVmlogSubscription(ctx context.Context, param api.VmLogFilterParam, fromSnapshotHeight *string) (*rpc.Subscription, error) {
+	return s.createVmLogSubscription(ctx, param.AddrRange, param.Topics, LogsSubscriptionV2, fromSnapshotHeight)
 }
-func (s *SubscribeApi) createVmLogSubscription(ctx context.Context, rangeMap map[string]*api.Range, topics [][]types.Hash, ft FilterType) (*rpc.Subscription, error) {
+func (s *SubscribeApi) createVmLogSubscription(ctx context.Context, rangeMap map[string]*api.Range, topics [][]types.Hash, ft FilterType, fromSnapshotHeight *string) (*rpc.Subscription, error) {
 	s.log.Info("createVmLogSubscription")
 	p, err := api.ToFilterParam(rangeMap, topics)
 	if err != nil {
@@ -646,29 +722,63 @@ func (s *SubscribeApi) createVmLogSubscription(ctx context.Context, rangeMap map
 	if !supported {
 		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
 	}
+	replay, err := newReplay(s.vite.Chain(), fromSnapshotHeight)
+	if err != nil {
+		return nil, err
+	}
 	rpcSub := notifier.CreateSubscription()
 
 	go func() {
 		logsMsg := make(chan []*Logs, 128)
 		sub := s.eventSystem.SubscribeLogs(p, logsMsg, ft)
+		replay.start(func() error {
+			return replay.replayLogs(p)
+		})
+
+		notify := func(msg []*Logs) {
+			if len(msg) == 0 {
+				return
+			}
+			if ft == LogsSubscriptionV2 {
+				result := make([]*LogsV2, len(msg))
+				for i, l := range msg {
+					result[i] = &LogsV2{l.Log, l.AccountBlockHash, l.AccountHeight, l.Addr, l.Removed}
+				}
+				notifier.Notify(rpcSub.ID, result)
+			} else {
+				notifier.Notify(rpcSub.ID, msg)
+			}
+		}
 
+		replayBatches := replay.batches()
 		for {
 			select {
-			case msg := <-logsMsg:
-				if ft == LogsSubscriptionV2 {
-					result := make([]*LogsV2, len(msg))
-					for i, l := range msg {
-						result[i] = &LogsV2{l.Log, l.AccountBlockHash, l.AccountHeight, l.Addr, l.Removed}
+			case batch, ok := <-replayBatches:
+				if !ok {
+					replayBatches = nil
+					for _, msg := range replay.finish() {
+						notify(replay.filterLogs(msg.([]*Logs)))
 					}
-					notifier.Notify(rpcSub.ID, result)
-				} else {
-					notifier.Notify(rpcSub.ID, msg)
+					continue
+				}
+				replay.received(batch)
+				notify(batch.items.([]*Logs))
+			case err := <-replay.errors():
+				s.log.Error("replay vm logs failed", "err", err)
+				sub.Unsubscribe()
+				notifier.Close(rpcSub.ID, err)
+				return
+			case msg := <-logsMsg:
+				if !replay.hold(msg) {
+					notify(replay.filterLogs(msg))
 				}
 
 			case <-rpcSub.Err():
+				replay.stop()
 				sub.Unsubscribe()
 				return
 			case <-notifier.Closed():
+				replay.stop()
 				sub.Unsubscribe()
 				return
 			}
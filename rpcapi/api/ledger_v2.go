@@ -64,6 +64,105 @@ func (l *LedgerApi) GetAccountBlocks(addr types.Address, originBlockHash *types.
 	}
 }
 
+const (
+	maxTransactionHistoryCount = 1000
+	maxVmLogCount              = 1000
+
+	transferDirectionIncoming = "incoming"
+	transferDirectionOutgoing = "outgoing"
+)
+
+type TransactionHistoryParam struct {
+	Address   types.Address      `json:"address"`
+	TokenId   *types.TokenTypeId `json:"tokenId"`
+	Direction string             `json:"direction"` // "incoming", "outgoing" or empty for both
+	Cursor    *string            `json:"cursor"`
+	Count     uint64             `json:"count"`
+}
+
+type TransactionHistory struct {
+	Transfers  []*Transfer `json:"transfers"`
+	NextCursor *string     `json:"nextCursor"`
+}
+
+type Transfer struct {
+	Direction      string        `json:"direction"`
+	Counterparty   types.Address `json:"counterparty"`
+	SnapshotHeight string        `json:"snapshotHeight"`
+	SendBlock      *AccountBlock `json:"sendBlock"`
+}
+
+// new api: the incoming and outgoing transfers of an address, including the ones not received yet
+func (l *LedgerApi) GetTransactionHistory(param TransactionHistoryParam) (*TransactionHistory, error) {
+	if param.Count == 0 {
+		return &TransactionHistory{}, nil
+	}
+	if param.Count > maxTransactionHistoryCount {
+		return nil, errors.New(fmt.Sprintf("count should be no more than %d", maxTransactionHistoryCount))
+	}
+
+	plugins := l.chain.Plugins()
+	if plugins == nil {
+		err := errors.New("config.OpenPlugins is false, api can't work")
+		return nil, err
+	}
+
+	plugin, ok := plugins.GetPlugin("txHistory").(*chain_plugins.TxHistory)
+	if !ok || plugin == nil {
+		return nil, errors.New("plugins-TxHistory's service not provided")
+	}
+
+	var direction byte
+	switch param.Direction {
+	case "":
+	case transferDirectionIncoming:
+		direction = chain_plugins.TxDirectionIncoming
+	case transferDirectionOutgoing:
+		direction = chain_plugins.TxDirectionOutgoing
+	default:
+		return nil, errors.New("invalid direction")
+	}
+
+	var cursor *chain_plugins.TxHistoryCursor
+	if param.Cursor != nil && len(*param.Cursor) > 0 {
+		var err error
+		if cursor, err = chain_plugins.ParseTxHistoryCursor(*param.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	items, nextCursor, err := plugin.GetTransfers(param.Address, param.TokenId, direction, cursor, param.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &TransactionHistory{
+		Transfers: make([]*Transfer, 0, len(items)),
+	}
+	for _, item := range items {
+		sendBlock, err := l.ledgerBlockToRpcBlock(item.SendBlock)
+		if err != nil {
+			return nil, err
+		}
+
+		transfer := &Transfer{
+			Direction:      transferDirectionIncoming,
+			Counterparty:   item.Counterparty,
+			SnapshotHeight: Uint64ToString(item.SnapshotHeight),
+			SendBlock:      sendBlock,
+		}
+		if item.Direction == chain_plugins.TxDirectionOutgoing {
+			transfer.Direction = transferDirectionOutgoing
+		}
+		history.Transfers = append(history.Transfers, transfer)
+	}
+	if nextCursor != nil {
+		c := nextCursor.String()
+		history.NextCursor = &c
+	}
+	return history, nil
+}
+
 // new api
 func (l *LedgerApi) GetAccountBlockByHash(blockHash types.Hash) (*AccountBlock, error) {
 	block, getError := l.chain.GetAccountBlockByHash(blockHash)
@@ -139,6 +238,21 @@ func (l *LedgerApi) GetAccountInfoByAddress(addr types.Address) (*AccountInfo, e
 	return ToAccountInfo(l.chain, info), nil
 }
 
+// new api
+func (l *LedgerApi) GetBalanceAt(addr types.Address, tokenId types.TokenTypeId, snapshotHeight string) (*string, error) {
+	height, err := StringToUint64(snapshotHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := l.chain.GetBalanceAt(addr, tokenId, height)
+	if err != nil {
+		l.log.Error("GetBalanceAt failed, error is "+err.Error(), "method", "GetBalanceAt")
+		return nil, err
+	}
+	return bigIntToString(balance), nil
+}
+
 func (l *LedgerApi) getAccountInfoByAddress(addr types.Address) (*ledger.AccountInfo, error) {
 	latestAccountBlock, err := l.chain.GetLatestAccountBlock(addr)
 	if err != nil {
@@ -409,6 +523,7 @@ func GetLogs(c chain.Chain, rangeMap map[string]*Range, topics [][]types.Hash) (
 	if err != nil {
 		return nil, err
 	}
+	logBloom := getVmLogBloomPlugin(c)
 	var logs []*Logs
 	for addr, hr := range filterParam.AddrRange {
 		startHeight := hr.FromHeight
@@ -436,6 +551,18 @@ func GetLogs(c chain.Chain, rangeMap map[string]*Range, topics [][]types.Hash) (
 			if err != nil {
 				return nil, err
 			}
+			if logBloom != nil {
+				mayContain, err := blocksMayContainLogs(c, logBloom, addr, filterParam.Topics, blocks)
+				if err != nil {
+					return nil, err
+				}
+				if !mayContain {
+					if finish {
+						break
+					}
+					continue
+				}
+			}
 			for i := len(blocks); i > 0; i-- {
 				if blocks[i-1].LogHash != nil {
 					list, err := c.GetVmLogList(blocks[i-1].LogHash)
@@ -457,6 +584,135 @@ func GetLogs(c chain.Chain, rangeMap map[string]*Range, topics [][]types.Hash) (
 	return logs, nil
 }
 
+type VmLogSnapshotRangeParam struct {
+	FromSnapshotHeight string          `json:"fromSnapshotHeight"`
+	ToSnapshotHeight   string          `json:"toSnapshotHeight"`
+	Addresses          []types.Address `json:"addresses"`
+	Topics             [][]types.Hash  `json:"topics"`
+	Count              int             `json:"count"` // maxVmLogCount if 0
+}
+
+type SnapshotRangeLogs struct {
+	Logs []*SnapshotLogs `json:"logs"`
+	// the fromSnapshotHeight of the next page, nil if all the logs in range are returned
+	NextSnapshotHeight *string `json:"nextSnapshotHeight"`
+}
+
+type SnapshotLogs struct {
+	Log              *ledger.VmLog  `json:"vmlog"`
+	AccountBlockHash types.Hash     `json:"accountBlockHash"`
+	AccountHeight    string         `json:"accountBlockHeight"`
+	Addr             *types.Address `json:"address"`
+	SnapshotHeight   string         `json:"snapshotHeight"`
+}
+
+// new api: query vm logs by snapshot height range and topics, addresses are optional.
+// The logs are paged by snapshot height, query from NextSnapshotHeight for the next page.
+func (l *LedgerApi) GetVmLogsBySnapshotRange(param VmLogSnapshotRangeParam) (*SnapshotRangeLogs, error) {
+	if param.Count < 0 || param.Count > maxVmLogCount {
+		return nil, errors.New(fmt.Sprintf("count should be no more than %d", maxVmLogCount))
+	}
+	if param.Count == 0 {
+		param.Count = maxVmLogCount
+	}
+
+	plugins := l.chain.Plugins()
+	if plugins == nil {
+		err := errors.New("config.OpenPlugins is false, api can't work")
+		return nil, err
+	}
+
+	plugin, ok := plugins.GetPlugin("vmLogIndex").(*chain_plugins.VmLogIndex)
+	if !ok || plugin == nil {
+		return nil, errors.New("plugins-VmLogIndex's service not provided")
+	}
+
+	fromHeight, err := StringToUint64(param.FromSnapshotHeight)
+	if err != nil {
+		return nil, err
+	}
+	toHeight, err := StringToUint64(param.ToSnapshotHeight)
+	if err != nil {
+		return nil, err
+	}
+	if latestHeight := l.chain.GetLatestSnapshotBlock().Height; toHeight == 0 || toHeight > latestHeight {
+		toHeight = latestHeight
+	}
+	if toHeight < fromHeight {
+		return nil, errors.New("to height < from height")
+	}
+
+	results, nextHeight, err := plugin.GetLogs(fromHeight, toHeight, param.Addresses, param.Topics, param.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	logs := &SnapshotRangeLogs{
+		Logs: make([]*SnapshotLogs, 0, len(results)),
+	}
+	for _, r := range results {
+		addr := r.AccountBlock.AccountAddress
+		logs.Logs = append(logs.Logs, &SnapshotLogs{
+			Log:              r.Log,
+			AccountBlockHash: r.AccountBlock.Hash,
+			AccountHeight:    Uint64ToString(r.AccountBlock.Height),
+			Addr:             &addr,
+			SnapshotHeight:   Uint64ToString(r.SnapshotHeight),
+		})
+	}
+	if nextHeight > 0 {
+		next := Uint64ToString(nextHeight)
+		logs.NextSnapshotHeight = &next
+	}
+	return logs, nil
+}
+
+func getVmLogBloomPlugin(c chain.Chain) *chain_plugins.VmLogBloom {
+	plugins := c.Plugins()
+	if plugins == nil {
+		return nil
+	}
+	logBloom, ok := plugins.GetPlugin("vmLogBloom").(*chain_plugins.VmLogBloom)
+	if !ok {
+		return nil
+	}
+	return logBloom
+}
+
+// blocksMayContainLogs checks the log blooms of the snapshot range which confirms blocks,
+// it returns true if any block is unconfirmed
+func blocksMayContainLogs(c chain.Chain, logBloom *chain_plugins.VmLogBloom, addr types.Address, topics [][]types.Hash, blocks []*ledger.AccountBlock) (bool, error) {
+	if len(blocks) <= 0 {
+		return false, nil
+	}
+	lowest, highest := blocks[0], blocks[0]
+	for _, block := range blocks[1:] {
+		if block.Height < lowest.Height {
+			lowest = block
+		}
+		if block.Height > highest.Height {
+			highest = block
+		}
+	}
+
+	toSnapshot, err := c.GetConfirmSnapshotHeaderByAbHash(highest.Hash)
+	if err != nil {
+		return false, err
+	}
+	if toSnapshot == nil {
+		return true, nil
+	}
+	fromSnapshot, err := c.GetConfirmSnapshotHeaderByAbHash(lowest.Hash)
+	if err != nil {
+		return false, err
+	}
+	if fromSnapshot == nil {
+		return true, nil
+	}
+
+	return logBloom.MayContain(fromSnapshot.Height, toSnapshot.Height, []types.Address{addr}, topics)
+}
+
 func getHeightPage(start uint64, end uint64, count uint64) (uint64, uint64, bool) {
 	gap := end - start + 1
 	if gap <= count {
@@ -543,18 +799,21 @@ func calcPoWDifficulty(c chain.Chain, param CalcPoWDifficultyParam) (result *Cal
 	if err != nil {
 		return nil, err
 	}
+	return calcPoWDifficultyByQuota(c, db, sb, param.SelfAddr, quotaRequired, param.UseStakeQuota, param.Multiple)
+}
 
+// calcPoWDifficultyByQuota calculates the difficulty of the next block of the account to get the required quota
+func calcPoWDifficultyByQuota(c chain.Chain, db vm_db.VmDb, sb *ledger.SnapshotBlock, addr types.Address, quotaRequired uint64, useStakeQuota bool, multiple uint16) (*CalcPoWDifficultyResult, error) {
 	qc, _, isCongestion := quota.CalcQc(db, sb.Height)
 
 	// get current quota
-	var stakeAmount *big.Int
 	var q types.Quota
-	if param.UseStakeQuota {
-		stakeAmount, err = c.GetStakeBeneficialAmount(param.SelfAddr)
+	if useStakeQuota {
+		stakeAmount, err := c.GetStakeBeneficialAmount(addr)
 		if err != nil {
 			return nil, err
 		}
-		q, err := quota.GetQuota(db, param.SelfAddr, stakeAmount, sb.Height)
+		q, err = quota.GetQuota(db, addr, stakeAmount, sb.Height)
 		if err != nil {
 			return nil, err
 		}
@@ -562,11 +821,10 @@ func calcPoWDifficulty(c chain.Chain, param CalcPoWDifficultyParam) (result *Cal
 			return &CalcPoWDifficultyResult{quotaRequired, Uint64ToString(quotaRequired), "", Float64ToString(float64(quotaRequired)/float64(quota.QuotaPerUt), 4), bigIntToString(qc), isCongestion}, nil
 		}
 	} else {
-		stakeAmount = big.NewInt(0)
 		q = types.NewQuota(0, 0, 0, 0, false, 0)
 	}
 	// calc difficulty if current quota is not enough
-	canPoW := quota.CanPoW(db, block.AccountAddress)
+	canPoW := quota.CanPoW(db, addr)
 	if !canPoW {
 		return nil, util.ErrCalcPoWTwice
 	}
@@ -574,9 +832,9 @@ func calcPoWDifficulty(c chain.Chain, param CalcPoWDifficultyParam) (result *Cal
 	if err != nil {
 		return nil, err
 	}
-	if isCongestion && param.Multiple > uint16(multipleDivision.Uint64()) {
+	if isCongestion && multiple > uint16(multipleDivision.Uint64()) {
 		d.Mul(d, multipleDivision)
-		d.Div(d, big.NewInt(int64(param.Multiple)))
+		d.Div(d, big.NewInt(int64(multiple)))
 	}
 	return &CalcPoWDifficultyResult{quotaRequired, Uint64ToString(quotaRequired), d.String(), Float64ToString(float64(quotaRequired)/float64(quota.QuotaPerUt), 4), bigIntToString(qc), isCongestion}, nil
 }
@@ -630,3 +888,160 @@ func calcQuotaRequired(c chain.Chain, param CalcQuotaRequiredParam) (*CalcQuotaR
 	}
 	return &CalcQuotaRequiredResult{Uint64ToString(quotaRequired), Float64ToString(float64(quotaRequired)/float64(quota.QuotaPerUt), 4)}, nil
 }
+
+type EstimateQuotaParam struct {
+	SelfAddr  types.Address      `json:"address"`
+	BlockType byte               `json:"blockType"`
+	ToAddr    *types.Address     `json:"toAddress"`
+	TokenId   *types.TokenTypeId `json:"tokenId"`
+	Amount    *string            `json:"amount"`
+	Data      []byte             `json:"data"`
+	Multiple  uint16             `json:"congestionMultiplier"`
+}
+
+type EstimateQuotaResult struct {
+	SendQuota           string  `json:"sendQuota"`
+	SendUtRequired      string  `json:"sendUtRequired"`
+	SendError           *string `json:"sendError"`    // not nil if the sender can not PoW, the stake is required
+	ReceiveQuota        *string `json:"receiveQuota"` // nil if the receiver is not a contract or the receive block failed
+	ReceiveUtRequired   *string `json:"receiveUtRequired"`
+	ReceiveError        *string `json:"receiveError"`
+	IsPoWRequired       bool    `json:"isPoWRequired"`
+	Difficulty          string  `json:"difficulty"`
+	RequiredStakeAmount *string `json:"requiredStakeAmount"` // stake amount of the sender to send the block without PoW
+	Qc                  string  `json:"qc"`
+	IsCongestion        bool    `json:"isCongestion"`
+}
+
+// EstimateQuota executes the send block and the receive block of the contract on the latest snapshot block,
+// and returns the quota used by them. The quota of the receive block is paid by the contract.
+func (l *LedgerApi) EstimateQuota(param EstimateQuotaParam) (*EstimateQuotaResult, error) {
+	if param.BlockType != ledger.BlockTypeSendCall && param.BlockType != ledger.BlockTypeSendCreate {
+		return nil, errors.New(fmt.Sprintf("block type %d is not supported", param.BlockType))
+	}
+	block := &ledger.AccountBlock{
+		BlockType:      param.BlockType,
+		AccountAddress: param.SelfAddr,
+		TokenId:        ledger.ViteTokenId,
+		Amount:         big.NewInt(0),
+		Fee:            big.NewInt(0),
+		Data:           param.Data,
+	}
+	if param.ToAddr != nil {
+		block.ToAddress = *param.ToAddr
+	} else if param.BlockType == ledger.BlockTypeSendCall {
+		return nil, errors.New("toAddress is nil")
+	}
+	if param.TokenId != nil {
+		block.TokenId = *param.TokenId
+	}
+	if param.Amount != nil {
+		amount, err := stringToBigInt(param.Amount)
+		if err != nil {
+			return nil, err
+		}
+		block.Amount = amount
+	}
+
+	return estimateQuota(newSimulator(l.vite), NewContractApi(l.vite), block, param.Multiple)
+}
+
+// estimateChain gives the sender the stake required by the quota of the send block, so that the send block is
+// executed regardless of the quota of the sender
+type estimateChain struct {
+	chain.Chain
+	addr  types.Address
+	stake *big.Int
+}
+
+func (c *estimateChain) GetStakeBeneficialAmount(addr types.Address) (*big.Int, error) {
+	amount, err := c.Chain.GetStakeBeneficialAmount(addr)
+	if err != nil || addr != c.addr {
+		return amount, err
+	}
+	if amount == nil || amount.Cmp(c.stake) < 0 {
+		return c.stake, nil
+	}
+	return amount, nil
+}
+
+func estimateQuota(s *simulator, contract *ContractApi, block *ledger.AccountBlock, multiple uint16) (*EstimateQuotaResult, error) {
+	var err error
+	if block.PrevHash, block.Height, err = getPrevHashHeight(s.chain, block.AccountAddress); err != nil {
+		return nil, err
+	}
+	db, err := vm_db.NewVmDb(s.chain, &block.AccountAddress, &s.sb.Hash, &block.PrevHash)
+	if err != nil {
+		return nil, err
+	}
+	quotaRequired, err := vm.GasRequiredForBlock(db, block, util.QuotaTableByHeight(s.sb.Height), s.sb.Height)
+	if err != nil {
+		return nil, err
+	}
+	stake, err := getRequiredStakeAmount(contract, quotaRequired)
+	if err != nil {
+		return nil, err
+	}
+
+	sendSimulator := &simulator{
+		chain:     &estimateChain{Chain: s.chain, addr: block.AccountAddress, stake: stake},
+		sbpReader: s.sbpReader,
+		sb:        s.sb,
+	}
+	sendVmBlock, _, err := sendSimulator.send(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// the quota used by the executed send block is required from the stake or PoW of the sender
+	sendQuota := sendVmBlock.AccountBlock.QuotaUsed
+	requiredStakeAmount, err := contract.GetRequiredStakeAmount(Uint64ToString(sendQuota))
+	if err != nil {
+		return nil, err
+	}
+	result := &EstimateQuotaResult{
+		SendQuota:           Uint64ToString(sendQuota),
+		SendUtRequired:      Float64ToString(float64(sendQuota)/float64(quota.QuotaPerUt), 4),
+		RequiredStakeAmount: requiredStakeAmount,
+	}
+	powResult, err := calcPoWDifficultyByQuota(s.chain, db, s.sb, block.AccountAddress, sendQuota, true, multiple)
+	if err == util.ErrCalcPoWTwice {
+		qc, _, isCongestion := quota.CalcQc(db, s.sb.Height)
+		sendError := "PoW or more stake required, " + err.Error()
+		result.SendError = &sendError
+		result.Qc = *bigIntToString(qc)
+		result.IsCongestion = isCongestion
+	} else if err != nil {
+		return nil, err
+	} else {
+		result.IsPoWRequired = len(powResult.Difficulty) > 0
+		result.Difficulty = powResult.Difficulty
+		result.Qc = *powResult.Qc
+		result.IsCongestion = powResult.IsCongestion
+	}
+
+	if !types.IsContractAddr(sendVmBlock.AccountBlock.ToAddress) {
+		return result, nil
+	}
+	receiveVmBlock, _, _, err := s.receive(sendVmBlock)
+	if err != nil {
+		errMsg := err.Error()
+		result.ReceiveError = &errMsg
+	}
+	if receiveVmBlock != nil {
+		quotaUsed := receiveVmBlock.AccountBlock.QuotaUsed
+		receiveQuota := Uint64ToString(quotaUsed)
+		receiveUtRequired := Float64ToString(float64(quotaUsed)/float64(quota.QuotaPerUt), 4)
+		result.ReceiveQuota = &receiveQuota
+		result.ReceiveUtRequired = &receiveUtRequired
+	}
+	return result, nil
+}
+
+func getRequiredStakeAmount(contract *ContractApi, q uint64) (*big.Int, error) {
+	amount, err := contract.GetRequiredStakeAmount(Uint64ToString(q))
+	if err != nil {
+		return nil, err
+	}
+	return stringToBigInt(amount)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+func TestEstimateQuota_stake(t *testing.T) {
+	c := newSimulateTestChain()
+	c.addContract(simulateTestContract, simulateTestCode)
+	s := &simulator{chain: c, sb: c.sb}
+
+	result, err := estimateQuota(s, &ContractApi{}, newSimulateTestBlock(simulateTestContract), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SendQuota != "21000" || result.SendUtRequired != "1" || result.SendError != nil ||
+		result.IsPoWRequired || result.Difficulty != "" {
+		t.Fatalf("send quota error, %+v", result)
+	}
+	if result.ReceiveError != nil || result.ReceiveQuota == nil || *result.ReceiveQuota == "0" {
+		t.Fatalf("receive quota error, %+v", result)
+	}
+}
+
+func TestEstimateQuota_pow(t *testing.T) {
+	c := newSimulateTestChain()
+	c.stakes[simulateTestUser] = big.NewInt(0)
+	s := &simulator{chain: c, sb: c.sb}
+
+	// the send block is executed without the stake of the sender
+	to := types.Address{3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 0}
+	result, err := estimateQuota(s, &ContractApi{}, newSimulateTestBlock(to), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SendQuota != "21000" || result.SendError != nil || !result.IsPoWRequired || result.Difficulty == "" ||
+		result.RequiredStakeAmount == nil || *result.RequiredStakeAmount == "0" {
+		t.Fatalf("send quota error, %+v", result)
+	}
+	if result.ReceiveQuota != nil || result.ReceiveError != nil {
+		t.Fatalf("the block to a user account is not received, %+v", result)
+	}
+}
+
+func TestEstimateQuota_powTwice(t *testing.T) {
+	c := newSimulateTestChain()
+	c.addContract(simulateTestContract, simulateTestCode)
+	c.stakes[simulateTestUser] = big.NewInt(0)
+	c.unconfirmed = []*ledger.AccountBlock{{AccountAddress: simulateTestUser, Height: 1, Nonce: []byte{1}}}
+	s := &simulator{chain: c, sb: c.sb}
+
+	// the sender has calculated PoW referring to the latest snapshot block
+	result, err := estimateQuota(s, &ContractApi{}, newSimulateTestBlock(simulateTestContract), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SendQuota != "21000" || result.SendError == nil || !strings.HasPrefix(*result.SendError, "PoW or more stake required") ||
+		result.IsPoWRequired || result.Difficulty != "" {
+		t.Fatalf("send quota error, %+v", result)
+	}
+	if result.ReceiveError != nil || result.ReceiveQuota == nil {
+		t.Fatalf("receive quota error, %+v", result)
+	}
+}
@@ -0,0 +1,110 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/net"
+	"github.com/vitelabs/go-vite/vite"
+)
+
+var errNotLightNode = errors.New("the node is not running in light mode")
+
+// LightApi serves the snapshot headers verified by the light node, and the account blocks proved by them
+type LightApi struct {
+	light net.LightClient
+	chain chain.Chain
+	log   log15.Logger
+}
+
+func NewLightApi(vite *vite.Vite) *LightApi {
+	return &LightApi{
+		light: vite.Net().Light(),
+		chain: vite.Chain(),
+		log:   log15.New("module", "rpc_api/light_api"),
+	}
+}
+
+type LightAccountBlocks struct {
+	Blocks []*AccountBlock   `json:"blocks"`
+	Proof  *net.AccountProof `json:"proof"`
+}
+
+type LightAccountState struct {
+	Address  types.Address                `json:"address"`
+	Block    *AccountBlock                `json:"block"`
+	Balances map[types.TokenTypeId]string `json:"balances"` // nil if the account chain is too long to replay
+	Proof    *net.AccountProof            `json:"proof"`
+}
+
+func (l *LightApi) GetLatestHeader() (*SnapshotBlock, error) {
+	if l.light == nil {
+		return nil, errNotLightNode
+	}
+	return ledgerSnapshotBlockToRpcBlock(l.light.LatestHeader())
+}
+
+func (l *LightApi) GetHeaderByHeight(height interface{}) (*SnapshotBlock, error) {
+	if l.light == nil {
+		return nil, errNotLightNode
+	}
+	heightUint64, err := parseHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	return ledgerSnapshotBlockToRpcBlock(l.light.GetHeader(heightUint64))
+}
+
+// GetAccountBlocks fetch at most count account blocks backward from the latest confirmed account block of addr
+func (l *LightApi) GetAccountBlocks(addr types.Address, count uint64) (*LightAccountBlocks, error) {
+	if l.light == nil {
+		return nil, errNotLightNode
+	}
+	blocks, proof, err := l.light.GetAccountBlocks(addr, count)
+	if err != nil {
+		l.log.Warn("GetAccountBlocks failed, error is "+err.Error(), "method", "GetAccountBlocks")
+		return nil, err
+	}
+
+	result := &LightAccountBlocks{Proof: proof}
+	for _, block := range blocks {
+		rpcBlock, err := ledgerToRpcBlock(l.chain, block)
+		if err != nil {
+			return nil, err
+		}
+		result.Blocks = append(result.Blocks, rpcBlock)
+	}
+	return result, nil
+}
+
+// GetAccountState fetch the latest confirmed account block of addr and the balances replayed from the account chain,
+// the storage can not be proved by the light node.
+func (l *LightApi) GetAccountState(addr types.Address) (*LightAccountState, error) {
+	if l.light == nil {
+		return nil, errNotLightNode
+	}
+	state, err := l.light.GetAccountState(addr)
+	if err != nil {
+		l.log.Warn("GetAccountState failed, error is "+err.Error(), "method", "GetAccountState")
+		return nil, err
+	}
+
+	block, err := ledgerToRpcBlock(l.chain, state.Block)
+	if err != nil {
+		return nil, err
+	}
+	result := &LightAccountState{
+		Address: state.Address,
+		Block:   block,
+		Proof:   state.Proof,
+	}
+	if state.Balances != nil {
+		result.Balances = make(map[types.TokenTypeId]string, len(state.Balances))
+		for tokenId, balance := range state.Balances {
+			result.Balances[tokenId] = balance.String()
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/net"
+)
+
+type lightTestClient struct {
+	head   *ledger.SnapshotBlock
+	blocks []*ledger.AccountBlock
+	proof  *net.AccountProof
+}
+
+func (c *lightTestClient) LatestHeader() *ledger.SnapshotBlock {
+	return c.head
+}
+
+func (c *lightTestClient) GetHeader(height uint64) *ledger.SnapshotBlock {
+	if height == c.head.Height {
+		return c.head
+	}
+	return nil
+}
+
+func (c *lightTestClient) GetAccountBlocks(addr types.Address, count uint64) ([]*ledger.AccountBlock, *net.AccountProof, error) {
+	return c.blocks, c.proof, nil
+}
+
+func (c *lightTestClient) GetAccountState(addr types.Address) (*net.AccountState, error) {
+	return &net.AccountState{
+		Address:  addr,
+		Block:    c.blocks[0],
+		Balances: map[types.TokenTypeId]*big.Int{ledger.ViteTokenId: big.NewInt(1069)},
+		Proof:    c.proof,
+	}, nil
+}
+
+func TestLightApi(t *testing.T) {
+	if _, err := (&LightApi{}).GetLatestHeader(); err != errNotLightNode {
+		t.Fatalf("error should be %v, but got %v", errNotLightNode, err)
+	}
+
+	now := time.Unix(1500000000, 0)
+	block := newSimulateTestBlock(simulateTestContract)
+	block.Height = 2
+	block.Hash = block.ComputeHash()
+	client := &lightTestClient{
+		head:   &ledger.SnapshotBlock{Height: 10, Hash: types.Hash{10}, PublicKey: make([]byte, 32), Timestamp: &now},
+		blocks: []*ledger.AccountBlock{block},
+		proof: &net.AccountProof{
+			Snapshot: ledger.HashHeight{Height: 10, Hash: types.Hash{10}},
+			Account:  ledger.HashHeight{Height: 2, Hash: block.Hash},
+		},
+	}
+	api := &LightApi{light: client, chain: newSimulateTestChain()}
+
+	header, err := api.GetHeaderByHeight("10")
+	if err != nil || header == nil || header.Hash != client.head.Hash {
+		t.Fatalf("wrong header %v, error %v", header, err)
+	}
+
+	blocks, err := api.GetAccountBlocks(simulateTestUser, 1)
+	if err != nil || len(blocks.Blocks) != 1 || blocks.Blocks[0].Hash != block.Hash || blocks.Proof != client.proof {
+		t.Fatalf("wrong account blocks %v, error %v", blocks, err)
+	}
+
+	state, err := api.GetAccountState(simulateTestUser)
+	if err != nil || state.Block.Height != "2" || len(state.Balances) != 1 || state.Balances[ledger.ViteTokenId] != "1069" {
+		t.Fatalf("wrong account state %v, error %v", state, err)
+	}
+}
@@ -1,7 +1,9 @@
 package api
 
 import (
+	"errors"
 	"strconv"
+	"time"
 
 	"github.com/vitelabs/go-vite/log15"
 	"github.com/vitelabs/go-vite/net"
@@ -70,3 +72,74 @@ func (n *NetApi) Nodes() Nodes {
 		Count: len(nodes),
 	}
 }
+
+// PeerScores return the scores of peers from high to low
+func (n *NetApi) PeerScores() []net.PeerScore {
+	return n.net.PeerScores()
+}
+
+// PrivateNetApi manages the bans and the trusted peers, it should not be exposed publicly
+type PrivateNetApi struct {
+	net net.Net
+}
+
+func NewPrivateNetApi(vite *vite.Vite) *PrivateNetApi {
+	return &PrivateNetApi{
+		net: vite.Net(),
+	}
+}
+
+// BanNode disconnect the node and refuse it for seconds, seconds 0 means permanently
+func (n *PrivateNetApi) BanNode(id string, seconds int64) error {
+	nodeId, err := vnode.Hex2NodeID(id)
+	if err != nil {
+		return err
+	}
+	if seconds < 0 {
+		return errors.New("seconds should not be negative")
+	}
+
+	n.net.BanNode(nodeId, time.Duration(seconds)*time.Second)
+	return nil
+}
+
+func (n *PrivateNetApi) UnbanNode(id string) error {
+	nodeId, err := vnode.Hex2NodeID(id)
+	if err != nil {
+		return err
+	}
+
+	n.net.UnbanNode(nodeId)
+	return nil
+}
+
+// AddTrustedPeer connect to the node like "id@host:port" and reconnect it always, the node can connect even if
+// the peers are full. The trusted node is persisted, it will be reconnected after restart.
+func (n *PrivateNetApi) AddTrustedPeer(node string) error {
+	nd, err := vnode.ParseNode(node)
+	if err != nil {
+		return err
+	}
+	if nd.ID == vnode.ZERO {
+		return errors.New("missing node id")
+	}
+
+	return n.net.AddTrustedPeer(nd)
+}
+
+// RemovePeer disconnect the peer and do not reconnect it any more, the argument can be node id or "id@host:port".
+// The nodes in StaticNodes and TrustedNodes of the config will be reconnected after restart, remove them from
+// the config to disconnect them permanently.
+func (n *PrivateNetApi) RemovePeer(node string) error {
+	nodeId, err := vnode.Hex2NodeID(node)
+	if err != nil {
+		var nd *vnode.Node
+		if nd, err = vnode.ParseNode(node); err != nil {
+			return err
+		}
+		nodeId = nd.ID
+	}
+
+	n.net.RemovePeer(nodeId)
+	return nil
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/consensus/core"
+	"github.com/vitelabs/go-vite/generator"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vite"
+	"github.com/vitelabs/go-vite/vm"
+	"github.com/vitelabs/go-vite/vm/util"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+type SimulateResult struct {
+	SendBlock    *SimulateBlockResult `json:"sendBlock"`
+	ReceiveBlock *SimulateBlockResult `json:"receiveBlock"` // nil if the send block failed or the receiver is not a contract
+}
+
+type SimulateBlockResult struct {
+	Block     *AccountBlock      `json:"block"`
+	IsRetry   bool               `json:"isRetry"`
+	Error     *string            `json:"error"`
+	VmLogList ledger.VmLogList   `json:"vmLogList"`
+	StateDiff *SimulateStateDiff `json:"stateDiff"`
+}
+
+// SimulateStateDiff holds the state of the account changed by the block, an empty storage value means the key is deleted
+type SimulateStateDiff struct {
+	Balance map[types.TokenTypeId]string `json:"balance"`
+	Storage map[string]string            `json:"storage"`
+	Code    string                       `json:"code,omitempty"`
+}
+
+// simulateChain makes the unsaved send block visible to the receiver
+type simulateChain struct {
+	chain.Chain
+	sendBlock *vm_db.VmAccountBlock
+}
+
+func (c *simulateChain) GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error) {
+	if blockHash == c.sendBlock.AccountBlock.Hash {
+		return c.sendBlock.AccountBlock, nil
+	}
+	return c.Chain.GetAccountBlockByHash(blockHash)
+}
+
+func (c *simulateChain) GetContractMeta(contractAddress types.Address) (*ledger.ContractMeta, error) {
+	if meta, ok := c.sendBlock.VmDb.GetUnsavedContractMeta()[contractAddress]; ok {
+		return meta, nil
+	}
+	return c.Chain.GetContractMeta(contractAddress)
+}
+
+// simulator executes blocks on the latest snapshot block without inserting them
+type simulator struct {
+	chain     chain.Chain
+	sbpReader core.SBPStatReader
+	sb        *ledger.SnapshotBlock
+}
+
+func newSimulator(v *vite.Vite) *simulator {
+	return &simulator{
+		chain:     v.Chain(),
+		sbpReader: v.Consensus().SBPReader(),
+		sb:        v.Chain().GetLatestSnapshotBlock(),
+	}
+}
+
+func (s *simulator) run(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, status util.GlobalStatus) (vmBlock *vm_db.VmAccountBlock, isRetry bool, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			vmBlock, isRetry, err = nil, false, errors.New(fmt.Sprintf("%v: %v", types.ErrVmRunPanic, e))
+		}
+	}()
+	v := vm.NewVM(util.NewVMConsensusReader(s.sbpReader))
+	return v.RunV2(db, block, sendBlock, status)
+}
+
+// send executes the send block after the latest block of the account
+func (s *simulator) send(block *ledger.AccountBlock) (*vm_db.VmAccountBlock, bool, error) {
+	var err error
+	if block.PrevHash, block.Height, err = getPrevHashHeight(s.chain, block.AccountAddress); err != nil {
+		return nil, false, err
+	}
+	db, err := vm_db.NewVmDb(s.chain, &block.AccountAddress, &s.sb.Hash, &block.PrevHash)
+	if err != nil {
+		return nil, false, err
+	}
+	vmBlock, isRetry, err := s.run(db, block, nil, nil)
+	if vmBlock != nil {
+		vmBlock.AccountBlock.Hash = vmBlock.AccountBlock.ComputeHash()
+	}
+	return vmBlock, isRetry, err
+}
+
+// receive executes the receive block of the executed send block, the send block is regarded as confirmed
+// by the latest snapshot block. It returns the chain which the unsaved send block is visible in.
+func (s *simulator) receive(sendVmBlock *vm_db.VmAccountBlock) (*vm_db.VmAccountBlock, chain.Chain, bool, error) {
+	sendBlock := sendVmBlock.AccountBlock
+	sc := &simulateChain{Chain: s.chain, sendBlock: sendVmBlock}
+	receiveBlock := &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeReceive,
+		AccountAddress: sendBlock.ToAddress,
+		FromBlockHash:  sendBlock.Hash,
+	}
+	var err error
+	if receiveBlock.PrevHash, receiveBlock.Height, err = getPrevHashHeight(s.chain, receiveBlock.AccountAddress); err != nil {
+		return nil, sc, false, err
+	}
+
+	var status util.GlobalStatus
+	meta, err := sc.GetContractMeta(receiveBlock.AccountAddress)
+	if err != nil {
+		return nil, sc, false, err
+	}
+	if meta != nil && (meta.SendConfirmedTimes > 0 || meta.SeedConfirmedTimes > 0) {
+		status = generator.NewVMGlobalStatus(s.chain, s.sb, sendBlock.Hash)
+	}
+
+	db, err := vm_db.NewVmDb(sc, &receiveBlock.AccountAddress, &s.sb.Hash, &receiveBlock.PrevHash)
+	if err != nil {
+		return nil, sc, false, err
+	}
+	vmBlock, isRetry, err := s.run(db, receiveBlock, sendBlock, status)
+	if vmBlock != nil {
+		vb := vmBlock.AccountBlock
+		for idx, v := range vb.SendBlockList {
+			v.Hash = v.ComputeSendHash(vb, uint8(idx))
+		}
+		vb.Hash = vb.ComputeHash()
+	}
+	return vmBlock, sc, isRetry, err
+}
+
+// simulate executes the send block and the receive block if the receiver is a contract
+func (s *simulator) simulate(block *ledger.AccountBlock) (*SimulateResult, error) {
+	sendVmBlock, isRetry, err := s.send(block)
+	result := &SimulateResult{}
+	if result.SendBlock, err = newSimulateBlockResult(s.chain, sendVmBlock, isRetry, err); err != nil {
+		return nil, err
+	}
+	if sendVmBlock == nil || result.SendBlock.Error != nil || !types.IsContractAddr(sendVmBlock.AccountBlock.ToAddress) {
+		return result, nil
+	}
+
+	receiveVmBlock, sc, isRetry, err := s.receive(sendVmBlock)
+	if result.ReceiveBlock, err = newSimulateBlockResult(sc, receiveVmBlock, isRetry, err); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Simulate executes an unsigned send block and the receive block of the contract on the latest snapshot block.
+// The blocks are neither inserted into the chain nor added to the pool. The prevHash and height of the block are
+// set to the latest ones of the account, the signature and PoW are not required.
+func (t Tx) Simulate(block *AccountBlock) (*SimulateResult, error) {
+	if block == nil {
+		return nil, errors.New("empty block")
+	}
+	if block.Height == "" {
+		block.Height = "0"
+	}
+	lb, err := block.RpcToLedgerBlock()
+	if err != nil {
+		return nil, err
+	}
+	if lb.BlockType != ledger.BlockTypeSendCall && lb.BlockType != ledger.BlockTypeSendCreate {
+		return nil, errors.New(fmt.Sprintf("block type %d is not supported", lb.BlockType))
+	}
+	if lb.BlockType == ledger.BlockTypeSendCall && !checkTxToAddressAvailable(lb.ToAddress) {
+		return nil, errors.New("ToAddress is invalid")
+	}
+	if err := checkTokenIdValid(t.vite.Chain(), &lb.TokenId); err != nil {
+		return nil, err
+	}
+
+	return newSimulator(t.vite).simulate(lb)
+}
+
+func getPrevHashHeight(c chain.Chain, addr types.Address) (types.Hash, uint64, error) {
+	prevBlock, err := c.GetLatestAccountBlock(addr)
+	if err != nil {
+		return types.Hash{}, 0, err
+	}
+	if prevBlock == nil {
+		return types.Hash{}, 1, nil
+	}
+	return prevBlock.Hash, prevBlock.Height + 1, nil
+}
+
+func newSimulateBlockResult(c chain.Chain, vmBlock *vm_db.VmAccountBlock, isRetry bool, runErr error) (*SimulateBlockResult, error) {
+	result := &SimulateBlockResult{IsRetry: isRetry}
+	if runErr != nil {
+		errMsg := runErr.Error()
+		result.Error = &errMsg
+	}
+	if vmBlock == nil {
+		return result, nil
+	}
+
+	var err error
+	if result.Block, err = ledgerToRpcBlock(c, vmBlock.AccountBlock); err != nil {
+		return nil, err
+	}
+	result.VmLogList = vmBlock.VmDb.GetLogList()
+
+	diff := &SimulateStateDiff{
+		Balance: make(map[types.TokenTypeId]string),
+		Storage: make(map[string]string),
+	}
+	for tokenId, balance := range vmBlock.VmDb.GetUnsavedBalanceMap() {
+		diff.Balance[tokenId] = balance.String()
+	}
+	for _, kv := range vmBlock.VmDb.GetUnsavedStorage() {
+		diff.Storage[hex.EncodeToString(kv[0])] = hex.EncodeToString(kv[1])
+	}
+	if code := vmBlock.VmDb.GetUnsavedContractCode(); len(code) > 0 {
+		diff.Code = hex.EncodeToString(code)
+	}
+	result.StateDiff = diff
+	return result, nil
+}
@@ -0,0 +1,261 @@
+package api
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm"
+)
+
+// simulateTestChain serves an empty chain at the latest snapshot block with the contracts and the stakes set by
+// tests, the other methods of chain.Chain are not implemented.
+type simulateTestChain struct {
+	chain.Chain
+	sb          *ledger.SnapshotBlock
+	metas       map[types.Address]*ledger.ContractMeta
+	codes       map[types.Address][]byte
+	getValue    func(addr types.Address, key []byte) ([]byte, error)
+	stake       *big.Int
+	stakes      map[types.Address]*big.Int
+	unconfirmed []*ledger.AccountBlock
+}
+
+func newSimulateTestChain() *simulateTestChain {
+	return &simulateTestChain{
+		sb:     &ledger.SnapshotBlock{Height: 1000, Hash: types.DataHash([]byte{1})},
+		metas:  make(map[types.Address]*ledger.ContractMeta),
+		codes:  make(map[types.Address][]byte),
+		stake:  new(big.Int).Mul(big.NewInt(1e6), big.NewInt(1e18)),
+		stakes: make(map[types.Address]*big.Int),
+	}
+}
+
+func (c *simulateTestChain) addContract(addr types.Address, code []byte) {
+	c.metas[addr] = &ledger.ContractMeta{Gid: types.DELEGATE_GID, QuotaRatio: 10}
+	c.codes[addr] = code
+}
+
+func (c *simulateTestChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	return c.sb
+}
+
+func (c *simulateTestChain) GetGenesisSnapshotBlock() *ledger.SnapshotBlock {
+	return c.sb
+}
+
+func (c *simulateTestChain) GetSnapshotHeaderByHash(hash types.Hash) (*ledger.SnapshotBlock, error) {
+	if hash == c.sb.Hash {
+		return c.sb, nil
+	}
+	return nil, nil
+}
+
+func (c *simulateTestChain) GetSnapshotBlockByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	if height == c.sb.Height {
+		return c.sb, nil
+	}
+	return nil, nil
+}
+
+func (c *simulateTestChain) GetConfirmSnapshotHeaderByAbHash(abHash types.Hash) (*ledger.SnapshotBlock, error) {
+	return nil, nil
+}
+
+func (c *simulateTestChain) GetLatestAccountBlock(addr types.Address) (*ledger.AccountBlock, error) {
+	return nil, nil
+}
+
+func (c *simulateTestChain) GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error) {
+	return nil, nil
+}
+
+func (c *simulateTestChain) GetReceiveAbBySendAb(sendBlockHash types.Hash) (*ledger.AccountBlock, error) {
+	return nil, nil
+}
+
+func (c *simulateTestChain) GetTokenInfoById(tokenId types.TokenTypeId) (*types.TokenInfo, error) {
+	return nil, nil
+}
+
+func (c *simulateTestChain) GetBalance(addr types.Address, tokenId types.TokenTypeId) (*big.Int, error) {
+	return new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18)), nil
+}
+
+func (c *simulateTestChain) IsContractAccount(address types.Address) (bool, error) {
+	return c.metas[address] != nil, nil
+}
+
+func (c *simulateTestChain) GetContractMeta(contractAddress types.Address) (*ledger.ContractMeta, error) {
+	return c.metas[contractAddress], nil
+}
+
+func (c *simulateTestChain) GetContractMetaInSnapshot(contractAddress types.Address, snapshotHeight uint64) (*ledger.ContractMeta, error) {
+	return c.metas[contractAddress], nil
+}
+
+func (c *simulateTestChain) GetContractCode(contractAddr types.Address) ([]byte, error) {
+	return c.codes[contractAddr], nil
+}
+
+func (c *simulateTestChain) GetValue(addr types.Address, key []byte) ([]byte, error) {
+	if c.getValue != nil {
+		return c.getValue(addr, key)
+	}
+	return nil, nil
+}
+
+func (c *simulateTestChain) GetStakeBeneficialAmount(addr types.Address) (*big.Int, error) {
+	if stake, ok := c.stakes[addr]; ok {
+		return stake, nil
+	}
+	return c.stake, nil
+}
+
+func (c *simulateTestChain) GetUnconfirmedBlocks(addr types.Address) []*ledger.AccountBlock {
+	return c.unconfirmed
+}
+
+func (c *simulateTestChain) GetQuotaUsedList(address types.Address) []types.QuotaInfo {
+	return make([]types.QuotaInfo, 75)
+}
+
+func (c *simulateTestChain) GetGlobalQuota() types.QuotaInfo {
+	return types.QuotaInfo{}
+}
+
+func (c *simulateTestChain) GetCallDepth(sendBlockHash types.Hash) (uint16, error) {
+	return 0, nil
+}
+
+func (c *simulateTestChain) GetVmLogList(logHash *types.Hash) (ledger.VmLogList, error) {
+	return nil, nil
+}
+
+func init() {
+	vm.InitVMConfig(false, false, false, false, "")
+	fork.SetForkPoints(&config.ForkPoints{
+		SeedFork:      &config.ForkPoint{Height: 100, Version: 1},
+		DexFork:       &config.ForkPoint{Height: 200, Version: 2},
+		DexFeeFork:    &config.ForkPoint{Height: 250, Version: 3},
+		StemFork:      &config.ForkPoint{Height: 300, Version: 4},
+		LeafFork:      &config.ForkPoint{Height: 400, Version: 5},
+		EarthFork:     &config.ForkPoint{Height: 500, Version: 6},
+		DexMiningFork: &config.ForkPoint{Height: 600, Version: 7},
+		DexRobotFork:  &config.ForkPoint{Height: 700, Version: 8},
+		CryptoFork:    &config.ForkPoint{Height: 800, Version: 9}})
+	fork.SetActiveChecker(mockActiveChecker{})
+}
+
+type mockActiveChecker struct {
+}
+
+func (m mockActiveChecker) IsForkActive(point fork.ForkPointItem) bool {
+	return true
+}
+
+var (
+	simulateTestUser     = types.Address{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 0}
+	simulateTestContract = types.Address{2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1}
+	// simulateTestCode stores 100 at slot 0 and emits a log with topic 1
+	simulateTestCode = []byte{
+		1,
+		byte(vm.PUSH1), 100, byte(vm.PUSH1), 0, byte(vm.SSTORE),
+		byte(vm.PUSH1), 1, byte(vm.PUSH1), 0, byte(vm.DUP1), byte(vm.LOG1),
+		byte(vm.STOP)}
+)
+
+func newSimulateTestBlock(toAddr types.Address) *ledger.AccountBlock {
+	return &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeSendCall,
+		AccountAddress: simulateTestUser,
+		ToAddress:      toAddr,
+		Amount:         big.NewInt(10),
+		Fee:            big.NewInt(0),
+		TokenId:        ledger.ViteTokenId,
+	}
+}
+
+func TestSimulator_send(t *testing.T) {
+	c := newSimulateTestChain()
+	s := &simulator{chain: c, sb: c.sb}
+
+	to := types.Address{3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 3, 0}
+	result, err := s.simulate(newSimulateTestBlock(to))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ReceiveBlock != nil {
+		t.Fatal("the block to a user account is not received")
+	}
+	send := result.SendBlock
+	if send.Error != nil || send.IsRetry || send.Block == nil {
+		t.Fatalf("send block error, %+v", send)
+	}
+	if send.Block.Height != "1" || send.Block.PrevHash != (types.Hash{}) || send.Block.ToAddress != to ||
+		*send.Block.Amount != "10" || *send.Block.QuotaUsed != "21000" {
+		t.Fatalf("send block error, %+v", send.Block)
+	}
+	expected := new(big.Int).Sub(new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18)), big.NewInt(10)).String()
+	if len(send.StateDiff.Balance) != 1 || send.StateDiff.Balance[ledger.ViteTokenId] != expected || len(send.StateDiff.Storage) != 0 {
+		t.Fatalf("send state diff error, %+v", send.StateDiff)
+	}
+}
+
+func TestSimulator_receive(t *testing.T) {
+	c := newSimulateTestChain()
+	c.addContract(simulateTestContract, simulateTestCode)
+	s := &simulator{chain: c, sb: c.sb}
+
+	result, err := s.simulate(newSimulateTestBlock(simulateTestContract))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SendBlock.Error != nil {
+		t.Fatalf("send block error, %v", *result.SendBlock.Error)
+	}
+	receive := result.ReceiveBlock
+	if receive == nil || receive.Error != nil || receive.IsRetry || receive.Block == nil {
+		t.Fatalf("receive block error, %+v", receive)
+	}
+	if receive.Block.BlockType != ledger.BlockTypeReceive || receive.Block.AccountAddress != simulateTestContract ||
+		receive.Block.FromBlockHash != result.SendBlock.Block.Hash || receive.Block.FromAddress != simulateTestUser ||
+		receive.Block.Height != "1" || receive.Block.Hash.IsZero() {
+		t.Fatalf("receive block error, %+v", receive.Block)
+	}
+	if len(receive.VmLogList) != 1 || len(receive.VmLogList[0].Topics) != 1 || receive.VmLogList[0].Topics[0] != (types.Hash{31: 1}) {
+		t.Fatalf("receive vm log error, %+v", receive.VmLogList)
+	}
+	if len(receive.StateDiff.Storage) != 1 ||
+		receive.StateDiff.Storage["0000000000000000000000000000000000000000000000000000000000000000"] != "64" ||
+		receive.StateDiff.Balance[ledger.ViteTokenId] != new(big.Int).Add(new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18)), big.NewInt(10)).String() {
+		t.Fatalf("receive state diff error, %+v", receive.StateDiff)
+	}
+}
+
+func TestSimulator_panic(t *testing.T) {
+	c := newSimulateTestChain()
+	c.addContract(simulateTestContract, simulateTestCode)
+	c.getValue = func(addr types.Address, key []byte) ([]byte, error) {
+		panic("get value failed")
+	}
+	s := &simulator{chain: c, sb: c.sb}
+
+	result, err := s.simulate(newSimulateTestBlock(simulateTestContract))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SendBlock.Error != nil {
+		t.Fatalf("send block error, %v", *result.SendBlock.Error)
+	}
+	receive := result.ReceiveBlock
+	if receive == nil || receive.Block != nil || receive.Error == nil ||
+		!strings.Contains(*receive.Error, types.ErrVmRunPanic.Error()) || !strings.Contains(*receive.Error, "get value failed") {
+		t.Fatalf("receive block error, %+v", receive)
+	}
+}
@@ -69,6 +69,20 @@ func GetApi(vite *vite.Vite, apiModule string) rpc.API {
 			Service:   api.NewNetApi(vite),
 			Public:    true,
 		}
+	case "light":
+		return rpc.API{
+			Namespace: "light",
+			Version:   "1.0",
+			Service:   api.NewLightApi(vite),
+			Public:    true,
+		}
+	case "private_net":
+		return rpc.API{
+			Namespace: "net",
+			Version:   "1.0",
+			Service:   api.NewPrivateNetApi(vite),
+			Public:    false,
+		}
 	case "contract":
 		return rpc.API{
 			Namespace: "contract",
@@ -0,0 +1,101 @@
+package sink
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/vitelabs/go-vite/common/types"
+)
+
+// maxCursorSnapshotHashes is the count of the latest delivered snapshot blocks whose hashes are kept in the cursor
+const maxCursorSnapshotHashes = 100
+
+// Cursor is the position of the last delivered event. All the snapshot blocks lower than or equal to SnapshotHeight
+// are delivered, AccountBlockHash is the last delivered account block after that snapshot block.
+// SnapshotHashes are the hashes of the latest delivered snapshot blocks, the last one is the block of SnapshotHeight.
+// They are compared with the chain on resume to find the snapshot blocks rolled back while the node was offline.
+type Cursor struct {
+	SnapshotHeight   uint64       `json:"snapshotHeight"`
+	SnapshotHashes   []types.Hash `json:"snapshotHashes,omitempty"`
+	AccountBlockHash types.Hash   `json:"accountBlockHash"`
+}
+
+// snapshotHash returns the hash of the snapshot block of SnapshotHeight, ok is false if it is unknown
+func (c *Cursor) snapshotHash() (hash types.Hash, ok bool) {
+	if len(c.SnapshotHashes) <= 0 {
+		return types.Hash{}, false
+	}
+	return c.SnapshotHashes[len(c.SnapshotHashes)-1], true
+}
+
+// advance moves the cursor to the position after event
+func (c *Cursor) advance(event *Event) {
+	switch event.Type {
+	case SnapshotBlockEvent:
+		// the events replayed from the chain may be delivered again
+		if event.Height > c.SnapshotHeight {
+			if event.Height != c.SnapshotHeight+1 {
+				c.SnapshotHashes = nil
+			}
+			c.SnapshotHashes = append(c.SnapshotHashes, event.Hash)
+			if len(c.SnapshotHashes) > maxCursorSnapshotHashes {
+				c.SnapshotHashes = c.SnapshotHashes[len(c.SnapshotHashes)-maxCursorSnapshotHashes:]
+			}
+			c.SnapshotHeight = event.Height
+			c.AccountBlockHash = types.Hash{}
+		}
+	case AccountBlockEvent:
+		c.AccountBlockHash = event.Hash
+	case SnapshotBlockRollbackEvent:
+		if event.Height <= c.SnapshotHeight {
+			if count := uint64(len(c.SnapshotHashes)); count > c.SnapshotHeight-event.Height {
+				c.SnapshotHashes = c.SnapshotHashes[:count-(c.SnapshotHeight-event.Height+1)]
+			} else {
+				c.SnapshotHashes = nil
+			}
+			c.SnapshotHeight = event.Height - 1
+			c.AccountBlockHash = types.Hash{}
+		}
+	case AccountBlockRollbackEvent:
+		if event.Hash == c.AccountBlockHash {
+			c.AccountBlockHash = types.Hash{}
+		}
+	}
+}
+
+// loadCursor returns nil if the cursor file is not existed
+func loadCursor(fileName string) (*Cursor, error) {
+	buf, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	cursor := &Cursor{}
+	if err := json.Unmarshal(buf, cursor); err != nil {
+		return nil, err
+	}
+	return cursor, nil
+}
+
+func saveCursor(fileName string, cursor *Cursor) error {
+	buf, err := json.Marshal(cursor)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fileName), 0700); err != nil {
+		return err
+	}
+
+	// replace the old cursor atomically
+	tmpFileName := fileName + ".tmp"
+	if err := ioutil.WriteFile(tmpFileName, buf, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpFileName, fileName)
+}
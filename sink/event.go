@@ -0,0 +1,97 @@
+package sink
+
+import (
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+)
+
+type EventType string
+
+const (
+	AccountBlockEvent          EventType = "accountBlock"
+	SnapshotBlockEvent         EventType = "snapshotBlock"
+	OnroadEvent                EventType = "onroad"
+	VmLogEvent                 EventType = "vmLog"
+	AccountBlockRollbackEvent  EventType = "accountBlockRollback"
+	SnapshotBlockRollbackEvent EventType = "snapshotBlockRollback"
+)
+
+// Event is the json message pushed to the sinks.
+// Hash and Height belong to the account block, except the snapshot block events.
+// The hash of a snapshot block rollback event is zero if the rolled back block is not in the cursor of the sink manager.
+type Event struct {
+	Type          EventType          `json:"type"`
+	Hash          types.Hash         `json:"hash"`
+	Height        uint64             `json:"height"`
+	BlockType     byte               `json:"blockType,omitempty"`
+	Address       *types.Address     `json:"address,omitempty"`
+	FromBlockHash *types.Hash        `json:"fromBlockHash,omitempty"`
+	ToAddress     *types.Address     `json:"toAddress,omitempty"`
+	TokenId       *types.TokenTypeId `json:"tokenId,omitempty"`
+	Amount        *string            `json:"amount,omitempty"`
+	LogIndex      *uint32            `json:"logIndex,omitempty"`
+	Log           *ledger.VmLog      `json:"log,omitempty"`
+}
+
+// newAccountBlockEvents returns the account block event, the onroad events of the send blocks
+// and the vm log events of the block
+func newAccountBlockEvents(block *ledger.AccountBlock, logs ledger.VmLogList) []*Event {
+	events := make([]*Event, 0, 1+len(block.SendBlockList)+len(logs))
+
+	event := newBlockEvent(AccountBlockEvent, block)
+	if block.IsReceiveBlock() {
+		fromBlockHash := block.FromBlockHash
+		event.FromBlockHash = &fromBlockHash
+	}
+	events = append(events, event)
+
+	if block.IsSendBlock() {
+		events = append(events, newOnroadEvent(block))
+	}
+	// the send blocks triggered by the contract
+	for _, sendBlock := range block.SendBlockList {
+		events = append(events, newOnroadEvent(sendBlock))
+	}
+
+	for i, vmLog := range logs {
+		event := newBlockEvent(VmLogEvent, block)
+		logIndex := uint32(i)
+		event.LogIndex = &logIndex
+		event.Log = vmLog
+		events = append(events, event)
+	}
+	return events
+}
+
+func newOnroadEvent(sendBlock *ledger.AccountBlock) *Event {
+	event := newBlockEvent(OnroadEvent, sendBlock)
+
+	toAddress := sendBlock.ToAddress
+	tokenId := sendBlock.TokenId
+	event.ToAddress = &toAddress
+	event.TokenId = &tokenId
+	if sendBlock.Amount != nil {
+		amount := sendBlock.Amount.String()
+		event.Amount = &amount
+	}
+	return event
+}
+
+func newBlockEvent(typ EventType, block *ledger.AccountBlock) *Event {
+	addr := block.AccountAddress
+	return &Event{
+		Type:      typ,
+		Hash:      block.Hash,
+		Height:    block.Height,
+		BlockType: block.BlockType,
+		Address:   &addr,
+	}
+}
+
+func newSnapshotBlockEvent(typ EventType, block *ledger.SnapshotBlock) *Event {
+	return &Event{
+		Type:   typ,
+		Hash:   block.Hash,
+		Height: block.Height,
+	}
+}
@@ -0,0 +1,454 @@
+package sink
+
+import (
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/log15"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+const (
+	defaultQueueSize = 10000
+	maxBatchSize     = 100
+	catchUpStep      = 100
+	retryInterval    = 3 * time.Second
+)
+
+var errStopped = errors.New("sink manager is stopped")
+
+type Chain interface {
+	Register(listener chain.EventListener)
+	UnRegister(listener chain.EventListener)
+
+	GetLatestSnapshotBlock() *ledger.SnapshotBlock
+	GetSnapshotHeaderByHeight(height uint64) (*ledger.SnapshotBlock, error)
+	GetSubLedger(startHeight, endHeight uint64) ([]*ledger.SnapshotChunk, error)
+	GetAllUnconfirmedBlocks() []*ledger.AccountBlock
+	GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error)
+	GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error)
+}
+
+// Manager listens to the chain and delivers the events to all sinks at least once.
+// The chain listener never waits for the sinks, the events which overflow the queue are dropped
+// and replayed from the chain by the cursor later. The rollback events are never dropped.
+type Manager struct {
+	chain Chain
+	sinks []Sink
+
+	cursorFile string
+	cursor     Cursor
+
+	queue *eventQueue
+
+	retryInterval time.Duration
+	stop          chan struct{}
+	wg            sync.WaitGroup
+
+	log log15.Logger
+}
+
+func NewManager(cfg *config.Sink, chain Chain, dataDir string) (*Manager, error) {
+	sinks := make([]Sink, 0, len(cfg.SinkTargets))
+	for _, target := range cfg.SinkTargets {
+		s, err := NewSink(target)
+		if err != nil {
+			for _, opened := range sinks {
+				opened.Close()
+			}
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+
+	queueSize := cfg.SinkQueueSize
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+
+	return &Manager{
+		chain:         chain,
+		sinks:         sinks,
+		cursorFile:    filepath.Join(dataDir, "sink", "cursor.json"),
+		queue:         newEventQueue(queueSize),
+		retryInterval: retryInterval,
+		stop:          make(chan struct{}),
+		log:           log15.New("module", "sink"),
+	}, nil
+}
+
+func (m *Manager) Start() error {
+	cursor, err := loadCursor(m.cursorFile)
+	if err != nil {
+		return err
+	}
+
+	if cursor != nil {
+		// resume from the cursor
+		m.cursor = *cursor
+		m.queue.setOverflowed()
+	} else {
+		// the first start, deliver the new events only
+		latestSnapshotBlock := m.chain.GetLatestSnapshotBlock()
+		m.cursor = Cursor{
+			SnapshotHeight: latestSnapshotBlock.Height,
+			SnapshotHashes: []types.Hash{latestSnapshotBlock.Hash},
+		}
+		if err := saveCursor(m.cursorFile, &m.cursor); err != nil {
+			return err
+		}
+	}
+
+	m.chain.Register(m)
+
+	m.wg.Add(1)
+	go m.loop()
+
+	m.log.Info("sink manager started", "snapshotHeight", m.cursor.SnapshotHeight, "accountBlockHash", m.cursor.AccountBlockHash)
+	return nil
+}
+
+func (m *Manager) Stop() {
+	m.chain.UnRegister(m)
+	close(m.stop)
+	m.wg.Wait()
+
+	for _, s := range m.sinks {
+		if err := s.Close(); err != nil {
+			m.log.Error("close sink failed", "sink", s.Name(), "err", err)
+		}
+	}
+}
+
+func (m *Manager) loop() {
+	defer m.wg.Done()
+
+	for {
+		events, overflowed := m.queue.pop(maxBatchSize)
+		if len(events) > 0 {
+			if err := m.deliver(events); err != nil {
+				return
+			}
+			continue
+		}
+
+		// all the events before the dropped one are delivered, replay the rest from the chain
+		if overflowed {
+			m.queue.resetOverflowed()
+			if err := m.catchUp(); err != nil {
+				if err == errStopped {
+					return
+				}
+				m.log.Error("catch up failed", "err", err)
+				m.queue.setOverflowed()
+				if !m.wait() {
+					return
+				}
+			}
+			continue
+		}
+
+		select {
+		case <-m.queue.signal:
+		case <-m.stop:
+			return
+		}
+	}
+}
+
+// deliver sends events to every sink until all of them accept, then moves the cursor
+func (m *Manager) deliver(events []*Event) error {
+	for _, s := range m.sinks {
+		for {
+			err := s.Send(events)
+			if err == nil {
+				break
+			}
+			m.log.Warn("send events failed", "sink", s.Name(), "count", len(events), "err", err)
+			if !m.wait() {
+				return errStopped
+			}
+		}
+	}
+
+	for _, event := range events {
+		m.cursor.advance(event)
+	}
+	if err := saveCursor(m.cursorFile, &m.cursor); err != nil {
+		m.log.Error("save cursor failed", "err", err)
+	}
+	return nil
+}
+
+// catchUp replays the events after the cursor from the chain
+func (m *Manager) catchUp() error {
+	latestSnapshotBlock := m.chain.GetLatestSnapshotBlock()
+
+	// the snapshot blocks were rolled back when the node was offline or the rollback events were dropped
+	if err := m.rollbackForked(latestSnapshotBlock.Height); err != nil {
+		return err
+	}
+
+	delivered, err := m.deliveredFunc()
+	if err != nil {
+		return err
+	}
+
+	for start := m.cursor.SnapshotHeight; start < latestSnapshotBlock.Height; start += catchUpStep {
+		chunks, err := m.chain.GetSubLedger(start, start+catchUpStep)
+		if err != nil {
+			return err
+		}
+		if len(chunks) > 0 && (chunks[0].SnapshotBlock == nil || chunks[0].SnapshotBlock.Height == start) {
+			chunks = chunks[1:]
+		}
+
+		for _, chunk := range chunks {
+			events, err := m.accountBlocksToEvents(chunk.AccountBlocks, delivered)
+			if err != nil {
+				return err
+			}
+			if chunk.SnapshotBlock != nil {
+				events = append(events, newSnapshotBlockEvent(SnapshotBlockEvent, chunk.SnapshotBlock))
+			}
+			if err := m.deliver(events); err != nil {
+				return err
+			}
+		}
+	}
+
+	events, err := m.accountBlocksToEvents(m.chain.GetAllUnconfirmedBlocks(), delivered)
+	if err != nil {
+		return err
+	}
+	if len(events) > 0 {
+		return m.deliver(events)
+	}
+	return nil
+}
+
+// rollbackForked delivers the rollback events of the delivered snapshot blocks which are not on the chain any more
+func (m *Manager) rollbackForked(latestHeight uint64) error {
+	var events []*Event
+	cursor := m.cursor
+	cursor.SnapshotHashes = append([]types.Hash(nil), m.cursor.SnapshotHashes...)
+	for cursor.SnapshotHeight > 0 {
+		hash, ok := cursor.snapshotHash()
+		if cursor.SnapshotHeight <= latestHeight {
+			if !ok {
+				if len(events) > 0 {
+					m.log.Warn("the fork point is lower than the delivered snapshot blocks in the cursor", "snapshotHeight", cursor.SnapshotHeight)
+				}
+				break
+			}
+			header, err := m.chain.GetSnapshotHeaderByHeight(cursor.SnapshotHeight)
+			if err != nil {
+				return err
+			}
+			if header != nil && header.Hash == hash {
+				break
+			}
+		}
+
+		event := &Event{Type: SnapshotBlockRollbackEvent, Hash: hash, Height: cursor.SnapshotHeight}
+		cursor.advance(event)
+		events = append(events, event)
+	}
+
+	if len(events) > 0 {
+		return m.deliver(events)
+	}
+	return nil
+}
+
+// deliveredFunc returns a function which reports whether the account block is delivered before the cursor.
+// The blocks of an account are inserted in the order of height, so the blocks of the account of
+// the cursor block are delivered if they are not higher than it.
+func (m *Manager) deliveredFunc() (func(block *ledger.AccountBlock) bool, error) {
+	var cursorBlock *ledger.AccountBlock
+	if m.cursor.AccountBlockHash != (types.Hash{}) {
+		var err error
+		if cursorBlock, err = m.chain.GetAccountBlockByHash(m.cursor.AccountBlockHash); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(block *ledger.AccountBlock) bool {
+		return cursorBlock != nil &&
+			block.AccountAddress == cursorBlock.AccountAddress &&
+			block.Height <= cursorBlock.Height
+	}, nil
+}
+
+func (m *Manager) accountBlocksToEvents(blocks []*ledger.AccountBlock, delivered func(block *ledger.AccountBlock) bool) ([]*Event, error) {
+	events := make([]*Event, 0, len(blocks))
+	for _, block := range blocks {
+		if delivered(block) {
+			continue
+		}
+
+		var logs ledger.VmLogList
+		if block.LogHash != nil {
+			var err error
+			if logs, err = m.chain.GetVmLogList(block.LogHash); err != nil {
+				return nil, err
+			}
+		}
+		events = append(events, newAccountBlockEvents(block, logs)...)
+	}
+	return events, nil
+}
+
+// wait returns false if the manager is stopped
+func (m *Manager) wait() bool {
+	select {
+	case <-time.After(m.retryInterval):
+		return true
+	case <-m.stop:
+		return false
+	}
+}
+
+func (m *Manager) PrepareInsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
+	return nil
+}
+
+func (m *Manager) InsertAccountBlocks(blocks []*vm_db.VmAccountBlock) error {
+	events := make([]*Event, 0, len(blocks))
+	for _, b := range blocks {
+		events = append(events, newAccountBlockEvents(b.AccountBlock, b.VmDb.GetLogList())...)
+	}
+	m.push(events, false)
+	return nil
+}
+
+func (m *Manager) PrepareInsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (m *Manager) InsertSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	events := make([]*Event, 0, len(chunks))
+	for _, chunk := range chunks {
+		events = append(events, newSnapshotBlockEvent(SnapshotBlockEvent, chunk.SnapshotBlock))
+	}
+	m.push(events, false)
+	return nil
+}
+
+func (m *Manager) PrepareDeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	return nil
+}
+
+func (m *Manager) DeleteAccountBlocks(blocks []*ledger.AccountBlock) error {
+	events := make([]*Event, 0, len(blocks))
+	for _, block := range blocks {
+		events = append(events, newBlockEvent(AccountBlockRollbackEvent, block))
+	}
+	m.push(events, true)
+	return nil
+}
+
+func (m *Manager) PrepareDeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	return nil
+}
+
+func (m *Manager) DeleteSnapshotBlocks(chunks []*ledger.SnapshotChunk) error {
+	events := make([]*Event, 0, len(chunks))
+	for _, chunk := range chunks {
+		for _, block := range chunk.AccountBlocks {
+			events = append(events, newBlockEvent(AccountBlockRollbackEvent, block))
+		}
+		if chunk.SnapshotBlock != nil {
+			events = append(events, newSnapshotBlockEvent(SnapshotBlockRollbackEvent, chunk.SnapshotBlock))
+		}
+	}
+	m.push(events, true)
+	return nil
+}
+
+func (m *Manager) push(events []*Event, force bool) {
+	if len(events) <= 0 {
+		return
+	}
+	if !m.queue.push(events, force) {
+		m.log.Warn("sink queue is full, the events will be replayed from the chain", "count", len(events))
+	}
+}
+
+// eventQueue is a bounded queue. Once an event is dropped, the queue drops all the events except
+// the forced ones until the overflowed flag is reset, so the dropped events can be replayed in order.
+type eventQueue struct {
+	mu         sync.Mutex
+	events     []*Event
+	size       int
+	overflowed bool
+
+	signal chan struct{}
+}
+
+func newEventQueue(size int) *eventQueue {
+	return &eventQueue{
+		size:   size,
+		signal: make(chan struct{}, 1),
+	}
+}
+
+// push returns false if the events are dropped
+func (q *eventQueue) push(events []*Event, force bool) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !force {
+		if q.overflowed {
+			return false
+		}
+		if len(q.events)+len(events) > q.size {
+			q.overflowed = true
+			q.notify()
+			return false
+		}
+	}
+
+	q.events = append(q.events, events...)
+	q.notify()
+	return true
+}
+
+func (q *eventQueue) pop(max int) ([]*Event, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	count := len(q.events)
+	if count > max {
+		count = max
+	}
+	events := q.events[:count:count]
+	q.events = q.events[count:]
+	return events, q.overflowed
+}
+
+func (q *eventQueue) setOverflowed() {
+	q.mu.Lock()
+	q.overflowed = true
+	q.mu.Unlock()
+	q.notify()
+}
+
+func (q *eventQueue) resetOverflowed() {
+	q.mu.Lock()
+	q.overflowed = false
+	q.mu.Unlock()
+}
+
+func (q *eventQueue) notify() {
+	select {
+	case q.signal <- struct{}{}:
+	default:
+	}
+}
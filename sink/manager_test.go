@@ -0,0 +1,232 @@
+package sink
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vitelabs/go-vite/chain"
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/config"
+	"github.com/vitelabs/go-vite/ledger"
+	"gotest.tools/assert"
+)
+
+type mockChain struct {
+	mu     sync.Mutex
+	chunks []*ledger.SnapshotChunk
+}
+
+func (c *mockChain) Register(listener chain.EventListener)   {}
+func (c *mockChain) UnRegister(listener chain.EventListener) {}
+
+func (c *mockChain) GetLatestSnapshotBlock() *ledger.SnapshotBlock {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.chunks[len(c.chunks)-1].SnapshotBlock
+}
+
+func (c *mockChain) GetSnapshotHeaderByHeight(height uint64) (*ledger.SnapshotBlock, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, chunk := range c.chunks {
+		if chunk.SnapshotBlock.Height == height {
+			return chunk.SnapshotBlock, nil
+		}
+	}
+	return nil, nil
+}
+
+func (c *mockChain) GetSubLedger(startHeight, endHeight uint64) ([]*ledger.SnapshotChunk, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var chunks []*ledger.SnapshotChunk
+	for _, chunk := range c.chunks {
+		if chunk.SnapshotBlock.Height >= startHeight && chunk.SnapshotBlock.Height <= endHeight {
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks, nil
+}
+
+func (c *mockChain) GetAllUnconfirmedBlocks() []*ledger.AccountBlock {
+	return nil
+}
+
+func (c *mockChain) GetAccountBlockByHash(blockHash types.Hash) (*ledger.AccountBlock, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, chunk := range c.chunks {
+		for _, block := range chunk.AccountBlocks {
+			if block.Hash == blockHash {
+				return block, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (c *mockChain) GetVmLogList(logListHash *types.Hash) (ledger.VmLogList, error) {
+	return nil, nil
+}
+
+func (c *mockChain) setChunks(chunks []*ledger.SnapshotChunk) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.chunks = chunks
+}
+
+type memorySink struct {
+	mu     sync.Mutex
+	events []*Event
+	fails  int
+}
+
+func (s *memorySink) Name() string { return "memory" }
+
+func (s *memorySink) Send(events []*Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fails > 0 {
+		s.fails--
+		return errors.New("sink is unavailable")
+	}
+	s.events = append(s.events, events...)
+	return nil
+}
+
+func (s *memorySink) Close() error { return nil }
+
+// waitEvents waits until count events are received and takes them
+func (s *memorySink) waitEvents(t *testing.T, count int) []*Event {
+	for i := 0; i < 500; i++ {
+		s.mu.Lock()
+		if len(s.events) >= count {
+			events := s.events
+			s.events = nil
+			s.mu.Unlock()
+			assert.Equal(t, len(events), count)
+			return events
+		}
+		s.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("wait %d events timeout", count)
+	return nil
+}
+
+func newTestChunk(height uint64, blocks ...*ledger.AccountBlock) *ledger.SnapshotChunk {
+	return &ledger.SnapshotChunk{
+		SnapshotBlock: &ledger.SnapshotBlock{Height: height, Hash: types.DataHash([]byte{byte(height)})},
+		AccountBlocks: blocks,
+	}
+}
+
+func newTestBlock(addr types.Address, height uint64) *ledger.AccountBlock {
+	return &ledger.AccountBlock{
+		BlockType:      ledger.BlockTypeReceive,
+		AccountAddress: addr,
+		Height:         height,
+		Hash:           types.DataHash(append(addr.Bytes(), byte(height))),
+	}
+}
+
+func TestManager(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sink")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	alice, bob := types.AddressGovernance, types.AddressAsset
+	alice1, bob1, alice2 := newTestBlock(alice, 1), newTestBlock(bob, 1), newTestBlock(alice, 2)
+	c := &mockChain{}
+	c.setChunks([]*ledger.SnapshotChunk{newTestChunk(1), newTestChunk(2, alice1, bob1), newTestChunk(3, alice2)})
+
+	m, err := NewManager(&config.Sink{SinkQueueSize: 1}, c, dir)
+	assert.NilError(t, err)
+	s := &memorySink{fails: 1}
+	m.sinks = []Sink{s}
+	m.retryInterval = 10 * time.Millisecond
+
+	// alice1 was delivered before the restart
+	assert.NilError(t, saveCursor(m.cursorFile, &Cursor{SnapshotHeight: 1, AccountBlockHash: alice1.Hash}))
+	assert.NilError(t, m.Start())
+
+	events := s.waitEvents(t, 4)
+	assert.Equal(t, events[0].Hash, bob1.Hash)
+	assert.Equal(t, events[1].Type, SnapshotBlockEvent)
+	assert.Equal(t, events[1].Height, uint64(2))
+	assert.Equal(t, events[2].Hash, alice2.Hash)
+	assert.Equal(t, events[3].Height, uint64(3))
+
+	// rollback
+	chunk3 := c.chunks[2]
+	c.setChunks(c.chunks[:2])
+	assert.NilError(t, m.DeleteSnapshotBlocks([]*ledger.SnapshotChunk{chunk3}))
+
+	events = s.waitEvents(t, 2)
+	assert.Equal(t, events[0].Type, AccountBlockRollbackEvent)
+	assert.Equal(t, events[0].Hash, alice2.Hash)
+	assert.Equal(t, events[1].Type, SnapshotBlockRollbackEvent)
+	assert.Equal(t, events[1].Height, uint64(3))
+
+	// the events overflow the queue are replayed from the chain
+	chunks := append(c.chunks, newTestChunk(3), newTestChunk(4))
+	c.setChunks(chunks)
+	assert.NilError(t, m.InsertSnapshotBlocks(chunks[2:]))
+
+	events = s.waitEvents(t, 2)
+	assert.Equal(t, events[0].Height, uint64(3))
+	assert.Equal(t, events[1].Height, uint64(4))
+
+	m.Stop()
+
+	cursor, err := loadCursor(m.cursorFile)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, *cursor, Cursor{
+		SnapshotHeight: 4,
+		SnapshotHashes: []types.Hash{chunks[1].SnapshotBlock.Hash, chunks[2].SnapshotBlock.Hash, chunks[3].SnapshotBlock.Hash},
+	})
+}
+
+func TestManager_forkedOffline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sink")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	forked3 := newTestChunk(3)
+	forked3.SnapshotBlock.Hash = types.DataHash([]byte("forked"))
+	c := &mockChain{}
+	c.setChunks([]*ledger.SnapshotChunk{newTestChunk(1), newTestChunk(2), forked3})
+
+	m, err := NewManager(&config.Sink{}, c, dir)
+	assert.NilError(t, err)
+	s := &memorySink{}
+	m.sinks = []Sink{s}
+
+	// the snapshot blocks 3 and 4 were delivered before the restart, then rolled back by the fork
+	delivered3, delivered4 := newTestChunk(3).SnapshotBlock, newTestChunk(4).SnapshotBlock
+	assert.NilError(t, saveCursor(m.cursorFile, &Cursor{
+		SnapshotHeight: 4,
+		SnapshotHashes: []types.Hash{c.chunks[1].SnapshotBlock.Hash, delivered3.Hash, delivered4.Hash},
+	}))
+	assert.NilError(t, m.Start())
+
+	events := s.waitEvents(t, 3)
+	assert.Equal(t, events[0].Type, SnapshotBlockRollbackEvent)
+	assert.Equal(t, events[0].Hash, delivered4.Hash)
+	assert.Equal(t, events[1].Type, SnapshotBlockRollbackEvent)
+	assert.Equal(t, events[1].Hash, delivered3.Hash)
+	assert.Equal(t, events[2].Type, SnapshotBlockEvent)
+	assert.Equal(t, events[2].Hash, forked3.SnapshotBlock.Hash)
+
+	m.Stop()
+}
+
+func TestManager_stopWithoutStart(t *testing.T) {
+	m, err := NewManager(&config.Sink{}, &mockChain{}, "")
+	assert.NilError(t, err)
+	m.Stop()
+}
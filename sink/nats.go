@@ -0,0 +1,153 @@
+package sink
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// natsSink publishes every event as a message to the subject, it speaks the text protocol of nats.
+// A PING is sent after the messages and the PONG acknowledges that the server has processed them.
+type natsSink struct {
+	addr    string
+	subject string
+
+	conn   net.Conn
+	reader *bufio.Reader
+	writer *bufio.Writer
+}
+
+func newNatsSink(addr string, subject string) *natsSink {
+	return &natsSink{
+		addr:    addr,
+		subject: subject,
+	}
+}
+
+func (s *natsSink) Name() string {
+	return NatsSinkType + " " + s.addr + " " + s.subject
+}
+
+func (s *natsSink) Send(events []*Event) error {
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.send(events); err != nil {
+		// reconnect next time
+		s.Close()
+		return err
+	}
+	return nil
+}
+
+func (s *natsSink) send(events []*Event) error {
+	if err := s.conn.SetDeadline(time.Now().Add(sendTimeout)); err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(s.writer, "PUB %s %d\r\n", s.subject, len(payload)); err != nil {
+			return err
+		}
+		if _, err := s.writer.Write(payload); err != nil {
+			return err
+		}
+		if _, err := s.writer.WriteString("\r\n"); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.writer.WriteString("PING\r\n"); err != nil {
+		return err
+	}
+	if err := s.writer.Flush(); err != nil {
+		return err
+	}
+
+	for {
+		line, err := s.readLine()
+		if err != nil {
+			return err
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := s.writer.WriteString("PONG\r\n"); err != nil {
+				return err
+			}
+			if err := s.writer.Flush(); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New(fmt.Sprintf("nats server responds %s", line))
+		}
+	}
+}
+
+func (s *natsSink) connect() error {
+	conn, err := net.DialTimeout("tcp", s.addr, sendTimeout)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	s.reader = bufio.NewReader(conn)
+	s.writer = bufio.NewWriter(conn)
+
+	if err := s.handshake(); err != nil {
+		s.Close()
+		return err
+	}
+	return nil
+}
+
+func (s *natsSink) handshake() error {
+	if err := s.conn.SetDeadline(time.Now().Add(sendTimeout)); err != nil {
+		return err
+	}
+
+	// the server sends INFO first
+	line, err := s.readLine()
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO") {
+		return errors.New(fmt.Sprintf("unexpected nats server greeting %s", line))
+	}
+
+	if _, err := s.writer.WriteString("CONNECT {\"verbose\":false,\"pedantic\":false,\"name\":\"gvite\"}\r\n"); err != nil {
+		return err
+	}
+	return s.writer.Flush()
+}
+
+func (s *natsSink) readLine() (string, error) {
+	line, err := s.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (s *natsSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	s.reader = nil
+	s.writer = nil
+	return err
+}
@@ -0,0 +1,126 @@
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vitelabs/go-vite/config"
+)
+
+const (
+	WebhookSinkType = "webhook"
+	FileSinkType    = "file"
+	NatsSinkType    = "nats"
+)
+
+const sendTimeout = 10 * time.Second
+
+// Sink delivers events to an external system. Send returns nil only if all the events are accepted,
+// the events are sent again if it fails.
+type Sink interface {
+	Name() string
+	Send(events []*Event) error
+	Close() error
+}
+
+func NewSink(target config.SinkTarget) (Sink, error) {
+	switch target.Type {
+	case WebhookSinkType:
+		return newWebhookSink(target.Target), nil
+	case FileSinkType:
+		return newFileSink(target.Target)
+	case NatsSinkType:
+		if target.Subject == "" {
+			return nil, errors.New("subject of nats sink is empty")
+		}
+		return newNatsSink(target.Target, target.Subject), nil
+	default:
+		return nil, errors.New(fmt.Sprintf("unknown sink type %s", target.Type))
+	}
+}
+
+// webhookSink posts the events as a json array
+type webhookSink struct {
+	url    string
+	client *http.Client
+}
+
+func newWebhookSink(url string) *webhookSink {
+	return &webhookSink{
+		url:    url,
+		client: &http.Client{Timeout: sendTimeout},
+	}
+}
+
+func (s *webhookSink) Name() string {
+	return WebhookSinkType + " " + s.url
+}
+
+func (s *webhookSink) Send(events []*Event) error {
+	buf, err := json.Marshal(events)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(s.url, "application/json", bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	ioutil.ReadAll(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return errors.New(fmt.Sprintf("webhook responds %s", resp.Status))
+	}
+	return nil
+}
+
+func (s *webhookSink) Close() error {
+	return nil
+}
+
+// fileSink appends the events to a file as json lines
+type fileSink struct {
+	fileName string
+	file     *os.File
+}
+
+func newFileSink(fileName string) (*fileSink, error) {
+	file, err := os.OpenFile(fileName, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSink{
+		fileName: fileName,
+		file:     file,
+	}, nil
+}
+
+func (s *fileSink) Name() string {
+	return FileSinkType + " " + s.fileName
+}
+
+func (s *fileSink) Send(events []*Event) error {
+	w := bufio.NewWriter(s.file)
+	enc := json.NewEncoder(w)
+	for _, event := range events {
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+func (s *fileSink) Close() error {
+	return s.file.Close()
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/vitelabs/go-vite/onroad"
 	"github.com/vitelabs/go-vite/pool"
 	"github.com/vitelabs/go-vite/producer"
+	"github.com/vitelabs/go-vite/sink"
 	"github.com/vitelabs/go-vite/verifier"
 	"github.com/vitelabs/go-vite/vm"
 	"github.com/vitelabs/go-vite/wallet"
@@ -38,6 +39,7 @@ type Vite struct {
 	pool          pool.BlockPool
 	consensus     consensus.Consensus
 	onRoad        *onroad.Manager
+	sink          *sink.Manager
 }
 
 func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err error) {
@@ -121,6 +123,14 @@ func New(cfg *config.Config, walletManager *wallet.Manager) (vite *Vite, err err
 
 	// set onroad
 	vite.onRoad = or
+
+	// sink
+	if cfg.Sink != nil && len(cfg.Sink.SinkTargets) > 0 {
+		vite.sink, err = sink.NewManager(cfg.Sink, chain, cfg.DataDir)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return
 }
 
@@ -147,6 +157,13 @@ func (v *Vite) Start() (err error) {
 
 	v.chain.Start()
 
+	if v.sink != nil {
+		if err := v.sink.Start(); err != nil {
+			log.Error("sink.Start failed, error is "+err.Error(), "method", "vite.Start")
+			return err
+		}
+	}
+
 	err = v.consensus.Init()
 	if err != nil {
 		return err
@@ -184,6 +201,9 @@ func (v *Vite) Stop() (err error) {
 		}
 	}
 	v.consensus.Stop()
+	if v.sink != nil {
+		v.sink.Stop()
+	}
 	v.chain.Stop()
 	v.onRoad.Stop()
 	return nil
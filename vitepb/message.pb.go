@@ -58,6 +58,7 @@ type Handshake struct {
 	Key                  []byte   `protobuf:"bytes,10,opt,name=Key,proto3" json:"Key,omitempty"`
 	Token                []byte   `protobuf:"bytes,11,opt,name=Token,proto3" json:"Token,omitempty"`
 	PublicAddress        []byte   `protobuf:"bytes,12,opt,name=PublicAddress,proto3" json:"PublicAddress,omitempty"`
+	EphemeralKey         []byte   `protobuf:"bytes,13,opt,name=EphemeralKey,proto3" json:"EphemeralKey,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -172,11 +173,19 @@ func (m *Handshake) GetPublicAddress() []byte {
 	return nil
 }
 
+func (m *Handshake) GetEphemeralKey() []byte {
+	if m != nil {
+		return m.EphemeralKey
+	}
+	return nil
+}
+
 type SyncConnHandshake struct {
 	ID                   []byte   `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
 	Timestamp            int64    `protobuf:"varint,2,opt,name=Timestamp,proto3" json:"Timestamp,omitempty"`
 	Key                  []byte   `protobuf:"bytes,3,opt,name=Key,proto3" json:"Key,omitempty"`
 	Token                []byte   `protobuf:"bytes,4,opt,name=Token,proto3" json:"Token,omitempty"`
+	EphemeralKey         []byte   `protobuf:"bytes,5,opt,name=EphemeralKey,proto3" json:"EphemeralKey,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -235,6 +244,13 @@ func (m *SyncConnHandshake) GetToken() []byte {
 	return nil
 }
 
+func (m *SyncConnHandshake) GetEphemeralKey() []byte {
+	if m != nil {
+		return m.EphemeralKey
+	}
+	return nil
+}
+
 type ChunkRequest struct {
 	From                 uint64   `protobuf:"varint,1,opt,name=From,proto3" json:"From,omitempty"`
 	To                   uint64   `protobuf:"varint,2,opt,name=To,proto3" json:"To,omitempty"`
@@ -1067,6 +1083,42 @@ func (m *Trace) GetTTL() uint32 {
 	return 0
 }
 
+type AccountBlockHashes struct {
+	Hashes               [][]byte `protobuf:"bytes,1,rep,name=Hashes,proto3" json:"Hashes,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AccountBlockHashes) Reset()         { *m = AccountBlockHashes{} }
+func (m *AccountBlockHashes) String() string { return proto.CompactTextString(m) }
+func (*AccountBlockHashes) ProtoMessage()    {}
+
+func (m *AccountBlockHashes) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AccountBlockHashes.Unmarshal(m, b)
+}
+func (m *AccountBlockHashes) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AccountBlockHashes.Marshal(b, m, deterministic)
+}
+func (m *AccountBlockHashes) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AccountBlockHashes.Merge(m, src)
+}
+func (m *AccountBlockHashes) XXX_Size() int {
+	return xxx_messageInfo_AccountBlockHashes.Size(m)
+}
+func (m *AccountBlockHashes) XXX_DiscardUnknown() {
+	xxx_messageInfo_AccountBlockHashes.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AccountBlockHashes proto.InternalMessageInfo
+
+func (m *AccountBlockHashes) GetHashes() [][]byte {
+	if m != nil {
+		return m.Hashes
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterEnum("vitepb.State_PeerStatus", State_PeerStatus_name, State_PeerStatus_value)
 	proto.RegisterType((*Handshake)(nil), "vitepb.Handshake")
@@ -1087,6 +1139,7 @@ func init() {
 	proto.RegisterType((*NewAccountBlock)(nil), "vitepb.NewAccountBlock")
 	proto.RegisterType((*NewAccountBlockBytes)(nil), "vitepb.NewAccountBlockBytes")
 	proto.RegisterType((*Trace)(nil), "vitepb.Trace")
+	proto.RegisterType((*AccountBlockHashes)(nil), "vitepb.AccountBlockHashes")
 }
 
 func init() { proto.RegisterFile("vitepb/message.proto", fileDescriptor_2a6a8486deb9ab39) }
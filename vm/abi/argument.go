@@ -17,19 +17,23 @@ type Argument struct {
 
 type Arguments []Argument
 
+// ArgumentMarshaling is the json form of Argument, components are the fields of tuple
+type ArgumentMarshaling struct {
+	Name       string
+	Type       string
+	Components []ArgumentMarshaling
+	Indexed    bool
+}
+
 // UnmarshalJSON implements json.Unmarshaler interface
 func (argument *Argument) UnmarshalJSON(data []byte) error {
-	var extarg struct {
-		Name    string
-		Type    string
-		Indexed bool
-	}
+	var extarg ArgumentMarshaling
 	err := json.Unmarshal(data, &extarg)
 	if err != nil {
 		return errArgumentJsonErr(err)
 	}
 
-	argument.Type, err = NewType(extarg.Type)
+	argument.Type, err = NewType(extarg.Type, extarg.Components...)
 	if err != nil {
 		return err
 	}
@@ -137,6 +141,10 @@ func (arguments Arguments) unpackAtomic(v interface{}, marshalledValues []interf
 		if structField, ok := abi2struct[arg.Name]; ok {
 			return set(elem.FieldByName(structField), reflectValue, arg)
 		}
+		// a single tuple is unpacked into the struct directly
+		if arg.Type.T == TupleTy {
+			return set(elem, reflectValue, arg)
+		}
 		return nil
 	}
 
@@ -144,21 +152,6 @@ func (arguments Arguments) unpackAtomic(v interface{}, marshalledValues []interf
 
 }
 
-// Computes the full size of an array;
-// i.e. counting nested arrays, which count towards size for unpacking.
-func getArraySize(arr *Type) int {
-	size := arr.Size
-	// Arrays can be nested, with each element being the same size
-	arr = arr.Elem
-	for arr.T == ArrayTy {
-		// Keep multiplying by elem.Size while the elem is an array.
-		size *= arr.Size
-		arr = arr.Elem
-	}
-	// Now we have the full array size, including its children.
-	return size
-}
-
 // UnpackValues can be used to unpack ABI-encoded hexdata according to the ABI-specification,
 // without supplying a struct to unpack into. Instead, this method returns a list containing the
 // values. An atomic argument will be a list with one element.
@@ -167,18 +160,18 @@ func (arguments Arguments) UnpackValues(data []byte) ([]interface{}, error) {
 	virtualArgs := 0
 	for index, arg := range arguments {
 		marshalledValue, err := toGoType((index+virtualArgs)*helper.WordSize, arg.Type, data)
-		if arg.Type.T == ArrayTy {
+		if (arg.Type.T == ArrayTy || arg.Type.T == TupleTy) && !isDynamicType(arg.Type) {
 			// If we have a static array, like [3]uint256, these are coded as
 			// just like uint256,uint256,uint256.
 			// This means that we need to add two 'virtual' arguments when
 			// we count the index from now on.
 			//
-			// Array values nested multiple levels deep are also encoded inline:
-			// [2][3]uint256: uint256,uint256,uint256,uint256,uint256,uint256
+			// Static tuples are encoded inline in the same way:
+			// (uint256,address): uint256,address
 			//
-			// Calculate the full array size to get the correct offset for the next argument.
+			// Calculate the full size to get the correct offset for the next argument.
 			// Decrement it by 1, as the normal index increment is still applied.
-			virtualArgs += getArraySize(&arg.Type) - 1
+			virtualArgs += getTypeSize(arg.Type)/helper.WordSize - 1
 		}
 		if err != nil {
 			return nil, err
@@ -208,11 +201,7 @@ func (arguments Arguments) Pack(args ...interface{}) ([]byte, error) {
 	// input offset is the bytes offset for packed output
 	inputOffset := 0
 	for _, abiArg := range abiArgs {
-		if abiArg.Type.T == ArrayTy {
-			inputOffset += helper.WordSize * abiArg.Type.Size
-		} else {
-			inputOffset += helper.WordSize
-		}
+		inputOffset += getTypeSize(abiArg.Type)
 	}
 	var ret []byte
 	for i, a := range args {
@@ -222,8 +211,8 @@ func (arguments Arguments) Pack(args ...interface{}) ([]byte, error) {
 		if err != nil {
 			return nil, err
 		}
-		// check for a slice type (string, bytes, slice)
-		if input.Type.requiresLengthPrefix() {
+		// check for a dynamic type (string, bytes, slice, dynamic array and tuple)
+		if isDynamicType(input.Type) {
 			// calculate the offset
 			offset := inputOffset + len(variableInput)
 			// set the offset
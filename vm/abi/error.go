@@ -22,6 +22,7 @@ var (
 	errPureUnderscoredOutput       = errors.New("abi: purely underscored output cannot unpack to struct")
 	errInvalidlFixedBytesType      = errors.New("abi: invalid type in call to make fixed byte array")
 	errInvalidlArrayType           = errors.New("abi: invalid type in array/slice unpacking stage")
+	errEmptyTuple                  = errors.New("abi: tuple should have components")
 )
 
 // parse json errors
@@ -62,6 +63,12 @@ func errUnsupportedArgType(t string) error {
 func errUnknownType(t Type) error {
 	return fmt.Errorf("abi: unknown type %v", t.T)
 }
+func errInvalidTupleField(name string) error {
+	return fmt.Errorf("abi: invalid tuple field name '%s'", name)
+}
+func errDuplicatedTupleField(name string) error {
+	return fmt.Errorf("abi: duplicated tuple field name '%s'", name)
+}
 
 // pack errors
 func errWrongPackedLength(marshalledValues []interface{}) error {
@@ -71,6 +78,10 @@ func errArgLengthMismatch(args []interface{}, abiArgs Arguments) error {
 	return fmt.Errorf("argument count mismatch: %d for %d", len(args), len(abiArgs))
 }
 
+func errTupleFieldNotFound(name string) error {
+	return fmt.Errorf("abi: field '%s' for tuple not found in the given struct", name)
+}
+
 // unpack errors
 func errInvalidStruct(v interface{}) error {
 	return fmt.Errorf("abi: Unpack(non-pointer %T)", v)
@@ -121,6 +132,9 @@ func errBigOffsetOverflow(bigOffsetEnd *big.Int) error {
 func errBigLengthOverflow(totalSize *big.Int) error {
 	return fmt.Errorf("abi length larger than int64: %v", totalSize)
 }
+func errTupleOffsetOverflow(offset, outputLength *big.Int) error {
+	return fmt.Errorf("abi: cannot marshal in to go tuple: offset %v would go over slice boundary (len=%v)", offset, outputLength)
+}
 func errInsufficientBigLength(outputLength, totalSize *big.Int) error {
 	return fmt.Errorf("abi: cannot marshal in to go type: length insufficient %v require %v", outputLength, totalSize)
 }
@@ -53,15 +53,11 @@ func (e Event) Pack(args ...interface{}) ([]types.Hash, []byte, error) {
 	topicIndex := 1
 	for i := 0; i < len(args); i++ {
 		if e.Inputs[i].Indexed {
-			topic, err := e.Inputs[i].Type.pack(reflect.ValueOf(args[i]))
+			topic, err := makeTopic(e.Inputs[i].Type, args[i])
 			if err != nil {
 				return nil, nil, err
 			}
-			if len(topic) <= types.HashSize {
-				topics[topicIndex], _ = types.BytesToHash(helper.LeftPadBytes(topic, types.HashSize))
-			} else {
-				topics[topicIndex] = types.DataHash(topic)
-			}
+			topics[topicIndex] = topic
 			topicIndex = topicIndex + 1
 		} else {
 			nonIndexedArgList = append(nonIndexedArgList, args[i])
@@ -78,6 +74,43 @@ func (e Event) Pack(args ...interface{}) ([]types.Hash, []byte, error) {
 	}
 }
 
+// Topics builds the topics to filter the logs of the event, query holds the accepted values
+// of every indexed input in order, empty rule matches any value.
+func (e Event) Topics(query ...[]interface{}) ([][]types.Hash, error) {
+	if len(query) > len(e.IndexedInputs) {
+		return nil, errArgLengthMismatch(make([]interface{}, len(query)), e.IndexedInputs)
+	}
+	topics := make([][]types.Hash, len(query)+1)
+	topics[0] = []types.Hash{e.Id()}
+	for i, rule := range query {
+		for _, v := range rule {
+			topic, err := makeTopic(e.IndexedInputs[i].Type, v)
+			if err != nil {
+				return nil, err
+			}
+			topics[i+1] = append(topics[i+1], topic)
+		}
+	}
+	return topics, nil
+}
+
+// makeTopic encodes the value of indexed input, the values longer than a hash are hashed
+func makeTopic(t Type, v interface{}) (types.Hash, error) {
+	if hash, ok := v.(types.Hash); ok && isHashedTopic(t) {
+		// the values are already hashed
+		return hash, nil
+	}
+	topic, err := t.pack(reflect.ValueOf(v))
+	if err != nil {
+		return types.Hash{}, err
+	}
+	if len(topic) <= types.HashSize {
+		hash, _ := types.BytesToHash(helper.LeftPadBytes(topic, types.HashSize))
+		return hash, nil
+	}
+	return types.DataHash(topic), nil
+}
+
 func (e Event) DirectUnPack(topics []types.Hash, data []byte) ([]interface{}, error) {
 	nonIndexedParams, err := e.NonIndexedInputs.DirectUnpack(data)
 	if err != nil {
@@ -88,7 +121,7 @@ func (e Event) DirectUnPack(topics []types.Hash, data []byte) ([]interface{}, er
 	args := make([]interface{}, 0)
 	for _, arg := range e.Inputs {
 		if arg.Indexed {
-			if arg.Type.T == ArrayTy || arg.Type.T == StringTy || arg.Type.T == SliceTy || arg.Type.T == BytesTy {
+			if isHashedTopic(arg.Type) {
 				args = append(args, topics[index])
 			} else {
 				arg, err := toGoType(0, arg.Type, topics[index].Bytes())
@@ -106,6 +139,11 @@ func (e Event) DirectUnPack(topics []types.Hash, data []byte) ([]interface{}, er
 	return args, nil
 }
 
+// isHashedTopic reports whether the indexed input of type t is logged by the hash
+func isHashedTopic(t Type) bool {
+	return t.T == ArrayTy || t.T == StringTy || t.T == SliceTy || t.T == BytesTy || t.T == TupleTy
+}
+
 func (e *Event) UnmarshalJSON(data []byte) error {
 	var fields struct {
 		Type    string
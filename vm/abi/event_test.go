@@ -373,3 +373,29 @@ func TestDirectUnpackEvent(t *testing.T) {
 		t.Fatalf("unpack event failed, got %v", result)
 	}
 }
+
+func TestEventTopics(t *testing.T) {
+	definition := `[{"name": "test", "type": "event", "inputs": [{"indexed": true, "name":"from", "type":"address"},{"indexed": true, "name":"memo", "type":"string"},{"indexed": false, "name":"amount", "type":"uint256"}]}]`
+	abi, err := JSONToABIContract(strings.NewReader(definition))
+	require.NoError(t, err)
+	e := abi.Events["test"]
+
+	from := types.AddressGovernance
+	topics, _, err := e.Pack(from, "hello", big.NewInt(1))
+	require.NoError(t, err)
+
+	query, err := e.Topics([]interface{}{types.AddressQuota, from}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(query))
+	require.Equal(t, []types.Hash{e.Id()}, query[0])
+	require.Equal(t, []types.Hash{topics[1]}, query[1][1:])
+	require.Equal(t, 0, len(query[2]))
+
+	// the hashes of dynamic values are used directly
+	query, err = e.Topics(nil, []interface{}{"hello", topics[2]})
+	require.NoError(t, err)
+	require.Equal(t, []types.Hash{topics[2], topics[2]}, query[2])
+
+	_, err = e.Topics(nil, nil, nil)
+	require.Error(t, err)
+}
@@ -433,3 +433,106 @@ func TestPackNumber(t *testing.T) {
 		}
 	}
 }
+
+func TestPackTuple(t *testing.T) {
+	components := []ArgumentMarshaling{
+		{Name: "a", Type: "uint256"},
+		{Name: "b", Type: "string"},
+	}
+	type tuple struct {
+		A *big.Int
+		B string
+	}
+
+	for i, test := range []struct {
+		typ    string
+		input  interface{}
+		output []byte
+	}{
+		{
+			"tuple",
+			struct {
+				A *big.Int
+				C string `abi:"b"`
+			}{big.NewInt(1), "foo"},
+			helper.HexToBytes("0000000000000000000000000000000000000000000000000000000000000001" +
+				"0000000000000000000000000000000000000000000000000000000000000040" +
+				"0000000000000000000000000000000000000000000000000000000000000003" +
+				"666f6f0000000000000000000000000000000000000000000000000000000000"),
+		},
+		{
+			"tuple[]",
+			[]tuple{{big.NewInt(1), "foo"}, {big.NewInt(2), "bar"}},
+			helper.HexToBytes("0000000000000000000000000000000000000000000000000000000000000002" +
+				"0000000000000000000000000000000000000000000000000000000000000040" +
+				"00000000000000000000000000000000000000000000000000000000000000c0" +
+				"0000000000000000000000000000000000000000000000000000000000000001" +
+				"0000000000000000000000000000000000000000000000000000000000000040" +
+				"0000000000000000000000000000000000000000000000000000000000000003" +
+				"666f6f0000000000000000000000000000000000000000000000000000000000" +
+				"0000000000000000000000000000000000000000000000000000000000000002" +
+				"0000000000000000000000000000000000000000000000000000000000000040" +
+				"0000000000000000000000000000000000000000000000000000000000000003" +
+				"6261720000000000000000000000000000000000000000000000000000000000"),
+		},
+	} {
+		typ, err := NewType(test.typ, components...)
+		if err != nil {
+			t.Fatalf("%v failed. Unexpected parse error: %v", i, err)
+		}
+
+		output, err := typ.pack(reflect.ValueOf(test.input))
+		if err != nil {
+			t.Fatalf("%v failed. Unexpected pack error: %v", i, err)
+		}
+
+		if !bytes.Equal(output, test.output) {
+			t.Errorf("%d failed. Expected bytes: '%x' Got: '%x'", i, test.output, output)
+		}
+	}
+
+	if _, err := NewType("tuple"); err != errEmptyTuple {
+		t.Fatalf("tuple without components should fail, got %v", err)
+	}
+}
+
+func TestMethodPackTuple(t *testing.T) {
+	const definition = `[
+	{ "type" : "function", "name" : "tuple", "inputs" : [ { "name" : "t", "type" : "tuple", "components" : [ { "name" : "a", "type" : "uint256" }, { "name" : "addr", "type" : "address" } ] }, { "name" : "n", "type" : "uint8" } ] },
+	{ "type" : "function", "name" : "nested", "inputs" : [ { "name" : "t", "type" : "tuple[2]", "components" : [ { "name" : "a", "type" : "uint8" }, { "name" : "ids", "type" : "tokenId[]" } ] } ] }
+	]`
+	abi, err := JSONToABIContract(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sig := abi.Methods["tuple"].Sig(); sig != "tuple((uint256,address),uint8)" {
+		t.Fatalf("wrong signature: %s", sig)
+	}
+	if sig := abi.Methods["nested"].Sig(); sig != "nested((uint8,tokenId[])[2])" {
+		t.Fatalf("wrong signature: %s", sig)
+	}
+
+	// static tuple is encoded in place
+	addr := types.Address{1}
+	sig := abi.Methods["tuple"].Id()
+	sig = append(sig, helper.LeftPadBytes([]byte{1}, helper.WordSize)...)
+	sig = append(sig, helper.LeftPadBytes(addr[:], helper.WordSize)...)
+	sig = append(sig, helper.LeftPadBytes([]byte{2}, helper.WordSize)...)
+
+	packed, err := abi.PackMethod("tuple", struct {
+		A    *big.Int
+		Addr types.Address
+	}{big.NewInt(1), addr}, uint8(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(packed, sig) {
+		t.Errorf("expected %x got %x", sig, packed)
+	}
+
+	// missing field
+	if _, err = abi.PackMethod("tuple", struct{ A *big.Int }{big.NewInt(1)}, uint8(2)); err == nil {
+		t.Error("pack should fail without tuple field")
+	}
+}
@@ -64,12 +64,56 @@ func set(dst, src reflect.Value, output Argument) error {
 		dst.Set(src)
 	case dstType.Kind() == reflect.Ptr:
 		return set(dst.Elem(), src, output)
+	case dstType.Kind() == reflect.Struct && srcType.Kind() == reflect.Struct:
+		return setStruct(dst, src, output)
+	case dstType.Kind() == reflect.Slice && srcType.Kind() == reflect.Slice:
+		slice := reflect.MakeSlice(dstType, src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			if err := set(slice.Index(i), src.Index(i), output); err != nil {
+				return err
+			}
+		}
+		dst.Set(slice)
+	case dstType.Kind() == reflect.Array && srcType.Kind() == reflect.Array:
+		if dst.Len() != src.Len() {
+			return errUnmarshalTypeFailed(src, dst)
+		}
+		for i := 0; i < src.Len(); i++ {
+			if err := set(dst.Index(i), src.Index(i), output); err != nil {
+				return err
+			}
+		}
 	default:
 		return errUnmarshalTypeFailed(src, dst)
 	}
 	return nil
 }
 
+// setStruct assigns the unpacked tuple src to the struct dst,
+// the fields are paired by the abi tags or the names, like arguments to struct.
+func setStruct(dst, src reflect.Value, output Argument) error {
+	srcType := src.Type()
+	names := make([]string, srcType.NumField())
+	for i := range names {
+		names[i] = srcType.Field(i).Tag.Get("json")
+	}
+
+	abi2struct, err := mapArgNamesToStructFields(names, dst)
+	if err != nil {
+		return err
+	}
+	for i, name := range names {
+		structField, ok := abi2struct[name]
+		if !ok {
+			continue
+		}
+		if err := set(dst.FieldByName(structField), src.Field(i), output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // requireAssignable assures that `dest` is a pointer and it's not an interface.
 func requireAssignable(dst, src reflect.Value) error {
 	if dst.Kind() != reflect.Ptr && dst.Kind() != reflect.Interface {
@@ -101,6 +145,15 @@ func requireUnpackKind(v reflect.Value, t reflect.Type, k reflect.Kind,
 //   find what variable is expected to be mapped into, if it exists and has not been
 //   used, pair them.
 func mapAbiToStructFields(args Arguments, value reflect.Value) (map[string]string, error) {
+	names := make([]string, len(args))
+	for i, arg := range args {
+		names[i] = arg.Name
+	}
+	return mapArgNamesToStructFields(names, value)
+}
+
+// mapArgNamesToStructFields maps the names of arguments or tuple fields to struct fields, see mapAbiToStructFields
+func mapArgNamesToStructFields(argNames []string, value reflect.Value) (map[string]string, error) {
 
 	typ := value.Type()
 
@@ -130,14 +183,14 @@ func mapAbiToStructFields(args Arguments, value reflect.Value) (map[string]strin
 
 		// check which argument field matches with the abi tag.
 		found := false
-		for _, abiField := range args {
-			if abiField.Name == tagName {
-				if abi2struct[abiField.Name] != "" {
+		for _, abiFieldName := range argNames {
+			if abiFieldName == tagName {
+				if abi2struct[abiFieldName] != "" {
 					return nil, errTagAlreadyMapped(structFieldName)
 				}
 				// pair them
-				abi2struct[abiField.Name] = structFieldName
-				struct2abi[structFieldName] = abiField.Name
+				abi2struct[abiFieldName] = structFieldName
+				struct2abi[structFieldName] = abiFieldName
 				found = true
 			}
 		}
@@ -150,9 +203,8 @@ func mapAbiToStructFields(args Arguments, value reflect.Value) (map[string]strin
 	}
 
 	// second round ~~~
-	for _, arg := range args {
+	for _, abiFieldName := range argNames {
 
-		abiFieldName := arg.Name
 		structFieldName := capitalise(abiFieldName)
 
 		if structFieldName == "" {
@@ -192,3 +244,13 @@ func mapAbiToStructFields(args Arguments, value reflect.Value) (map[string]strin
 
 	return abi2struct, nil
 }
+
+// Convert assigns the unpacked value src to the value dst points to, the tuples
+// are converted to the structs with the same fields, used by the generated bindings.
+func Convert(dst, src interface{}) error {
+	value := reflect.ValueOf(dst)
+	if value.Kind() != reflect.Ptr || value.IsNil() {
+		return errUnmarshalTypeFailed(reflect.ValueOf(src), value)
+	}
+	return set(value.Elem(), reflect.ValueOf(src), Argument{})
+}
@@ -1,6 +1,8 @@
 package abi
 
 import (
+	"fmt"
+	"github.com/vitelabs/go-vite/common/helper"
 	"github.com/vitelabs/go-vite/common/types"
 	"reflect"
 	"regexp"
@@ -21,6 +23,7 @@ const (
 	TokenIdTy
 	FixedBytesTy
 	BytesTy
+	TupleTy
 )
 
 // Type is the reflection of the supported argument type
@@ -33,6 +36,10 @@ type Type struct {
 	T    byte // Our own type checking
 
 	stringKind string // holds the unparsed string for deriving signatures
+
+	// Tuple relative fields
+	TupleElems    []*Type  // Type information of all tuple fields
+	TupleRawNames []string // Raw field name of all tuple fields
 }
 
 var (
@@ -40,8 +47,9 @@ var (
 	typeRegex = regexp.MustCompile("([a-zA-Z]+)([0-9]+)?")
 )
 
-// NewType creates a new reflection type of abi type given in t.
-func NewType(t string) (typ Type, err error) {
+// NewType creates a new reflection type of abi type given in t,
+// components are the fields of tuple type, and ignored by other types.
+func NewType(t string, components ...ArgumentMarshaling) (typ Type, err error) {
 	if t == "uint" || t == "int" {
 		// this should fail because it means that there's something wrong with
 		// the abi type (the compiler should always format it to the size...always)
@@ -60,12 +68,14 @@ func NewType(t string) (typ Type, err error) {
 	if strings.Count(t, "[") != 0 {
 		i := strings.LastIndex(t, "[")
 		// recursively embed the type
-		embeddedType, err := NewType(t[:i])
+		embeddedType, err := NewType(t[:i], components...)
 		if err != nil {
 			return Type{}, err
 		}
 		// grab the last cell and create a type from there
 		sliced := t[i:]
+		// tuple is written as (type1,type2...) in signatures
+		typ.stringKind = embeddedType.stringKind + sliced
 		// grab the slice size with regexp
 		re := regexp.MustCompile("[0-9]+")
 		intz := re.FindAllString(sliced, -1)
@@ -153,6 +163,50 @@ func NewType(t string) (typ Type, err error) {
 			typ.Size = varSize
 			typ.Type = reflect.ArrayOf(varSize, reflect.TypeOf(byte(0)))
 		}
+	case "tuple":
+		if len(components) == 0 {
+			return Type{}, errEmptyTuple
+		}
+		var (
+			fields     []reflect.StructField
+			elems      []*Type
+			names      []string
+			expression string // canonical parameter expression
+		)
+		expression += "("
+		used := make(map[string]bool)
+		for idx, c := range components {
+			cType, err := NewType(c.Type, c.Components...)
+			if err != nil {
+				return Type{}, err
+			}
+			fieldName := capitalise(c.Name)
+			if fieldName == "" {
+				return Type{}, errInvalidTupleField(c.Name)
+			}
+			if used[fieldName] {
+				return Type{}, errDuplicatedTupleField(c.Name)
+			}
+			used[fieldName] = true
+			fields = append(fields, reflect.StructField{
+				Name: fieldName, // reflect.StructOf panics for unexported fields
+				Type: cType.Type,
+				Tag:  reflect.StructTag(fmt.Sprintf("json:\"%s\"", c.Name)),
+			})
+			elems = append(elems, &cType)
+			names = append(names, c.Name)
+			expression += cType.stringKind
+			if idx != len(components)-1 {
+				expression += ","
+			}
+		}
+		expression += ")"
+		typ.Kind = reflect.Struct
+		typ.Type = reflect.StructOf(fields)
+		typ.TupleElems = elems
+		typ.TupleRawNames = names
+		typ.T = TupleTy
+		typ.stringKind = expression
 	default:
 		return Type{}, errUnsupportedArgType(t)
 	}
@@ -173,23 +227,80 @@ func (t Type) pack(v reflect.Value) ([]byte, error) {
 		return nil, err
 	}
 
-	if t.T == SliceTy || t.T == ArrayTy {
-		var packed []byte
+	switch t.T {
+	case SliceTy, ArrayTy:
+		var ret []byte
 
+		if t.requiresLengthPrefix() {
+			// append length prefix for slice
+			packed, err := packNum(reflect.ValueOf(v.Len()))
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, packed...)
+		}
+
+		// dynamic elements are referred by the offsets, and appended at the tail
+		offset := 0
+		offsetReq := isDynamicType(*t.Elem)
+		if offsetReq {
+			offset = getTypeSize(*t.Elem) * v.Len()
+		}
+		var tail []byte
 		for i := 0; i < v.Len(); i++ {
 			val, err := t.Elem.pack(v.Index(i))
 			if err != nil {
 				return nil, err
 			}
-			packed = append(packed, val...)
+			if !offsetReq {
+				ret = append(ret, val...)
+				continue
+			}
+			packedOffset, err := packNum(reflect.ValueOf(offset))
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, packedOffset...)
+			offset += len(val)
+			tail = append(tail, val...)
 		}
-		if t.T == SliceTy {
-			return packBytesSlice(packed, v.Len())
-		} else if t.T == ArrayTy {
-			return packed, nil
+		return append(ret, tail...), nil
+	case TupleTy:
+		fieldmap, err := mapArgNamesToStructFields(t.TupleRawNames, v)
+		if err != nil {
+			return nil, err
 		}
+		// calculate offset if any
+		offset := 0
+		for _, elem := range t.TupleElems {
+			offset += getTypeSize(*elem)
+		}
+		var ret, tail []byte
+		for i, elem := range t.TupleElems {
+			field := v.FieldByName(fieldmap[t.TupleRawNames[i]])
+			if !field.IsValid() {
+				return nil, errTupleFieldNotFound(t.TupleRawNames[i])
+			}
+			val, err := elem.pack(field)
+			if err != nil {
+				return nil, err
+			}
+			if isDynamicType(*elem) {
+				packedOffset, err := packNum(reflect.ValueOf(offset))
+				if err != nil {
+					return nil, err
+				}
+				ret = append(ret, packedOffset...)
+				tail = append(tail, val...)
+				offset += len(val)
+			} else {
+				ret = append(ret, val...)
+			}
+		}
+		return append(ret, tail...), nil
+	default:
+		return packElement(t, v)
 	}
-	return packElement(t, v)
 }
 
 // requireLengthPrefix returns whether the type requires any sort of length
@@ -197,3 +308,47 @@ func (t Type) pack(v reflect.Value) ([]byte, error) {
 func (t Type) requiresLengthPrefix() bool {
 	return t.T == StringTy || t.T == BytesTy || t.T == SliceTy
 }
+
+// isDynamicType returns true if the type is dynamic.
+// The following types are called “dynamic”:
+// * bytes
+// * string
+// * T[] for any T
+// * T[k] for any dynamic T and any k >= 0
+// * (T1,...,Tk) if Ti is dynamic for some 1 <= i <= k
+func isDynamicType(t Type) bool {
+	if t.T == TupleTy {
+		for _, elem := range t.TupleElems {
+			if isDynamicType(*elem) {
+				return true
+			}
+		}
+		return false
+	}
+	return t.T == StringTy || t.T == BytesTy || t.T == SliceTy || (t.T == ArrayTy && isDynamicType(*t.Elem))
+}
+
+// getTypeSize returns the size that this type needs to occupy.
+// We distinguish static and dynamic types. Static types are encoded in-place
+// and dynamic types are encoded at a separately allocated location after the
+// current block.
+// So for a static variable, the size returned represents the size that the
+// variable actually occupies.
+// For a dynamic variable, the returned size is fixed 32 bytes, which is used
+// to store the location reference for actual value storage.
+func getTypeSize(t Type) int {
+	if t.T == ArrayTy && !isDynamicType(*t.Elem) {
+		// Recursively calculate type size if it is a nested array
+		if t.Elem.T == ArrayTy || t.Elem.T == TupleTy {
+			return t.Size * getTypeSize(*t.Elem)
+		}
+		return t.Size * helper.WordSize
+	} else if t.T == TupleTy && !isDynamicType(t) {
+		total := 0
+		for _, elem := range t.TupleElems {
+			total += getTypeSize(*elem)
+		}
+		return total
+	}
+	return helper.WordSize
+}
@@ -63,17 +63,6 @@ func readFixedBytes(t Type, word []byte) (interface{}, error) {
 
 }
 
-func getFullElemSize(elem *Type) int {
-	//all other should be counted as 32 (slices have pointers to respective elements)
-	size := helper.WordSize
-	//arrays wrap it, each element being the same size
-	for elem.T == ArrayTy {
-		size *= elem.Size
-		elem = elem.Elem
-	}
-	return size
-}
-
 // iteratively unpack elements
 func forEachUnpack(t Type, output []byte, start, size int) (interface{}, error) {
 	if size < 0 {
@@ -96,12 +85,9 @@ func forEachUnpack(t Type, output []byte, start, size int) (interface{}, error)
 		return nil, errInvalidlArrayType
 	}
 
-	// Arrays have packed elements, resulting in longer unpack steps.
-	// Slices have just 32 bytes per element (pointing to the contents).
-	elemSize := helper.WordSize
-	if t.T == ArrayTy {
-		elemSize = getFullElemSize(t.Elem)
-	}
+	// Static elements are packed in place, resulting in longer unpack steps.
+	// Dynamic elements have just 32 bytes per element (pointing to the contents).
+	elemSize := getTypeSize(*t.Elem)
 
 	for i, j := start, 0; j < size; i, j = i+elemSize, j+1 {
 
@@ -118,6 +104,24 @@ func forEachUnpack(t Type, output []byte, start, size int) (interface{}, error)
 	return refSlice.Interface(), nil
 }
 
+// forTupleUnpack unpacks the fields of tuple one by one, into the struct of t.Type
+func forTupleUnpack(t Type, output []byte) (interface{}, error) {
+	retval := reflect.New(t.Type).Elem()
+	virtualArgs := 0
+	for index, elem := range t.TupleElems {
+		marshalledValue, err := toGoType((index+virtualArgs)*helper.WordSize, *elem, output)
+		if err != nil {
+			return nil, err
+		}
+		if (elem.T == ArrayTy || elem.T == TupleTy) && !isDynamicType(*elem) {
+			// static arrays and tuples are encoded inline, see Arguments.UnpackValues
+			virtualArgs += getTypeSize(*elem)/helper.WordSize - 1
+		}
+		retval.Field(index).Set(reflect.ValueOf(marshalledValue))
+	}
+	return retval.Interface(), nil
+}
+
 // toGoType parses the output bytes and recursively assigns the value of these bytes
 // into a go type with accordance with the ABI spec.
 func toGoType(index int, t Type, output []byte) (interface{}, error) {
@@ -142,9 +146,26 @@ func toGoType(index int, t Type, output []byte) (interface{}, error) {
 	}
 
 	switch t.T {
+	case TupleTy:
+		if isDynamicType(t) {
+			begin, err := tuplePointsTo(index, output)
+			if err != nil {
+				return nil, err
+			}
+			return forTupleUnpack(t, output[begin:])
+		}
+		return forTupleUnpack(t, output[index:])
 	case SliceTy:
-		return forEachUnpack(t, output, begin, end)
+		// the offsets of dynamic elements are relative to the beginning of the elements
+		return forEachUnpack(t, output[begin:], 0, end)
 	case ArrayTy:
+		if isDynamicType(*t.Elem) {
+			begin, err := tuplePointsTo(index, output)
+			if err != nil {
+				return nil, err
+			}
+			return forEachUnpack(t, output[begin:], 0, t.Size)
+		}
 		return forEachUnpack(t, output, index, t.Size)
 	case StringTy: // variable arrays are written at the end of the return bytes
 		return string(output[begin : begin+end]), nil
@@ -207,3 +228,17 @@ func lengthPrefixPointsTo(index int, output []byte) (start int, length int, err
 	length = int(lengthBig.Uint64())
 	return
 }
+
+// tuplePointsTo resolves the location reference for dynamic tuple and array.
+func tuplePointsTo(index int, output []byte) (start int, err error) {
+	offset := new(big.Int).SetBytes(output[index : index+helper.WordSize])
+	outputLength := big.NewInt(int64(len(output)))
+
+	if offset.Cmp(outputLength) > 0 {
+		return 0, errTupleOffsetOverflow(offset, outputLength)
+	}
+	if offset.BitLen() > 63 {
+		return 0, errBigOffsetOverflow(offset)
+	}
+	return int(offset.Uint64()), nil
+}
@@ -729,3 +729,100 @@ func TestOOMMaliciousInput(t *testing.T) {
 		}
 	}
 }
+
+func TestUnpackTuple(t *testing.T) {
+	const definition = `[
+	{ "type" : "function", "name" : "tuple", "inputs" : [ { "name" : "n", "type" : "uint8" }, { "name" : "t", "type" : "tuple", "components" : [ { "name" : "a", "type" : "uint256" }, { "name" : "b", "type" : "string" }, { "name" : "inner", "type" : "tuple[]", "components" : [ { "name" : "addr", "type" : "address" }, { "name" : "ids", "type" : "tokenId[2]" } ] } ] } ] },
+	{ "type" : "event", "name" : "tupleEvent", "inputs" : [ { "name" : "owner", "type" : "address", "indexed" : true }, { "name" : "t", "type" : "tuple[2]", "components" : [ { "name" : "a", "type" : "uint64" }, { "name" : "b", "type" : "bool" } ] } ] }
+	]`
+	abi, err := JSONToABIContract(strings.NewReader(definition))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type inner struct {
+		Addr types.Address
+		Ids  [2]types.TokenTypeId
+	}
+	type outer struct {
+		A      *big.Int
+		Text   string `abi:"b"`
+		Inner  []inner
+		Unused bool
+	}
+	in := outer{
+		A:    big.NewInt(100),
+		Text: "hello",
+		Inner: []inner{
+			{types.Address{1}, [2]types.TokenTypeId{{1}, {2}}},
+			{types.Address{2}, [2]types.TokenTypeId{{3}, {4}}},
+		},
+	}
+
+	data, err := abi.PackMethod("tuple", uint8(7), in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// unpack into struct
+	var out struct {
+		N uint8
+		T outer
+	}
+	if err = abi.UnpackMethod(&out, "tuple", data); err != nil {
+		t.Fatal(err)
+	}
+	if out.N != 7 || !reflect.DeepEqual(out.T, in) {
+		t.Fatalf("expected %v got %v", in, out.T)
+	}
+
+	// unpack into anonymous struct
+	params, err := abi.DirectUnpackMethodInput("tuple", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(params) != 2 || params[0].(uint8) != 7 {
+		t.Fatalf("wrong params: %v", params)
+	}
+	var direct outer
+	if err = Convert(&direct, params[1]); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(direct, in) {
+		t.Fatalf("expected %v got %v", in, direct)
+	}
+	if err = Convert(direct, params[1]); err == nil {
+		t.Fatal("convert to non-pointer should fail")
+	}
+
+	// static tuple array in event
+	type flag struct {
+		A uint64
+		B bool
+	}
+	flags := [2]flag{{1, true}, {2, false}}
+	owner := types.Address{9}
+	topics, eventData, err := abi.PackEvent("tupleEvent", owner, flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(eventData) != 4*helper.WordSize {
+		t.Fatalf("static tuple array should be encoded in place, got %d bytes", len(eventData))
+	}
+
+	var eventOut [2]flag
+	if err = abi.UnpackEvent(&eventOut, "tupleEvent", eventData); err != nil {
+		t.Fatal(err)
+	}
+	if eventOut != flags {
+		t.Fatalf("expected %v got %v", flags, eventOut)
+	}
+
+	name, params, err := abi.DirectUnpackEvent(topics, eventData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != abi.Events["tupleEvent"].String() || len(params) != 2 || params[0].(types.Address) != owner {
+		t.Fatalf("wrong event %s: %v", name, params)
+	}
+}
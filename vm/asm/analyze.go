@@ -0,0 +1,172 @@
+package asm
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/vitelabs/go-vite/vm"
+)
+
+// kinds of issues
+const (
+	IssueUnreachable   = "unreachable"
+	IssueInvalidOpCode = "invalidOpCode"
+	IssueInvalidJump   = "invalidJump"
+	IssueUnboundedLoop = "unboundedLoop"
+)
+
+// Issue is a problem found in code
+type Issue struct {
+	Kind    string `json:"kind"`
+	Pc      uint64 `json:"pc"`
+	Message string `json:"message"`
+}
+
+// Function is a function found in the dispatcher of a contract
+type Function struct {
+	Selector string `json:"selector"` // hex encoded 4 bytes
+	Entry    uint64 `json:"entry"`    // pc of the function body
+}
+
+// Report is the result of analysis
+type Report struct {
+	Blocks    []*BasicBlock `json:"blocks"`
+	Functions []*Function   `json:"functions"`
+	Issues    []*Issue      `json:"issues"`
+	AuxCode   string        `json:"auxCode,omitempty"` // hex encoded metadata hash appended by the compiler
+}
+
+// Analyze disassembles the code and reconstructs the control-flow graph, finds the functions in
+// the dispatcher, and reports unreachable code, invalid opcodes in the instruction set of the
+// snapshot height, invalid jumps and loops without exits.
+func Analyze(code []byte, snapshotHeight uint64) *Report {
+	code, aux := vm.SplitAuxCode(code)
+	infos := vm.InstructionSetInfo(snapshotHeight, false)
+	g := NewCFG(Disassemble(code), &infos)
+
+	r := &Report{
+		Blocks:    g.Blocks,
+		Functions: findFunctions(g),
+		Issues:    make([]*Issue, 0),
+	}
+	if r.Blocks == nil {
+		r.Blocks = make([]*BasicBlock, 0)
+	}
+	if len(aux) > 0 {
+		r.AuxCode = hex.EncodeToString(aux)
+	}
+
+	var unreachable *BasicBlock
+	for _, b := range g.Blocks {
+		if !b.Reachable {
+			if onlyInvalid(b) {
+				unreachable = nil
+				continue
+			}
+			// consecutive unreachable blocks are reported once
+			if unreachable == nil {
+				unreachable = b
+				r.Issues = append(r.Issues, &Issue{Kind: IssueUnreachable, Pc: b.Start})
+			}
+			r.Issues[len(r.Issues)-1].Message = fmt.Sprintf("code in [%d, %d) is unreachable", unreachable.Start, b.End)
+			continue
+		}
+		unreachable = nil
+
+		for _, ins := range b.Instructions {
+			if !infos[ins.Op].Valid && ins.Op != opInvalid {
+				r.Issues = append(r.Issues, &Issue{
+					Kind:    IssueInvalidOpCode,
+					Pc:      ins.Pc,
+					Message: fmt.Sprintf("%v is invalid at snapshot height %d", ins.Name(), snapshotHeight),
+				})
+			}
+		}
+		if b.InvalidJump {
+			r.Issues = append(r.Issues, &Issue{
+				Kind:    IssueInvalidJump,
+				Pc:      b.last().Pc,
+				Message: fmt.Sprintf("jump to 0x%x which is not a JUMPDEST", b.Instructions[len(b.Instructions)-2].Arg),
+			})
+		}
+	}
+
+	for _, loop := range g.loops() {
+		if hasExit(loop) {
+			continue
+		}
+		r.Issues = append(r.Issues, &Issue{
+			Kind:    IssueUnboundedLoop,
+			Pc:      loop[0].Start,
+			Message: fmt.Sprintf("loop of %d blocks starting at %d has no exit, it runs until the quota is exhausted", len(loop), loop[0].Start),
+		})
+	}
+
+	sort.SliceStable(r.Issues, func(i, j int) bool { return r.Issues[i].Pc < r.Issues[j].Pc })
+	return r
+}
+
+// onlyInvalid reports whether the block is made up of the designated invalid opcode, which is used
+// by the compiler as the separator of code and metadata
+func onlyInvalid(b *BasicBlock) bool {
+	for _, ins := range b.Instructions {
+		if ins.Op != opInvalid {
+			return false
+		}
+	}
+	return true
+}
+
+// hasExit reports whether the execution can leave the loop
+func hasExit(loop []*BasicBlock) bool {
+	in := make(map[uint64]bool, len(loop))
+	for _, b := range loop {
+		in[b.Start] = true
+	}
+	for _, b := range loop {
+		if b.InvalidJump {
+			return true
+		}
+		for _, pc := range b.Succs {
+			if !in[pc] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findFunctions matches the dispatcher pattern, the selector is compared with the first 4 bytes of
+// calldata and the execution jumps to the function if they are equal:
+//
+//	PUSH4 selector, [DUPn|SWAPn], EQ, PUSHn entry, JUMPI
+func findFunctions(g *CFG) []*Function {
+	functions := make([]*Function, 0)
+	found := make(map[string]bool)
+	for _, b := range g.Blocks {
+		if !b.Reachable || len(b.Succs) == 0 || b.last().Op != opJumpi || b.InvalidJump || b.DynamicJump {
+			continue
+		}
+		list := b.Instructions
+		n := len(list)
+		if n < 4 || list[n-3].Op != opEq {
+			continue
+		}
+		i := n - 4
+		if i > 0 && list[i].Op >= opDup1 && list[i].Op <= opSwap16 {
+			i--
+		}
+		if list[i].Op != opPush4 || len(list[i].Arg) != 4 {
+			continue
+		}
+		selector := hex.EncodeToString(list[i].Arg)
+		// the mask of selector
+		if selector == "ffffffff" || found[selector] {
+			continue
+		}
+		found[selector] = true
+		functions = append(functions, &Function{Selector: selector, Entry: b.Succs[0]})
+	}
+	return functions
+}
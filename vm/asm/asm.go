@@ -0,0 +1,86 @@
+// Package asm disassembles the code of Solidity++ contracts and analyzes it statically
+package asm
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/vitelabs/go-vite/vm"
+)
+
+const (
+	opPush1    = 0x60
+	opPush4    = 0x63
+	opPush32   = 0x7f
+	opDup1     = 0x80
+	opSwap16   = 0x9f
+	opEq       = 0x14
+	opJump     = 0x56
+	opJumpi    = 0x57
+	opJumpdest = 0x5b
+	// opInvalid is the designated invalid opcode, the compiler uses it for failed assertions
+	opInvalid = 0xfe
+)
+
+// Instruction is a disassembled instruction
+type Instruction struct {
+	Pc  uint64
+	Op  byte
+	Arg []byte // the immediate data of PUSH
+}
+
+// Name returns the name of the opcode, INVALID if the opcode is not defined
+func (ins *Instruction) Name() string {
+	if name := vm.OpCodeName(ins.Op); name != "" {
+		return name
+	}
+	return "INVALID"
+}
+
+func (ins *Instruction) String() string {
+	if ins.Arg != nil {
+		return fmt.Sprintf("%05d %v 0x%x", ins.Pc, ins.Name(), ins.Arg)
+	}
+	if vm.OpCodeName(ins.Op) == "" {
+		return fmt.Sprintf("%05d INVALID(0x%02x)", ins.Pc, ins.Op)
+	}
+	return fmt.Sprintf("%05d %v", ins.Pc, ins.Name())
+}
+
+func (ins *Instruction) MarshalJSON() ([]byte, error) {
+	v := struct {
+		Pc  uint64 `json:"pc"`
+		Op  string `json:"op"`
+		Arg string `json:"arg,omitempty"`
+	}{Pc: ins.Pc, Op: ins.Name()}
+	if ins.Arg != nil {
+		v.Arg = "0x" + hex.EncodeToString(ins.Arg)
+	} else if vm.OpCodeName(ins.Op) == "" {
+		v.Op = fmt.Sprintf("INVALID(0x%02x)", ins.Op)
+	}
+	return json.Marshal(v)
+}
+
+func isPush(op byte) bool {
+	return op >= opPush1 && op <= opPush32
+}
+
+// Disassemble decodes the code into instructions, a truncated PUSH at the end takes the remaining bytes
+func Disassemble(code []byte) []*Instruction {
+	list := make([]*Instruction, 0, len(code))
+	for pc := uint64(0); pc < uint64(len(code)); {
+		ins := &Instruction{Pc: pc, Op: code[pc]}
+		pc++
+		if isPush(ins.Op) {
+			end := pc + uint64(ins.Op-opPush1+1)
+			if end > uint64(len(code)) {
+				end = uint64(len(code))
+			}
+			ins.Arg = code[pc:end]
+			pc = end
+		}
+		list = append(list, ins)
+	}
+	return list
+}
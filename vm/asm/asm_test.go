@@ -0,0 +1,103 @@
+package asm
+
+import (
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/fork"
+	"github.com/vitelabs/go-vite/config"
+)
+
+func init() {
+	fork.SetForkPoints(&config.ForkPoints{
+		SeedFork:      &config.ForkPoint{Height: 100, Version: 1},
+		DexFork:       &config.ForkPoint{Height: 200, Version: 2},
+		DexFeeFork:    &config.ForkPoint{Height: 250, Version: 3},
+		StemFork:      &config.ForkPoint{Height: 300, Version: 4},
+		LeafFork:      &config.ForkPoint{Height: 400, Version: 5},
+		EarthFork:     &config.ForkPoint{Height: 500, Version: 6},
+		DexMiningFork: &config.ForkPoint{Height: 600, Version: 7},
+		DexRobotFork:  &config.ForkPoint{Height: 700, Version: 8},
+		CryptoFork:    &config.ForkPoint{Height: 800, Version: 9}})
+	fork.SetActiveChecker(mockActiveChecker{})
+}
+
+type mockActiveChecker struct {
+}
+
+func (m mockActiveChecker) IsForkActive(point fork.ForkPointItem) bool {
+	return true
+}
+
+// a contract with two functions, compiled by Solidity++
+const testCode = "608060405260043610604c576000357c0100000000000000000000000000000000000000000000000000000000900463ffffffff1680632d16c91a146052578063ba3d93d614609257604c565b60006000fd5b607c6004803603602081101560675760006000fd5b810190808035906020019092919050505060ae565b6040518082815260200191505060405180910390f35b609860c3565b6040518082815260200191505060405180910390f35b60008160006000505401905060be565b919050565b6000600060005054905060d1565b9056fea165627a7a72305820614b83ac7ad21936973699f9740d3cdc61d467686393dccfdda77dc053f21b7c0029"
+
+func TestDisassemble(t *testing.T) {
+	code, _ := hex.DecodeString("6080604052fe61ff")
+	var got []string
+	for _, ins := range Disassemble(code) {
+		got = append(got, ins.String())
+	}
+	expected := []string{
+		"00000 PUSH1 0x80",
+		"00002 PUSH1 0x40",
+		"00004 MSTORE",
+		"00005 INVALID(0xfe)",
+		"00006 PUSH2 0xff",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	code, _ := hex.DecodeString(testCode)
+	r := Analyze(code, 1000)
+
+	expected := []*Function{{Selector: "2d16c91a", Entry: 82}, {Selector: "ba3d93d6", Entry: 146}}
+	if !reflect.DeepEqual(r.Functions, expected) {
+		t.Fatalf("expected functions %v, got %v", expected, r.Functions)
+	}
+	if len(r.Issues) != 0 {
+		t.Fatalf("unexpected issues %v", r.Issues[0])
+	}
+	if len(r.AuxCode) != 86 {
+		t.Fatalf("aux code should be split, got %v", r.AuxCode)
+	}
+	for _, b := range r.Blocks {
+		if !b.Reachable && b.Instructions[0].Op != opInvalid {
+			t.Fatalf("block %d should be reachable", b.Start)
+		}
+	}
+}
+
+func TestAnalyze_issues(t *testing.T) {
+	tests := []struct {
+		code   string
+		height uint64
+		issues []*Issue
+	}{
+		// STOP, JUMPDEST, PUSH1 0 CALLDATALOAD POP
+		{"005b600035505b", 1000, []*Issue{{IssueUnreachable, 1, "code in [1, 7) is unreachable"}}},
+		// JUMPDEST, RANDOM, PUSH1 0, JUMP
+		{"5b4b600056", 1000, []*Issue{{IssueUnboundedLoop, 0, "loop of 1 blocks starting at 0 has no exit, it runs until the quota is exhausted"}}},
+		{"5b4b600056", 50, []*Issue{{IssueInvalidOpCode, 1, "RANDOM is invalid at snapshot height 50"}, {IssueUnreachable, 2, "code in [2, 5) is unreachable"}}},
+		// JUMPDEST PUSH1 0 CALLDATALOAD PUSH1 0x0a JUMPI, PUSH1 0 JUMP, JUMPDEST STOP
+		{"5b600035600a576000565b00", 1000, nil},
+		// PUSH1 4 JUMP STOP STOP
+		{"6004560000", 1000, []*Issue{{IssueInvalidJump, 2, "jump to 0x04 which is not a JUMPDEST"}, {IssueUnreachable, 3, "code in [3, 5) is unreachable"}}},
+	}
+	for _, tt := range tests {
+		code, _ := hex.DecodeString(tt.code)
+		r := Analyze(code, tt.height)
+		if len(r.Issues) != len(tt.issues) {
+			t.Fatalf("%s: expected %d issues, got %d", tt.code, len(tt.issues), len(r.Issues))
+		}
+		for i, issue := range tt.issues {
+			if *r.Issues[i] != *issue {
+				t.Fatalf("%s: expected issue %v, got %v", tt.code, issue, r.Issues[i])
+			}
+		}
+	}
+}
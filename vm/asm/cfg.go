@@ -0,0 +1,202 @@
+package asm
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/vitelabs/go-vite/vm"
+)
+
+// BasicBlock is a sequence of instructions which is entered at the first one and left at the last one
+type BasicBlock struct {
+	Start        uint64         `json:"start"` // pc of the first instruction
+	End          uint64         `json:"end"`   // pc after the last instruction
+	Instructions []*Instruction `json:"instructions"`
+	Succs        []uint64       `json:"succs,omitempty"` // start of the successors
+	Reachable    bool           `json:"reachable"`
+	// the block ends with a jump to the destination from the stack, the successors are all the
+	// JUMPDESTs pushed by the code
+	DynamicJump bool `json:"dynamicJump,omitempty"`
+	// the block ends with a jump to a constant which is not a JUMPDEST
+	InvalidJump bool `json:"invalidJump,omitempty"`
+}
+
+func (b *BasicBlock) last() *Instruction {
+	return b.Instructions[len(b.Instructions)-1]
+}
+
+// CFG is the control-flow graph of code
+type CFG struct {
+	Blocks  []*BasicBlock
+	blockAt map[uint64]*BasicBlock
+}
+
+// Block returns the block starting at pc
+func (g *CFG) Block(pc uint64) *BasicBlock {
+	return g.blockAt[pc]
+}
+
+// terminates reports whether the execution can not continue to the next instruction
+func terminates(info vm.OpCodeInfo) bool {
+	return info.Halts || info.Reverts || !info.Valid
+}
+
+// NewCFG splits the instructions into basic blocks and connects them with the edges of jumps and fallthroughs.
+// A block starts at JUMPDEST or after JUMP, JUMPI and terminating instructions.
+func NewCFG(list []*Instruction, infos *[256]vm.OpCodeInfo) *CFG {
+	g := &CFG{blockAt: make(map[uint64]*BasicBlock)}
+
+	var current *BasicBlock
+	for _, ins := range list {
+		if current != nil && ins.Op == opJumpdest {
+			current = nil
+		}
+		if current == nil {
+			current = &BasicBlock{Start: ins.Pc}
+			g.Blocks = append(g.Blocks, current)
+			g.blockAt[ins.Pc] = current
+		}
+		current.Instructions = append(current.Instructions, ins)
+		current.End = ins.Pc + 1 + uint64(len(ins.Arg))
+		if ins.Op == opJump || ins.Op == opJumpi || terminates(infos[ins.Op]) {
+			current = nil
+		}
+	}
+
+	// the destinations of dynamic jumps, all the JUMPDESTs pushed as constants
+	var pushedDests []uint64
+	pushed := make(map[uint64]bool)
+	for _, ins := range list {
+		if ins.Arg == nil {
+			continue
+		}
+		v := new(big.Int).SetBytes(ins.Arg)
+		if !v.IsUint64() {
+			continue
+		}
+		if dest := v.Uint64(); !pushed[dest] && g.isJumpdest(dest) {
+			pushed[dest] = true
+			pushedDests = append(pushedDests, dest)
+		}
+	}
+	sort.Slice(pushedDests, func(i, j int) bool { return pushedDests[i] < pushedDests[j] })
+
+	for i, b := range g.Blocks {
+		last := b.last()
+		switch {
+		case last.Op == opJump || last.Op == opJumpi:
+			g.addJumpEdges(b, pushedDests)
+			if last.Op == opJumpi && i+1 < len(g.Blocks) {
+				b.Succs = append(b.Succs, g.Blocks[i+1].Start)
+			}
+		case terminates(infos[last.Op]):
+		case i+1 < len(g.Blocks):
+			b.Succs = append(b.Succs, g.Blocks[i+1].Start)
+		}
+	}
+
+	if len(g.Blocks) > 0 {
+		g.markReachable(g.Blocks[0])
+	}
+	return g
+}
+
+func (g *CFG) isJumpdest(pc uint64) bool {
+	b, ok := g.blockAt[pc]
+	return ok && b.Instructions[0].Op == opJumpdest
+}
+
+// addJumpEdges adds the edges of the jump at the end of the block, the destination is constant
+// if the jump follows a PUSH
+func (g *CFG) addJumpEdges(b *BasicBlock, pushedDests []uint64) {
+	if len(b.Instructions) < 2 || b.Instructions[len(b.Instructions)-2].Arg == nil {
+		b.DynamicJump = true
+		b.Succs = append(b.Succs, pushedDests...)
+		return
+	}
+	v := new(big.Int).SetBytes(b.Instructions[len(b.Instructions)-2].Arg)
+	if !v.IsUint64() || !g.isJumpdest(v.Uint64()) {
+		b.InvalidJump = true
+		return
+	}
+	b.Succs = append(b.Succs, v.Uint64())
+}
+
+func (g *CFG) markReachable(entry *BasicBlock) {
+	entry.Reachable = true
+	queue := []*BasicBlock{entry}
+	for len(queue) > 0 {
+		b := queue[0]
+		queue = queue[1:]
+		for _, pc := range b.Succs {
+			if succ := g.blockAt[pc]; !succ.Reachable {
+				succ.Reachable = true
+				queue = append(queue, succ)
+			}
+		}
+	}
+}
+
+// loops returns the strongly connected components of reachable blocks which contain cycles
+func (g *CFG) loops() [][]*BasicBlock {
+	var (
+		index   = 0
+		indexes = make(map[*BasicBlock]int)
+		lowLink = make(map[*BasicBlock]int)
+		onStack = make(map[*BasicBlock]bool)
+		stack   []*BasicBlock
+		loops   [][]*BasicBlock
+		connect func(b *BasicBlock)
+	)
+
+	// Tarjan's algorithm
+	connect = func(b *BasicBlock) {
+		indexes[b] = index
+		lowLink[b] = index
+		index++
+		stack = append(stack, b)
+		onStack[b] = true
+
+		selfLoop := false
+		for _, pc := range b.Succs {
+			succ := g.blockAt[pc]
+			if succ == b {
+				selfLoop = true
+			}
+			if _, ok := indexes[succ]; !ok {
+				connect(succ)
+				if lowLink[succ] < lowLink[b] {
+					lowLink[b] = lowLink[succ]
+				}
+			} else if onStack[succ] && indexes[succ] < lowLink[b] {
+				lowLink[b] = indexes[succ]
+			}
+		}
+
+		if lowLink[b] != indexes[b] {
+			return
+		}
+		var scc []*BasicBlock
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[top] = false
+			scc = append(scc, top)
+			if top == b {
+				break
+			}
+		}
+		if len(scc) > 1 || selfLoop {
+			sort.Slice(scc, func(i, j int) bool { return scc[i].Start < scc[j].Start })
+			loops = append(loops, scc)
+		}
+	}
+
+	for _, b := range g.Blocks {
+		if _, ok := indexes[b]; !ok && b.Reachable {
+			connect(b)
+		}
+	}
+	sort.Slice(loops, func(i, j int) bool { return loops[i][0].Start < loops[j][0].Start })
+	return loops
+}
@@ -91,6 +91,14 @@ func ContainsStatusCode(code []byte) bool {
 	return false
 }
 
+// SplitAuxCode splits the code into the executable part and the swarm hash of metadata appended by the compiler
+func SplitAuxCode(code []byte) ([]byte, []byte) {
+	if containsAuxCode(code) {
+		return code[:len(code)-43], code[len(code)-43:]
+	}
+	return code, nil
+}
+
 func containsAuxCode(code []byte) bool {
 	l := len(code)
 	if l > 43 && bytes.Equal(code[l-43:l-34], auxCodePrefix) && bytes.Equal(code[l-2:], auxCodeSuffix) {
@@ -9,6 +9,7 @@ import (
 	"github.com/vitelabs/go-vite/vm/contracts"
 	"github.com/vitelabs/go-vite/vm/util"
 	"github.com/vitelabs/go-vite/vm_db"
+	"math/big"
 )
 
 // memoryGasCosts calculates the quadratic gas for memory expansion. It does so
@@ -208,21 +209,38 @@ func gasExp(vm *VM, c *contract, stack *stack, mem *memory, memorySize uint64) (
 }
 
 func gasBlake2b(vm *VM, c *contract, stack *stack, mem *memory, memorySize uint64) (uint64, bool, error) {
+	return hashGasCost(vm, mem, memorySize, stack.back(1), vm.gasTable.Blake2bQuota, vm.gasTable.Blake2bWordQuota)
+}
+
+func gasSha256(vm *VM, c *contract, stack *stack, mem *memory, memorySize uint64) (uint64, bool, error) {
+	return hashGasCost(vm, mem, memorySize, stack.back(1), vm.gasTable.Sha256Quota, vm.gasTable.Sha256WordQuota)
+}
+
+func gasKeccak256(vm *VM, c *contract, stack *stack, mem *memory, memorySize uint64) (uint64, bool, error) {
+	return hashGasCost(vm, mem, memorySize, stack.back(1), vm.gasTable.Keccak256Quota, vm.gasTable.Keccak256WordQuota)
+}
+
+func gasEd25519Verify(vm *VM, c *contract, stack *stack, mem *memory, memorySize uint64) (uint64, bool, error) {
+	return hashGasCost(vm, mem, memorySize, stack.back(1), vm.gasTable.Ed25519Quota, vm.gasTable.Ed25519WordQuota)
+}
+
+// hashGasCost returns the gas of memory expansion, the base quota and the quota of each word of data
+func hashGasCost(vm *VM, mem *memory, memorySize uint64, size *big.Int, quota, wordQuota uint64) (uint64, bool, error) {
 	var overflow bool
 	gas, _, err := memoryGasCost(vm, mem, memorySize)
 	if err != nil {
 		return 0, true, err
 	}
 
-	if gas, overflow = helper.SafeAdd(gas, vm.gasTable.Blake2bQuota); overflow {
+	if gas, overflow = helper.SafeAdd(gas, quota); overflow {
 		return 0, true, util.ErrGasUintOverflow
 	}
 
-	wordGas, overflow := helper.BigUint64(stack.back(1))
+	wordGas, overflow := helper.BigUint64(size)
 	if overflow {
 		return 0, true, util.ErrGasUintOverflow
 	}
-	if wordGas, overflow = helper.SafeMul(helper.ToWordSize(wordGas), vm.gasTable.Blake2bWordQuota); overflow {
+	if wordGas, overflow = helper.SafeMul(helper.ToWordSize(wordGas), wordQuota); overflow {
 		return 0, true, util.ErrGasUintOverflow
 	}
 	if gas, overflow = helper.SafeAdd(gas, wordGas); overflow {
@@ -1,12 +1,15 @@
 package vm
 
 import (
+	"crypto/sha256"
 	"encoding/hex"
 	"github.com/vitelabs/go-vite/common/helper"
 	"github.com/vitelabs/go-vite/common/types"
 	"github.com/vitelabs/go-vite/crypto"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
 	"github.com/vitelabs/go-vite/ledger"
 	"github.com/vitelabs/go-vite/vm/util"
+	"golang.org/x/crypto/sha3"
 )
 
 func opStop(pc *uint64, vm *VM, c *contract, mem *memory, stack *stack) ([]byte, error) {
@@ -340,6 +343,44 @@ func opBlake2b(pc *uint64, vm *VM, c *contract, mem *memory, stack *stack) ([]by
 	return nil, nil
 }
 
+func opSha256(pc *uint64, vm *VM, c *contract, mem *memory, stack *stack) ([]byte, error) {
+	offset, size := stack.pop(), stack.pop()
+	data := mem.get(offset.Int64(), size.Int64())
+	hash := sha256.Sum256(data)
+	stack.push(c.intPool.Get().SetBytes(hash[:]))
+
+	c.intPool.Put(offset, size)
+	return nil, nil
+}
+
+func opKeccak256(pc *uint64, vm *VM, c *contract, mem *memory, stack *stack) ([]byte, error) {
+	offset, size := stack.pop(), stack.pop()
+	data := mem.get(offset.Int64(), size.Int64())
+	d := sha3.NewLegacyKeccak256()
+	d.Write(data)
+	stack.push(c.intPool.Get().SetBytes(d.Sum(nil)))
+
+	c.intPool.Put(offset, size)
+	return nil, nil
+}
+
+// opEd25519Verify verifies the 64 bytes signature in memory of the message in memory
+// by the public key on stack, pushes 1 if the signature is valid, otherwise 0.
+// The signature scheme is the same as account blocks, which hashes with blake2b-512.
+func opEd25519Verify(pc *uint64, vm *VM, c *contract, mem *memory, stack *stack) ([]byte, error) {
+	offset, size, sigOffset, pubKey := stack.pop(), stack.pop(), stack.pop(), stack.peek()
+	data := mem.get(offset.Int64(), size.Int64())
+	sig := mem.get(sigOffset.Int64(), ed25519.SignatureSize)
+	if ed25519.Verify(helper.PaddedBigBytes(pubKey, ed25519.PublicKeySize), data, sig) {
+		pubKey.SetUint64(1)
+	} else {
+		pubKey.SetUint64(0)
+	}
+
+	c.intPool.Put(offset, size, sigOffset)
+	return nil, nil
+}
+
 func opAddress(pc *uint64, vm *VM, c *contract, mem *memory, stack *stack) ([]byte, error) {
 	stack.push(c.intPool.Get().SetBytes(c.block.AccountAddress.Bytes()))
 	return nil, nil
@@ -19,9 +19,17 @@ var (
 	offchainRandInterpreter   = &interpreter{offchainRandInstructionSet}
 	earthInterpreter          = &interpreter{earthInstructionSet}
 	offchainEarthInterpreter  = &interpreter{offchainEarthInstructionSet}
+	cryptoInterpreter         = &interpreter{cryptoInstructionSet}
+	offchainCryptoInterpreter = &interpreter{offchainCryptoInstructionSet}
 )
 
 func newInterpreter(blockHeight uint64, offChain bool) *interpreter {
+	if fork.IsCryptoFork(blockHeight) {
+		if offChain {
+			return offchainCryptoInterpreter
+		}
+		return cryptoInterpreter
+	}
 	if fork.IsEarthFork(blockHeight) {
 		if offChain {
 			return offchainEarthInterpreter
@@ -89,8 +97,27 @@ func (i *interpreter) runLoop(vm *VM, c *contract) (ret []byte, err error) {
 			mem.resize(memorySize)
 		}
 
+		var step *TraceStep
+		if vm.tracer != nil {
+			step = &TraceStep{Pc: currentPc, Op: op.String(), Cost: cost, QuotaLeft: c.quotaLeft, Stack: copyStack(st)}
+			if op == SSTORE {
+				step.Storage = &StorageAccess{Key: bigToStorageKey(st.back(0)), Value: st.back(1).Bytes(), Write: true}
+			} else if op == SLOAD {
+				step.Storage = &StorageAccess{Key: bigToStorageKey(st.peek())}
+			}
+		}
+
 		res, err := operation.execute(&pc, vm, c, mem, st)
 
+		if step != nil {
+			if op == SLOAD && err == nil {
+				step.Storage.Value = st.peek().Bytes()
+			}
+			step.Memory = mem.store
+			step.Err = err
+			vm.tracer.CaptureState(step)
+		}
+
 		if nodeConfig.IsDebug {
 			currentCode := ""
 			if currentPc < uint64(len(c.code)) {
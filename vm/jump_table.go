@@ -36,8 +36,45 @@ var (
 	offchainRandInstructionSet   = newRandOffchainInstructionSet()
 	earthInstructionSet          = newEarthInstructionSet()
 	offchainEarthInstructionSet  = newEarthOffchainInstructionSet()
+	cryptoInstructionSet         = newCryptoInstructionSet()
+	offchainCryptoInstructionSet = newCryptoOffchainInstructionSet()
 )
 
+func newCryptoInstructionSet() [256]operation {
+	instructionSet := newEarthInstructionSet()
+	addCryptoOperations(&instructionSet)
+	return instructionSet
+}
+func newCryptoOffchainInstructionSet() [256]operation {
+	instructionSet := newEarthOffchainInstructionSet()
+	addCryptoOperations(&instructionSet)
+	return instructionSet
+}
+
+func addCryptoOperations(instructionSet *[256]operation) {
+	instructionSet[SHA256] = operation{
+		execute:       opSha256,
+		gasCost:       gasSha256,
+		validateStack: makeStackFunc(2, 1),
+		memorySize:    memoryBlake2b,
+		valid:         true,
+	}
+	instructionSet[KECCAK256] = operation{
+		execute:       opKeccak256,
+		gasCost:       gasKeccak256,
+		validateStack: makeStackFunc(2, 1),
+		memorySize:    memoryBlake2b,
+		valid:         true,
+	}
+	instructionSet[ED25519VERIFY] = operation{
+		execute:       opEd25519Verify,
+		gasCost:       gasEd25519Verify,
+		validateStack: makeStackFunc(4, 1),
+		memorySize:    memoryEd25519Verify,
+		valid:         true,
+	}
+}
+
 func newEarthInstructionSet() [256]operation {
 	instructionSet := newRandInstructionSet()
 	instructionSet[CALL2] = operation{
@@ -994,3 +1031,27 @@ func newBaseInstructionSet() [256]operation {
 		},
 	}
 }
+
+// OpCodeInfo describes an opcode in an instruction set, it is used by static analysis of code.
+type OpCodeInfo struct {
+	Name    string // empty if the opcode is not defined
+	Valid   bool   // whether the opcode is valid in the instruction set
+	Halts   bool   // the execution stops after the opcode
+	Reverts bool   // the execution stops and the state is reverted after the opcode
+	Jumps   bool   // the opcode is JUMP or JUMPI
+}
+
+// InstructionSetInfo returns the opcodes of the instruction set used at the snapshot height
+func InstructionSetInfo(snapshotHeight uint64, offChain bool) [256]OpCodeInfo {
+	var infos [256]OpCodeInfo
+	for i, op := range newInterpreter(snapshotHeight, offChain).instructionSet {
+		infos[i] = OpCodeInfo{
+			Name:    opCodeToString[opCode(i)],
+			Valid:   op.valid,
+			Halts:   op.halts,
+			Reverts: op.reverts,
+			Jumps:   op.jumps,
+		}
+	}
+	return infos
+}
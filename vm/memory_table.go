@@ -2,6 +2,7 @@ package vm
 
 import (
 	"github.com/vitelabs/go-vite/common/helper"
+	"github.com/vitelabs/go-vite/crypto/ed25519"
 	"math/big"
 )
 
@@ -18,6 +19,15 @@ func memoryBlake2b(stack *stack) *big.Int {
 	return calcMemSize(stack.back(0), stack.back(1))
 }
 
+func memoryEd25519Verify(stack *stack) *big.Int {
+	x := calcMemSize(stack.back(0), stack.back(1))
+	y := calcMemSize(stack.back(2), big.NewInt(ed25519.SignatureSize))
+	if x.Cmp(y) < 0 {
+		return y
+	}
+	return x
+}
+
 func memoryCallDataCopy(stack *stack) *big.Int {
 	return calcMemSize(stack.back(0), stack.back(2))
 }
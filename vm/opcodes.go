@@ -55,7 +55,10 @@ const (
 
 // 0x20 range - hash ops.
 const (
-	BLAKE2B opCode = 0x21
+	BLAKE2B       opCode = 0x21
+	SHA256        opCode = 0x22
+	KECCAK256     opCode = 0x23
+	ED25519VERIFY opCode = 0x24
 )
 
 // 0x30 range - closure state.
@@ -233,7 +236,10 @@ var opCodeToString = map[opCode]string{
 	MULMOD: "MULMOD",
 
 	// 0x20 range - crypto.
-	BLAKE2B: "BLAKE2B",
+	BLAKE2B:       "BLAKE2B",
+	SHA256:        "SHA256",
+	KECCAK256:     "KECCAK256",
+	ED25519VERIFY: "ED25519VERIFY",
 
 	// 0x30 range - closure state.
 	ADDRESS:        "ADDRESS",
@@ -376,6 +382,11 @@ func (op opCode) String() string {
 	return str
 }
 
+// OpCodeName returns the name of the opcode, or an empty string if it is not defined
+func OpCodeName(op byte) string {
+	return opCodeToString[opCode(op)]
+}
+
 var stringToOp = map[string]opCode{
 	"STOP":           STOP,
 	"ADD":            ADD,
@@ -404,6 +415,9 @@ var stringToOp = map[string]opCode{
 	"ADDMOD":         ADDMOD,
 	"MULMOD":         MULMOD,
 	"BLAKE2B":        BLAKE2B,
+	"SHA256":         SHA256,
+	"KECCAK256":      KECCAK256,
+	"ED25519VERIFY":  ED25519VERIFY,
 	"ADDRESS":        ADDRESS,
 	"BALANCE":        BALANCE,
 	"ORIGIN":         ORIGIN,
@@ -0,0 +1,237 @@
+package vm
+
+import (
+	"encoding/hex"
+	"math/big"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm_db"
+)
+
+// Tracer observes the execution of an account block, it is set to a vm by SetTracer.
+type Tracer interface {
+	// CaptureStart is called before the code of a contract runs, it is called again for a delegate call
+	CaptureStart(addr types.Address, code []byte, data []byte, quotaLeft uint64)
+	// CaptureState is called after every opcode is executed
+	CaptureState(step *TraceStep)
+	// CaptureEnd is called after the code of a contract stops
+	CaptureEnd(ret []byte, quotaLeft uint64, err error)
+	// CaptureResult is called with the result after the account block is executed
+	CaptureResult(vmBlock *vm_db.VmAccountBlock, err error)
+}
+
+// TraceStep holds the states of an executed opcode, it must not be modified by tracers
+type TraceStep struct {
+	Pc        uint64
+	Op        string
+	Cost      uint64
+	QuotaLeft uint64
+	Stack     []*big.Int     // the stack before the opcode is executed, the last one is the top
+	Memory    []byte         // the memory after the opcode is executed, it is reused by the vm
+	Storage   *StorageAccess // the storage read or written by the opcode
+	Err       error
+}
+
+type StorageAccess struct {
+	Key   []byte
+	Value []byte
+	Write bool
+}
+
+// SetTracer sets a tracer to observe the execution, it makes the execution slower
+func (vm *VM) SetTracer(tracer Tracer) {
+	vm.tracer = tracer
+}
+
+func copyStack(st *stack) []*big.Int {
+	data := make([]*big.Int, len(st.data))
+	for i, v := range st.data {
+		data[i] = new(big.Int).Set(v)
+	}
+	return data
+}
+
+func bigToStorageKey(v *big.Int) []byte {
+	key, _ := types.BigToHash(v)
+	return key.Bytes()
+}
+
+type StructLog struct {
+	Pc          uint64         `json:"pc"`
+	Op          string         `json:"op"`
+	Cost        uint64         `json:"cost"`
+	QuotaLeft   uint64         `json:"quotaLeft"`
+	Depth       int            `json:"depth"`
+	Stack       []string       `json:"stack"`
+	MemoryDelta *MemoryDelta   `json:"memoryDelta,omitempty"`
+	Storage     *StorageRecord `json:"storage,omitempty"`
+	Error       string         `json:"error,omitempty"`
+}
+
+// MemoryDelta is the changed range of the memory after an opcode is executed
+type MemoryDelta struct {
+	Offset uint64 `json:"offset"`
+	Data   string `json:"data"`
+}
+
+type StorageRecord struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Write bool   `json:"write"`
+}
+
+type StructTraceResult struct {
+	QuotaUsed  uint64       `json:"quotaUsed"`
+	Failed     bool         `json:"failed"`
+	Error      string       `json:"error,omitempty"`
+	ReturnData string       `json:"returnData"`
+	StructLogs []*StructLog `json:"structLogs"`
+}
+
+// StructTracer records every opcode with the stack, the memory delta and the storage access
+type StructTracer struct {
+	result   StructTraceResult
+	memories [][]byte // the memory of every call depth
+}
+
+func NewStructTracer() *StructTracer {
+	return &StructTracer{result: StructTraceResult{StructLogs: make([]*StructLog, 0)}}
+}
+
+func (t *StructTracer) CaptureStart(addr types.Address, code []byte, data []byte, quotaLeft uint64) {
+	t.memories = append(t.memories, nil)
+}
+
+func (t *StructTracer) CaptureState(step *TraceStep) {
+	log := &StructLog{
+		Pc:        step.Pc,
+		Op:        step.Op,
+		Cost:      step.Cost,
+		QuotaLeft: step.QuotaLeft,
+		Depth:     len(t.memories),
+		Stack:     make([]string, len(step.Stack)),
+	}
+	for i, v := range step.Stack {
+		log.Stack[i] = "0x" + v.Text(16)
+	}
+
+	depth := len(t.memories) - 1
+	if offset, data, changed := memoryDelta(t.memories[depth], step.Memory); changed {
+		log.MemoryDelta = &MemoryDelta{Offset: offset, Data: hex.EncodeToString(data)}
+		t.memories[depth] = append(t.memories[depth][:0], step.Memory...)
+	}
+
+	if step.Storage != nil {
+		log.Storage = &StorageRecord{
+			Key:   hex.EncodeToString(step.Storage.Key),
+			Value: hex.EncodeToString(step.Storage.Value),
+			Write: step.Storage.Write,
+		}
+	}
+	if step.Err != nil {
+		log.Error = step.Err.Error()
+	}
+	t.result.StructLogs = append(t.result.StructLogs, log)
+}
+
+func (t *StructTracer) CaptureEnd(ret []byte, quotaLeft uint64, err error) {
+	t.memories = t.memories[:len(t.memories)-1]
+	if len(t.memories) == 0 {
+		t.result.ReturnData = hex.EncodeToString(ret)
+	}
+}
+
+func (t *StructTracer) CaptureResult(vmBlock *vm_db.VmAccountBlock, err error) {
+	if vmBlock != nil {
+		t.result.QuotaUsed = vmBlock.AccountBlock.QuotaUsed
+	}
+	if err != nil {
+		t.result.Failed = true
+		t.result.Error = err.Error()
+	}
+}
+
+func (t *StructTracer) GetResult() interface{} {
+	return &t.result
+}
+
+// memoryDelta returns the range of cur which is different from prev
+func memoryDelta(prev, cur []byte) (uint64, []byte, bool) {
+	start := 0
+	for start < len(prev) && start < len(cur) && prev[start] == cur[start] {
+		start++
+	}
+	if start == len(cur) {
+		return 0, nil, false
+	}
+	end := len(cur)
+	if len(prev) == len(cur) {
+		for end > start && prev[end-1] == cur[end-1] {
+			end--
+		}
+	}
+	return uint64(start), cur[start:end], true
+}
+
+type CallTraceSendBlock struct {
+	BlockType byte              `json:"blockType"`
+	ToAddress types.Address     `json:"toAddress"`
+	TokenId   types.TokenTypeId `json:"tokenId"`
+	Amount    string            `json:"amount"`
+	Data      []byte            `json:"data"`
+}
+
+type CallTraceResult struct {
+	QuotaUsed     uint64                `json:"quotaUsed"`
+	Failed        bool                  `json:"failed"`
+	Error         string                `json:"error,omitempty"`
+	SendBlockList []*CallTraceSendBlock `json:"sendBlockList"`
+	VmLogList     []*ledger.VmLog       `json:"vmLogList"`
+}
+
+// CallTracer only records the send blocks and the vm logs emitted by the account block
+type CallTracer struct {
+	result CallTraceResult
+}
+
+func NewCallTracer() *CallTracer {
+	return &CallTracer{}
+}
+
+func (t *CallTracer) CaptureStart(addr types.Address, code []byte, data []byte, quotaLeft uint64) {}
+func (t *CallTracer) CaptureState(step *TraceStep)                                                {}
+func (t *CallTracer) CaptureEnd(ret []byte, quotaLeft uint64, err error)                          {}
+
+func (t *CallTracer) CaptureResult(vmBlock *vm_db.VmAccountBlock, err error) {
+	t.result.SendBlockList = make([]*CallTraceSendBlock, 0)
+	t.result.VmLogList = make([]*ledger.VmLog, 0)
+	if vmBlock != nil {
+		block := vmBlock.AccountBlock
+		t.result.QuotaUsed = block.QuotaUsed
+		for _, sendBlock := range block.SendBlockList {
+			amount := "0"
+			if sendBlock.Amount != nil {
+				amount = sendBlock.Amount.String()
+			}
+			t.result.SendBlockList = append(t.result.SendBlockList, &CallTraceSendBlock{
+				BlockType: sendBlock.BlockType,
+				ToAddress: sendBlock.ToAddress,
+				TokenId:   sendBlock.TokenId,
+				Amount:    amount,
+				Data:      sendBlock.Data,
+			})
+		}
+		if vmBlock.VmDb != nil {
+			t.result.VmLogList = append(t.result.VmLogList, vmBlock.VmDb.GetLogList()...)
+		}
+	}
+	if err != nil {
+		t.result.Failed = true
+		t.result.Error = err.Error()
+	}
+}
+
+func (t *CallTracer) GetResult() interface{} {
+	return &t.result
+}
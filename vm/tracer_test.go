@@ -0,0 +1,129 @@
+package vm
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/vitelabs/go-vite/common/types"
+	"github.com/vitelabs/go-vite/ledger"
+	"github.com/vitelabs/go-vite/vm/contracts/abi"
+	"github.com/vitelabs/go-vite/vm/util"
+)
+
+func TestTracer(t *testing.T) {
+	viteTotalSupply := new(big.Int).Mul(big.NewInt(1e9), util.AttovPerVite)
+	db, addr1, _, hash12, _, _ := prepareDb(viteTotalSupply)
+
+	// code2 stores 100 at slot 0 and calls addr3 with data=100 and amount=10
+	addr2 := types.Address{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1}
+	code2 := []byte{
+		1,
+		byte(PUSH1), 100, byte(PUSH1), 0, byte(SSTORE),
+		byte(PUSH1), 32, byte(PUSH1), 100, byte(PUSH1), 0, byte(DUP1), byte(SWAP2), byte(SWAP1), byte(MSTORE),
+		byte(PUSH1), 10, byte(PUSH10), 'V', 'I', 'T', 'E', ' ', 'T', 'O', 'K', 'E', 'N', byte(PUSH21), 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 2, 1, byte(CALL)}
+	db.codeMap[addr2] = code2
+	db.contractMetaMap[addr2] = &ledger.ContractMeta{Gid: types.DELEGATE_GID, SendConfirmedTimes: 1, QuotaRatio: 10}
+	db.accountBlockMap[addr2] = make(map[types.Hash]*ledger.AccountBlock)
+	db.storageMap[types.AddressQuota][ToKey(abi.GetStakeBeneficialKey(addr2))], _ = abi.ABIQuota.PackVariable(abi.VariableNameStakeBeneficial, new(big.Int).Mul(big.NewInt(1e9), big.NewInt(1e18)))
+	addr3 := types.Address{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 2, 1}
+	db.contractMetaMap[addr3] = &ledger.ContractMeta{Gid: types.DELEGATE_GID, SendConfirmedTimes: 2, QuotaRatio: 10}
+
+	hash13 := types.DataHash([]byte{1, 3})
+	block13 := &ledger.AccountBlock{
+		Height:         3,
+		AccountAddress: addr1,
+		BlockType:      ledger.BlockTypeSendCall,
+		PrevHash:       hash12,
+		Amount:         big.NewInt(10),
+		Fee:            big.NewInt(0),
+		TokenId:        ledger.ViteTokenId,
+		Hash:           hash13,
+		ToAddress:      addr2,
+		Difficulty:     big.NewInt(67108863),
+		Nonce:          []byte{1},
+		Data:           []byte{1},
+	}
+	db.addr = addr1
+	sendCallBlock, _, err := NewVM(nil).RunV2(db, block13, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.accountBlockMap[addr1][hash13] = sendCallBlock.AccountBlock
+
+	block21 := &ledger.AccountBlock{
+		Height:         1,
+		AccountAddress: addr2,
+		FromBlockHash:  hash13,
+		BlockType:      ledger.BlockTypeReceive,
+		Hash:           types.DataHash([]byte{2, 1}),
+	}
+	db.addr = addr2
+	structTracer, callTracer := NewStructTracer(), NewCallTracer()
+
+	vm := NewVM(nil)
+	vm.SetTracer(structTracer)
+	receiveCallBlock, _, err := vm.RunV2(db, block21, sendCallBlock.AccountBlock, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structResult := structTracer.GetResult().(*StructTraceResult)
+	if structResult.Failed || structResult.QuotaUsed != receiveCallBlock.AccountBlock.QuotaUsed {
+		t.Fatalf("struct trace result error, %+v", structResult)
+	}
+	logs := structResult.StructLogs
+	if len(logs) != 15 || logs[0].Op != "PUSH1" || logs[0].Pc != 0 || logs[0].Depth != 1 || logs[13].Op != "CALL" || logs[14].Op != "STOP" {
+		t.Fatalf("struct logs error, len %v", len(logs))
+	}
+	if logs[2].Op != "SSTORE" || len(logs[2].Stack) != 2 || logs[2].Stack[0] != "0x64" || logs[2].Stack[1] != "0x0" ||
+		logs[2].Storage == nil || !logs[2].Storage.Write || logs[2].Storage.Key != "0000000000000000000000000000000000000000000000000000000000000000" || logs[2].Storage.Value != "64" {
+		t.Fatalf("sstore log error, %+v", logs[2])
+	}
+	if logs[9].Op != "MSTORE" || logs[9].MemoryDelta == nil || logs[9].MemoryDelta.Offset != 0 ||
+		logs[9].MemoryDelta.Data != "0000000000000000000000000000000000000000000000000000000000000064" {
+		t.Fatalf("mstore log error, %+v", logs[9])
+	}
+	for i, log := range logs {
+		if i != 9 && log.MemoryDelta != nil {
+			t.Fatalf("memory delta of %v error, %+v", log.Op, log.MemoryDelta)
+		}
+	}
+
+	// the slot is written by the previous execution, so the quota used is less
+	vm = NewVM(nil)
+	vm.SetTracer(callTracer)
+	if _, _, err := vm.RunV2(db, block21, sendCallBlock.AccountBlock, nil); err != nil {
+		t.Fatal(err)
+	}
+	callResult := callTracer.GetResult().(*CallTraceResult)
+	if callResult.Failed || callResult.QuotaUsed == 0 || callResult.QuotaUsed >= receiveCallBlock.AccountBlock.QuotaUsed ||
+		len(callResult.SendBlockList) != 1 ||
+		callResult.SendBlockList[0].BlockType != ledger.BlockTypeSendCall ||
+		callResult.SendBlockList[0].ToAddress != addr3 ||
+		callResult.SendBlockList[0].Amount != "10" ||
+		len(callResult.VmLogList) != 0 {
+		t.Fatalf("call trace result error, %+v", callResult)
+	}
+}
+
+func TestMemoryDelta(t *testing.T) {
+	tests := []struct {
+		prev, cur []byte
+		offset    uint64
+		data      []byte
+		changed   bool
+	}{
+		{nil, nil, 0, nil, false},
+		{[]byte{1, 2}, []byte{1, 2}, 0, nil, false},
+		{nil, []byte{0, 0}, 0, []byte{0, 0}, true},
+		{[]byte{1, 2, 3, 4}, []byte{1, 5, 6, 4}, 1, []byte{5, 6}, true},
+		{[]byte{1, 2}, []byte{1, 2, 0, 0}, 2, []byte{0, 0}, true},
+		{[]byte{1, 2}, []byte{1, 3, 0, 0}, 1, []byte{3, 0, 0}, true},
+	}
+	for i, test := range tests {
+		offset, data, changed := memoryDelta(test.prev, test.cur)
+		if offset != test.offset || !bytes.Equal(data, test.data) || changed != test.changed {
+			t.Fatalf("%v memory delta error, expected %v %v %v, got %v %v %v", i, test.offset, test.data, test.changed, offset, data, changed)
+		}
+	}
+}
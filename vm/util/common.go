@@ -112,6 +112,14 @@ func PackContractCode(contractType uint8, code []byte) []byte {
 	return helper.JoinBytes([]byte{contractType}, code)
 }
 
+// UnpackContractCode splits the code saved in chain into the contract type and the code
+func UnpackContractCode(code []byte) (uint8, []byte) {
+	if len(code) < contractTypeSize {
+		return 0, nil
+	}
+	return code[0], code[contractTypeSize:]
+}
+
 // NewContractAddress generate contract address in create contract request block
 func NewContractAddress(accountAddress types.Address, accountBlockHeight uint64, prevBlockHash types.Hash) types.Address {
 	return types.CreateContractAddress(
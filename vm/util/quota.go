@@ -140,6 +140,12 @@ type QuotaTable struct {
 	SarQuota            uint64
 	Blake2bQuota        uint64
 	Blake2bWordQuota    uint64
+	Sha256Quota         uint64
+	Sha256WordQuota     uint64
+	Keccak256Quota      uint64
+	Keccak256WordQuota  uint64
+	Ed25519Quota        uint64
+	Ed25519WordQuota    uint64
 	AddressQuota        uint64
 	BalanceQuota        uint64
 	CallerQuota         uint64
@@ -243,7 +249,9 @@ type QuotaTable struct {
 
 // QuotaTableByHeight returns different quota table by hard fork version
 func QuotaTableByHeight(sbHeight uint64) *QuotaTable {
-	if fork.IsDexRobotFork(sbHeight) {
+	if fork.IsCryptoFork(sbHeight) {
+		return &cryptoQuotaTable
+	} else if fork.IsDexRobotFork(sbHeight) {
 		return &dexRobotQuotaTable
 	} else if fork.IsEarthFork(sbHeight) {
 		return &earthQuotaTable
@@ -357,6 +365,7 @@ var (
 	dexAgentQuotaTable = newDexAgentQuotaTable()
 	earthQuotaTable    = newEarthQuotaTable()
 	dexRobotQuotaTable    = newDexRobotQuotaTable()
+	cryptoQuotaTable   = newCryptoQuotaTable()
 )
 
 func newViteQuotaTable() QuotaTable {
@@ -505,3 +514,14 @@ func newDexRobotQuotaTable() QuotaTable {
 	gt.DexFundCancelOrderBySendHashQuota = 15200
 	return gt
 }
+
+func newCryptoQuotaTable() QuotaTable {
+	gt := newDexRobotQuotaTable()
+	gt.Sha256Quota = 30
+	gt.Sha256WordQuota = 2
+	gt.Keccak256Quota = 20
+	gt.Keccak256WordQuota = 1
+	gt.Ed25519Quota = 1500
+	gt.Ed25519WordQuota = 1
+	return gt
+}
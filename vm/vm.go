@@ -159,6 +159,8 @@ type VM struct {
 	// latest snapshot block height, used for fork check
 	latestSnapshotHeight uint64
 	gasTable             *util.QuotaTable
+	// tracer observes the execution, it is nil unless the block is traced
+	tracer Tracer
 }
 
 // NewVM is a constructor of VM. This method is called before running an
@@ -207,6 +209,9 @@ func (vm *VM) ConsensusReader() util.ConsensusReader {
 func (vm *VM) RunV2(db vm_db.VmDb, block *ledger.AccountBlock, sendBlock *ledger.AccountBlock, status util.GlobalStatus) (vmAccountBlock *vm_db.VmAccountBlock, isRetry bool, err error) {
 	defer monitor.LogTimerConsuming([]string{"vm", "run"}, time.Now())
 	defer func() {
+		if vm.tracer != nil {
+			vm.tracer.CaptureResult(vmAccountBlock, err)
+		}
 		db.Finish()
 		if nodeConfig.IsDebug {
 			printDebugBlockInfo(block, vmAccountBlock, err)